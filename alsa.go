@@ -0,0 +1,110 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+)
+
+// Minimal systems, e.g. embedded Yocto builds, may have neither PulseAudio nor PipeWire to load the
+// filter into. The ALSA mode reads from an ALSA capture device, runs the filter in the filter host
+// (see host.go) and writes to an ALSA playback device, usually one side of the snd-aloop loopback:
+// applications then record the filtered microphone from the other side, e.g. hw:Loopback,1.
+//
+// `noisetorch alsa` runs it in the foreground, e.g. as a system service. With AlsaFallback the
+// daemon runs it while it can't reach an audio server.
+
+func alsaPidFile() string {
+	return filepath.Join(pipeWireRuntimeDir(), "alsa.pid")
+}
+
+// alsaCommand returns the host filtering capture into playback with the current settings.
+func alsaCommand(ctx *ntcontext, capture, playback string, foreground bool) (*exec.Cmd, error) {
+	if err := installModel(ctx.librnnoise, activeModel(ctx)); err != nil {
+		return nil, err
+	}
+	host, err := dumpHost()
+	if err != nil {
+		return nil, err
+	}
+	chain, err := hostChainArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-A", capture, "-P", playback}
+	if foreground {
+		args = append(args, "-f")
+	}
+	return exec.Command(host, append(args, chain...)...), nil
+}
+
+// alsaCLI implements `noisetorch alsa`, it filters until it's killed.
+func alsaCLI(ctx *ntcontext, opt CLIOpts) {
+	capture, playback := ctx.config.AlsaCapture, ctx.config.AlsaPlayback
+	if opt.alsaCapture != "" {
+		capture = opt.alsaCapture
+	}
+	if opt.alsaPlayback != "" {
+		playback = opt.alsaPlayback
+	}
+	if opt.model != "" {
+		if err := validateModel(opt.model); err != nil {
+			opt.fail("Invalid model: %v\n", err)
+		}
+		ctx.config.Model = opt.model
+	}
+	cmd, err := alsaCommand(ctx, capture, playback, true)
+	if err != nil {
+		opt.fail("%v\n", err)
+	}
+	cmd.Stderr = os.Stderr
+
+	// the host cleans up on the same signals, pass them on and wait for it
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, exitSignals...)
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		opt.fail("Couldn't start the filter host: %v\n", err)
+	}
+	go func() {
+		sig := <-sigs
+		infof("Received %s, stopping\n", sig)
+		cmd.Process.Signal(sig)
+	}()
+	infof("Filtering %s into %s\n", capture, playback)
+	fmt.Fprintf(os.Stderr, "Filtering %s into %s, Ctrl+C to stop\n", capture, playback)
+	if err := cmd.Wait(); err != nil {
+		opt.fail("The filter stopped: %v\n", err)
+	}
+	cleanupExit(0)
+}
+
+// startAlsaFallback runs the ALSA mode detached, for the daemon while there's no audio server.
+func startAlsaFallback(ctx *ntcontext) error {
+	cmd, err := alsaCommand(ctx, ctx.config.AlsaCapture, ctx.config.AlsaPlayback, false)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(pipeWireRuntimeDir(), 0700); err != nil {
+		return err
+	}
+	if err := startHost(cmd, alsaPidFile()); err != nil {
+		return fmt.Errorf("the ALSA filter didn't start: %w", err)
+	}
+	infof("No audio server, filtering %s into %s through ALSA\n", ctx.config.AlsaCapture, ctx.config.AlsaPlayback)
+	return nil
+}
+
+func stopAlsaFallback() error {
+	return stopDetached(alsaPidFile(), hostName)
+}
+
+func alsaFallbackRunning() bool {
+	_, running := detachedPid(alsaPidFile(), hostName)
+	return running
+}
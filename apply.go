@@ -0,0 +1,336 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// A setup file declares the state NoiseTorch should be in, as profiles of which one is applied:
+//
+//	profile: office
+//	profiles:
+//	  office:
+//	    inputs:
+//	      - alsa_input.usb-Blue_Yeti-00.analog-stereo
+//	    headphones: alsa_output.pci-0000_00_1f.3.analog-stereo
+//	    stages:
+//	      filter: {threshold: 90, suppression: 100}
+//	      gate: {threshold: -50}
+//	      gain: {db: 6}
+//	  gaming:
+//	    inputs: [alsa_input.usb-Blue_Yeti-00.analog-stereo]
+//
+// `noisetorch apply setup.yaml` brings the audio server into that state, `-daemon` keeps it there.
+// The stages listed make up the chain, the filter keeps the configured threshold if it isn't
+// given. The file is either YAML as above or TOML like our config file, depending on its extension.
+type setupFile struct {
+	Profile  string                  `yaml:"profile" toml:"profile"` // may be left out if there's only one
+	Profiles map[string]setupProfile `yaml:"profiles" toml:"profiles"`
+}
+
+type setupProfile struct {
+	Inputs     []string    `yaml:"inputs" toml:"inputs"`         // device IDs of the microphones
+	Headphones string      `yaml:"headphones" toml:"headphones"` // device ID, empty to not filter them
+	Stages     setupStages `yaml:"stages" toml:"stages"`
+}
+
+type setupStages struct {
+	Filter *filterStage `yaml:"filter" toml:"filter"`
+	Gate   *gateStage   `yaml:"gate" toml:"gate"`
+	Gain   *gainStage   `yaml:"gain" toml:"gain"`
+}
+
+type filterStage struct {
+	Threshold   *int `yaml:"threshold" toml:"threshold"`
+	Suppression *int `yaml:"suppression" toml:"suppression"` // percent
+}
+
+// gateStage leaves out what the config has.
+type gateStage struct {
+	Threshold *int `yaml:"threshold" toml:"threshold"` // dB
+	Attack    *int `yaml:"attack" toml:"attack"`       // ms
+	Release   *int `yaml:"release" toml:"release"`     // ms
+}
+
+type gainStage struct {
+	DB int `yaml:"db" toml:"db"`
+}
+
+// readSetup reads the setup file and returns the profile it applies.
+func readSetup(path string) (setupProfile, error) {
+	var s setupFile
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		f, err := os.Open(path)
+		if err != nil {
+			return setupProfile{}, err
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(&s); err != nil && err != io.EOF {
+			return setupProfile{}, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		md, err := toml.DecodeFile(path, &s)
+		if err != nil {
+			return setupProfile{}, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return setupProfile{}, fmt.Errorf("unknown keys in %s: %v", path, undecoded)
+		}
+	}
+
+	name := s.Profile
+	if name == "" && len(s.Profiles) == 1 {
+		for n := range s.Profiles {
+			name = n
+		}
+	}
+	if name == "" {
+		return setupProfile{}, fmt.Errorf("%s has %d profiles, pick one with 'profile'", path, len(s.Profiles))
+	}
+	p, ok := s.Profiles[name]
+	if !ok {
+		return setupProfile{}, fmt.Errorf("%s has no profile %s", path, name)
+	}
+	if len(p.Inputs) == 0 && p.Headphones == "" {
+		return setupProfile{}, fmt.Errorf("profile %s configures neither a microphone nor headphones", name)
+	}
+	if p.Stages.Filter == nil && len(p.Inputs) > 0 {
+		// a chain without its filter would only be a detour
+		p.Stages.Filter = &filterStage{}
+	}
+	return p, nil
+}
+
+// simpleSetup is the setup of a preset or quick state: the filter with threshold for one
+// microphone, and the gate and gain as configured.
+func simpleSetup(c *config, threshold int, microphone, headphones string) setupProfile {
+	p := setupProfile{Headphones: headphones}
+	if microphone != "" {
+		p.Inputs = []string{microphone}
+	}
+	p.Stages.Filter = &filterStage{Threshold: &threshold}
+	if c.Gate {
+		p.Stages.Gate = &gateStage{}
+	}
+	if c.OutputGain != 0 {
+		p.Stages.Gain = &gainStage{DB: c.OutputGain}
+	}
+	return p
+}
+
+// applyStages puts the stages into the config, checking them like the config file is checked.
+func applyStages(c *config, s setupStages) error {
+	set := func(key string, dst *int, v *int) error {
+		if v == nil {
+			return nil
+		}
+		old := *dst
+		*dst = *v
+		if err := validateConfigValue(c, key); err != nil {
+			*dst = old
+			return err
+		}
+		return nil
+	}
+	if f := s.Filter; f != nil {
+		if err := set("Threshold", &c.Threshold, f.Threshold); err != nil {
+			return err
+		}
+		if err := set("Suppression", &c.Suppression, f.Suppression); err != nil {
+			return err
+		}
+	}
+	c.Gate = s.Gate != nil
+	if g := s.Gate; g != nil {
+		if err := set("GateThreshold", &c.GateThreshold, g.Threshold); err != nil {
+			return err
+		}
+		if err := set("GateAttack", &c.GateAttack, g.Attack); err != nil {
+			return err
+		}
+		if err := set("GateRelease", &c.GateRelease, g.Release); err != nil {
+			return err
+		}
+	}
+	gain := 0
+	if s.Gain != nil {
+		gain = s.Gain.DB
+	}
+	return set("OutputGain", &c.OutputGain, &gain)
+}
+
+// runningChain describes which devices the currently loaded modules are attached to.
+type runningChain struct {
+	inputs      []string
+	output      string
+	threshold   int    // -1 if the engine has no threshold
+	suppression int    // -1 if the engine has no suppression port
+	label       string // LADSPA label of the engine
+	gate        bool
+}
+
+var (
+	argSource  = regexp.MustCompile(`(?:^| )source=(\S+) sink=nui_mic_raw_`)
+	argMaster  = regexp.MustCompile(`(?:^| )master=(\S+)`)
+	argOutSink = regexp.MustCompile(`source=nui_out_out_sink\.monitor sink=(\S+)`)
+	argControl = regexp.MustCompile(`(?:^| )control=(\d+)`)
+	argMix     = regexp.MustCompile(`(?:^| )control=\d+,(\d+)`)
+	argLabel   = regexp.MustCompile(`(?:^| )label=(\S+)`)
+)
+
+// onlyInput returns the filtered microphone if there is exactly one.
+func (c runningChain) onlyInput() string {
+	if len(c.inputs) != 1 {
+		return ""
+	}
+	return c.inputs[0]
+}
+
+func getRunningChain(ctx *ntcontext) (runningChain, error) {
+	chain := runningChain{threshold: -1, suppression: -1}
+	if ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire {
+		// the native filter-chain isn't a module, we have nothing to compare against
+		return chain, nil
+	}
+	mods, err := ctx.paClient.ModuleList()
+	if err != nil {
+		return chain, err
+	}
+	for _, m := range mods {
+		a := m.Argument
+		switch {
+		case m.Name == "module-loopback" && argSource.MatchString(a):
+			chain.inputs = append(chain.inputs, argSource.FindStringSubmatch(a)[1])
+		case m.Name == "module-loopback" && argOutSink.MatchString(a):
+			chain.output = argOutSink.FindStringSubmatch(a)[1]
+		case m.Name == "module-ladspa-source" && argMaster.MatchString(a):
+			chain.inputs = append(chain.inputs, argMaster.FindStringSubmatch(a)[1])
+		case m.Name == "module-ladspa-sink" && argMaster.MatchString(a):
+			chain.output = argMaster.FindStringSubmatch(a)[1]
+		}
+		if m.Name == "module-ladspa-sink" && strings.Contains(a, "label="+gateLabel+" ") {
+			chain.gate = true
+		} else if (m.Name == "module-ladspa-sink" || m.Name == "module-ladspa-source") && argLabel.MatchString(a) {
+			chain.label = argLabel.FindStringSubmatch(a)[1]
+			if chain.label == engines[0].label && argControl.MatchString(a) {
+				chain.threshold, _ = strconv.Atoi(argControl.FindStringSubmatch(a)[1])
+			}
+			if chain.label == engines[0].label && argMix.MatchString(a) {
+				chain.suppression, _ = strconv.Atoi(argMix.FindStringSubmatch(a)[1])
+			}
+			if v, ok := liveValuesFor(ctx, m.Index); ok && chain.label == engines[0].label {
+				// changed since it was loaded, see livecontrol.go
+				chain.threshold, chain.suppression = v.control, v.suppression
+			}
+		}
+	}
+	return withIdleInputs(ctx, chain), nil
+}
+
+// reconcile loads, reloads or unloads the supressor so the running state matches the setup.
+func reconcile(ctx *ntcontext, s setupProfile) error {
+	ctx.config.FilterInput = len(s.Inputs) > 0
+	ctx.config.FilterOutput = s.Headphones != ""
+	gain := ctx.config.OutputGain
+	if err := applyStages(ctx.config, s.Stages); err != nil {
+		return err
+	}
+
+	state, _ := supressorState(ctx)
+	chain, err := getRunningChain(ctx)
+	if err != nil {
+		return err
+	}
+	if state == loaded && ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire {
+		// we can't tell how the filter-chain is configured, leave it alone
+		return nil
+	}
+	if state == loaded && sameIDs(chain.inputs, s.Inputs) && chain.output == s.Headphones && chain.gate == ctx.config.Gate &&
+		chain.label == currentEngine(ctx).label && (!currentEngine(ctx).rnnoise || (chain.threshold == ctx.config.Threshold && chain.suppression == ctx.config.Suppression)) {
+		if ctx.config.FilterInput && ctx.config.OutputGain != gain {
+			// the gain isn't part of the modules, it's set on the filtered microphone
+			return applyGain(ctx)
+		}
+		return nil
+	}
+	debugf("Running chain %+v doesn't match setup %+v, reloading\n", chain, s)
+
+	var inputs []device
+	sources := getSources(ctx, ctx.paClient)
+	for _, id := range s.Inputs {
+		d, ok := findDevice(sources, id)
+		if !ok {
+			return fmt.Errorf("PulseAudio source not found: %s", id)
+		}
+		inputs = append(inputs, d)
+	}
+	out := device{}
+	if s.Headphones != "" {
+		d, ok := findDevice(getSinks(ctx, ctx.paClient), s.Headphones)
+		if !ok {
+			return fmt.Errorf("PulseAudio sink not found: %s", s.Headphones)
+		}
+		out = d
+	}
+
+	if state != unloaded || len(chain.inputs) > 0 || chain.output != "" {
+		if err := unloadSupressor(ctx); err != nil {
+			return err
+		}
+	}
+	inp := device{}
+	if len(inputs) > 0 {
+		inp = inputs[0]
+	}
+	if err := loadSupressor(ctx, &inp, &out); err != nil {
+		return err
+	}
+	// loading applies the gain
+	for i := 1; i < len(inputs); i++ {
+		if err := loadInputSupressor(ctx, &inputs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameIDs reports whether both are the same devices, in any order.
+func sameIDs(running, wanted []string) bool {
+	if len(running) != len(wanted) {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, id := range running {
+		seen[id] = true
+	}
+	for _, id := range wanted {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func findDevice(devices []device, id string) (device, bool) {
+	for _, d := range devices {
+		if d.ID == id {
+			d.checked = true
+			return d, true
+		}
+	}
+	return device{}, false
+}
@@ -0,0 +1,41 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"embed"
+	"path"
+	"runtime"
+)
+
+// releaseArch is the architecture in the names of the release files, NoiseTorch_<arch>_<version>.tgz.
+func releaseArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "arm":
+		return "armv7"
+	}
+	return runtime.GOARCH
+}
+
+// bundledFor picks the build of an embedded native program for the architecture we run on. Release
+// builds bundle one per architecture we release for, built by `make plugins` into dir/<GOARCH>.
+// Other builds only have native, the one of the machine building them, which is useless when cross
+// compiling, so it's only used if it matches.
+func bundledFor(files embed.FS, dir, name string, native []byte) []byte {
+	if data, err := files.ReadFile(path.Join(dir, runtime.GOARCH, name)); err == nil && len(data) > 0 {
+		return data
+	}
+	f, err := elf.NewFile(bytes.NewReader(native))
+	if err != nil {
+		return native // doesn't matter, whoever runs it tells
+	}
+	if m, ok := elfMachines[runtime.GOARCH]; ok && f.Machine != m {
+		return nil
+	}
+	return native
+}
@@ -0,0 +1,57 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/noisetorch/pulseaudio"
+)
+
+const noIndex = 0xffffffff // PA_INVALID_INDEX
+
+// AudioServer is what we ask of the audio server: its devices and modules, loading and unloading
+// modules, and change events. A *pulseaudio.Client is one, talking the pulseaudio protocol to
+// PulseAudio or pipewire-pulse, fakeServer another in the tests, see fakeserver_test.go.
+// Everything the protocol client can't do goes through pactl and pw-cli, the interface doesn't
+// cover that.
+type AudioServer interface {
+	ServerInfo() (*pulseaudio.Server, error)
+	Sources() ([]pulseaudio.Source, error)
+	Sinks() ([]pulseaudio.Sink, error)
+	ModuleList() ([]pulseaudio.Module, error)
+	LoadModule(name, argument string) (uint32, error)
+	UnloadModule(index uint32) error
+	// Updates signals every change, coalesced, until the connection is lost.
+	Updates() (<-chan struct{}, error)
+	Connected() bool
+	Close()
+}
+
+// dialAudioServer is newAudioServer, the tests connect the daemon to a fake server instead.
+var dialAudioServer = newAudioServer
+
+// connected is whether there's a connection to the audio server. There's no client at all before
+// the first one, a nil interface unlike the nil *pulseaudio.Client it used to be.
+func connected(ctx *ntcontext) bool {
+	return ctx.paClient != nil && ctx.paClient.Connected()
+}
+
+// newAudioServer connects to the audio server. Sandboxes point PULSE_SERVER to the socket they
+// share, which the pulseaudio library doesn't look at.
+func newAudioServer() (AudioServer, error) {
+	var c *pulseaudio.Client
+	var err error
+	if server := os.Getenv("PULSE_SERVER"); strings.HasPrefix(server, "unix:") {
+		c, err = pulseaudio.NewClient(strings.TrimPrefix(server, "unix:"))
+	} else {
+		c, err = pulseaudio.NewClient()
+	}
+	if err != nil {
+		// not a non-nil interface holding a nil client
+		return nil, err
+	}
+	return c, nil
+}
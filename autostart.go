@@ -0,0 +1,212 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Start on login loads the filter for the last used microphone through `noisetorch -i` when the user
+// logs in. We prefer a systemd user unit, so the filter is ordered after the audio server, and fall
+// back to an XDG autostart entry on systems without systemd.
+//
+// The files are regenerated whenever what they'd contain changes (e.g. another microphone, or the
+// binary moved after an update), and never touched if they weren't written by us.
+
+const (
+	autostartUnit   = "noisetorch.service"
+	autostartMarker = "# Generated by NoiseTorch, changes will be overwritten"
+)
+
+func autostartUnitPath() string {
+	return filepath.Join(xdgOrFallback("XDG_CONFIG_HOME", filepath.Join(os.Getenv("HOME"), ".config")), "systemd", "user", autostartUnit)
+}
+
+func autostartDesktopPath() string {
+	if sandboxed == sandboxSnap {
+		// where snapd looks for the entries of the autostart declared in snapcraft.yaml
+		return filepath.Join(os.Getenv("SNAP_USER_DATA"), ".config", "autostart", "noisetorch.desktop")
+	}
+	return filepath.Join(xdgOrFallback("XDG_CONFIG_HOME", filepath.Join(os.Getenv("HOME"), ".config")), "autostart", "noisetorch.desktop")
+}
+
+func haveSystemdUser() bool {
+	if sandboxed != "" {
+		return false
+	}
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+	ok, _ := exists("/run/systemd/system")
+	return ok
+}
+
+var (
+	// systemd's ExecStart= expands specifiers (%) and environment variables ($)
+	systemdQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+	// the desktop entry spec wants a backslash before ", `, $ and \, and expands field codes (%)
+	desktopQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`, "%", "%%")
+)
+
+func autostartCommand(quoter *strings.Replacer, args ...string) (string, error) {
+	exe, err := sandboxExecutable()
+	if err != nil {
+		return "", err
+	}
+	cmd := []string{}
+	for _, a := range append([]string{exe}, args...) {
+		cmd = append(cmd, `"`+quoter.Replace(a)+`"`)
+	}
+	return strings.Join(cmd, " "), nil
+}
+
+func autostartLoadArgs(ctx *ntcontext) []string {
+	args := []string{"-i"}
+	if ctx.config.LastUsedInput != "" {
+		args = append(args, "-s", ctx.config.LastUsedInput)
+	}
+	return args
+}
+
+func autostartUnitContent(ctx *ntcontext) (string, error) {
+	start, err := autostartCommand(systemdQuoter, autostartLoadArgs(ctx)...)
+	if err != nil {
+		return "", err
+	}
+	stop, err := autostartCommand(systemdQuoter, "-u")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s
+[Unit]
+Description=NoiseTorch noise suppression for the microphone
+After=pipewire-pulse.service pulseaudio.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=%s
+ExecStop=%s
+
+[Install]
+WantedBy=default.target
+`, autostartMarker, start, stop), nil
+}
+
+func autostartDesktopContent(ctx *ntcontext) (string, error) {
+	start, err := autostartCommand(desktopQuoter, autostartLoadArgs(ctx)...)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s
+[Desktop Entry]
+Type=Application
+Name=NoiseTorch
+Comment=Load the filtered microphone on login
+Exec=%s
+Icon=noisetorch
+NoDisplay=true
+X-GNOME-Autostart-enabled=true
+`, autostartMarker, start), nil
+}
+
+// generatedByUs reports whether path exists and was written by us. Files the user wrote themselves
+// are left alone.
+func generatedByUs(path string) (bool, string) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return false, ""
+	}
+	return strings.HasPrefix(string(buf), autostartMarker), string(buf)
+}
+
+func systemctlUser(args ...string) error {
+	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	debugf("Calling: %s\n", cmd.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("systemctl %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func writeIfChanged(path, content string) (bool, error) {
+	ours, cur := generatedByUs(path)
+	if cur == content {
+		return false, nil
+	}
+	if cur != "" && !ours {
+		return false, fmt.Errorf("%s exists and wasn't created by NoiseTorch, not touching it", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	debugf("Writing %s\n", path)
+	return true, os.WriteFile(path, []byte(content), 0644)
+}
+
+func removeIfOurs(path string) (bool, error) {
+	if ours, _ := generatedByUs(path); !ours {
+		return false, nil
+	}
+	debugf("Removing %s\n", path)
+	return true, os.Remove(path)
+}
+
+// syncAutostart makes the autostart files match the config: writes or updates them if start on login
+// is enabled, and removes (stale) ones otherwise.
+func syncAutostart(ctx *ntcontext) error {
+	if sandboxed == sandboxFlatpak {
+		return requestPortalAutostart(ctx, ctx.config.Autostart)
+	}
+	unit, desktop := autostartUnitPath(), autostartDesktopPath()
+	if !ctx.config.Autostart {
+		if ours, _ := generatedByUs(unit); ours {
+			// disable while the unit file is still there, so systemd knows what to remove
+			if err := systemctlUser("disable", autostartUnit); err != nil {
+				errorf("Couldn't disable unit: %v\n", err)
+			}
+			if _, err := removeIfOurs(unit); err != nil {
+				return err
+			}
+			if err := systemctlUser("daemon-reload"); err != nil {
+				errorf("Couldn't reload systemd: %v\n", err)
+			}
+		}
+		_, err := removeIfOurs(desktop)
+		return err
+	}
+
+	if haveSystemdUser() {
+		content, err := autostartUnitContent(ctx)
+		if err != nil {
+			return err
+		}
+		changed, err := writeIfChanged(unit, content)
+		if err != nil {
+			return err
+		}
+		// a desktop entry from before systemd was available would load the filter a second time
+		if _, err := removeIfOurs(desktop); err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		if err := systemctlUser("daemon-reload"); err != nil {
+			return err
+		}
+		return systemctlUser("enable", autostartUnit)
+	}
+
+	content, err := autostartDesktopContent(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = writeIfChanged(desktop, content)
+	return err
+}
@@ -0,0 +1,128 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// `noisetorch bench` tells whether the machine, e.g. a Raspberry Pi, is fast enough for the filter
+// before loading it into the audio server. The filter host (see host.go) runs the chain of the
+// current settings over generated audio as fast as it can, and we compare the CPU time it took with
+// the length of the audio. RNNoise costs the same with or without voice, so generated audio is as
+// good as a recording.
+
+const (
+	defaultBenchSeconds = 5
+	maxBenchSeconds     = 60
+	benchFrame          = 480 // samples, the 10 ms RNNoise processes at once
+)
+
+type benchResult struct {
+	Engine       string  `json:"engine"`
+	Model        string  `json:"model"`
+	Gate         bool    `json:"gate"`
+	Seconds      float64 `json:"seconds"`      // of audio filtered
+	CPUSeconds   float64 `json:"cpuSeconds"`   // it took
+	FramesPerSec float64 `json:"framesPerSec"` // 10 ms frames per CPU second
+	RealtimeCPU  float64 `json:"realtimeCPU"`  // percent of one core filtering in real time takes
+	Cores        int     `json:"cores"`
+}
+
+// verdict sums up the result. Audio servers need headroom for themselves and other streams, so
+// half a core is where it gets tight.
+func (r benchResult) verdict() string {
+	switch {
+	case r.RealtimeCPU < 50:
+		return "Fast enough, the filter will run fine on this machine."
+	case r.RealtimeCPU < 90:
+		return "Fast enough, but with little room to spare: expect crackling while the machine is busy."
+	default:
+		return "Too slow, the filter can't keep up with the microphone on this machine."
+	}
+}
+
+func runBench(ctx *ntcontext, secs int) (benchResult, error) {
+	if err := installModel(ctx.librnnoise, activeModel(ctx)); err != nil {
+		return benchResult{}, err
+	}
+	host, err := dumpHost()
+	if err != nil {
+		return benchResult{}, err
+	}
+	chain, err := hostChainArgs(ctx)
+	if err != nil {
+		return benchResult{}, err
+	}
+	cmd := exec.Command(host, append([]string{"-B", strconv.Itoa(secs)}, chain...)...)
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return benchResult{}, fmt.Errorf("the filter host failed: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return benchResult{}, fmt.Errorf("couldn't run the filter host: %w", err)
+	}
+
+	// frames 4800000 cpu 1.234567
+	var frames int64
+	var cpu float64
+	if _, err := fmt.Sscanf(string(out), "frames %d cpu %f", &frames, &cpu); err != nil || frames <= 0 || cpu <= 0 {
+		return benchResult{}, fmt.Errorf("unexpected output of the filter host: %s", strings.TrimSpace(string(out)))
+	}
+	res := benchResult{
+		Engine:     currentEngine(ctx).name,
+		Model:      modelName(activeModel(ctx)),
+		Gate:       ctx.config.Gate,
+		Seconds:    float64(frames) / filterRate,
+		CPUSeconds: cpu,
+		Cores:      runtime.NumCPU(),
+	}
+	res.FramesPerSec = float64(frames) / benchFrame / cpu
+	res.RealtimeCPU = cpu / res.Seconds * 100
+	infof("Benchmark: %.1fs of audio in %.2fs of CPU, %.1f%% of a core in real time\n", res.Seconds, cpu, res.RealtimeCPU)
+	return res, nil
+}
+
+// benchCLI implements `noisetorch bench`.
+func benchCLI(ctx *ntcontext, opt CLIOpts) {
+	if opt.benchSecs < 1 || opt.benchSecs > maxBenchSeconds {
+		opt.fail("-seconds must be between 1 and %d\n", maxBenchSeconds)
+	}
+	if opt.model != "" {
+		if err := validateModel(opt.model); err != nil {
+			opt.fail("Invalid model: %v\n", err)
+		}
+		ctx.config.Model = opt.model
+	}
+	if !opt.json {
+		fmt.Printf("Filtering generated audio for %d seconds...\n", opt.benchSecs)
+	}
+	res, err := runBench(ctx, opt.benchSecs)
+	if err != nil {
+		opt.fail("Couldn't run the benchmark: %v\n", err)
+	}
+	if opt.json {
+		printJSON(res)
+		cleanupExit(0)
+	}
+
+	fmt.Printf("Engine: %s", res.Engine)
+	if currentEngine(ctx).rnnoise {
+		fmt.Printf(", model: %s", res.Model)
+	}
+	if res.Gate {
+		fmt.Printf(", with the noise gate")
+	}
+	fmt.Println()
+	fmt.Printf("Filtered %.1f s of audio in %.2f s of CPU time\n", res.Seconds, res.CPUSeconds)
+	fmt.Printf("Frames per second: %.0f (10 ms frames, real time needs 100)\n", res.FramesPerSec)
+	fmt.Printf("CPU for a microphone in real time: %.1f%% of one core (of %d)\n", res.RealtimeCPU, res.Cores)
+	fmt.Println(res.verdict())
+	cleanupExit(0)
+}
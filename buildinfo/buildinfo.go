@@ -0,0 +1,74 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+// Package buildinfo holds everything that is configured at build time through -ldflags '-X ...'.
+//
+// Distributions that want to turn off a subsystem should set the corresponding feature flag, e.g.
+//
+//	-X noisetorch/buildinfo.hotkeys=false
+//
+// instead of patching the source or relying on the value of Distribution.
+package buildinfo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// these will be changed by the build
+var (
+	NameSuffix   = ""
+	Version      = "unknown"
+	Distribution = "custom"
+	UpdateURL    = ""
+	PublicKey    = ""
+	WebsiteURL   = ""
+	SystemPlugin = "" // path of a distribution's RNNoise plugin, preferred over the bundled one
+)
+
+// feature flags, set to "false" to compile a build without the subsystem enabled
+var (
+	updates = "true"
+	tray    = "true"
+	hotkeys = "true"
+	webUI   = "true"
+)
+
+// Features describes which optional subsystems are enabled in this build.
+type Features struct {
+	Updates bool
+	Tray    bool
+	Hotkeys bool
+	WebUI   bool
+}
+
+// Enabled returns the feature set of this build.
+func Enabled() Features {
+	return Features{
+		Updates: enabled(updates) && UpdateURL != "" && PublicKey != "",
+		Tray:    enabled(tray),
+		Hotkeys: enabled(hotkeys),
+		WebUI:   enabled(webUI),
+	}
+}
+
+func enabled(flag string) bool {
+	return flag != "false" && flag != "0"
+}
+
+// String returns a human readable summary, used for -buildinfo.
+func String() string {
+	f := Enabled()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Version: %s\n", Version)
+	fmt.Fprintf(&b, "Distribution: %s\n", Distribution)
+	fmt.Fprintf(&b, "Website: %s\n", WebsiteURL)
+	fmt.Fprintf(&b, "Update URL: %s\n", UpdateURL)
+	fmt.Fprintf(&b, "System plugin: %s\n", SystemPlugin)
+	fmt.Fprintf(&b, "Features:\n")
+	fmt.Fprintf(&b, "\tupdates: %t\n", f.Updates)
+	fmt.Fprintf(&b, "\ttray: %t\n", f.Tray)
+	fmt.Fprintf(&b, "\thotkeys: %t\n", f.Hotkeys)
+	fmt.Fprintf(&b, "\twebui: %t\n", f.WebUI)
+	return b.String()
+}
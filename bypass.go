@@ -0,0 +1,203 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Bypass lets users compare the filtered microphone with the raw one without unloading anything,
+// e.g. to show the other side of a call what the filter does. A second loopback feeds the raw
+// microphone straight into the denoised null sink, and the two loopbacks are crossfaded. Applications
+// keep recording from the same filtered microphone all the while.
+//
+// It needs the null sink of the PulseAudio chain. On PipeWire the filtered microphone is the
+// filter itself, there's nothing to mix the raw microphone into.
+
+const (
+	bypassFade  = 250 * time.Millisecond
+	bypassSteps = 10
+)
+
+type bypass struct {
+	sync.Mutex
+	active bool
+	busy   bool
+}
+
+func bypassAvailable(ctx *ntcontext) bool {
+	return ctx.serverInfo.servertype == servertype_pulse && ctx.config.FilterInput && ctx.noiseSupressorState == loaded
+}
+
+// uiToggleBypass crossfades between the filtered and the raw microphone.
+func uiToggleBypass(ctx *ntcontext) {
+	b := &ctx.bypass
+	b.Lock()
+	if b.busy || !bypassAvailable(ctx) {
+		b.Unlock()
+		return
+	}
+	b.busy = true
+	enable := !b.active
+	b.Unlock()
+
+	err := setBypass(ctx, enable)
+	b.Lock()
+	b.busy = false
+	if err == nil {
+		b.active = enable
+	}
+	b.Unlock()
+	if err != nil {
+		errorf("Couldn't switch bypass: %v\n", err)
+		ctx.views.Push(makeErrorView(ctx, trf("Couldn't switch bypass: %v", err)))
+	}
+	(*ctx.masterWindow).Changed()
+}
+
+// endBypass switches back to the filter, e.g. before the chain is reloaded.
+func endBypass(ctx *ntcontext) {
+	b := &ctx.bypass
+	b.Lock()
+	active := b.active
+	b.active = false
+	b.Unlock()
+	if active && ctx.noiseSupressorState == loaded {
+		if err := setBypass(ctx, false); err != nil {
+			errorf("Couldn't end bypass: %v\n", err)
+		}
+	}
+}
+
+func setBypass(ctx *ntcontext, enable bool) error {
+	c := ctx.paClient
+	for _, inp := range inputSelections(ctx) {
+		names := inputChainFor(&inp)
+		filter, found, err := findChainModule(ctx, "module-loopback", "sink="+names.raw+" ")
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("the loopback of %s isn't loaded", inp.Name)
+		}
+		raw, found, err := findChainModule(ctx, "module-loopback", "sink="+names.denoised+" ")
+		if err != nil {
+			return err
+		}
+		rawIdx := raw.Index
+		if !found {
+			if !enable {
+				continue
+			}
+			rawIdx, err = loadModule(ctx, "module-loopback",
+				fmt.Sprintf("source=%s sink=%s %s %s latency_msec=%d source_dont_move=true sink_dont_move=true"+
+					" "+loopbackStreamProperties(ctx), inp.ID, names.denoised, loopbackChannelArgs(&inp), loopbackRateArgs(ctx), inputLoopbackLatency(ctx, &inp)))
+			if err != nil {
+				return err
+			}
+			debugf("Loaded bypass loopback as idx: %d\n", rawIdx)
+		}
+
+		inputs, err := listSinkInputs()
+		if err != nil {
+			return err
+		}
+		// the first step of the crossfade silences a new bypass loopback, it only plays for a moment
+		filterStream, filterOk := streamOf(inputs, filter.Index)
+		rawStream, rawOk := streamOf(inputs, rawIdx)
+		if !filterOk || !rawOk {
+			return fmt.Errorf("the loopbacks of %s have no streams", inp.Name)
+		}
+		if enable {
+			infof("Bypassing the filter of %s\n", inp.Name)
+			err = crossfade(filterStream, rawStream)
+		} else {
+			infof("Filtering %s again\n", inp.Name)
+			err = crossfade(rawStream, filterStream)
+		}
+		if err != nil {
+			return err
+		}
+		if !enable {
+			c.UnloadModule(rawIdx)
+		}
+	}
+	return nil
+}
+
+// streamOf returns the index of the stream created by module.
+func streamOf(streams []stream, module uint32) (uint32, bool) {
+	for _, s := range streams {
+		if s.module == module {
+			return s.index, true
+		}
+	}
+	return 0, false
+}
+
+// crossfade fades out the sink input from while fading in the one to, keeping the loudness the same.
+func crossfade(from, to uint32) error {
+	for i := 0; i <= bypassSteps; i++ {
+		t := float64(i) / bypassSteps
+		if err := setSinkInputVolume(from, math.Cos(t*math.Pi/2)); err != nil {
+			return err
+		}
+		if err := setSinkInputVolume(to, math.Sin(t*math.Pi/2)); err != nil {
+			return err
+		}
+		if i < bypassSteps {
+			time.Sleep(bypassFade / bypassSteps)
+		}
+	}
+	return nil
+}
+
+// setSinkInputVolume sets the volume of a sink input, 1 being 100%.
+func setSinkInputVolume(index uint32, volume float64) error {
+	cmd := audioCommand("pactl", "set-sink-input-volume", strconv.FormatUint(uint64(index), 10),
+		fmt.Sprintf("%d%%", int(math.Round(volume*100))))
+	debugf("Calling: %s\n", cmd.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pactl set-sink-input-volume failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func bypassView(ctx *ntcontext, w *nucular.Window) {
+	if !bypassAvailable(ctx) {
+		return
+	}
+	b := &ctx.bypass
+	b.Lock()
+	active, busy := b.active, b.busy
+	b.Unlock()
+
+	txt := tr("Bypass")
+	if active {
+		txt = tr("Filter again")
+	}
+	if key := ctx.config.Hotkeys["bypass"]; key != "" {
+		txt += " (" + key + ")"
+	}
+	w.Row(25).Ratio(0.7, 0.3)
+	if active {
+		w.LabelColored(tr("Bypassed, the filtered microphone is unfiltered"), "LC", orange)
+	} else {
+		w.Label(tr("Compare with the unfiltered microphone"), "LC")
+	}
+	if busy {
+		w.Spacing(1)
+		return
+	}
+	if focusable(ctx, w, w.ButtonText(txt)) {
+		go uiToggleBypass(ctx)
+	}
+}
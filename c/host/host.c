@@ -0,0 +1,716 @@
+/*
+  (c) Copyright 2021 github.com/lawl GPL3+
+
+  Runs a chain of LADSPA plugins outside of the audio server, started by
+  NoiseTorch-ng for the JACK backend (jack.go) and the ALSA mode (alsa.go).
+
+  libjack and libasound are opened at runtime, so this builds without their
+  headers and uses whatever the system has: the libjack of jackd, or the one
+  of PipeWire when started through pw-jack.
+
+  noisetorch-host [-n name] [-c channels] [-r rate] [-i regex]... [-o regex]...
+                  -p plugin.so:label [-C port=value]... [-p ...]
+  noisetorch-host -l
+  noisetorch-host -A capture -P playback [-f] [-c channels] [-r rate]
+                  -p plugin.so:label [-C port=value]... [-p ...]
+  noisetorch-host -B seconds [-r rate] -p plugin.so:label [-C port=value]...
+
+  Without -A it's a JACK client. -i and -o are the auto-connection rules: the
+  ports matching them are connected to the inputs, or from the outputs, of
+  the client, spread over the channels in order. Ports appearing later are
+  connected as well. -l lists the audio ports of the server, one per line:
+  name, tab, in or out.
+
+  With -A it reads from the ALSA capture device and writes the filtered audio
+  to the playback device, usually a side of snd-aloop.
+
+  With -B it runs the chain over generated audio, a voice-like tone in white
+  noise, as fast as it can for the given number of seconds and prints how
+  much it filtered: "frames", the number of samples, and "cpu", the CPU
+  seconds it took, e.g. "frames 4800000 cpu 1.234567". Nothing is opened.
+
+  Once running it prints "ready" and, unless -f, detaches from stdout and
+  stderr. Errors before that go to stderr with a non-zero exit status.
+*/
+
+#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <math.h>
+#include <poll.h>
+#include <signal.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <time.h>
+#include <unistd.h>
+
+#include "../ladspa/ladspa.h"
+
+#define MAX_CHANNELS 2
+#define MAX_STAGES 4
+#define MAX_RULES 32
+#define CHUNK 1024
+#define ALSA_PERIOD 480 /* 10ms at 48kHz, what RNNoise processes at once */
+
+/* the subset of jack/jack.h we use */
+typedef struct _jack_client jack_client_t;
+typedef struct _jack_port jack_port_t;
+typedef uint32_t jack_nframes_t;
+typedef uint32_t jack_port_id_t;
+typedef int jack_options_t;
+typedef int jack_status_t;
+
+#define JackNoStartServer 0x01
+#define JackPortIsInput 0x1
+#define JackPortIsOutput 0x2
+#define JACK_DEFAULT_AUDIO_TYPE "32 bit float mono audio"
+
+static struct {
+  jack_client_t *(*client_open)(const char *, jack_options_t, jack_status_t *,
+                                ...);
+  int (*client_close)(jack_client_t *);
+  int (*activate)(jack_client_t *);
+  int (*deactivate)(jack_client_t *);
+  jack_nframes_t (*get_sample_rate)(jack_client_t *);
+  jack_port_t *(*port_register)(jack_client_t *, const char *, const char *,
+                                unsigned long, unsigned long);
+  void *(*port_get_buffer)(jack_port_t *, jack_nframes_t);
+  const char *(*port_name)(const jack_port_t *);
+  int (*set_process_callback)(jack_client_t *, int (*)(jack_nframes_t, void *),
+                              void *);
+  int (*set_port_registration_callback)(jack_client_t *,
+                                        void (*)(jack_port_id_t, int, void *),
+                                        void *);
+  void (*on_shutdown)(jack_client_t *, void (*)(void *), void *);
+  const char **(*get_ports)(jack_client_t *, const char *, const char *,
+                            unsigned long);
+  int (*connect)(jack_client_t *, const char *, const char *);
+  void (*free)(void *);
+} jack;
+
+static int load_jack(void) {
+  void *lib = dlopen("libjack.so.0", RTLD_NOW);
+  if (!lib) {
+    fprintf(stderr, "couldn't load libjack: %s\n", dlerror());
+    return -1;
+  }
+#define SYM(field, name)                                                       \
+  if (!(*(void **)&jack.field = dlsym(lib, name))) {                           \
+    fprintf(stderr, "libjack lacks %s\n", name);                               \
+    return -1;                                                                 \
+  }
+  SYM(client_open, "jack_client_open");
+  SYM(client_close, "jack_client_close");
+  SYM(activate, "jack_activate");
+  SYM(deactivate, "jack_deactivate");
+  SYM(get_sample_rate, "jack_get_sample_rate");
+  SYM(port_register, "jack_port_register");
+  SYM(port_get_buffer, "jack_port_get_buffer");
+  SYM(port_name, "jack_port_name");
+  SYM(set_process_callback, "jack_set_process_callback");
+  SYM(set_port_registration_callback, "jack_set_port_registration_callback");
+  SYM(on_shutdown, "jack_on_shutdown");
+  SYM(get_ports, "jack_get_ports");
+  SYM(connect, "jack_connect");
+  SYM(free, "jack_free");
+#undef SYM
+  return 0;
+}
+
+/* the subset of alsa/asoundlib.h we use */
+typedef struct _snd_pcm snd_pcm_t;
+typedef long snd_pcm_sframes_t;
+
+#define SND_PCM_STREAM_PLAYBACK 0
+#define SND_PCM_STREAM_CAPTURE 1
+#define SND_PCM_FORMAT_S16_LE 2
+#define SND_PCM_ACCESS_RW_INTERLEAVED 3
+
+static struct {
+  int (*pcm_open)(snd_pcm_t **, const char *, int, int);
+  int (*pcm_set_params)(snd_pcm_t *, int, int, unsigned int, unsigned int, int,
+                        unsigned int);
+  snd_pcm_sframes_t (*pcm_readi)(snd_pcm_t *, void *, unsigned long);
+  snd_pcm_sframes_t (*pcm_writei)(snd_pcm_t *, const void *, unsigned long);
+  int (*pcm_recover)(snd_pcm_t *, int, int);
+  int (*pcm_close)(snd_pcm_t *);
+  const char *(*strerror)(int);
+} alsa;
+
+static int load_alsa(void) {
+  void *lib = dlopen("libasound.so.2", RTLD_NOW);
+  if (!lib) {
+    fprintf(stderr, "couldn't load libasound: %s\n", dlerror());
+    return -1;
+  }
+#define SYM(field, name)                                                       \
+  if (!(*(void **)&alsa.field = dlsym(lib, name))) {                           \
+    fprintf(stderr, "libasound lacks %s\n", name);                            \
+    return -1;                                                                 \
+  }
+  SYM(pcm_open, "snd_pcm_open");
+  SYM(pcm_set_params, "snd_pcm_set_params");
+  SYM(pcm_readi, "snd_pcm_readi");
+  SYM(pcm_writei, "snd_pcm_writei");
+  SYM(pcm_recover, "snd_pcm_recover");
+  SYM(pcm_close, "snd_pcm_close");
+  SYM(strerror, "snd_strerror");
+#undef SYM
+  return 0;
+}
+
+typedef struct {
+  const LADSPA_Descriptor *desc;
+  LADSPA_Handle handle[MAX_CHANNELS];
+  LADSPA_Data *controls; /* one per port, the audio ones are unused */
+  unsigned long in, out;  /* the audio ports */
+} stage;
+
+static jack_client_t *client;
+static int nchannels = 1;
+static jack_port_t *inputs[MAX_CHANNELS], *outputs[MAX_CHANNELS];
+static stage stages[MAX_STAGES];
+static int nstages;
+static LADSPA_Data bufs[MAX_CHANNELS][2][CHUNK];
+
+static const char *in_rules[MAX_RULES], *out_rules[MAX_RULES];
+static int nin_rules, nout_rules;
+
+/* written to by callbacks and signal handlers, read by the main loop */
+static int wake[2];
+
+static void notify(char c) {
+  if (write(wake[1], &c, 1) < 0) {
+    /* the pipe is full, the main loop has enough to do */
+  }
+}
+
+static void on_signal(int sig) {
+  (void)sig;
+  notify('q');
+}
+
+static void on_shutdown(void *arg) {
+  (void)arg;
+  notify('q');
+}
+
+static void on_registration(jack_port_id_t id, int registered, void *arg) {
+  (void)id;
+  (void)arg;
+  if (registered)
+    notify('c');
+}
+
+/* run_chain filters n frames of channel c, at most CHUNK */
+static void run_chain(int c, const float *in, float *out, unsigned long n) {
+  memcpy(bufs[c][0], in, n * sizeof(float));
+  int cur = 0;
+  for (int s = 0; s < nstages; s++) {
+    stages[s].desc->run(stages[s].handle[c], n);
+    cur = !cur;
+  }
+  memcpy(out, bufs[c][cur], n * sizeof(float));
+}
+
+static int process(jack_nframes_t nframes, void *arg) {
+  (void)arg;
+  for (int c = 0; c < nchannels; c++) {
+    float *in = jack.port_get_buffer(inputs[c], nframes);
+    float *out = jack.port_get_buffer(outputs[c], nframes);
+    for (jack_nframes_t done = 0; done < nframes;) {
+      unsigned long n = nframes - done;
+      if (n > CHUNK)
+        n = CHUNK;
+      run_chain(c, in + done, out + done, n);
+      done += n;
+    }
+  }
+  return 0;
+}
+
+static int add_stage(const char *spec, unsigned long rate) {
+  if (nstages == MAX_STAGES) {
+    fprintf(stderr, "too many plugins\n");
+    return -1;
+  }
+  char *path = strdup(spec);
+  char *label = strrchr(path, ':');
+  if (!label) {
+    fprintf(stderr, "%s: expected plugin.so:label\n", spec);
+    return -1;
+  }
+  *label++ = '\0';
+
+  void *lib = dlopen(path, RTLD_NOW);
+  if (!lib) {
+    fprintf(stderr, "couldn't load %s: %s\n", path, dlerror());
+    return -1;
+  }
+  LADSPA_Descriptor_Function descriptor =
+      (LADSPA_Descriptor_Function)dlsym(lib, "ladspa_descriptor");
+  if (!descriptor) {
+    fprintf(stderr, "%s isn't a LADSPA plugin\n", path);
+    return -1;
+  }
+  stage *st = &stages[nstages];
+  for (unsigned long i = 0; (st->desc = descriptor(i)); i++) {
+    if (strcmp(st->desc->Label, label) == 0)
+      break;
+  }
+  if (!st->desc) {
+    fprintf(stderr, "%s has no plugin labelled %s\n", path, label);
+    return -1;
+  }
+
+  const LADSPA_Descriptor *d = st->desc;
+  st->controls = calloc(d->PortCount, sizeof(LADSPA_Data));
+  st->in = st->out = d->PortCount;
+  for (unsigned long p = 0; p < d->PortCount; p++) {
+    LADSPA_PortDescriptor pd = d->PortDescriptors[p];
+    if (LADSPA_IS_PORT_AUDIO(pd) && LADSPA_IS_PORT_INPUT(pd))
+      st->in = p;
+    if (LADSPA_IS_PORT_AUDIO(pd) && LADSPA_IS_PORT_OUTPUT(pd))
+      st->out = p;
+  }
+  if (st->in == d->PortCount || st->out == d->PortCount) {
+    fprintf(stderr, "%s isn't a mono filter\n", label);
+    return -1;
+  }
+
+  int from = nstages % 2;
+  for (int c = 0; c < nchannels; c++) {
+    st->handle[c] = d->instantiate(d, rate);
+    if (!st->handle[c]) {
+      fprintf(stderr, "couldn't instantiate %s\n", label);
+      return -1;
+    }
+    for (unsigned long p = 0; p < d->PortCount; p++) {
+      if (p == st->in)
+        d->connect_port(st->handle[c], p, bufs[c][from]);
+      else if (p == st->out)
+        d->connect_port(st->handle[c], p, bufs[c][!from]);
+      else
+        d->connect_port(st->handle[c], p, &st->controls[p]);
+    }
+  }
+  nstages++;
+  return 0;
+}
+
+static int set_control(const char *spec) {
+  if (nstages == 0) {
+    fprintf(stderr, "-C %s comes before any -p\n", spec);
+    return -1;
+  }
+  const char *eq = strrchr(spec, '=');
+  if (!eq) {
+    fprintf(stderr, "%s: expected port=value\n", spec);
+    return -1;
+  }
+  stage *st = &stages[nstages - 1];
+  size_t len = eq - spec;
+  for (unsigned long p = 0; p < st->desc->PortCount; p++) {
+    const char *name = st->desc->PortNames[p];
+    if (strlen(name) == len && strncmp(name, spec, len) == 0 &&
+        LADSPA_IS_PORT_CONTROL(st->desc->PortDescriptors[p])) {
+      st->controls[p] = strtof(eq + 1, NULL);
+      return 0;
+    }
+  }
+  fprintf(stderr, "%s has no control port %.*s\n", st->desc->Label, (int)len,
+          spec);
+  return -1;
+}
+
+static int ours(const char *port) {
+  const char *own = jack.port_name(inputs[0]);
+  size_t len = strchr(own, ':') - own + 1;
+  return strncmp(port, own, len) == 0;
+}
+
+/* connect applies the auto-connection rules, connections that already exist
+   are left alone by JACK */
+static void connect_ports(void) {
+  for (int r = 0; r < nin_rules; r++) {
+    const char **ports = jack.get_ports(client, in_rules[r],
+                                        JACK_DEFAULT_AUDIO_TYPE, JackPortIsOutput);
+    for (int i = 0, k = 0; ports && ports[i]; i++) {
+      if (ours(ports[i]))
+        continue;
+      jack.connect(client, ports[i], jack.port_name(inputs[k++ % nchannels]));
+    }
+    jack.free(ports);
+  }
+  for (int r = 0; r < nout_rules; r++) {
+    const char **ports = jack.get_ports(client, out_rules[r],
+                                        JACK_DEFAULT_AUDIO_TYPE, JackPortIsInput);
+    for (int i = 0, k = 0; ports && ports[i]; i++) {
+      if (ours(ports[i]))
+        continue;
+      jack.connect(client, jack.port_name(outputs[k++ % nchannels]), ports[i]);
+    }
+    jack.free(ports);
+  }
+}
+
+static int list_ports(void) {
+  const char **ports =
+      jack.get_ports(client, NULL, JACK_DEFAULT_AUDIO_TYPE, JackPortIsOutput);
+  for (int i = 0; ports && ports[i]; i++)
+    printf("%s\tout\n", ports[i]);
+  jack.free(ports);
+  ports = jack.get_ports(client, NULL, JACK_DEFAULT_AUDIO_TYPE, JackPortIsInput);
+  for (int i = 0; ports && ports[i]; i++)
+    printf("%s\tin\n", ports[i]);
+  jack.free(ports);
+  jack.client_close(client);
+  return 0;
+}
+
+static void detach(void) {
+  int null = open("/dev/null", O_RDWR);
+  if (null >= 0) {
+    dup2(null, STDOUT_FILENO);
+    dup2(null, STDERR_FILENO);
+    close(null);
+  }
+}
+
+static void ready(int foreground) {
+  printf("ready\n");
+  fflush(stdout);
+  if (!foreground)
+    detach();
+}
+
+static void activate_stages(void) {
+  for (int s = 0; s < nstages; s++) {
+    for (int c = 0; c < nchannels; c++) {
+      if (stages[s].desc->activate)
+        stages[s].desc->activate(stages[s].handle[c]);
+    }
+  }
+}
+
+static void cleanup_stages(void) {
+  for (int s = 0; s < nstages; s++) {
+    for (int c = 0; c < nchannels; c++) {
+      if (stages[s].desc->deactivate)
+        stages[s].desc->deactivate(stages[s].handle[c]);
+      stages[s].desc->cleanup(stages[s].handle[c]);
+    }
+  }
+}
+
+/* the -p and -C arguments, applied once the sample rate is known, in order */
+static struct {
+  int opt;
+  const char *arg;
+} chain[MAX_STAGES * 16];
+static int nchain;
+
+static int setup_chain(unsigned long rate) {
+  for (int i = 0; i < nchain; i++) {
+    int err = chain[i].opt == 'p' ? add_stage(chain[i].arg, rate)
+                                  : set_control(chain[i].arg);
+    if (err < 0)
+      return -1;
+  }
+  if (nstages == 0) {
+    fprintf(stderr, "no plugin given\n");
+    return -1;
+  }
+  activate_stages();
+  return 0;
+}
+
+static int open_pcm(snd_pcm_t **pcm, const char *name, int stream,
+                    unsigned long rate) {
+  int err = alsa.pcm_open(pcm, name, stream, 0);
+  if (err < 0) {
+    fprintf(stderr, "couldn't open %s: %s\n", name, alsa.strerror(err));
+    return -1;
+  }
+  /* resampled by ALSA if the device doesn't run at the rate */
+  err = alsa.pcm_set_params(*pcm, SND_PCM_FORMAT_S16_LE,
+                            SND_PCM_ACCESS_RW_INTERLEAVED, nchannels, rate, 1,
+                            4 * ALSA_PERIOD * 1000000 / rate);
+  if (err < 0) {
+    fprintf(stderr, "couldn't set up %s: %s\n", name, alsa.strerror(err));
+    return -1;
+  }
+  return 0;
+}
+
+static volatile sig_atomic_t quit;
+
+static void on_quit(int sig) {
+  (void)sig;
+  quit = 1;
+}
+
+static int run_alsa(const char *capture, const char *playback,
+                    unsigned long rate, int foreground) {
+  snd_pcm_t *in, *out;
+  if (load_alsa() < 0 || setup_chain(rate) < 0)
+    return 1;
+  if (open_pcm(&in, capture, SND_PCM_STREAM_CAPTURE, rate) < 0 ||
+      open_pcm(&out, playback, SND_PCM_STREAM_PLAYBACK, rate) < 0)
+    return 1;
+
+  struct sigaction sa = {.sa_handler = on_quit};
+  sigaction(SIGTERM, &sa, NULL);
+  sigaction(SIGINT, &sa, NULL);
+  signal(SIGHUP, SIG_IGN);
+  ready(foreground);
+
+  static int16_t frames[ALSA_PERIOD * MAX_CHANNELS];
+  static float raw[MAX_CHANNELS][ALSA_PERIOD], filtered[MAX_CHANNELS][ALSA_PERIOD];
+  while (!quit) {
+    snd_pcm_sframes_t n = alsa.pcm_readi(in, frames, ALSA_PERIOD);
+    if (n < 0) {
+      if (quit || alsa.pcm_recover(in, n, 1) < 0) {
+        fprintf(stderr, "capture failed: %s\n", alsa.strerror(n));
+        break;
+      }
+      continue;
+    }
+    for (snd_pcm_sframes_t i = 0; i < n; i++) {
+      for (int c = 0; c < nchannels; c++)
+        raw[c][i] = frames[i * nchannels + c] / 32768.0f;
+    }
+    for (int c = 0; c < nchannels; c++)
+      run_chain(c, raw[c], filtered[c], n);
+    for (snd_pcm_sframes_t i = 0; i < n; i++) {
+      for (int c = 0; c < nchannels; c++) {
+        float v = filtered[c][i] * 32768.0f;
+        frames[i * nchannels + c] =
+            v > 32767.0f ? 32767 : v < -32768.0f ? -32768 : (int16_t)v;
+      }
+    }
+    for (snd_pcm_sframes_t done = 0; done < n && !quit;) {
+      snd_pcm_sframes_t w =
+          alsa.pcm_writei(out, frames + done * nchannels, n - done);
+      if (w < 0) {
+        /* after an underrun the playback starts over */
+        if (alsa.pcm_recover(out, w, 1) < 0) {
+          fprintf(stderr, "playback failed: %s\n", alsa.strerror(w));
+          quit = 1;
+        }
+        continue;
+      }
+      done += w;
+    }
+  }
+
+  alsa.pcm_close(in);
+  alsa.pcm_close(out);
+  cleanup_stages();
+  return 0;
+}
+
+static double seconds(clockid_t clock) {
+  struct timespec ts;
+  clock_gettime(clock, &ts);
+  return ts.tv_sec + ts.tv_nsec / 1e9;
+}
+
+static int run_bench(unsigned long rate, double duration) {
+  if (setup_chain(rate) < 0)
+    return 1;
+
+  /* a second of audio, filtered over and over: a 150 Hz voice with a few
+     harmonics, spoken in 4 Hz syllables, in white noise */
+  float *audio = malloc(rate * sizeof(float));
+  if (!audio) {
+    perror("malloc");
+    return 1;
+  }
+  uint32_t seed = 1;
+  for (unsigned long i = 0; i < rate; i++) {
+    double t = (double)i / rate, voice = 0;
+    for (int h = 1; h <= 5; h++)
+      voice += sin(2 * M_PI * 150 * h * t) / h;
+    voice *= 0.15 * (0.5 + 0.5 * sin(2 * M_PI * 4 * t));
+    seed = seed * 1664525 + 1013904223;
+    audio[i] = voice + 0.05 * ((double)seed / UINT32_MAX * 2 - 1);
+  }
+
+  static float filtered[ALSA_PERIOD];
+  unsigned long frames = 0, pos = 0;
+  double start = seconds(CLOCK_MONOTONIC);
+  double cpu = seconds(CLOCK_PROCESS_CPUTIME_ID);
+  while (seconds(CLOCK_MONOTONIC) - start < duration) {
+    /* the clock isn't free, look at it every 100 periods */
+    for (int i = 0; i < 100; i++) {
+      if (pos + ALSA_PERIOD > rate)
+        pos = 0;
+      run_chain(0, audio + pos, filtered, ALSA_PERIOD);
+      pos += ALSA_PERIOD;
+      frames += ALSA_PERIOD;
+    }
+  }
+  cpu = seconds(CLOCK_PROCESS_CPUTIME_ID) - cpu;
+
+  printf("frames %lu cpu %.6f\n", frames, cpu);
+  cleanup_stages();
+  free(audio);
+  return 0;
+}
+
+static int run_jack(const char *name, unsigned long rate, int list,
+                    int foreground) {
+  if (load_jack() < 0)
+    return 1;
+  jack_status_t status;
+  client = jack.client_open(name, JackNoStartServer, &status);
+  if (!client) {
+    fprintf(stderr, "couldn't connect to the JACK server (status 0x%x)\n",
+            status);
+    return 1;
+  }
+  if (list)
+    return list_ports();
+
+  jack_nframes_t server_rate = jack.get_sample_rate(client);
+  if (rate && server_rate != rate) {
+    fprintf(stderr, "the filter needs a sample rate of %lu Hz, JACK runs at %u Hz\n",
+            rate, server_rate);
+    return 1;
+  }
+  if (setup_chain(server_rate) < 0)
+    return 1;
+
+  for (int c = 0; c < nchannels; c++) {
+    char port[32];
+    snprintf(port, sizeof(port), "in_%d", c + 1);
+    inputs[c] = jack.port_register(client, port, JACK_DEFAULT_AUDIO_TYPE,
+                                   JackPortIsInput, 0);
+    snprintf(port, sizeof(port), "out_%d", c + 1);
+    outputs[c] = jack.port_register(client, port, JACK_DEFAULT_AUDIO_TYPE,
+                                    JackPortIsOutput, 0);
+    if (!inputs[c] || !outputs[c]) {
+      fprintf(stderr, "couldn't register the ports\n");
+      return 1;
+    }
+  }
+
+  if (pipe2(wake, O_CLOEXEC | O_NONBLOCK) < 0) {
+    perror("pipe");
+    return 1;
+  }
+  signal(SIGTERM, on_signal);
+  signal(SIGINT, on_signal);
+  signal(SIGHUP, SIG_IGN);
+  jack.set_process_callback(client, process, NULL);
+  jack.set_port_registration_callback(client, on_registration, NULL);
+  jack.on_shutdown(client, on_shutdown, NULL);
+  if (jack.activate(client)) {
+    fprintf(stderr, "couldn't activate the client\n");
+    return 1;
+  }
+  connect_ports();
+  ready(foreground);
+
+  for (;;) {
+    struct pollfd p = {.fd = wake[0], .events = POLLIN};
+    if (poll(&p, 1, -1) < 0 && errno != EINTR)
+      break;
+    char cmds[64];
+    ssize_t n = read(wake[0], cmds, sizeof(cmds));
+    if (memchr(cmds, 'q', n > 0 ? n : 0))
+      break;
+    if (n > 0)
+      connect_ports();
+  }
+
+  jack.deactivate(client);
+  cleanup_stages();
+  jack.client_close(client);
+  return 0;
+}
+
+int main(int argc, char **argv) {
+  const char *name = "NoiseTorch", *capture = NULL, *playback = NULL;
+  int list = 0, foreground = 0;
+  unsigned long rate = 0;
+  double bench = 0;
+  int opt;
+
+  signal(SIGPIPE, SIG_IGN);
+  while ((opt = getopt(argc, argv, "n:c:r:i:o:p:C:lA:P:fB:")) != -1) {
+    switch (opt) {
+    case 'n':
+      name = optarg;
+      break;
+    case 'c':
+      nchannels = atoi(optarg);
+      if (nchannels < 1 || nchannels > MAX_CHANNELS) {
+        fprintf(stderr, "-c must be between 1 and %d\n", MAX_CHANNELS);
+        return 2;
+      }
+      break;
+    case 'r':
+      rate = strtoul(optarg, NULL, 10);
+      break;
+    case 'i':
+    case 'o':
+      if ((opt == 'i' ? nin_rules : nout_rules) == MAX_RULES) {
+        fprintf(stderr, "too many -%c rules\n", opt);
+        return 2;
+      }
+      if (opt == 'i')
+        in_rules[nin_rules++] = optarg;
+      else
+        out_rules[nout_rules++] = optarg;
+      break;
+    case 'p':
+    case 'C':
+      if (nchain == (int)(sizeof(chain) / sizeof(chain[0]))) {
+        fprintf(stderr, "too many arguments\n");
+        return 2;
+      }
+      chain[nchain].opt = opt;
+      chain[nchain++].arg = optarg;
+      break;
+    case 'l':
+      list = 1;
+      break;
+    case 'A':
+      capture = optarg;
+      break;
+    case 'P':
+      playback = optarg;
+      break;
+    case 'f':
+      foreground = 1;
+      break;
+    case 'B':
+      bench = strtod(optarg, NULL);
+      if (bench <= 0) {
+        fprintf(stderr, "-B must be a number of seconds\n");
+        return 2;
+      }
+      break;
+    default:
+      return 2;
+    }
+  }
+
+  if (bench > 0) {
+    nchannels = 1;
+    return run_bench(rate ? rate : 48000, bench);
+  }
+  if (capture || playback) {
+    if (!capture || !playback) {
+      fprintf(stderr, "-A and -P go together\n");
+      return 2;
+    }
+    return run_alsa(capture, playback, rate ? rate : 48000, foreground);
+  }
+  return run_jack(name, rate, list, foreground);
+}
@@ -4,9 +4,17 @@
   warranty.
 */
 
+#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <fcntl.h>
 #include <math.h>
+#include <stdint.h>
+#include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
+#include <sys/mman.h>
+#include <time.h>
+#include <unistd.h>
 
 #include "ladspa.h"
 #include "utils.h"
@@ -17,26 +25,125 @@
 #define SF_INPUT 0
 #define SF_OUTPUT 1
 #define SF_VAD 2
+#define SF_SUPPRESSION 3
 
 #define FRAMESIZE_NSAMPLES 480
 #define FRAMESIZE_BYTES (480 * sizeof(float))
 
 #define VAD_GRACE_PERIOD 20
 
+/* Bumped whenever the labels, the ports or the VAD status file change.
+   NoiseTorch only uses a copy of this plugin installed by a distribution if
+   it has the ABI it expects. Keep in sync with pluginABI in systemplugin.go */
+const uint32_t nt_ladspa_abi = 1;
+
+/* Published through a small mmaped file in $XDG_RUNTIME_DIR, so NoiseTorch
+   can show whether the gate is currently open. Keep in sync with vad.go */
+typedef struct {
+  int64_t updated_ms;
+  float vad_prob;
+  int32_t gate_open;
+} vadStatus;
+
 typedef struct {
 
   DenoiseState *st;
+  RNNModel *model;
   ringbuf_t in_buf;
   ringbuf_t out_buf;
   int32_t remaining_grace_period;
   int init;
 
+  /* the raw frame RNNoise got last, its output is one frame behind */
+  float prev_frame[FRAMESIZE_NSAMPLES];
+
   LADSPA_Data *m_pfVAD;
+  LADSPA_Data *m_pfSuppression;
   LADSPA_Data *m_pfInput;
   LADSPA_Data *m_pfOutput;
 
+  vadStatus *status;
+  char *status_path;
+
 } rnnoiseFilter;
 
+static void openVADStatus(rnnoiseFilter *psFilter) {
+  const char *dir = getenv("XDG_RUNTIME_DIR");
+  char path[4096];
+  int fd;
+  void *m;
+
+  psFilter->status = NULL;
+  psFilter->status_path = NULL;
+
+  /* a shared directory like /tmp would let others plant or read the file */
+  if (dir == NULL || dir[0] == '\0') {
+    return;
+  }
+  snprintf(path, sizeof(path), "%s/noisetorch-vad-%d-%p", dir, (int)getpid(),
+           (void *)psFilter);
+
+  fd = open(path, O_RDWR | O_CREAT | O_EXCL | O_NOFOLLOW, 0600);
+  if (fd < 0) {
+    return;
+  }
+  if (ftruncate(fd, sizeof(vadStatus)) == 0) {
+    m = mmap(NULL, sizeof(vadStatus), PROT_READ | PROT_WRITE, MAP_SHARED, fd,
+             0);
+    if (m != MAP_FAILED) {
+      psFilter->status = (vadStatus *)m;
+      psFilter->status_path = strdup(path);
+    }
+  }
+  close(fd);
+
+  if (psFilter->status == NULL) {
+    unlink(path);
+  }
+}
+
+static void closeVADStatus(rnnoiseFilter *psFilter) {
+  if (psFilter->status != NULL) {
+    munmap(psFilter->status, sizeof(vadStatus));
+    unlink(psFilter->status_path);
+    free(psFilter->status_path);
+  }
+}
+
+static void updateVADStatus(rnnoiseFilter *psFilter, float vad_prob) {
+  struct timespec now;
+
+  if (psFilter->status == NULL) {
+    return;
+  }
+  clock_gettime(CLOCK_REALTIME, &now);
+  psFilter->status->vad_prob = vad_prob;
+  psFilter->status->gate_open = psFilter->remaining_grace_period >= 0;
+  psFilter->status->updated_ms =
+      (int64_t)now.tv_sec * 1000 + now.tv_nsec / 1000000;
+}
+
+/* NoiseTorch places an alternative model next to the plugin as
+   "<plugin>.rnnn". Keep in sync with model.go */
+static RNNModel *loadModel(void) {
+  Dl_info info;
+  char path[4096];
+  FILE *f;
+  RNNModel *model;
+
+  if (dladdr((void *)loadModel, &info) == 0 || info.dli_fname == NULL) {
+    return NULL;
+  }
+  snprintf(path, sizeof(path), "%s.rnnn", info.dli_fname);
+  f = fopen(path, "r");
+  if (f == NULL) {
+    return NULL;
+  }
+  model = rnnoise_model_from_file(f);
+  fclose(f);
+  return model;
+}
+
 static LADSPA_Handle
 instantiateSimpleFilter(const LADSPA_Descriptor *Descriptor,
                         unsigned long SampleRate) {
@@ -50,7 +157,10 @@ instantiateSimpleFilter(const LADSPA_Descriptor *Descriptor,
     psFilter->out_buf = ringbuf_new(FRAMESIZE_BYTES * 100);
     psFilter->init = 0;
     psFilter->remaining_grace_period = VAD_GRACE_PERIOD;
-    psFilter->st = rnnoise_create(NULL);
+    memset(psFilter->prev_frame, 0, sizeof(psFilter->prev_frame));
+    psFilter->model = loadModel();
+    psFilter->st = rnnoise_create(psFilter->model);
+    openVADStatus(psFilter);
   }
 
   return psFilter;
@@ -72,6 +182,9 @@ static void connectPortToSimpleFilter(LADSPA_Handle Instance,
   case SF_VAD:
     psFilter->m_pfVAD = DataLocation;
     break;
+  case SF_SUPPRESSION:
+    psFilter->m_pfSuppression = DataLocation;
+    break;
   case SF_INPUT:
     psFilter->m_pfInput = DataLocation;
     break;
@@ -90,12 +203,16 @@ static void runFilter(LADSPA_Handle Instance, unsigned long n_samples) {
   ringbuf_t in_buf = psFilter->in_buf;
   ringbuf_t out_buf = psFilter->out_buf;
 
-  float *in, *out, vad_thresh;
+  float *in, *out, vad_thresh, wet;
 
   in = psFilter->m_pfInput;
   out = psFilter->m_pfOutput;
 
   vad_thresh = *psFilter->m_pfVAD / 100;
+  /* how much of the denoised signal is mixed with the raw one. RNNoise
+     returns the frame before the one it's given, so the raw signal is mixed
+     in one frame late to stay aligned, else the mix would comb filter */
+  wet = *psFilter->m_pfSuppression / 100;
 
   for (int i = 0; i < n_samples; i++) {
     in[i] = in[i] * 32767;
@@ -115,13 +232,21 @@ static void runFilter(LADSPA_Handle Instance, unsigned long n_samples) {
       psFilter->remaining_grace_period = VAD_GRACE_PERIOD;
     }
 
-    if (psFilter->remaining_grace_period >= 0) {
+    const int gate_open = psFilter->remaining_grace_period >= 0;
+    if (gate_open) {
       psFilter->remaining_grace_period--;
-    } else {
-      for (int i = 0; i < FRAMESIZE_NSAMPLES; i++) {
-        tmp[i] = 0.f;
+    }
+    const float *raw = tmpin + (i * FRAMESIZE_NSAMPLES);
+    /* a closed gate mutes the raw signal too, not only the denoised one */
+    if (!gate_open) {
+      memset(tmp, 0, FRAMESIZE_BYTES);
+    } else if (wet < 1.f) {
+      for (int j = 0; j < FRAMESIZE_NSAMPLES; j++) {
+        tmp[j] = wet * tmp[j] + (1.f - wet) * psFilter->prev_frame[j];
       }
     }
+    memcpy(psFilter->prev_frame, raw, FRAMESIZE_BYTES);
+    updateVADStatus(psFilter, vad_prob);
     ringbuf_memcpy_into(out_buf, tmp, FRAMESIZE_BYTES);
   }
 
@@ -145,13 +270,169 @@ static void runFilter(LADSPA_Handle Instance, unsigned long n_samples) {
 
 static void cleanupFilter(LADSPA_Handle Instance) {
   rnnoiseFilter *psFilter = (rnnoiseFilter *)Instance;
+  closeVADStatus(psFilter);
   rnnoise_destroy(psFilter->st);
+  if (psFilter->model != NULL) {
+    rnnoise_model_free(psFilter->model);
+  }
   ringbuf_free(&(psFilter->in_buf));
   ringbuf_free(&(psFilter->out_buf));
   free(Instance);
 }
 
+/* Noise gate, meant to run after the filter to cut residual noise like
+   keyboard clicks that RNNoise lets through together with voice. */
+
+#define GATE_THRESHOLD 0
+#define GATE_ATTACK 1
+#define GATE_RELEASE 2
+#define GATE_INPUT 3
+#define GATE_OUTPUT 4
+
+typedef struct {
+  float sample_rate;
+  float envelope;
+  float gain;
+
+  LADSPA_Data *m_pfThreshold;
+  LADSPA_Data *m_pfAttack;
+  LADSPA_Data *m_pfRelease;
+  LADSPA_Data *m_pfInput;
+  LADSPA_Data *m_pfOutput;
+} noiseGate;
+
+static LADSPA_Handle instantiateGate(const LADSPA_Descriptor *Descriptor,
+                                     unsigned long SampleRate) {
+  noiseGate *psGate = (noiseGate *)calloc(1, sizeof(noiseGate));
+
+  if (psGate) {
+    psGate->sample_rate = (float)SampleRate;
+  }
+  return psGate;
+}
+
+static void connectPortToGate(LADSPA_Handle Instance, unsigned long Port,
+                              LADSPA_Data *DataLocation) {
+  noiseGate *psGate = (noiseGate *)Instance;
+
+  switch (Port) {
+  case GATE_THRESHOLD:
+    psGate->m_pfThreshold = DataLocation;
+    break;
+  case GATE_ATTACK:
+    psGate->m_pfAttack = DataLocation;
+    break;
+  case GATE_RELEASE:
+    psGate->m_pfRelease = DataLocation;
+    break;
+  case GATE_INPUT:
+    psGate->m_pfInput = DataLocation;
+    break;
+  case GATE_OUTPUT:
+    psGate->m_pfOutput = DataLocation;
+    break;
+  }
+}
+
+/* per sample gain step to fully open/close the gate in ms milliseconds */
+static float gateStep(float ms, float sample_rate) {
+  float samples = ms * sample_rate / 1000.f;
+  return samples < 1.f ? 1.f : 1.f / samples;
+}
+
+static void runGate(LADSPA_Handle Instance, unsigned long n_samples) {
+  noiseGate *psGate = (noiseGate *)Instance;
+  float threshold = powf(10.f, *psGate->m_pfThreshold / 20.f);
+  float attack = gateStep(*psGate->m_pfAttack, psGate->sample_rate);
+  float release = gateStep(*psGate->m_pfRelease, psGate->sample_rate);
+  /* the envelope decays over ~10ms so the gate doesn't follow single waveform periods */
+  float decay = 1.f - gateStep(10.f, psGate->sample_rate);
+
+  for (unsigned long i = 0; i < n_samples; i++) {
+    float in = psGate->m_pfInput[i];
+    float level = fabsf(in);
+
+    psGate->envelope = level > psGate->envelope ? level : psGate->envelope * decay;
+    if (psGate->envelope > threshold) {
+      psGate->gain += attack;
+    } else {
+      psGate->gain -= release;
+    }
+    if (psGate->gain > 1.f) {
+      psGate->gain = 1.f;
+    } else if (psGate->gain < 0.f) {
+      psGate->gain = 0.f;
+    }
+    psGate->m_pfOutput[i] = in * psGate->gain;
+  }
+}
+
+static void cleanupGate(LADSPA_Handle Instance) { free(Instance); }
+
 static LADSPA_Descriptor *g_psDescriptor = NULL;
+static LADSPA_Descriptor *g_psGateDescriptor = NULL;
+
+static LADSPA_Descriptor *makeGateDescriptor(void) {
+  char **pcPortNames;
+  LADSPA_PortDescriptor *piPortDescriptors;
+  LADSPA_PortRangeHint *psPortRangeHints;
+  LADSPA_Descriptor *psDescriptor;
+
+  psDescriptor = (LADSPA_Descriptor *)malloc(sizeof(LADSPA_Descriptor));
+  if (psDescriptor == NULL) {
+    return NULL;
+  }
+
+  psDescriptor->UniqueID = 16682995;
+  psDescriptor->Label = strdup("nt-gate");
+  psDescriptor->Properties = LADSPA_PROPERTY_HARD_RT_CAPABLE;
+  psDescriptor->Name = strdup("nt-gate noise gate ladspa module");
+  psDescriptor->Maker = strdup("nt-org");
+  psDescriptor->Copyright = strdup("GPL3+");
+  psDescriptor->PortCount = 5;
+  piPortDescriptors =
+      (LADSPA_PortDescriptor *)calloc(5, sizeof(LADSPA_PortDescriptor));
+  psDescriptor->PortDescriptors =
+      (const LADSPA_PortDescriptor *)piPortDescriptors;
+  piPortDescriptors[GATE_THRESHOLD] = LADSPA_PORT_INPUT | LADSPA_PORT_CONTROL;
+  piPortDescriptors[GATE_ATTACK] = LADSPA_PORT_INPUT | LADSPA_PORT_CONTROL;
+  piPortDescriptors[GATE_RELEASE] = LADSPA_PORT_INPUT | LADSPA_PORT_CONTROL;
+  piPortDescriptors[GATE_INPUT] = LADSPA_PORT_INPUT | LADSPA_PORT_AUDIO;
+  piPortDescriptors[GATE_OUTPUT] = LADSPA_PORT_OUTPUT | LADSPA_PORT_AUDIO;
+  pcPortNames = (char **)calloc(5, sizeof(char *));
+  psDescriptor->PortNames = (const char **)pcPortNames;
+  pcPortNames[GATE_THRESHOLD] = strdup("Threshold (dB)");
+  pcPortNames[GATE_ATTACK] = strdup("Attack (ms)");
+  pcPortNames[GATE_RELEASE] = strdup("Release (ms)");
+  pcPortNames[GATE_INPUT] = strdup("Input");
+  pcPortNames[GATE_OUTPUT] = strdup("Output");
+  psPortRangeHints =
+      ((LADSPA_PortRangeHint *)calloc(5, sizeof(LADSPA_PortRangeHint)));
+  psDescriptor->PortRangeHints = (const LADSPA_PortRangeHint *)psPortRangeHints;
+  psPortRangeHints[GATE_THRESHOLD].HintDescriptor =
+      (LADSPA_HINT_BOUNDED_BELOW | LADSPA_HINT_BOUNDED_ABOVE);
+  psPortRangeHints[GATE_THRESHOLD].LowerBound = -80;
+  psPortRangeHints[GATE_THRESHOLD].UpperBound = 0;
+  psPortRangeHints[GATE_ATTACK].HintDescriptor =
+      (LADSPA_HINT_BOUNDED_BELOW | LADSPA_HINT_BOUNDED_ABOVE);
+  psPortRangeHints[GATE_ATTACK].LowerBound = 0;
+  psPortRangeHints[GATE_ATTACK].UpperBound = 100;
+  psPortRangeHints[GATE_RELEASE].HintDescriptor =
+      (LADSPA_HINT_BOUNDED_BELOW | LADSPA_HINT_BOUNDED_ABOVE);
+  psPortRangeHints[GATE_RELEASE].LowerBound = 0;
+  psPortRangeHints[GATE_RELEASE].UpperBound = 2000;
+  psPortRangeHints[GATE_INPUT].HintDescriptor = 0;
+  psPortRangeHints[GATE_OUTPUT].HintDescriptor = 0;
+  psDescriptor->instantiate = instantiateGate;
+  psDescriptor->connect_port = connectPortToGate;
+  psDescriptor->activate = NULL;
+  psDescriptor->run = runGate;
+  psDescriptor->run_adding = NULL;
+  psDescriptor->set_run_adding_gain = NULL;
+  psDescriptor->deactivate = NULL;
+  psDescriptor->cleanup = cleanupGate;
+  return psDescriptor;
+}
 
 ON_LOAD_ROUTINE {
 
@@ -169,27 +450,34 @@ ON_LOAD_ROUTINE {
     g_psDescriptor->Name = strdup("nt-filter rnnoise ladspa module");
     g_psDescriptor->Maker = strdup("nt-org");
     g_psDescriptor->Copyright = strdup("GPL3+");
-    g_psDescriptor->PortCount = 3;
+    g_psDescriptor->PortCount = 4;
     piPortDescriptors =
-        (LADSPA_PortDescriptor *)calloc(3, sizeof(LADSPA_PortDescriptor));
+        (LADSPA_PortDescriptor *)calloc(4, sizeof(LADSPA_PortDescriptor));
     g_psDescriptor->PortDescriptors =
         (const LADSPA_PortDescriptor *)piPortDescriptors;
     piPortDescriptors[SF_VAD] = LADSPA_PORT_INPUT | LADSPA_PORT_CONTROL;
+    piPortDescriptors[SF_SUPPRESSION] = LADSPA_PORT_INPUT | LADSPA_PORT_CONTROL;
     piPortDescriptors[SF_INPUT] = LADSPA_PORT_INPUT | LADSPA_PORT_AUDIO;
     piPortDescriptors[SF_OUTPUT] = LADSPA_PORT_OUTPUT | LADSPA_PORT_AUDIO;
-    pcPortNames = (char **)calloc(3, sizeof(char *));
+    pcPortNames = (char **)calloc(4, sizeof(char *));
     g_psDescriptor->PortNames = (const char **)pcPortNames;
     pcPortNames[SF_VAD] = strdup("VAD %%");
+    pcPortNames[SF_SUPPRESSION] = strdup("Suppression (%)");
     pcPortNames[SF_INPUT] = strdup("Input");
     pcPortNames[SF_OUTPUT] = strdup("Output");
     psPortRangeHints =
-        ((LADSPA_PortRangeHint *)calloc(3, sizeof(LADSPA_PortRangeHint)));
+        ((LADSPA_PortRangeHint *)calloc(4, sizeof(LADSPA_PortRangeHint)));
     g_psDescriptor->PortRangeHints =
         (const LADSPA_PortRangeHint *)psPortRangeHints;
     psPortRangeHints[SF_VAD].HintDescriptor =
         (LADSPA_HINT_BOUNDED_BELOW | LADSPA_HINT_BOUNDED_ABOVE);
     psPortRangeHints[SF_VAD].LowerBound = 0;
     psPortRangeHints[SF_VAD].UpperBound = 95;
+    psPortRangeHints[SF_SUPPRESSION].HintDescriptor =
+        (LADSPA_HINT_BOUNDED_BELOW | LADSPA_HINT_BOUNDED_ABOVE |
+         LADSPA_HINT_DEFAULT_MAXIMUM);
+    psPortRangeHints[SF_SUPPRESSION].LowerBound = 0;
+    psPortRangeHints[SF_SUPPRESSION].UpperBound = 100;
     psPortRangeHints[SF_INPUT].HintDescriptor = 0;
     psPortRangeHints[SF_OUTPUT].HintDescriptor = 0;
     g_psDescriptor->instantiate = instantiateSimpleFilter;
@@ -201,6 +489,8 @@ ON_LOAD_ROUTINE {
     g_psDescriptor->deactivate = NULL;
     g_psDescriptor->cleanup = cleanupFilter;
   }
+
+  g_psGateDescriptor = makeGateDescriptor();
 }
 
 static void deleteDescriptor(LADSPA_Descriptor *psDescriptor) {
@@ -219,7 +509,10 @@ static void deleteDescriptor(LADSPA_Descriptor *psDescriptor) {
   }
 }
 
-ON_UNLOAD_ROUTINE { deleteDescriptor(g_psDescriptor); }
+ON_UNLOAD_ROUTINE {
+  deleteDescriptor(g_psDescriptor);
+  deleteDescriptor(g_psGateDescriptor);
+}
 
 const LADSPA_Descriptor *ladspa_descriptor(unsigned long Index) {
   /* Return the requested descriptor or null if the index is out of
@@ -227,6 +520,8 @@ const LADSPA_Descriptor *ladspa_descriptor(unsigned long Index) {
   switch (Index) {
   case 0:
     return g_psDescriptor;
+  case 1:
+    return g_psGateDescriptor;
   default:
     return NULL;
   }
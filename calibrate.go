@@ -0,0 +1,154 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+const calibrationDuration = 5 * time.Second
+
+type calibrationResult struct {
+	noiseFloor float64 // RMS, dBFS
+	peak       float64 // dBFS
+	vadP95     float32 // 95th percentile of the voice probability the filter saw in the noise
+	suggested  int
+}
+
+// calibrate records ambient noise from the source while the filter is loaded and suggests
+// a threshold just above the voice probability RNNoise assigns to that noise.
+func calibrate(source string) (calibrationResult, error) {
+	var res calibrationResult
+
+	cmd := audioCommand("parec", "--raw", "--format=float32le", "--channels=1",
+		fmt.Sprintf("--rate=%d", meterRate), "--client-name=NoiseTorch calibration", "-d", source)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return res, err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return res, fmt.Errorf("couldn't start parec: %w", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	probs := make(chan []float32)
+	go func() {
+		var p []float32
+		deadline := time.Now().Add(calibrationDuration)
+		for time.Now().Before(deadline) {
+			if status, ok := readVADStatus(); ok {
+				p = append(p, status.prob)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		probs <- p
+	}()
+
+	buf := make([]byte, meterRate*4*int(calibrationDuration/time.Second))
+	if _, err := io.ReadFull(bufio.NewReader(stdout), buf); err != nil {
+		<-probs
+		return res, fmt.Errorf("couldn't record from %s: %w", source, err)
+	}
+
+	var sum, peak float64
+	n := len(buf) / 4
+	for i := 0; i < len(buf); i += 4 {
+		s := float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i:])))
+		sum += s * s
+		peak = math.Max(peak, math.Abs(s))
+	}
+	res.noiseFloor = toDBFS(math.Sqrt(sum / float64(n)))
+	res.peak = toDBFS(peak)
+
+	p := <-probs
+	if len(p) == 0 {
+		return res, fmt.Errorf("the filter didn't report any voice activity, make sure it is loaded")
+	}
+	sort.Slice(p, func(i, j int) bool { return p[i] < p[j] })
+	res.vadP95 = p[len(p)*95/100]
+	res.suggested = clampSuggestion(int(math.Ceil(float64(res.vadP95)*100)) + 5)
+
+	debugf("Calibration result: %+v\n", res)
+	return res, nil
+}
+
+func toDBFS(v float64) float64 {
+	if v <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(v)
+}
+
+func (r calibrationResult) String() string {
+	return fmt.Sprintf("Noise floor: %.1f dBFS, peak: %.1f dBFS, voice probability in noise (95th percentile): %.0f%%\n"+
+		"Suggested threshold: %d%%", r.noiseFloor, r.peak, r.vadP95*100, r.suggested)
+}
+
+type calibrationui struct {
+	running bool
+	result  calibrationResult
+	err     error
+}
+
+func uiCalibrate(ctx *ntcontext, inp device) {
+	ctx.calibration = calibrationui{running: true}
+	ctx.views.Push(calibrationView)
+	(*ctx.masterWindow).Changed()
+
+	res, err := calibrate(inp.ID)
+	ctx.calibration = calibrationui{result: res, err: err}
+	(*ctx.masterWindow).Changed()
+}
+
+func calibrationView(ctx *ntcontext, w *nucular.Window) {
+	c := &ctx.calibration
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Calibrate Voice Activation Threshold"), "CB")
+	w.Row(40).Dynamic(1)
+
+	if c.running {
+		w.Row(15).Dynamic(1)
+		w.Label(tr("Please stay quiet for 5 seconds while we listen to your surroundings..."), "CB")
+		return
+	}
+
+	if c.err != nil {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(c.err.Error(), "CB", red)
+		w.Row(25).Dynamic(1)
+		if focusable(ctx, w, w.ButtonText(tr("OK"))) {
+			ctx.views.Pop()
+		}
+		return
+	}
+
+	w.Row(15).Dynamic(1)
+	w.Label(trf("Noise floor: %.1f dBFS, peak: %.1f dBFS", c.result.noiseFloor, c.result.peak), "CB")
+	w.Row(15).Dynamic(1)
+	w.Label(trf("Your surroundings look like voice with up to %.0f%% probability.", c.result.vadP95*100), "CB")
+	w.Row(15).Dynamic(1)
+	w.Label(trf("Suggested threshold: %d%% (currently %d%%)", c.result.suggested, ctx.config.Threshold), "CB")
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Cancel"))) {
+		ctx.views.Pop()
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Apply"))) {
+		if ctx.config.Threshold != c.result.suggested {
+			ctx.config.Threshold = c.result.suggested
+			requestLiveControls(ctx)
+			go writeConfig(ctx.config)
+		}
+		ctx.views.Pop()
+	}
+}
@@ -4,9 +4,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/syndtr/gocapability/capability"
 )
@@ -27,7 +31,7 @@ func getCurrentCaps() *capability.Capabilities {
 
 func getSelfFileCaps() *capability.Capabilities {
 	self, err := os.Executable()
-	log.Printf("Getting caps for: %s\n", self)
+	debugf("Getting caps for: %s\n", self)
 	if err != nil {
 		log.Fatalf("Could not get path to own executable: %+v\n", err)
 	}
@@ -66,14 +70,91 @@ func pkexecSetcapSelf() error {
 		log.Fatalf("Couldn't find path to own binary\n")
 		return err
 	}
+	return pkexecSetcap(self)
+}
 
-	cmd := exec.Command("pkexec", self, "-setcap")
-	log.Printf("Calling: %s\n", cmd.String())
-	err = cmd.Run()
-	if err != nil {
-		log.Printf("Couldn't setcap self as root: %v\n", err)
+// pkexecSetcap runs the binary at path as the privileged helper through polkit, which grants the
+// capability to that binary. Distributions can install assets/polkit/org.noisetorch.setcap.policy to
+// give the prompt a proper description.
+func pkexecSetcap(path string) error {
+	cmd := exec.Command("pkexec", path, "-setcap")
+	debugf("Calling: %s\n", cmd.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		errorf("Couldn't setcap self as root: %v: %s\n", err, strings.TrimSpace(string(out)))
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
 		return err
 	}
+	return nil
+}
 
+// setcapHelper is what runs as root through pkexec: it grants the capability to its own binary
+// and nothing else, and refuses binaries other users could have swapped out beneath it.
+func setcapHelper() error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("the helper has to run as root, through pkexec")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(self)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() || fi.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("%s is writable by others, not granting it anything", self)
+	}
+	if err := makeBinarySetcapped(); err != nil {
+		return err
+	}
+	if !hasCapSysResource(getSelfFileCaps()) {
+		return fmt.Errorf("the capability didn't stick to %s", self)
+	}
 	return nil
 }
+
+// ST_NOSUID of statfs(2)
+const stNosuid = 0x2
+
+// nosuidMount tells whether path is on a filesystem mounted nosuid, which ignores file capabilities.
+func nosuidMount(path string) bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false
+	}
+	return st.Flags&stNosuid != 0
+}
+
+// fixPermissions grants the capability to our binary through the helper. A binary on a nosuid
+// filesystem can't have it, it is copied to where the release puts it first. It returns the
+// binary to run from now on.
+func fixPermissions() (string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if self, err = filepath.EvalSymlinks(self); err != nil {
+		return "", err
+	}
+	if nosuidMount(self) {
+		target := installedBinary()
+		if self == target || nosuidMount(filepath.Dir(target)) {
+			return "", fmt.Errorf("%s is on a filesystem mounted nosuid, which can't grant capabilities. Install NoiseTorch-ng elsewhere", self)
+		}
+		infof("%s is on a nosuid filesystem, moving it to %s\n", self, target)
+		if err := copyFile(self, target+".new", 0755); err != nil {
+			return "", err
+		}
+		if err := os.Rename(target+".new", target); err != nil {
+			os.Remove(target + ".new")
+			return "", err
+		}
+		self = target
+	}
+	if err := pkexecSetcap(self); err != nil {
+		return "", err
+	}
+	return self, nil
+}
@@ -0,0 +1,62 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pa_channel_position_t, in order
+var channelPositionNames = []string{
+	"mono", "front-left", "front-right", "front-center", "rear-center", "rear-left", "rear-right", "lfe",
+	"front-left-of-center", "front-right-of-center", "side-left", "side-right",
+	"aux0", "aux1", "aux2", "aux3", "aux4", "aux5", "aux6", "aux7",
+	"aux8", "aux9", "aux10", "aux11", "aux12", "aux13", "aux14", "aux15",
+	"aux16", "aux17", "aux18", "aux19", "aux20", "aux21", "aux22", "aux23",
+	"aux24", "aux25", "aux26", "aux27", "aux28", "aux29", "aux30", "aux31",
+	"top-center", "top-front-left", "top-front-right", "top-front-center",
+	"top-rear-left", "top-rear-right", "top-rear-center",
+}
+
+func channelMapString(m []byte) string {
+	names := make([]string, len(m))
+	for i, pos := range m {
+		if int(pos) < len(channelPositionNames) {
+			names[i] = channelPositionNames[pos]
+		} else {
+			names[i] = fmt.Sprintf("unknown%d", pos)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// inputChannels decides how many channels the input chain runs with. RNNoise is mono, but
+// module-ladspa-sink runs one plugin instance per channel, so stereo interfaces (e.g. two XLR
+// inputs) keep both channels. Array microphones with more channels get downmixed to mono,
+// the capsules pick up the same voice and filtering each of them just multiplies the CPU cost.
+func inputChannels(inp *device) int {
+	if inp.channels == 2 {
+		return 2
+	}
+	return 1
+}
+
+// inputChannelArgs returns the module arguments for the channel layout of the input chain.
+// Interfaces often label their inputs aux0, aux1, ... which pulseaudio can't remix to
+// front-left/front-right, so stereo is copied by index (remix=false on the loopback) instead of
+// by position.
+func inputChannelArgs(inp *device) string {
+	if inputChannels(inp) == 2 {
+		return "channels=2 channel_map=front-left,front-right"
+	}
+	return "channels=1 channel_map=mono"
+}
+
+func loopbackChannelArgs(inp *device) string {
+	if inputChannels(inp) == 2 {
+		return inputChannelArgs(inp) + " remix=false"
+	}
+	return inputChannelArgs(inp)
+}
@@ -4,30 +4,70 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
-
-	"github.com/blang/semver/v4"
-	"github.com/noisetorch/pulseaudio"
+	"time"
 )
 
 type CLIOpts struct {
-	doLog       bool
-	setcap      bool
-	sinkName    string
-	unload      bool
-	loadInput   bool
-	loadOutput  bool
-	threshold   int
-	list        bool
-	checkUpdate bool
+	doLog          bool
+	logLevel       string
+	setcap         bool
+	sinkName       string
+	unload         bool
+	loadInput      bool
+	loadOutput     bool
+	threshold      int
+	list           bool
+	checkUpdate    bool
+	rollback       bool
+	fixPermissions bool
+	listen         string
+	metrics        string
+	metricsPublic  bool
+	connect        string
+	token          string
+	fingerprint    string
+	buildinfo      bool
+	daemon         bool
+	setupFile      string
+	vadStatus      bool
+	calibrate      bool
+	switchState    bool
+	recordDir      string
+	recordSecs     int
+	model          string
+	engine         string
+	mute           bool
+	unmute         bool
+	json           bool
+	status         bool
+	doctor         bool
+	cleanup        bool
+	yes            bool
+	replace        bool
+	configArgs     []string
+	presetArgs     []string
+	dryRun         bool
+	completion     []string
+	helpAll        bool
+	man            bool
+	alsa           bool
+	alsaCapture    string
+	alsaPlayback   string
+	bench          bool
+	benchSecs      int
+	selftest       bool
+	mini           bool
 }
 
 func parseCLIOpts() CLIOpts {
 	var opt CLIOpts
-	flag.BoolVar(&opt.doLog, "log", false, "Print debugging output to stdout")
+	flag.BoolVar(&opt.doLog, "log", false, "Print the log to stdout, it is always written to ~/.cache/noisetorch/noisetorch.log")
+	flag.StringVar(&opt.logLevel, "log-level", "info", "Only log messages of at least this level ("+strings.Join(levelNames, ", ")+")")
 	flag.BoolVar(&opt.setcap, "setcap", false, "for internal use only")
 	flag.StringVar(&opt.sinkName, "s", "", "Use the specified source/sink device ID")
 	flag.BoolVar(&opt.loadInput, "i", false, "Load supressor for input. If no source device ID is specified the default pulse audio source is used.")
@@ -35,19 +75,53 @@ func parseCLIOpts() CLIOpts {
 	flag.BoolVar(&opt.unload, "u", false, "Unload supressor")
 	flag.IntVar(&opt.threshold, "t", -1, "Voice activation threshold")
 	flag.BoolVar(&opt.list, "l", false, "List available PulseAudio devices")
+	flag.BoolVar(&opt.list, "list-devices", false, "Same as -l")
+	flag.BoolVar(&opt.status, "status", false, "Print whether the supressor is loaded, for which devices and at what threshold")
+	flag.BoolVar(&opt.doctor, "doctor", false, "Check the audio server, the plugin and the permissions, and whether audio goes through the filter")
+	flag.BoolVar(&opt.json, "json", false, "Print the output of devices, status, vad-status, doctor, bench, selftest and errors as JSON")
+	flag.BoolVar(&opt.selftest, "selftest", false, "Play a noisy sample through the filter on a test microphone and check that it removes the noise")
 	flag.BoolVar(&opt.checkUpdate, "c", false, "Check if update is available (but do not update)")
+	flag.BoolVar(&opt.rollback, "rollback", false, "Go back to the version installed before the last update")
+	flag.StringVar(&opt.listen, "listen", "", "Serve the control API over TLS on the given address (e.g. 127.0.0.1:9344). Requests need the token in ~/.config/noisetorch/"+apiTokenFile)
+	flag.StringVar(&opt.metrics, "metrics", "", "Serve Prometheus metrics on the given address (e.g. 127.0.0.1:9345) at /metrics. There is no authentication, so only loopback addresses are accepted without -metrics-public")
+	flag.BoolVar(&opt.metricsPublic, "metrics-public", false, "Let -metrics listen on addresses other than loopback ones. Anyone who can reach it can read the metrics")
+	flag.StringVar(&opt.connect, "connect", "", "Manage the NoiseTorch instance serving its control API at host:port instead of the local audio server")
+	flag.StringVar(&opt.token, "token", "", "Token of the control API given with -connect, from ~/.config/noisetorch/"+apiTokenFile+" on that machine. Defaults to ours")
+	flag.StringVar(&opt.fingerprint, "fingerprint", "", "SHA-256 fingerprint of the control API certificate given with -connect, logged by that machine when it starts serving. Defaults to that of ours")
+	flag.BoolVar(&opt.mini, "mini", false, "Open the window in the compact mini view")
+	flag.BoolVar(&opt.replace, "replace", false, "Take over the loaded filters from the running NoiseTorch-ng GUI or daemon, instead of showing its window")
+	flag.BoolVar(&opt.daemon, "daemon", false, "Run without GUI, keep the supressor loaded (reloading it if the audio server restarts) and unload it on exit. Use with -s, -t and -o")
+	flag.BoolVar(&opt.calibrate, "calibrate", false, "Listen to the surroundings for 5 seconds and set the threshold accordingly. The filter must be loaded, use -s to pick the source")
+	flag.BoolVar(&opt.switchState, "switch", false, "Switch to the other saved quick switch state (e.g. from Normal to Stream) and load it")
+	flag.StringVar(&opt.recordDir, "record-sample", "", "Record the raw and the filtered microphone into WAV files in the given directory. The filter must be loaded, use -s to pick the source")
+	flag.IntVar(&opt.recordSecs, "record-seconds", defaultSampleSeconds, "Length of the samples recorded by -record-sample")
+	flag.StringVar(&opt.engine, "engine", "", "Use the given noise suppression engine ("+engineIDs()+")")
+	flag.StringVar(&opt.model, "model", "", "Use the given RNNoise model file (.rnnn) instead of the built-in model when loading")
+	flag.BoolVar(&opt.mute, "mute", false, "Mute the filtered microphone")
+	flag.BoolVar(&opt.unmute, "unmute", false, "Unmute the filtered microphone")
+	flag.BoolVar(&opt.vadStatus, "vad-status", false, "Print whether the filter currently detects voice")
+	flag.BoolVar(&opt.buildinfo, "buildinfo", false, "Print the build configuration and enabled features")
+	flag.BoolVar(&opt.helpAll, "help-all", false, "Print the help of all commands with their flags, and examples")
+	flag.BoolVar(&opt.man, "man", false, "Print the man page")
+	flag.Usage = usage
 	flag.Parse()
 
+	if flag.NArg() > 0 {
+		parseSubcommand(&opt, flag.Args())
+	}
+
 	return opt
 }
 
 func doCLI(opt CLIOpts, config *config, librnnoise string) {
+	// without a command we return to start the GUI, which handles signals itself
+	stop := catchCLISignals()
+	defer stop()
+
 	if opt.checkUpdate {
-		latestRelease, err := getLatestRelease()
+		latestRelease, err := getLatestRelease(config.UpdateChannel)
 		if err == nil {
-			latestVersion, _ := semver.Make(strings.TrimLeft(latestRelease, "v"))
-			currentVersion, _ := semver.Make(strings.TrimLeft(version, "v"))
-			if currentVersion.Compare(latestVersion) == -1 {
+			if newerVersion(latestRelease) {
 				fmt.Println("New version available: " + latestRelease)
 			} else {
 				fmt.Println("No update available")
@@ -55,21 +129,63 @@ func doCLI(opt CLIOpts, config *config, librnnoise string) {
 		} else {
 			fmt.Println("Cannot look for updates right now.")
 		}
-		cleanupExit(librnnoise, 0)
+		cleanupExit(0)
+	}
+
+	if opt.rollback {
+		rollbackCLI(opt)
+	}
+
+	if opt.vadStatus {
+		status, ok := readVADStatus()
+		if opt.json {
+			printJSON(struct {
+				Known       bool    `json:"known"`
+				Speaking    bool    `json:"speaking"`
+				Probability float32 `json:"probability"`
+			}{ok, status.gateOpen, status.prob})
+		} else if ok {
+			fmt.Println(status)
+		} else {
+			fmt.Println("unknown (filter not loaded or idle)")
+		}
+		if !ok {
+			cleanupExit(1)
+		}
+		cleanupExit(0)
+	}
+
+	if opt.configArgs != nil {
+		doConfigCommand(opt, config)
+	}
+
+	if opt.completion != nil {
+		doCompletion(opt, config)
 	}
 
 	if opt.setcap {
-		err := makeBinarySetcapped()
+		if err := setcapHelper(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			cleanupExit(1)
+		}
+		cleanupExit(0)
+	}
+
+	if opt.fixPermissions {
+		path, err := fixPermissions()
 		if err != nil {
-			cleanupExit(librnnoise, 1)
+			opt.fail("Couldn't grant CAP_SYS_RESOURCE: %v\n", err)
 		}
-		cleanupExit(librnnoise, 0)
+		fmt.Printf("Granted CAP_SYS_RESOURCE to %s\n", path)
+		cleanupExit(0)
 	}
 
-	paClient, err := pulseaudio.NewClient()
+	paClient, err := newAudioServer()
+	if opt.doctor {
+		doctorCLI(opt, paClient, err, config, librnnoise)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Couldn't create pulseaudio client: %v\n", err)
-		cleanupExit(librnnoise, 1)
+		opt.fail("Couldn't create pulseaudio client: %v\n", err)
 	}
 	defer paClient.Close()
 
@@ -86,38 +202,170 @@ func doCLI(opt CLIOpts, config *config, librnnoise string) {
 
 	ctx.paClient = paClient
 
+	if opt.selftest {
+		selftestCLI(&ctx, opt)
+	}
+
+	if opt.mute || opt.unmute {
+		if err := setVirtualSourceMute(&ctx, opt.mute); err != nil {
+			opt.fail("Couldn't change mute: %v\n", err)
+		}
+		cleanupExit(0)
+	}
+
+	if opt.cleanup {
+		cleanupCLI(&ctx, opt)
+	}
+
+	if opt.setupFile != "" {
+		setup, err := readSetup(opt.setupFile)
+		if err != nil {
+			opt.fail("Couldn't read setup file: %v\n", err)
+		}
+		if err := reconcile(&ctx, setup); err != nil {
+			opt.fail("Couldn't apply setup file: %v\n", err)
+		}
+		cleanupExit(0)
+	}
+
+	if opt.switchState {
+		st, err := switchQuickState(&ctx)
+		if err != nil {
+			opt.fail("Couldn't switch state: %v\n", err)
+		}
+		if err := reconcile(&ctx, simpleSetup(ctx.config, st.Threshold, st.Microphone, st.Headphones)); err != nil {
+			opt.fail("Couldn't load state %s: %v\n", st.Name, err)
+		}
+		writeConfig(ctx.config)
+		fmt.Printf("Switched to %s\n", st.Name)
+		cleanupExit(0)
+	}
+
+	if opt.presetArgs != nil {
+		doPresetCommand(&ctx, opt)
+	}
+
+	if opt.calibrate {
+		source := opt.sinkName
+		if source == "" {
+			chain, err := getRunningChain(&ctx)
+			if err != nil || len(chain.inputs) == 0 {
+				opt.fail("No source specified and couldn't find the loaded filter's source: %v\n", err)
+			}
+			source = chain.inputs[0]
+		}
+		fmt.Println("Please stay quiet for 5 seconds...")
+		res, err := calibrate(source)
+		if err != nil {
+			opt.fail("Calibration failed: %v\n", err)
+		}
+		fmt.Println(res)
+		ctx.config.Threshold = res.suggested
+		writeConfig(ctx.config)
+		fmt.Println("Saved. Reload the filter to apply it.")
+		cleanupExit(0)
+	}
+
+	if opt.recordDir != "" {
+		source := opt.sinkName
+		if source == "" {
+			chain, err := getRunningChain(&ctx)
+			if err != nil || len(chain.inputs) == 0 {
+				opt.fail("No source specified and couldn't find the loaded filter's source: %v\n", err)
+			}
+			source = chain.inputs[0]
+		}
+		inp, ok := findDevice(getSources(&ctx, paClient), source)
+		if !ok {
+			opt.fail("PulseAudio source not found: %s\n", source)
+		}
+		if opt.recordSecs <= 0 {
+			opt.fail("-record-seconds must be positive\n")
+		}
+		fmt.Printf("Recording %d seconds, please talk...\n", opt.recordSecs)
+		paths, err := recordSamples(&ctx, &inp, opt.recordDir, opt.recordSecs)
+		if err != nil {
+			opt.fail("Recording failed: %v\n", err)
+		}
+		for _, p := range paths {
+			fmt.Println(p)
+		}
+		cleanupExit(0)
+	}
+
 	if opt.list {
-		fmt.Println("Sources:")
 		sources := getSources(&ctx, paClient)
+		sinks := getSinks(&ctx, paClient)
+		if opt.json {
+			var devs remoteDevices
+			for _, d := range sources {
+				devs.Sources = append(devs.Sources, remoteDevice{ID: d.ID, Name: d.Name})
+			}
+			for _, d := range sinks {
+				devs.Sinks = append(devs.Sinks, remoteDevice{ID: d.ID, Name: d.Name})
+			}
+			printJSON(devs)
+			cleanupExit(0)
+		}
+
+		fmt.Println("Sources:")
 		for i := range sources {
 			fmt.Printf("\tDevice Name: %s\n\tDevice ID: %s\n\n", sources[i].Name, sources[i].ID)
 		}
 
 		fmt.Println("Sinks:")
-		sinks := getSinks(&ctx, paClient)
 		for i := range sinks {
 			fmt.Printf("\tDevice Name: %s\n\tDevice ID: %s\n\n", sinks[i].Name, sinks[i].ID)
 		}
 
-		cleanupExit(librnnoise, 0)
+		cleanupExit(0)
 	}
 
-	if opt.threshold > 0 {
-		if opt.threshold > 95 {
-			fmt.Fprintf(os.Stderr, "Threshold of '%d' too high, setting to maximum of 95.\n", opt.threshold)
-			ctx.config.Threshold = 95
+	if opt.status {
+		status, err := cliStatus(&ctx)
+		if err != nil {
+			opt.fail("Couldn't query status: %v\n", err)
+		}
+		if opt.json {
+			printJSON(status)
 		} else {
-			ctx.config.Threshold = opt.threshold
+			fmt.Printf("State: %s\n", status.State)
+			fmt.Printf("Audio server: %s %s\n", status.Server, status.ServerVersion)
+			for _, inp := range status.Inputs {
+				fmt.Printf("Microphone: %s\n", inp)
+			}
+			if status.Output != "" {
+				fmt.Printf("Headphones: %s\n", status.Output)
+			}
+			if status.Threshold >= 0 {
+				fmt.Printf("Threshold: %d\n", status.Threshold)
+			}
+			fmt.Printf("In use: %t\n", status.VirtualDeviceInUse)
+			if status.CPUPercent != nil {
+				fmt.Printf("CPU usage: %.1f%%\n", *status.CPUPercent)
+			}
 		}
+		cleanupExit(0)
+	}
+
+	if opt.threshold > 0 {
+		ctx.config.Threshold = clampThreshold(opt.threshold)
+	}
+
+	if opt.model != "" {
+		if err := validateModel(opt.model); err != nil {
+			opt.fail("Invalid model: %v\n", err)
+		}
+		ctx.config.Model = opt.model
 	}
 
 	if opt.unload {
-		err := unloadSupressor(&ctx)
+		restoreRouting(&ctx, nil)
+		err := turnOffSupressor(&ctx)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error unloading PulseAudio Module: %+v\n", err)
-			cleanupExit(librnnoise, 1)
+			opt.fail("Error unloading PulseAudio Module: %+v\n", err)
 		}
-		cleanupExit(librnnoise, 0)
+		cleanupExit(0)
 	}
 
 	if opt.loadInput {
@@ -126,24 +374,27 @@ func doCLI(opt CLIOpts, config *config, librnnoise string) {
 		if opt.sinkName == "" {
 			defaultSource, err := getDefaultSourceID(paClient)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "No source specified to load and failed to load default source: %+v\n", err)
-				cleanupExit(librnnoise, 1)
+				opt.fail("No source specified to load and failed to load default source: %+v\n", err)
 			}
 			opt.sinkName = defaultSource
 		}
 		for i := range sources {
 			if sources[i].ID == opt.sinkName {
 				sources[i].checked = true
-				err := loadSupressor(&ctx, &sources[i], &device{})
+				if opt.dryRun {
+					if err := dryRun(&ctx, &sources[i], &device{}); err != nil {
+						opt.fail("%v\n", err)
+					}
+					cleanupExit(0)
+				}
+				err := loadSupressorCLI(&ctx, &sources[i], &device{})
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error loading PulseAudio Module: %+v\n", err)
-					cleanupExit(librnnoise, 1)
+					opt.fail("Error loading PulseAudio Module: %+v\n", err)
 				}
-				cleanupExit(librnnoise, 0)
+				cleanupExit(0)
 			}
 		}
-		fmt.Fprintf(os.Stderr, "PulseAudio source not found: %s\n", opt.sinkName)
-		cleanupExit(librnnoise, 1)
+		opt.fail("PulseAudio source not found: %s\n", opt.sinkName)
 
 	}
 	if opt.loadOutput {
@@ -152,30 +403,127 @@ func doCLI(opt CLIOpts, config *config, librnnoise string) {
 		if opt.sinkName == "" {
 			defaultSink, err := getDefaultSinkID(paClient)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "No sink specified to load and failed to load default sink: %+v\n", err)
-				cleanupExit(librnnoise, 1)
+				opt.fail("No sink specified to load and failed to load default sink: %+v\n", err)
 			}
 			opt.sinkName = defaultSink
 		}
 		for i := range sinks {
 			if sinks[i].ID == opt.sinkName {
 				sinks[i].checked = true
-				err := loadSupressor(&ctx, &device{}, &sinks[i])
+				if opt.dryRun {
+					if err := dryRun(&ctx, &device{}, &sinks[i]); err != nil {
+						opt.fail("%v\n", err)
+					}
+					cleanupExit(0)
+				}
+				err := loadSupressorCLI(&ctx, &device{}, &sinks[i])
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error loading PulseAudio Module: %+v\n", err)
-					cleanupExit(librnnoise, 1)
+					opt.fail("Error loading PulseAudio Module: %+v\n", err)
 				}
-				cleanupExit(librnnoise, 0)
+				cleanupExit(0)
 			}
 		}
-		fmt.Fprintf(os.Stderr, "PulseAudio sink not found: %s\n", opt.sinkName)
-		cleanupExit(librnnoise, 1)
+		opt.fail("PulseAudio sink not found: %s\n", opt.sinkName)
 
 	}
 
 }
 
-func cleanupExit(librnnoise string, exitCode int) {
-	removeLib(librnnoise)
+// doctorCLI runs the self-test, it also reports when the audio server isn't reachable.
+func doctorCLI(opt CLIOpts, paClient AudioServer, clientErr error, config *config, librnnoise string) {
+	ctx := ntcontext{config: config, librnnoise: librnnoise}
+	if clientErr == nil {
+		ctx.paClient = paClient
+		info, err := serverInfo(paClient)
+		if err != nil {
+			errorf("Couldn't fetch audio server info: %v\n", err)
+		}
+		ctx.serverInfo = info
+	}
+	if !opt.json {
+		fmt.Println("Checking, please talk into your microphone...")
+	}
+	results := runDoctor(&ctx, nil)
+	if opt.json {
+		printJSON(results)
+	} else {
+		printDoctor(results)
+	}
+	if doctorFailed(results) {
+		cleanupExit(1)
+	}
+	cleanupExit(0)
+}
+
+// long enough to average out the audio server's bursts, short enough to not be annoying
+const statusCPUInterval = 500 * time.Millisecond
+
+// cliStatus describes the running chain, it's what the control API reports as well.
+func cliStatus(ctx *ntcontext) (remoteStatus, error) {
+	// nothing is selected, so this looks for any chain
+	ctx.config.FilterInput = true
+	ctx.config.FilterOutput = false
+	state, inUse := supressorState(ctx)
+	chain, err := getRunningChain(ctx)
+	if err != nil {
+		return remoteStatus{}, err
+	}
+	if state != loaded && chain.output != "" {
+		// the headphones are filtered but not the microphone
+		ctx.config.FilterInput, ctx.config.FilterOutput = false, true
+		state, inUse = supressorState(ctx)
+	}
+	status := remoteStatus{
+		State:              stateName(state),
+		VirtualDeviceInUse: inUse,
+		Threshold:          chain.threshold,
+		Server:             ctx.serverInfo.name,
+		ServerVersion:      fmt.Sprintf("%d.%d.%d", ctx.serverInfo.major, ctx.serverInfo.minor, ctx.serverInfo.patch),
+		Input:              chain.onlyInput(),
+		Inputs:             chain.inputs,
+		Output:             chain.output,
+	}
+	if state != unloaded {
+		if pid, err := filterHostPid(ctx); err != nil {
+			errorf("Couldn't find the process running the filter: %v\n", err)
+		} else if cpu, err := cpuUsage(pid, statusCPUInterval); err != nil {
+			errorf("Couldn't measure CPU usage: %v\n", err)
+		} else {
+			status.CPUPercent = &cpu
+		}
+	}
+	return status, nil
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't encode JSON: %v\n", err)
+	}
+}
+
+// fail reports the error of a CLI command, as JSON on stdout with -json, and exits.
+func (opt CLIOpts) fail(format string, args ...interface{}) {
+	if opt.json {
+		printJSON(struct {
+			Error string `json:"error"`
+		}{strings.TrimSpace(fmt.Sprintf(format, args...))})
+	} else {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+	cleanupExit(1)
+}
+
+func clampThreshold(threshold int) int {
+	if threshold > 95 {
+		fmt.Fprintf(os.Stderr, "Threshold of '%d' too high, setting to maximum of 95.\n", threshold)
+		return 95
+	}
+	return threshold
+}
+
+func cleanupExit(exitCode int) {
+	hooksRunning.Wait()
 	os.Exit(exitCode)
 }
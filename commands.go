@@ -0,0 +1,343 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Every one-shot action is a subcommand with its own flags, e.g. `noisetorch load -s DEVICE -t 80`.
+// Running without a subcommand starts the GUI (or the daemon, with -daemon).
+//
+// Subcommands fill in the same CLIOpts the flat flags of older versions did, those keep working
+// for existing scripts but aren't advertised anymore.
+
+type subcommand struct {
+	name  string
+	args  string // positional arguments, for the usage line
+	help  string
+	nargs int // number of positional arguments, -1 for any
+	flags func(fs *flag.FlagSet, opt *CLIOpts)
+	set   func(opt *CLIOpts, args []string)
+}
+
+var subcommands = []subcommand{
+	{
+		name: "load",
+		help: "Load the supressor for a microphone, or for headphones with -o",
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.StringVar(&opt.sinkName, "s", "", "Device ID to filter, the default source/sink if empty")
+			fs.IntVar(&opt.threshold, "t", -1, "Voice activation threshold")
+			fs.BoolVar(&opt.loadOutput, "o", false, "Filter headphones instead of a microphone")
+			fs.StringVar(&opt.engine, "engine", "", "Noise suppression engine ("+engineIDs()+")")
+			fs.StringVar(&opt.model, "model", "", "RNNoise model file (.rnnn) to use instead of the built-in model")
+			fs.BoolVar(&opt.dryRun, "dry-run", false, "Print the pactl commands (or the PipeWire filter-chain) instead of loading")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.loadInput = !opt.loadOutput },
+	},
+	{
+		name: "unload",
+		help: "Unload all supressors",
+		set:  func(opt *CLIOpts, args []string) { opt.unload = true },
+	},
+	{
+		name: "devices",
+		help: "List the available sources and sinks",
+		set:  func(opt *CLIOpts, args []string) { opt.list = true },
+	},
+	{
+		name: "status",
+		help: "Print whether the supressor is loaded, for which devices, and how much CPU it uses",
+		set:  func(opt *CLIOpts, args []string) { opt.status = true },
+	},
+	{
+		name:  "apply",
+		args:  "SETUP.yaml|SETUP.toml",
+		help:  "Bring the supressor into the state described by a setup file",
+		nargs: 1,
+		set:   func(opt *CLIOpts, args []string) { opt.setupFile = args[0] },
+	},
+	{
+		name: "mute",
+		help: "Mute the filtered microphone",
+		set:  func(opt *CLIOpts, args []string) { opt.mute = true },
+	},
+	{
+		name: "unmute",
+		help: "Unmute the filtered microphone",
+		set:  func(opt *CLIOpts, args []string) { opt.unmute = true },
+	},
+	{
+		name: "switch",
+		help: "Switch to the other saved quick switch state (e.g. from Normal to Stream) and load it",
+		set:  func(opt *CLIOpts, args []string) { opt.switchState = true },
+	},
+	{
+		name: "calibrate",
+		help: "Listen to the surroundings for 5 seconds and save a matching threshold. The filter must be loaded",
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.StringVar(&opt.sinkName, "s", "", "Source to calibrate, the filtered one if empty")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.calibrate = true },
+	},
+	{
+		name:  "record",
+		args:  "DIR",
+		help:  "Record the raw and the filtered microphone into WAV files in DIR. The filter must be loaded",
+		nargs: 1,
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.StringVar(&opt.sinkName, "s", "", "Source to record, the filtered one if empty")
+			fs.IntVar(&opt.recordSecs, "seconds", defaultSampleSeconds, "Length of the samples")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.recordDir = args[0] },
+	},
+	{
+		name: "alsa",
+		help: "Filter an ALSA capture device into an ALSA playback device, e.g. a loopback, without an audio server",
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.StringVar(&opt.alsaCapture, "capture", "", "ALSA device to filter, AlsaCapture from the config if empty")
+			fs.StringVar(&opt.alsaPlayback, "playback", "", "ALSA device to write the filtered audio to, AlsaPlayback from the config if empty")
+			fs.StringVar(&opt.engine, "engine", "", "Noise suppression engine ("+engineIDs()+")")
+			fs.StringVar(&opt.model, "model", "", "RNNoise model file (.rnnn) to use instead of the built-in model")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.alsa = true },
+	},
+	{
+		name: "bench",
+		help: "Run the filter over generated audio for a few seconds and print how much CPU it needs on this machine",
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.IntVar(&opt.benchSecs, "seconds", defaultBenchSeconds, "How long to run the filter")
+			fs.StringVar(&opt.engine, "engine", "", "Noise suppression engine ("+engineIDs()+")")
+			fs.StringVar(&opt.model, "model", "", "RNNoise model file (.rnnn) to use instead of the built-in model")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.bench = true },
+	},
+	{
+		name: "cleanup",
+		help: "Remove modules left behind by a NoiseTorch-ng that didn't exit cleanly",
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.BoolVar(&opt.yes, "y", false, "Don't ask before removing them")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.cleanup = true },
+	},
+	{
+		name: "doctor",
+		help: "Check everything the filter needs and print what to do about problems",
+		set:  func(opt *CLIOpts, args []string) { opt.doctor = true },
+	},
+	{
+		name: "selftest",
+		help: "Play a noisy sample through the filter on a test microphone and check that it removes the noise",
+		flags: func(fs *flag.FlagSet, opt *CLIOpts) {
+			fs.StringVar(&opt.engine, "engine", "", "Noise suppression engine ("+engineIDs()+")")
+			fs.StringVar(&opt.model, "model", "", "RNNoise model file (.rnnn) to use instead of the built-in model")
+		},
+		set: func(opt *CLIOpts, args []string) { opt.selftest = true },
+	},
+	{
+		name: "vad-status",
+		help: "Print whether the filter currently detects voice",
+		set:  func(opt *CLIOpts, args []string) { opt.vadStatus = true },
+	},
+	{
+		name: "check-update",
+		help: "Check if an update is available (but do not update)",
+		set:  func(opt *CLIOpts, args []string) { opt.checkUpdate = true },
+	},
+	{
+		name: "fix-permissions",
+		help: "Grant the binary CAP_SYS_RESOURCE, asking for the administrator password",
+		set:  func(opt *CLIOpts, args []string) { opt.fixPermissions = true },
+	},
+	{
+		name: "rollback",
+		help: "Go back to the version installed before the last update",
+		set:  func(opt *CLIOpts, args []string) { opt.rollback = true },
+	},
+	{
+		name:  "preset",
+		args:  "apply NAME | list",
+		help:  "Load the devices and settings of a saved preset, or list the presets",
+		nargs: -1,
+		set:   func(opt *CLIOpts, args []string) { opt.presetArgs = append([]string{}, args...) },
+	},
+	{
+		name:  "config",
+		args:  "get KEY | set KEY VALUE | list | dump | edit",
+		help:  "Show or change settings, dump prints the whole config file and edit opens it in $EDITOR",
+		nargs: -1,
+		set:   func(opt *CLIOpts, args []string) { opt.configArgs = append([]string{}, args...) },
+	},
+	{
+		name:  "completion",
+		args:  "bash | zsh | fish",
+		help:  "Print a shell completion script, e.g. source <(noisetorch completion bash)",
+		nargs: -1,
+		set:   func(opt *CLIOpts, args []string) { opt.completion = append([]string{}, args...) },
+	},
+}
+
+// legacyFlags are the flat flags replaced by subcommands. They still work, but aren't shown in the help.
+var legacyFlags = map[string]bool{
+	"i": true, "u": true, "l": true, "list-devices": true, "c": true, "status": true, "vad-status": true,
+	"calibrate": true, "switch": true, "record-sample": true, "record-seconds": true, "mute": true, "unmute": true, "rollback": true,
+}
+
+func findSubcommand(name string) (subcommand, bool) {
+	for _, c := range subcommands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return subcommand{}, false
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, tr("Usage: %s [flags]              start the GUI\n"), os.Args[0])
+	fmt.Fprintf(out, tr("       %s [flags] COMMAND [command flags] [args]\n\nCommands:\n"), os.Args[0])
+	for _, c := range subcommands {
+		fmt.Fprintf(out, "  %-14s %s\n", c.name, tr(c.help))
+	}
+	fmt.Fprintf(out, tr("\nRun '%s COMMAND -h' for the flags of a command, or '%s -help-all' for all of them.\n\nFlags:\n"), os.Args[0], os.Args[0])
+	flag.VisitAll(func(f *flag.Flag) {
+		if legacyFlags[f.Name] || f.Name == "setcap" {
+			return
+		}
+		fmt.Fprintf(out, "  -%s\n    \t%s\n", f.Name, tr(f.Usage))
+	})
+}
+
+// parseSubcommand parses the command line after the global flags into opt.
+func parseSubcommand(opt *CLIOpts, args []string) {
+	c, ok := findSubcommand(args[0])
+	if !ok {
+		fmt.Fprintf(flag.CommandLine.Output(), "Unknown command: %s\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	if c.flags != nil {
+		c.flags(fs, opt)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), tr("Usage: %s %s"), os.Args[0], c.name)
+		if c.flags != nil {
+			fmt.Fprintf(fs.Output(), tr(" [flags]"))
+		}
+		fmt.Fprintf(fs.Output(), " %s\n\n%s\n", c.args, tr(c.help))
+		if c.flags != nil {
+			fmt.Fprintf(fs.Output(), tr("\nFlags:\n"))
+			fs.VisitAll(func(f *flag.Flag) { f.Usage = tr(f.Usage) })
+			fs.PrintDefaults()
+		}
+	}
+	fs.Parse(args[1:])
+
+	if c.nargs >= 0 && fs.NArg() != c.nargs {
+		fs.Usage()
+		os.Exit(2)
+	}
+	c.set(opt, fs.Args())
+}
+
+// doConfigCommand implements `noisetorch config ...`, it doesn't need the audio server.
+func doConfigCommand(opt CLIOpts, config *config) {
+	args := opt.configArgs
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		for _, key := range configKeys() {
+			value, _ := getConfigValue(config, key)
+			fmt.Printf("%s = %s\n", key, value)
+		}
+	case len(args) == 1 && args[0] == "dump":
+		buf, err := encodeConfig(config)
+		if err != nil {
+			opt.fail("Couldn't encode config: %v\n", err)
+		}
+		os.Stdout.Write(buf)
+	case len(args) == 1 && args[0] == "edit":
+		if err := editConfig(config); err != nil {
+			opt.fail("%v\n", err)
+		}
+	case len(args) == 2 && args[0] == "get":
+		value, err := getConfigValue(config, args[1])
+		if err != nil {
+			opt.fail("%v\n", err)
+		}
+		fmt.Println(value)
+	case len(args) == 3 && args[0] == "set":
+		if err := setConfigValue(config, args[1], args[2]); err != nil {
+			opt.fail("%v\n", err)
+		}
+		writeConfig(config)
+	default:
+		opt.fail("Usage: %s config get KEY | set KEY VALUE | list | dump | edit\nKeys: %s\n", os.Args[0], strings.Join(configKeys(), ", "))
+	}
+	cleanupExit(0)
+}
+
+// editConfig opens the config in the user's editor, and only replaces the config file once the
+// edited copy is valid.
+func editConfig(conf *config) error {
+	buf, err := encodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(configDir(), "edit-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(buf)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	stdin := bufio.NewReader(os.Stdin)
+	for {
+		// $EDITOR may come with arguments, e.g. "code --wait"
+		cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", tmp.Name())
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("editor %s failed: %v", editor, err)
+		}
+
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		_, problems, err := parseConfig(edited)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		if len(problems) == 0 {
+			return os.Rename(tmp.Name(), filepath.Join(configDir(), configFile))
+		}
+
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%v\n", p)
+		}
+		fmt.Fprintf(os.Stderr, "Edit again? [Y/n] ")
+		answer, _ := stdin.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+			return fmt.Errorf("config not changed")
+		}
+	}
+}
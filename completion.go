@@ -0,0 +1,446 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// `noisetorch completion bash|zsh|fish` prints a completion script generated from the subcommand
+// table and the flags, so it never falls behind the CLI. Device IDs and preset names change, the
+// scripts ask `noisetorch completion values KIND` for them while completing.
+
+// Kinds of values a flag or positional argument completes to. Dynamic ones are listed by
+// `noisetorch completion values`, files and directories are left to the shell.
+const (
+	completeNone    = ""
+	completeFiles   = "@files"
+	completeDirs    = "@dirs"
+	completeDevices = "devices"
+	completePresets = "presets"
+	completeKeys    = "config-keys"
+)
+
+var dynamicCompletions = []string{completeDevices, completePresets, completeKeys}
+
+// flagCompletions is what the values of flags complete to, static lists are given as words.
+func flagCompletions() map[string][]string {
+	var engineIDs []string
+	for _, e := range engines {
+		engineIDs = append(engineIDs, e.id)
+	}
+	return map[string][]string{
+		"s":         {completeDevices},
+		"engine":    engineIDs,
+		"model":     {completeFiles},
+		"log-level": levelNames,
+	}
+}
+
+// positionalCompletions is what the positional arguments of subcommands complete to, by position.
+var positionalCompletions = map[string][][]string{
+	"apply":      {{completeFiles}},
+	"record":     {{completeDirs}},
+	"preset":     {{"apply", "list"}, {completePresets}},
+	"config":     {{"get", "set", "list", "dump", "edit"}, {completeKeys}},
+	"completion": {{"bash", "zsh", "fish"}},
+}
+
+type completionFlag struct {
+	name  string
+	usage string
+	value bool // takes a value
+}
+
+func completionFlags(fs *flag.FlagSet, skip func(name string) bool) []completionFlag {
+	var res []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if skip != nil && skip(f.Name) {
+			return
+		}
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		res = append(res, completionFlag{f.Name, f.Usage, !isBool || !b.IsBoolFlag()})
+	})
+	return res
+}
+
+func globalCompletionFlags() []completionFlag {
+	return completionFlags(flag.CommandLine, func(name string) bool { return legacyFlags[name] || name == "setcap" })
+}
+
+func subcommandCompletionFlags(c subcommand) []completionFlag {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	if c.flags != nil {
+		c.flags(fs, &CLIOpts{})
+	}
+	return completionFlags(fs, nil)
+}
+
+// valueFlags returns the names of all flags taking a value, the scripts skip their values when
+// looking for the subcommand.
+func valueFlags() []string {
+	seen := make(map[string]bool)
+	add := func(flags []completionFlag) {
+		for _, f := range flags {
+			if f.value {
+				seen[f.name] = true
+			}
+		}
+	}
+	add(globalCompletionFlags())
+	for _, c := range subcommands {
+		add(subcommandCompletionFlags(c))
+	}
+	var res []string
+	for name := range seen {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func dashed(flags []completionFlag) []string {
+	res := make([]string, len(flags))
+	for i, f := range flags {
+		res[i] = "-" + f.name
+	}
+	return res
+}
+
+func isDynamic(kind string) bool {
+	for _, d := range dynamicCompletions {
+		if kind == d {
+			return true
+		}
+	}
+	return false
+}
+
+// doCompletion implements `noisetorch completion ...`, generating the scripts doesn't need the
+// audio server so packages can do it while building.
+func doCompletion(opt CLIOpts, config *config) {
+	args := opt.completion
+	var err error
+	switch {
+	case len(args) == 1 && args[0] == "bash":
+		writeBashCompletion(os.Stdout)
+	case len(args) == 1 && args[0] == "zsh":
+		writeZshCompletion(os.Stdout)
+	case len(args) == 1 && args[0] == "fish":
+		writeFishCompletion(os.Stdout)
+	case len(args) == 2 && args[0] == "values":
+		err = printCompletionValues(config, args[1])
+	default:
+		opt.fail("Usage: %s completion bash | zsh | fish\n", os.Args[0])
+	}
+	if err != nil {
+		opt.fail("Couldn't list %s: %v\n", args[1], err)
+	}
+	cleanupExit(0)
+}
+
+func printCompletionValues(config *config, kind string) error {
+	switch kind {
+	case completePresets:
+		for _, p := range config.Presets {
+			fmt.Println(p.Name)
+		}
+	case completeKeys:
+		for _, k := range configKeys() {
+			fmt.Println(k)
+		}
+	case completeDevices:
+		c, err := newAudioServer()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		ctx := ntcontext{config: config, paClient: c}
+		for _, d := range append(getSources(&ctx, c), getSinks(&ctx, c)...) {
+			fmt.Println(d.ID)
+		}
+	default:
+		return fmt.Errorf("unknown kind '%s'", kind)
+	}
+	return nil
+}
+
+// Bash
+
+func bashWords(w io.Writer, words []string, indent string) {
+	switch {
+	case len(words) == 1 && words[0] == completeFiles:
+		fmt.Fprintf(w, "%sCOMPREPLY=($(compgen -f -- \"$cur\"))", indent)
+	case len(words) == 1 && words[0] == completeDirs:
+		fmt.Fprintf(w, "%sCOMPREPLY=($(compgen -d -- \"$cur\"))", indent)
+	case len(words) == 1 && isDynamic(words[0]):
+		fmt.Fprintf(w, "%s_noisetorch_values %s", indent, words[0])
+	default:
+		fmt.Fprintf(w, "%sCOMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))", indent, strings.Join(words, " "))
+	}
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `# bash completion for noisetorch, generated by "noisetorch completion bash"
+
+_noisetorch_values() {
+    local IFS=$'\n' v
+    COMPREPLY=()
+    for v in $(compgen -W "$(noisetorch completion values "$1" 2>/dev/null)" -- "$cur"); do
+        COMPREPLY+=("$(printf '%%q' "$v")")
+    done
+}
+
+_noisetorch() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" cmd="" pos=0 i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            %s) ((i++)) ;;
+            -*) ;;
+            *) if [[ -z $cmd ]]; then cmd="${COMP_WORDS[i]}"; else ((pos++)); fi ;;
+        esac
+    done
+
+    case "$prev" in
+`, strings.Join(dashed(valueFlagList()), "|"))
+	completions := flagCompletions()
+	for _, name := range valueFlags() {
+		fmt.Fprintf(w, "        -%s)\n", name)
+		if words, ok := completions[name]; ok {
+			bashWords(w, words, "            ")
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "            return ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n\n    if [[ $cur == -* ]]; then\n        case \"$cmd\" in\n")
+	fmt.Fprintf(w, "            \"\") COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", strings.Join(dashed(globalCompletionFlags()), " "))
+	for _, c := range subcommands {
+		if flags := subcommandCompletionFlags(c); len(flags) > 0 {
+			fmt.Fprintf(w, "            %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", c.name, strings.Join(dashed(flags), " "))
+		}
+	}
+	fmt.Fprintf(w, "        esac\n        return\n    fi\n\n    case \"$cmd\" in\n")
+	var names []string
+	for _, c := range subcommands {
+		names = append(names, c.name)
+	}
+	fmt.Fprintf(w, "        \"\") COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", strings.Join(names, " "))
+	for _, c := range subcommands {
+		positions, ok := positionalCompletions[c.name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "        %s)\n            case $pos in\n", c.name)
+		for i, words := range positions {
+			fmt.Fprintf(w, "                %d) ", i)
+			bashWords(w, words, "")
+			fmt.Fprintf(w, " ;;\n")
+		}
+		fmt.Fprintf(w, "            esac ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n}\n\ncomplete -F _noisetorch noisetorch\n")
+}
+
+func valueFlagList() []completionFlag {
+	var res []completionFlag
+	for _, name := range valueFlags() {
+		res = append(res, completionFlag{name: name, value: true})
+	}
+	return res
+}
+
+// Zsh
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func zshWords(w io.Writer, words []string) {
+	switch {
+	case len(words) == 1 && words[0] == completeFiles:
+		fmt.Fprintf(w, "_files")
+	case len(words) == 1 && words[0] == completeDirs:
+		fmt.Fprintf(w, "_files -/")
+	case len(words) == 1 && isDynamic(words[0]):
+		fmt.Fprintf(w, `compadd -- ${(f)"$(noisetorch completion values %s 2>/dev/null)"}`, words[0])
+	default:
+		fmt.Fprintf(w, "compadd -- %s", strings.Join(words, " "))
+	}
+}
+
+// zshDescribed writes an array of name:description pairs and completes them with _describe.
+func zshDescribed(w io.Writer, indent, tag string, names, descs []string) {
+	fmt.Fprintf(w, "%slocal -a items=(\n", indent)
+	for i, n := range names {
+		desc := strings.ReplaceAll(tr(descs[i]), ":", `\:`)
+		fmt.Fprintf(w, "%s    %s\n", indent, zshQuote(strings.ReplaceAll(n, ":", `\:`)+":"+desc))
+	}
+	fmt.Fprintf(w, "%s)\n%s_describe %s items\n", indent, indent, tag)
+}
+
+func zshFlags(w io.Writer, indent string, flags []completionFlag) {
+	names, descs := make([]string, len(flags)), make([]string, len(flags))
+	for i, f := range flags {
+		names[i], descs[i] = "-"+f.name, f.usage
+	}
+	zshDescribed(w, indent, "option", names, descs)
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintf(w, `#compdef noisetorch
+# zsh completion for noisetorch, generated by "noisetorch completion zsh"
+
+_noisetorch() {
+    local cmd="" pos=0 i
+    for ((i = 2; i < CURRENT; i++)); do
+        case ${words[i]} in
+            (%s) ((i++)) ;;
+            (-*) ;;
+            (*) if [[ -z $cmd ]]; then cmd=${words[i]}; else ((pos++)); fi ;;
+        esac
+    done
+
+    case ${words[CURRENT-1]} in
+`, strings.Join(dashed(valueFlagList()), "|"))
+	completions := flagCompletions()
+	for _, name := range valueFlags() {
+		fmt.Fprintf(w, "        (-%s) ", name)
+		if words, ok := completions[name]; ok {
+			zshWords(w, words)
+			fmt.Fprintf(w, "; ")
+		}
+		fmt.Fprintf(w, "return ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n\n    if [[ $PREFIX == -* ]]; then\n        case $cmd in\n            (\"\")\n")
+	zshFlags(w, "                ", globalCompletionFlags())
+	fmt.Fprintf(w, "                ;;\n")
+	for _, c := range subcommands {
+		if flags := subcommandCompletionFlags(c); len(flags) > 0 {
+			fmt.Fprintf(w, "            (%s)\n", c.name)
+			zshFlags(w, "                ", flags)
+			fmt.Fprintf(w, "                ;;\n")
+		}
+	}
+	fmt.Fprintf(w, "        esac\n        return\n    fi\n\n    case $cmd in\n        (\"\")\n")
+	names, descs := make([]string, len(subcommands)), make([]string, len(subcommands))
+	for i, c := range subcommands {
+		names[i], descs[i] = c.name, c.help
+	}
+	zshDescribed(w, "            ", "command", names, descs)
+	fmt.Fprintf(w, "            ;;\n")
+	for _, c := range subcommands {
+		positions, ok := positionalCompletions[c.name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "        (%s)\n            case $pos in\n", c.name)
+		for i, words := range positions {
+			fmt.Fprintf(w, "                (%d) ", i)
+			zshWords(w, words)
+			fmt.Fprintf(w, " ;;\n")
+		}
+		fmt.Fprintf(w, "            esac ;;\n")
+	}
+	fmt.Fprintf(w, `    esac
+}
+
+if [[ $funcstack[1] == _noisetorch ]]; then
+    _noisetorch "$@"
+else
+    compdef _noisetorch noisetorch
+fi
+`)
+}
+
+// Fish
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func fishQuoted(words []string) []string {
+	res := make([]string, len(words))
+	for i, w := range words {
+		res[i] = fishQuote(w)
+	}
+	return res
+}
+
+// fishArgs returns the arguments of `complete` for words.
+func fishArgs(words []string) string {
+	switch {
+	case len(words) == 1 && words[0] == completeFiles:
+		return "-F"
+	case len(words) == 1 && words[0] == completeDirs:
+		return `-x -a "(__fish_complete_directories)"`
+	case len(words) == 1 && isDynamic(words[0]):
+		return fmt.Sprintf(`-x -a "(noisetorch completion values %s 2>/dev/null)"`, words[0])
+	}
+	return fmt.Sprintf("-x -a %s", fishQuote(strings.Join(words, " ")))
+}
+
+func writeFishFlags(w io.Writer, cond string, flags []completionFlag) {
+	completions := flagCompletions()
+	for _, f := range flags {
+		args := ""
+		if f.value {
+			args = " -r"
+			if words, ok := completions[f.name]; ok {
+				args = " " + fishArgs(words)
+			}
+		}
+		fmt.Fprintf(w, "complete -c noisetorch -n %s -o %s%s -d %s\n", fishQuote(cond), f.name, args, fishQuote(tr(f.usage)))
+	}
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintf(w, `# fish completion for noisetorch, generated by "noisetorch completion fish"
+
+# __noisetorch_at CMD [POS] succeeds if the command line is at subcommand CMD, "" for none yet,
+# and at its positional argument POS if given
+function __noisetorch_at
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    set -l cmd ""
+    set -l pos 0
+    set -l skip 0
+    for t in $tokens
+        if test $skip -eq 1
+            set skip 0
+            continue
+        end
+        switch $t
+            case %s
+                set skip 1
+            case '-*'
+            case '*'
+                if test -z "$cmd"
+                    set cmd $t
+                else
+                    set pos (math $pos + 1)
+                end
+        end
+    end
+    test "$cmd" = "$argv[1]"; or return 1
+    test (count $argv) -lt 2; or test $pos -eq $argv[2]
+end
+
+complete -c noisetorch -f
+`, strings.Join(fishQuoted(dashed(valueFlagList())), " "))
+	writeFishFlags(w, `__noisetorch_at ""`, globalCompletionFlags())
+	for _, c := range subcommands {
+		fmt.Fprintf(w, "complete -c noisetorch -n %s -a %s -d %s\n", fishQuote(`__noisetorch_at ""`), c.name, fishQuote(tr(c.help)))
+	}
+	for _, c := range subcommands {
+		writeFishFlags(w, "__noisetorch_at "+c.name, subcommandCompletionFlags(c))
+		for i, words := range positionalCompletions[c.name] {
+			fmt.Fprintf(w, "complete -c noisetorch -n %s %s\n", fishQuote(fmt.Sprintf("__noisetorch_at %s %d", c.name, i)), fishArgs(words))
+		}
+	}
+}
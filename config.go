@@ -5,39 +5,141 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"log"
+	"noisetorch/buildinfo"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 type config struct {
+	Version               int // of the format, see configVersion
 	Threshold             int
+	Suppression           int // percent of the noise RNNoise removes, the rest of the raw signal is mixed back in
 	DisplayMonitorSources bool
 	EnableUpdates         bool
 	FilterInput           bool
 	FilterOutput          bool
 	LastUsedInput         string
 	LastUsedOutput        string
+	AdditionalInputs      []string                 // device IDs filtered alongside LastUsedInput
+	Profiles              map[string]deviceProfile // by device ID
+	DeviceFlags           map[string]deviceFlags   // favorites and hidden devices by device ID, see favorites.go
+	ShowHiddenDevices     bool
+	NativePipeWire        bool
+	InProcessDSP          bool   // run RNNoise ourselves instead of in the audio server, see dsp.go
+	AlsaCapture           string // ALSA device the ALSA mode filters
+	AlsaPlayback          string // and writes the filtered audio to
+	AlsaFallback          bool   // run the ALSA mode while the daemon can't reach an audio server
+	JackClient            bool   // filter as a JACK client, see jack.go
+	JackChannels          int
+	JackInputs            []string // JACK ports connected to the inputs of the client, regular expressions
+	JackOutputs           []string // JACK ports connected to its outputs
+	ReconnectGracePeriod  int      // seconds
+	LearnThreshold        bool
+	Model                 string // path to an RNNoise model file, empty for the built-in one
+	SystemPlugin          bool   // prefer the RNNoise plugin installed on the system over the bundled one
+	SystemPluginPath      string // where it is, empty to look in the LADSPA directories
+	Engine                string
+	AttenuationLimit      int // dB, DeepFilterNet only
+	OutputGain            int // dB, applied to the filtered microphone
+	Gate                  bool
+	GateThreshold         int // dB
+	GateAttack            int // ms
+	GateRelease           int // ms
+	QuickStates           []quickState
+	ActiveQuickState      int
+	Presets               []preset
+	ActivePreset          string            // name of the preset last applied
+	NoisePresets          []noisePreset     // saved by the user next to the built-in ones, see noisepresets.go
+	Hotkeys               map[string]string // by hotkey action id
+	Autostart             bool              // load the filter for LastUsedInput on login
+	ReloadOnHotplug       bool
+	BufferLatency         int      // ms, of the loopbacks feeding the filters
+	CaptureRate           int      // Hz requested from the microphone, 0 for filterRate
+	CaptureFormat         string   // sample format requested from the microphone, empty for the server's choice
+	KeepAwake             bool     // keep the filtered microphone from suspending while idle
+	IdleUnload            bool     // unload the filter while nothing records from the filtered microphone
+	IdleUnloadMinutes     int      // min
+	UnloadOnExit          bool     // unload the filters when the window is closed
+	ConfirmQuit           bool     // ask what to do with loaded filters on Quit
+	Watchdog              bool     // reload the chain when the filtered microphone gets stuck
+	WatchdogTimeout       int      // s
+	AutoRoute             []string // applications moved to the filtered microphone, by name or binary
+	OutputApps            []string // applications moved to the filtered headphones, by name or binary
+	UIScale               int      // percent, 0 to follow the desktop
+	WindowWidth           int      // unscaled, see windowstate.go
+	WindowHeight          int
+	WindowPosition        []int  // x and y of the window frame, empty to leave it to the window manager
+	LastView              string // view shown when the window was closed, empty for the main view
+	Theme                 string
+	Language              string // e.g. "de", empty to follow the environment
+	Onboarded             bool   // the setup on first start was done or skipped
+	UpdateChannel         string
+	NotifyFilter          bool
+	NotifyConnection      bool
+	NotifyUpdates         bool
+	Hooks                 map[string]string // shell commands by event, see hooks.go
+	OBS                   bool              // load the filter while OBS streams or records
+	OBSHost               string
+	OBSPort               int
+	OBSPassword           string // of obs-websocket
 }
 
 const configFile = "config.toml"
 
-func initializeConfigIfNot() {
-	log.Println("Checking if config needs to be initialized")
-
+func defaultConfig() config {
 	// if you're a package maintainer and you mess with this, we have a problem.
 	// Unless you set -tags release on the build the updater is *not* compiled in anymore. DO NOT MESS WITH THIS!
 	// This isn't and never was the proper location to disable the updater.
-	conf := config{
+	return config{
+		Version:               configVersion,
 		Threshold:             95,
+		Suppression:           100,
 		DisplayMonitorSources: false,
 		EnableUpdates:         true,
 		FilterInput:           true,
 		FilterOutput:          false,
 		LastUsedInput:         "",
-		LastUsedOutput:        ""}
+		LastUsedOutput:        "",
+		ReconnectGracePeriod:  3,
+		AlsaCapture:           "default",
+		AlsaPlayback:          "hw:Loopback,0",
+		JackChannels:          1,
+		Engine:                "rnnoise",
+		SystemPlugin:          buildinfo.SystemPlugin != "",
+		AttenuationLimit:      100,
+		GateThreshold:         -50,
+		GateAttack:            5,
+		GateRelease:           200,
+		QuickStates:           defaultQuickStates(),
+		Hotkeys:               defaultHotkeys(),
+		ReloadOnHotplug:       true,
+		BufferLatency:         defaultBufferLatency,
+		Watchdog:              true,
+		WatchdogTimeout:       defaultWatchdogTimeout,
+		IdleUnloadMinutes:     defaultIdleMinutes,
+		ConfirmQuit:           true,
+		WindowWidth:           defaultWindowWidth,
+		WindowHeight:          defaultWindowHeight,
+		Theme:                 themeDark,
+		UpdateChannel:         channelStable,
+		NotifyFilter:          true,
+		NotifyConnection:      true,
+		NotifyUpdates:         true,
+		OBSHost:               "localhost",
+		OBSPort:               defaultOBSPort}
+}
+
+func initializeConfigIfNot() {
+	debugf("Checking if config needs to be initialized\n")
+
+	conf := defaultConfig()
 
 	configdir := configDir()
 	ok, err := exists(configdir)
@@ -56,28 +158,106 @@ func initializeConfigIfNot() {
 		log.Fatalf("Couldn't check if config file exists: %v\n", err)
 	}
 	if !ok {
-		log.Println("Initializing config")
+		infof("Initializing config\n")
 		writeConfig(&conf)
 	}
 }
 
 func readConfig() *config {
 	f := filepath.Join(configDir(), configFile)
-	config := config{}
-	if _, err := toml.DecodeFile(f, &config); err != nil {
+	buf, err := os.ReadFile(f)
+	if err != nil {
+		log.Fatalf("Couldn't read config file: %v\n", err)
+	}
+	config, problems, err := parseConfig(buf)
+	if err != nil {
 		log.Fatalf("Couldn't read config file: %v\n", err)
 	}
+	for _, p := range problems {
+		warnf("Ignoring invalid setting in %s: %v\n", f, p)
+	}
 
 	return &config
 }
 
 func writeConfig(conf *config) {
 	f := filepath.Join(configDir(), configFile)
+	buf, err := encodeConfig(conf)
+	if err != nil {
+		log.Fatalf("Couldn't write config file: %v\n", err)
+	}
+	os.WriteFile(f, buf, 0644)
+}
+
+func encodeConfig(conf *config) ([]byte, error) {
 	var buffer bytes.Buffer
 	if err := toml.NewEncoder(&buffer).Encode(&conf); err != nil {
-		log.Fatalf("Couldn't write config file: %v\n", err)
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// configKeys lists the settings `noisetorch config` can show and change: the scalar ones, lists and
+// tables are easier to edit in the file itself.
+func configKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(config{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Name == "Version" {
+			continue
+		}
+		switch t.Field(i).Type.Kind() {
+		case reflect.Bool, reflect.Int, reflect.String:
+			keys = append(keys, t.Field(i).Name)
+		}
+	}
+	return keys
+}
+
+func configField(c *config, key string) (reflect.Value, error) {
+	for _, k := range configKeys() {
+		if strings.EqualFold(k, key) {
+			return reflect.ValueOf(c).Elem().FieldByName(k), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown setting '%s'", key)
+}
+
+func getConfigValue(c *config, key string) (string, error) {
+	v, err := configField(c, key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+func setConfigValue(c *config, key, value string) error {
+	v, err := configField(c, key)
+	if err != nil {
+		return err
+	}
+	old := reflect.ValueOf(v.Interface())
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", key)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", key)
+		}
+		v.SetInt(int64(n))
+	case reflect.String:
+		v.SetString(value)
+	}
+	if err := validateConfigValue(c, key); err != nil {
+		v.Set(old)
+		return err
 	}
-	os.WriteFile(f, buffer.Bytes(), 0644)
+	return nil
 }
 
 func configDir() string {
@@ -99,12 +279,12 @@ func xdgOrFallback(xdg string, fallback string) string {
 	dir := os.Getenv(xdg)
 	if dir != "" {
 		if ok, err := exists(dir); ok && err == nil {
-			log.Printf("Resolved $%s to '%s'\n", xdg, dir)
+			debugf("Resolved $%s to '%s'\n", xdg, dir)
 			return dir
 		}
 
 	}
 
-	log.Printf("Couldn't resolve $%s falling back to '%s'\n", xdg, fallback)
+	debugf("Couldn't resolve $%s falling back to '%s'\n", xdg, fallback)
 	return fallback
 }
@@ -0,0 +1,323 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The config file carries the version of its format. Older files are migrated when read, keys
+// missing from them get their default, and values out of range are replaced by their default
+// rather than breaking the filter.
+
+// configVersion is the format written by this build. Bump it and append a migration whenever a
+// setting is renamed or moves.
+const configVersion = 2
+
+// configMigrations[i] upgrades a config of version i to version i+1. They work on the raw file
+// so they still see the keys the config struct doesn't have anymore.
+var configMigrations = []func(raw map[string]interface{}){
+	migrateUnversionedConfig,
+	migrateOnboarded,
+}
+
+// migrateUnversionedConfig moves the settings of configs written before the format was
+// versioned to where they live now.
+func migrateUnversionedConfig(raw map[string]interface{}) {
+	if offsets, ok := raw["LatencyOffsets"].(map[string]interface{}); ok {
+		profiles, _ := raw["Profiles"].(map[string]interface{})
+		if profiles == nil {
+			profiles = make(map[string]interface{})
+			raw["Profiles"] = profiles
+		}
+		for id, offset := range offsets {
+			p, _ := profiles[id].(map[string]interface{})
+			if p == nil {
+				// like updateProfile, a new profile starts out with the current threshold
+				threshold, ok := raw["Threshold"]
+				if !ok {
+					threshold = int64(defaultConfig().Threshold)
+				}
+				p = map[string]interface{}{"Threshold": threshold}
+				profiles[id] = p
+			}
+			if _, ok := p["LatencyOffset"]; !ok {
+				p["LatencyOffset"] = offset
+			}
+		}
+		delete(raw, "LatencyOffsets")
+	}
+
+	if hotkey, ok := raw["QuickSwitchHotkey"]; ok {
+		hotkeys, _ := raw["Hotkeys"].(map[string]interface{})
+		if hotkeys == nil {
+			hotkeys = make(map[string]interface{})
+			raw["Hotkeys"] = hotkeys
+		}
+		if _, ok := hotkeys["quickswitch"]; !ok {
+			hotkeys["quickswitch"] = hotkey
+		}
+		delete(raw, "QuickSwitchHotkey")
+	}
+}
+
+// migrateOnboarded skips the setup on first start for everyone who used NoiseTorch before it existed.
+func migrateOnboarded(raw map[string]interface{}) {
+	raw["Onboarded"] = true
+}
+
+// configRule checks a single setting.
+type configRule struct {
+	key   string
+	check func(c *config) error
+}
+
+func rangeRule(key string, min, max int) configRule {
+	return configRule{key, func(c *config) error {
+		v := int(reflect.ValueOf(c).Elem().FieldByName(key).Int())
+		if v < min || v > max {
+			return fmt.Errorf("%s must be between %d and %d, not %d", key, min, max, v)
+		}
+		return nil
+	}}
+}
+
+// engineRule checks the setting holding the control port of an engine.
+func engineRule(key, id string) configRule {
+	c := engineByID(id).control
+	return rangeRule(key, c.min, c.max)
+}
+
+var configSchema = []configRule{
+	engineRule("Threshold", "rnnoise"),
+	engineRule("AttenuationLimit", "deepfilternet"),
+	rangeRule("Suppression", 0, 100),
+	rangeRule("OutputGain", minGain, maxGain),
+	rangeRule("GateThreshold", minGateThreshold, maxGateThreshold),
+	rangeRule("GateAttack", 0, maxGateAttack),
+	rangeRule("GateRelease", 0, maxGateRelease),
+	rangeRule("ReconnectGracePeriod", 0, 60),
+	rangeRule("JackChannels", 1, maxJackChannels),
+	rangeRule("BufferLatency", minBufferLatency, maxBufferLatency),
+	{"CaptureRate", func(c *config) error {
+		for _, r := range captureRates {
+			if r == c.CaptureRate {
+				return nil
+			}
+		}
+		return fmt.Errorf("CaptureRate must be 0, 16000, 32000, 44100 or 48000, not %d", c.CaptureRate)
+	}},
+	{"CaptureFormat", func(c *config) error {
+		if !knownCaptureFormat(c.CaptureFormat) {
+			return fmt.Errorf("CaptureFormat must be empty or one of %s, not '%s'", captureFormatNames(), c.CaptureFormat)
+		}
+		return nil
+	}},
+	rangeRule("WatchdogTimeout", minWatchdogTimeout, maxWatchdogTimeout),
+	rangeRule("IdleUnloadMinutes", minIdleMinutes, maxIdleMinutes),
+	rangeRule("OBSPort", 1, 65535),
+	{"UIScale", func(c *config) error {
+		if c.UIScale != 0 && (c.UIScale < minUIScale || c.UIScale > maxUIScale) {
+			return fmt.Errorf("UIScale must be 0 or between %d and %d, not %d", minUIScale, maxUIScale, c.UIScale)
+		}
+		return nil
+	}},
+	rangeRule("WindowWidth", minWindowSize, maxWindowSize),
+	rangeRule("WindowHeight", minWindowSize, maxWindowSize),
+	{"WindowPosition", func(c *config) error {
+		if len(c.WindowPosition) != 0 && len(c.WindowPosition) != 2 {
+			return fmt.Errorf("WindowPosition must be empty or x and y, not %v", c.WindowPosition)
+		}
+		return nil
+	}},
+	{"LastView", func(c *config) error {
+		if c.LastView != "" && !knownView(c.LastView) {
+			var names []string
+			for _, v := range restorableViews {
+				names = append(names, v.name)
+			}
+			return fmt.Errorf("LastView must be empty or one of %s, not '%s'", strings.Join(names, ", "), c.LastView)
+		}
+		return nil
+	}},
+	{"Theme", func(c *config) error {
+		for _, t := range themes {
+			if t.id == c.Theme {
+				return nil
+			}
+		}
+		return fmt.Errorf("Theme must be one of %s, %s or %s, not '%s'", themeDark, themeLight, themeSystem, c.Theme)
+	}},
+	{"Language", func(c *config) error {
+		if c.Language != "" && !knownLanguage(c.Language) {
+			var ids []string
+			for _, l := range languages {
+				ids = append(ids, l.id)
+			}
+			return fmt.Errorf("Language must be empty or one of %s, not '%s'", strings.Join(ids, ", "), c.Language)
+		}
+		return nil
+	}},
+	{"UpdateChannel", func(c *config) error {
+		if c.UpdateChannel != channelStable && c.UpdateChannel != channelBeta {
+			return fmt.Errorf("UpdateChannel must be %s or %s, not '%s'", channelStable, channelBeta, c.UpdateChannel)
+		}
+		return nil
+	}},
+	{"Engine", func(c *config) error {
+		for _, e := range engines {
+			if e.id == c.Engine {
+				return nil
+			}
+		}
+		return fmt.Errorf("Engine must be one of %s, not '%s'", engineIDs(), c.Engine)
+	}},
+	{"ActiveQuickState", func(c *config) error {
+		n := len(defaultQuickStates())
+		if c.ActiveQuickState < 0 || c.ActiveQuickState >= n {
+			return fmt.Errorf("ActiveQuickState must be between 0 and %d, not %d", n-1, c.ActiveQuickState)
+		}
+		return nil
+	}},
+	{"Presets", func(c *config) error {
+		for i, p := range c.Presets {
+			if strings.TrimSpace(p.Name) == "" {
+				return fmt.Errorf("preset %d has no name", i+1)
+			}
+			if j, _ := findPreset(c, p.Name); j != i {
+				return fmt.Errorf("there are two presets named '%s'", p.Name)
+			}
+			if t := engineByID("rnnoise").control; p.Threshold < t.min || p.Threshold > t.max {
+				return fmt.Errorf("Threshold of preset %s must be between %d and %d, not %d", p.Name, t.min, t.max, p.Threshold)
+			}
+			if p.OutputGain < minGain || p.OutputGain > maxGain {
+				return fmt.Errorf("OutputGain of preset %s must be between %d and %d, not %d", p.Name, minGain, maxGain, p.OutputGain)
+			}
+			if p.BufferLatency < minBufferLatency || p.BufferLatency > maxBufferLatency {
+				return fmt.Errorf("BufferLatency of preset %s must be between %d and %d, not %d", p.Name, minBufferLatency, maxBufferLatency, p.BufferLatency)
+			}
+		}
+		return nil
+	}},
+	{"NoisePresets", func(c *config) error {
+		t := engineByID("rnnoise").control
+		for i, p := range c.NoisePresets {
+			if strings.TrimSpace(p.Name) == "" {
+				return fmt.Errorf("noise preset %d has no name", i+1)
+			}
+			if builtinNoisePreset(p.Name) {
+				return fmt.Errorf("the noise preset %s has the name of a built-in one", p.Name)
+			}
+			for _, q := range c.NoisePresets[:i] {
+				if strings.EqualFold(p.Name, q.Name) {
+					return fmt.Errorf("there are two noise presets named '%s'", p.Name)
+				}
+			}
+			if p.Threshold < t.min || p.Threshold > t.max {
+				return fmt.Errorf("Threshold of noise preset %s must be between %d and %d, not %d", p.Name, t.min, t.max, p.Threshold)
+			}
+			if p.Suppression < 0 || p.Suppression > 100 {
+				return fmt.Errorf("Suppression of noise preset %s must be between 0 and 100, not %d", p.Name, p.Suppression)
+			}
+			if p.OutputGain < minGain || p.OutputGain > maxGain {
+				return fmt.Errorf("OutputGain of noise preset %s must be between %d and %d, not %d", p.Name, minGain, maxGain, p.OutputGain)
+			}
+		}
+		return nil
+	}},
+	{"Hooks", func(c *config) error {
+		for event := range c.Hooks {
+			if !knownHook(event) {
+				return fmt.Errorf("unknown event '%s' in Hooks, must be one of %s", event, strings.Join(hookEvents, ", "))
+			}
+		}
+		return nil
+	}},
+	{"Hotkeys", func(c *config) error {
+		for id, spec := range c.Hotkeys {
+			known := false
+			for _, a := range hotkeyActions {
+				known = known || a.id == id
+			}
+			if !known {
+				return fmt.Errorf("unknown shortcut '%s' in Hotkeys", id)
+			}
+			if spec == "" {
+				continue
+			}
+			if _, _, err := parseHotkey(spec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+}
+
+// validateConfig returns the problems with c and replaces the offending settings by their default.
+func validateConfig(c *config) []error {
+	var errs []error
+	defaults := reflect.ValueOf(defaultConfig())
+	for _, r := range configSchema {
+		if err := r.check(c); err != nil {
+			errs = append(errs, err)
+			reflect.ValueOf(c).Elem().FieldByName(r.key).Set(defaults.FieldByName(r.key))
+		}
+	}
+	return errs
+}
+
+// validateConfigValue checks a single setting, as changed by `noisetorch config set`.
+func validateConfigValue(c *config, key string) error {
+	for _, r := range configSchema {
+		if strings.EqualFold(r.key, key) {
+			return r.check(c)
+		}
+	}
+	return nil
+}
+
+// parseConfig migrates, decodes and validates a config file. Only a file that isn't TOML at all
+// is an error, all other problems are returned alongside the usable config.
+func parseConfig(buf []byte) (config, []error, error) {
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(buf), &raw); err != nil {
+		return config{}, nil, err
+	}
+
+	var problems []error
+	version := 0
+	if v, ok := raw["Version"].(int64); ok && v > 0 {
+		version = int(v)
+	}
+	if version > configVersion {
+		problems = append(problems, fmt.Errorf("config version %d is newer than this version of NoiseTorch supports (%d)", version, configVersion))
+	}
+	for ; version < configVersion; version++ {
+		infof("Migrating config from version %d to %d\n", version, version+1)
+		configMigrations[version](raw)
+	}
+	raw["Version"] = int64(configVersion)
+
+	var migrated bytes.Buffer
+	if err := toml.NewEncoder(&migrated).Encode(raw); err != nil {
+		return config{}, nil, err
+	}
+	// keys missing from older config files keep their default
+	c := defaultConfig()
+	md, err := toml.Decode(migrated.String(), &c)
+	if err != nil {
+		return config{}, nil, err
+	}
+	for _, key := range md.Undecoded() {
+		problems = append(problems, fmt.Errorf("unknown setting '%s'", key))
+	}
+	c.Version = configVersion
+	problems = append(problems, validateConfig(&c)...)
+	return c, problems, nil
+}
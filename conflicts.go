@@ -0,0 +1,204 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Another filter on the same audio processes the voice twice or mutes it, behind many "no audio"
+// reports. Effects tools are recognized by the devices they create; they may filter another
+// microphone than ours, we can't tell, so they're only a conflict while we filter the same kind of
+// device. A second NoiseTorch chain on a microphone that already has one, e.g. of the original
+// NoiseTorch or of another instance, or one filtering a filtered microphone, always is.
+
+const (
+	conflictEffectsInput  = iota // an effects tool processes microphones as well
+	conflictEffectsOutput        // an effects tool processes what applications play as well
+	conflictSameSource           // several NoiseTorch chains filter the same microphone
+	conflictStacked              // a NoiseTorch chain filters a filtered microphone
+)
+
+type conflict struct {
+	kind   int
+	tool   string // EasyEffects or PulseEffects
+	device string // description of the microphone
+}
+
+type conflictui struct {
+	list   []conflict
+	hidden string // conflictsKey of the list the user hid
+}
+
+// effectsTools are matched against the names of devices and the application id in their properties.
+var effectsTools = []struct{ prefix, name string }{
+	{"easyeffects", "EasyEffects"},
+	{"pulseeffects", "PulseEffects"},
+	{"com.github.wwmm.easyeffects", "EasyEffects"},
+	{"com.github.wwmm.pulseeffects", "PulseEffects"},
+}
+
+func effectsTool(name string, props map[string]string) string {
+	for _, s := range []string{strings.ToLower(name), strings.ToLower(props["application.id"])} {
+		for _, t := range effectsTools {
+			if strings.HasPrefix(s, t.prefix) {
+				return t.name
+			}
+		}
+	}
+	return ""
+}
+
+// describe returns what the conflict is and what to do about it, sprintf is trf or fmt.Sprintf.
+func (c conflict) describe(sprintf func(string, ...interface{}) string) (string, string) {
+	switch c.kind {
+	case conflictEffectsInput:
+		return sprintf("%s processes microphones as well", c.tool),
+			sprintf("Two filters on one microphone process the voice twice or mute it. Turn off the input effects of %s, or at least its noise reduction.", c.tool)
+	case conflictEffectsOutput:
+		return sprintf("%s processes what applications play as well", c.tool),
+			sprintf("Two filters on one output process the sound twice or mute it. Turn off the output effects of %s, or don't filter the headphones here.", c.tool)
+	case conflictSameSource:
+		return sprintf("%s is filtered more than once", c.device),
+			sprintf("Another NoiseTorch, e.g. the original one or a second instance, filters it too. Unload the filter there, or quit it.")
+	default:
+		return sprintf("A filter records from %s, which is already filtered", c.device),
+			sprintf("Filtering the filtered microphone again only adds delay and cuts the voice. Pick the real microphone there instead.")
+	}
+}
+
+// findConflicts looks for other filters. input and output are whether we filter microphones or
+// headphones, effects tools only conflict then.
+func findConflicts(ctx *ntcontext, input, output bool) ([]conflict, error) {
+	c := ctx.paClient
+	sources, err := c.Sources()
+	if err != nil {
+		return nil, err
+	}
+	sinks, err := c.Sinks()
+	if err != nil {
+		return nil, err
+	}
+	mods, err := c.ModuleList()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []conflict
+	seen := make(map[conflict]bool)
+	add := func(k conflict) {
+		if !seen[k] {
+			seen[k] = true
+			res = append(res, k)
+		}
+	}
+	for _, s := range sources {
+		if t := effectsTool(s.Name, s.PropList); t != "" && input && s.MonitorSourceIndex == noIndex {
+			add(conflict{kind: conflictEffectsInput, tool: t})
+		}
+	}
+	for _, s := range sinks {
+		t := effectsTool(s.Name, s.PropList)
+		switch {
+		case t == "":
+		case strings.Contains(strings.ToLower(s.Name), "mic"):
+			// PulseEffects on PulseAudio filters the microphone into a sink, PulseEffects_mic
+			if input {
+				add(conflict{kind: conflictEffectsInput, tool: t})
+			}
+		case output:
+			add(conflict{kind: conflictEffectsOutput, tool: t})
+		}
+	}
+
+	// the chains by the microphone they filter: the loopback into the filter on PulseAudio, the
+	// ladspa source on PipeWire
+	chains := make(map[string][]string)
+	var filtered []string
+	for _, m := range mods {
+		args := moduleArgMap(m.Argument)
+		var source, chain string
+		switch {
+		case m.Name == "module-loopback" && strings.HasPrefix(args["sink"], "nui_mic_raw"):
+			source, chain = args["source"], args["sink"]
+		case m.Name == "module-ladspa-source" && (strings.HasPrefix(args["source_name"], "Filtered Microphone") || strings.HasPrefix(args["source_name"], "nui_mic")):
+			source, chain = args["master"], args["source_name"]
+		default:
+			continue
+		}
+		if _, ok := chains[source]; !ok {
+			filtered = append(filtered, source)
+		}
+		chains[source] = append(chains[source], chain)
+	}
+	sort.Strings(filtered)
+	for _, name := range filtered {
+		description := name
+		ours := false
+		for _, s := range sources {
+			if s.Name == name {
+				if s.Description != "" {
+					description = s.Description
+				}
+				ours = s.MonitorSourceIndex == noIndex && isNoiseTorchDevice(s.Name, s.PropList)
+			}
+		}
+		if len(chains[name]) > 1 {
+			add(conflict{kind: conflictSameSource, device: description})
+		}
+		if ours {
+			add(conflict{kind: conflictStacked, device: description})
+		}
+	}
+	return res, nil
+}
+
+// conflictsKey identifies a list of conflicts, to keep it hidden until it changes.
+func conflictsKey(list []conflict) string {
+	var b strings.Builder
+	for _, c := range list {
+		fmt.Fprintf(&b, "%d/%s/%s;", c.kind, c.tool, c.device)
+	}
+	return b.String()
+}
+
+// updateConflicts looks for conflicts again, it runs on every change of the audio server.
+func updateConflicts(ctx *ntcontext) {
+	active := ctx.noiseSupressorState == loaded && !jackMode(ctx)
+	list, err := findConflicts(ctx, active && ctx.config.FilterInput, active && ctx.config.FilterOutput)
+	if err != nil {
+		errorf("Couldn't look for conflicting filters: %v\n", err)
+		return
+	}
+	if conflictsKey(list) != conflictsKey(ctx.conflicts.list) {
+		for _, c := range list {
+			text, _ := c.describe(fmt.Sprintf)
+			warnf("Conflicting filter: %s\n", text)
+		}
+	}
+	ctx.conflicts.list = list
+}
+
+func conflictsView(ctx *ntcontext, w *nucular.Window) {
+	list := ctx.conflicts.list
+	if len(list) == 0 || conflictsKey(list) == ctx.conflicts.hidden {
+		return
+	}
+	for i, c := range list {
+		text, hint := c.describe(trf)
+		w.Row(20).Ratio(0.85, 0.15)
+		w.LabelColored(text, "LC", orange)
+		if i > 0 {
+			w.Spacing(1)
+		} else if focusable(ctx, w, w.ButtonText(tr("Hide"))) {
+			ctx.conflicts.hidden = conflictsKey(list)
+		}
+		w.Row(30).Dynamic(1)
+		w.LabelWrapColored(hint, orange)
+	}
+}
@@ -0,0 +1,152 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+	nstyle "github.com/aarzilli/nucular/style"
+	"github.com/noisetorch/pulseaudio"
+)
+
+// Servers with many devices, or still enumerating Bluetooth ones, can take seconds to answer. So
+// after connecting the server info and the devices are queried concurrently, with a spinner
+// showing meanwhile, and the main view shows up after metadataTimeout at the latest with whatever
+// came back by then. refreshDevices picks up the rest with the next update.
+
+const (
+	metadataTimeout = 3 * time.Second
+	spinnerDots     = 8
+)
+
+type connectui struct {
+	status string
+	stop   chan struct{}
+}
+
+// serverMetadata is what the main view needs from the server.
+type serverMetadata struct {
+	sources, sinks             []device
+	defaultSource, defaultSink string // empty if the server didn't answer in time
+}
+
+// fetchMetadata queries the server concurrently and sets ctx.serverInfo, also if it only arrives
+// after the timeout.
+func fetchMetadata(ctx *ntcontext, c AudioServer) serverMetadata {
+	infoc := make(chan audioserverinfo, 1)
+	defaultsc := make(chan *pulseaudio.Server, 1)
+	sourcesc := make(chan []device, 1)
+	sinksc := make(chan []device, 1)
+	go func() {
+		info, err := serverInfo(c)
+		if err != nil {
+			errorf("Couldn't fetch audio server info: %s\n", err)
+		}
+		infoc <- info
+	}()
+	go func() {
+		server, err := c.ServerInfo()
+		if err != nil {
+			errorf("Couldn't fetch the default devices: %v\n", err)
+		}
+		defaultsc <- server
+	}()
+	go func() { sourcesc <- getSources(ctx, c) }()
+	go func() { sinksc <- getSinks(ctx, c) }()
+
+	var m serverMetadata
+	gotInfo := false
+	timeout := time.After(metadataTimeout)
+	for pending := 4; pending > 0; pending-- {
+		select {
+		case ctx.serverInfo = <-infoc:
+			gotInfo = true
+		case server := <-defaultsc:
+			if server != nil {
+				m.defaultSource, m.defaultSink = server.DefaultSource, server.DefaultSink
+			}
+		case m.sources = <-sourcesc:
+		case m.sinks = <-sinksc:
+		case <-timeout:
+			warnf("The audio server didn't answer within %s, continuing without\n", metadataTimeout)
+			if !gotInfo {
+				go func() {
+					ctx.serverInfo = <-infoc
+					(*ctx.masterWindow).Changed()
+				}()
+			}
+			return m
+		}
+	}
+	return m
+}
+
+// defaultFunc returns a fallback for preselectDevice that doesn't ask the server again.
+func defaultFunc(id string) func(AudioServer) (string, error) {
+	return func(AudioServer) (string, error) {
+		if id == "" {
+			return "", fmt.Errorf("the audio server didn't tell in time")
+		}
+		return id, nil
+	}
+}
+
+// showConnecting sets what the connect view says and keeps its spinner turning.
+func showConnecting(ctx *ntcontext, status string) {
+	ctx.connect.status = status
+	if ctx.connect.stop == nil {
+		stop := make(chan struct{})
+		ctx.connect.stop = stop
+		go func() {
+			t := time.NewTicker(time.Second / 15)
+			defer t.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-t.C:
+					(*ctx.masterWindow).Changed()
+				}
+			}
+		}()
+	}
+	(*ctx.masterWindow).Changed()
+}
+
+func hideConnecting(ctx *ntcontext) {
+	if ctx.connect.stop != nil {
+		close(ctx.connect.stop)
+		ctx.connect.stop = nil
+	}
+}
+
+func connectView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(50).Dynamic(1)
+	w.Label(ctx.connect.status, "CB")
+	w.Row(40).Dynamic(1)
+	bounds, out := w.Custom(nstyle.WidgetStateInactive)
+	if out == nil {
+		return
+	}
+	// dots in a circle, the brightest one going round once a second
+	size := bounds.H / 5
+	radius := float64(bounds.H-size) / 2
+	cx, cy := bounds.X+bounds.W/2, bounds.Y+bounds.H/2
+	head := int(time.Now().UnixNano()/int64(time.Second/spinnerDots)) % spinnerDots
+	for i := 0; i < spinnerDots; i++ {
+		angle := 2 * math.Pi * float64(i) / spinnerDots
+		p := image.Pt(cx+int(radius*math.Sin(angle))-size/2, cy-int(radius*math.Cos(angle))-size/2)
+		// colors are premultiplied, fading takes all channels
+		alpha := 255 - 200*((head-i+spinnerDots)%spinnerDots)/spinnerDots
+		fade := func(v uint8) uint8 { return uint8(int(v) * alpha / 255) }
+		c := color.RGBA{fade(lightBlue.R), fade(lightBlue.G), fade(lightBlue.B), fade(lightBlue.A)}
+		out.FillCircle(rect.Rect{X: p.X, Y: p.Y, W: size, H: size}, c)
+	}
+}
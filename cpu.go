@@ -0,0 +1,230 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The filter doesn't run in our process but in the one of the audio server (or the PipeWire
+// filter-chain we started), so that's the process we measure.
+
+// USER_HZ, which /proc/<pid>/stat counts in, is 100 on every Linux architecture we run on
+const clockTicks = 100
+
+// filterHostPid returns the process the filter runs in.
+func filterHostPid(ctx *ntcontext) (int, error) {
+	if ctx.serverInfo.servertype == servertype_pipewire {
+		if ctx.config.NativePipeWire {
+			buf, err := os.ReadFile(pipeWirePidFile())
+			if err != nil {
+				return 0, err
+			}
+			return strconv.Atoi(strings.TrimSpace(string(buf)))
+		}
+		return findProcess("pipewire-pulse")
+	}
+	return getPulsePid()
+}
+
+// findProcess returns a process of ours with the given name.
+func findProcess(name string) (int, error) {
+	dirs, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return 0, err
+	}
+	for _, dir := range dirs {
+		comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+		if err != nil || strings.TrimSpace(string(comm)) != name {
+			continue
+		}
+		info, err := os.Stat(dir)
+		if err != nil || !ownedByUs(info) {
+			continue
+		}
+		return strconv.Atoi(filepath.Base(dir))
+	}
+	return 0, fmt.Errorf("no %s process found", name)
+}
+
+func ownedByUs(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
+
+// processCPUTime returns the CPU time the process has used so far.
+func processCPUTime(pid int) (time.Duration, error) {
+	buf, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// the command name can contain spaces, the fields we want come after it
+	s := string(buf)
+	fields := strings.Fields(s[strings.LastIndex(s, ")")+1:])
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(utime+stime) * time.Second / clockTicks, nil
+}
+
+// cpuUsage measures the CPU usage of the process over the given interval, in percent of one core.
+func cpuUsage(pid int, interval time.Duration) (float64, error) {
+	before, err := processCPUTime(pid)
+	if err != nil {
+		return 0, err
+	}
+	time.Sleep(interval)
+	after, err := processCPUTime(pid)
+	if err != nil {
+		return 0, err
+	}
+	return float64(after-before) / float64(interval) * 100, nil
+}
+
+const (
+	cpuSampleInterval = 2 * time.Second
+	// the filter runs in the realtime audio thread, above this it's likely to miss its deadlines
+	// whenever something else needs the core
+	cpuWarnPercent = 50.0
+)
+
+// cpuMonitor is what the GUI shows about the CPU usage of the filter. Unless the filter has a process
+// of its own, its share is what the audio server uses on top of what it used while unloaded.
+type cpuMonitor struct {
+	known        bool
+	filter       float64 // percent of one core
+	perChannel   float64 // filter usage per filtered channel, 0 until measured
+	baseline     float64 // usage of the audio server while unloaded
+	haveBaseline bool
+}
+
+// filterHasOwnProcess tells whether all the CPU usage of filterHostPid is the filter's.
+func filterHasOwnProcess(ctx *ntcontext) bool {
+	return ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire
+}
+
+func cpuWatcher(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	for {
+		time.Sleep(cpuSampleInterval)
+		if !connected(ctx) {
+			continue
+		}
+		state := ctx.noiseSupressorState
+		if state != loaded && filterHasOwnProcess(ctx) {
+			continue
+		}
+		pid, err := filterHostPid(ctx)
+		if err != nil {
+			debugf("Couldn't find the process running the filter: %v\n", err)
+			continue
+		}
+		usage, err := cpuUsage(pid, time.Second)
+		if err != nil {
+			debugf("Couldn't measure CPU usage: %v\n", err)
+			continue
+		}
+		if state != loaded || ctx.noiseSupressorState != loaded {
+			m := &ctx.cpu
+			if m.haveBaseline {
+				// smooth out bursts of other clients
+				m.baseline = 0.8*m.baseline + 0.2*usage
+			} else {
+				m.baseline, m.haveBaseline = usage, true
+			}
+			if m.known {
+				m.known = false
+				(*ctx.masterWindow).Changed()
+			}
+			continue
+		}
+
+		filter := usage
+		if !filterHasOwnProcess(ctx) && ctx.cpu.haveBaseline {
+			filter = math.Max(usage-ctx.cpu.baseline, 0)
+		}
+		ctx.cpu.filter, ctx.cpu.known = filter, true
+		if n := loadedChannels(ctx); n > 0 {
+			ctx.cpu.perChannel = filter / float64(n)
+		}
+		(*ctx.masterWindow).Changed()
+	}
+}
+
+// loadedChannels counts the channels the loaded filters run on, RNNoise runs once per channel.
+func loadedChannels(ctx *ntcontext) int {
+	if len(ctx.chain.inputs) == 0 && ctx.chain.output == "" {
+		// e.g. the native PipeWire filter-chain, which we can't inspect
+		return selectedChannels(ctx)
+	}
+	n := 0
+	for _, id := range ctx.chain.inputs {
+		if inp, ok := findDevice(ctx.inputList, id); ok {
+			n += inputChannels(&inp)
+		} else {
+			n++
+		}
+	}
+	if ctx.chain.output != "" {
+		n++
+	}
+	return n
+}
+
+// selectedChannels counts the channels the filters would run on with the current selection.
+func selectedChannels(ctx *ntcontext) int {
+	n := 0
+	for _, inp := range inputSelections(ctx) {
+		n += inputChannels(&inp)
+	}
+	if _, ok := outputSelection(ctx); ok {
+		n++
+	}
+	return n
+}
+
+func cpuView(ctx *ntcontext, w *nucular.Window) {
+	m := ctx.cpu
+	if ctx.noiseSupressorState == loaded && m.known {
+		what := tr("Filter CPU usage")
+		if !filterHasOwnProcess(ctx) && !m.haveBaseline {
+			// loaded before we started, there was nothing to compare with
+			what = tr("Audio server CPU usage")
+		}
+		w.Row(15).Dynamic(1)
+		text := trf("%s: %.1f%% of one core", what, m.filter)
+		if m.filter >= cpuWarnPercent {
+			w.LabelColored(trf("%s, expect dropouts. Filter fewer channels or raise the buffer latency.", text), "LC", orange)
+		} else {
+			w.Label(text, "LC")
+		}
+		return
+	}
+	if ctx.noiseSupressorState == loaded || m.perChannel == 0 {
+		return
+	}
+	// what we measured before lets us warn before loading a heavier configuration
+	n := selectedChannels(ctx)
+	if expected := m.perChannel * float64(n); n > 1 && expected >= cpuWarnPercent {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(trf("Filtering %d channels will likely use ~%.0f%% of a core and cause dropouts.", n, expected), "LC", orange)
+	}
+}
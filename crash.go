@@ -0,0 +1,236 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/url"
+	"noisetorch/buildinfo"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// When we panic, we save what's needed to understand the crash into a zip file users can attach to
+// an issue. Device serial numbers and the user name are redacted, the rest of the bundle is meant
+// to be read by the user before sending it anywhere.
+
+// crashui is what the crash dialog shows.
+type crashui struct {
+	reason string
+	bundle string
+	err    error
+}
+
+// recoverCrash is deferred at the top of the main goroutine, the GUI and our long running goroutines.
+// With the GUI running, it shows the crash dialog, otherwise it exits.
+func recoverCrash(ctx *ntcontext) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	errorf("Crashed: %v\n%s", r, stack)
+
+	reason := fmt.Sprint(r)
+	bundle, err := writeCrashBundle(ctx, reason, stack)
+	if err != nil {
+		errorf("Couldn't write crash report: %v\n", err)
+	}
+
+	if ctx.masterWindow == nil || ctx.crash.reason != "" {
+		fmt.Fprintf(os.Stderr, "NoiseTorch-ng crashed: %s\n", reason)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Diagnostics were saved to %s, please attach them when reporting the problem.\n", bundle)
+		}
+		os.Exit(2)
+	}
+	ctx.crash = crashui{reason: reason, bundle: bundle, err: err}
+	ctx.views.Push(crashView)
+	(*ctx.masterWindow).Changed()
+}
+
+// redactor hides device serial numbers, which are part of both device properties and IDs, and the
+// name of the user, which is part of paths.
+func redactor(ctx *ntcontext) *strings.Replacer {
+	var pairs []string
+	addSerials := func(props map[string]string) {
+		for k, v := range props {
+			if strings.Contains(k, "serial") && v != "" {
+				pairs = append(pairs, v, "<serial>")
+			}
+		}
+	}
+	if connected(ctx) {
+		if sources, err := ctx.paClient.Sources(); err == nil {
+			for _, s := range sources {
+				addSerials(s.PropList)
+			}
+		}
+		if sinks, err := ctx.paClient.Sinks(); err == nil {
+			for _, s := range sinks {
+				addSerials(s.PropList)
+			}
+		}
+	}
+	if u, err := user.Current(); err == nil && len(u.Username) > 2 {
+		pairs = append(pairs, u.Username, "<user>")
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// writeCrashBundle writes the diagnostics bundle and returns its path.
+func writeCrashBundle(ctx *ntcontext, reason string, stack []byte) (path string, err error) {
+	// whatever crashed may crash us again
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("crashed while writing crash report: %v", r)
+		}
+	}()
+
+	dir := cacheDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path = filepath.Join(dir, fmt.Sprintf("crash-%s.zip", time.Now().Format("20060102-150405")))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	redact := redactor(ctx)
+	z := zip.NewWriter(f)
+	now := time.Now()
+	add := func(name, content string) error {
+		w, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(redact.Replace(content)))
+		return err
+	}
+
+	files := []struct {
+		name    string
+		content func() string
+	}{
+		{"crash.txt", func() string {
+			return fmt.Sprintf("%s\n\n%s\n%s", reason, stack, buildinfo.String())
+		}},
+		{"log.txt", func() string {
+			text, _ := appLog.text(levelDebug)
+			return text
+		}},
+		{"config.toml", func() string {
+			if ctx.config == nil {
+				return "not loaded\n"
+			}
+			// a copy without the password, the bundle ends up in public issues
+			c := *ctx.config
+			if c.OBSPassword != "" {
+				c.OBSPassword = "(redacted)"
+			}
+			buf, err := encodeConfig(&c)
+			if err != nil {
+				return err.Error()
+			}
+			return string(buf)
+		}},
+		{"server.txt", func() string {
+			s := ctx.serverInfo
+			return fmt.Sprintf("%s %d.%d.%d (pipewire: %t, outdated: %t)\nstate: %d\nchain: %+v\n",
+				s.name, s.major, s.minor, s.patch, s.servertype == servertype_pipewire, s.outdatedPipeWire,
+				ctx.noiseSupressorState, ctx.chain)
+		}},
+		{"devices.txt", func() string { return crashDevices(ctx) }},
+	}
+	for _, file := range files {
+		if err := add(file.name, file.content()); err != nil {
+			return "", err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func crashDevices(ctx *ntcontext) string {
+	if !connected(ctx) {
+		return "not connected to the audio server\n"
+	}
+	var b strings.Builder
+	for _, list := range []struct {
+		devices []device
+		sink    bool
+	}{{ctx.inputList, false}, {ctx.outputList, true}} {
+		for _, d := range list.devices {
+			text, err := deviceDetails(ctx, d.ID, list.sink)
+			if err != nil {
+				text = fmt.Sprintf("%s: %v\n", d.ID, err)
+			}
+			b.WriteString(text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// crashIssueURL opens a new issue on GitHub, if that's where we come from.
+func crashIssueURL(c crashui) string {
+	if !strings.Contains(buildinfo.WebsiteURL, "github.com") {
+		return ""
+	}
+	body := fmt.Sprintf("NoiseTorch-ng %s (%s) crashed: %s\n\nWhat were you doing when it crashed?\n\n"+
+		"Please attach %s after looking through it.\n", buildinfo.Version, buildinfo.Distribution, c.reason, filepath.Base(c.bundle))
+	return strings.TrimSuffix(buildinfo.WebsiteURL, "/") + "/issues/new?title=" +
+		url.QueryEscape("Crash: "+c.reason) + "&body=" + url.QueryEscape(body)
+}
+
+func crashView(ctx *ntcontext, w *nucular.Window) {
+	c := ctx.crash
+	w.Row(15).Dynamic(1)
+	w.Label(tr("NoiseTorch-ng ran into a problem"), "CB")
+	w.Row(40).Dynamic(1)
+	w.LabelWrap(c.reason)
+
+	w.Row(15).Dynamic(1)
+	if c.err != nil {
+		w.LabelColored(trf("Couldn't save diagnostics: %v", c.err), "LC", red)
+	} else {
+		w.Label(tr("Diagnostics, with device serial numbers removed, were saved to:"), "LC")
+		w.Row(15).Dynamic(1)
+		w.Label(c.bundle, "LC")
+	}
+	w.Row(15).Dynamic(1)
+	w.Label(tr("The filter keeps working if it was loaded. Please restart NoiseTorch-ng."), "LC")
+
+	issue := crashIssueURL(c)
+	w.Row(25).Dynamic(3)
+	if issue != "" && c.err == nil {
+		if focusable(ctx, w, w.ButtonText(tr("Report on GitHub"))) {
+			exec.Command("xdg-open", issue).Start()
+		}
+	} else {
+		w.Spacing(1)
+	}
+	if c.err == nil {
+		if focusable(ctx, w, w.ButtonText(tr("Show bundle"))) {
+			exec.Command("xdg-open", filepath.Dir(c.bundle)).Start()
+		}
+	} else {
+		w.Spacing(1)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Quit"))) {
+		cleanupExit(1)
+	}
+}
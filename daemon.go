@@ -0,0 +1,172 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runDaemon keeps the supressor loaded without any GUI: it (re)loads the filter whenever the audio
+// server (re)appears and unloads it again when we're told to exit. With a setup file, the running
+// state is continuously reconciled against it, so edits to the file take effect on their own.
+// SIGHUP reads the config again. With AlsaFallback the filter runs between ALSA devices while there's
+// no audio server, see alsa.go.
+func runDaemon(ctx *ntcontext, opt CLIOpts, instance net.Listener) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, append(exitSignals, syscall.SIGHUP)...)
+
+	takeover := make(chan struct{}, 1)
+	go serveInstance(instance, "daemon", func(cmd string) {
+		if cmd == "replace" {
+			// before the new instance gets the socket and looks at the chains
+			if connected(ctx) {
+				wakeIdleChains(ctx)
+			}
+			instance.Close()
+			takeover <- struct{}{}
+		}
+	})
+
+	applyDaemonOpts(ctx, opt)
+
+	grace := time.Duration(ctx.config.ReconnectGracePeriod) * time.Second
+	var lostAt time.Time
+
+	infof("Running as daemon\n")
+	for {
+		if !connected(ctx) {
+			if lostAt.IsZero() && ctx.paClient != nil {
+				lostAt = time.Now()
+			}
+			paClient, err := dialAudioServer()
+			if err != nil {
+				errorf("Couldn't create pulseaudio client: %v\n", err)
+				if ctx.config.AlsaFallback && !alsaFallbackRunning() {
+					if err := startAlsaFallback(ctx); err != nil {
+						errorf("%v\n", err)
+					}
+				}
+			} else {
+				if alsaFallbackRunning() {
+					infof("Audio server is back, stopping the ALSA filter\n")
+				}
+				if err := stopAlsaFallback(); err != nil {
+					errorf("Couldn't stop the ALSA filter: %v\n", err)
+				}
+				if ctx.paClient != nil {
+					metricsReconnected(ctx)
+				}
+				ctx.paClient = paClient
+				ctx.serverInfo, err = serverInfo(paClient)
+				if err != nil {
+					errorf("Couldn't fetch audio server info: %s\n", err)
+				}
+				infof("Connected to audio server. Server name '%s'\n", ctx.serverInfo.name)
+			}
+		}
+
+		if connected(ctx) && !lostAt.IsZero() {
+			// after a blip the modules are often still there, or reappear shortly after the
+			// server is back. Give them a chance to instead of rebuilding the chain.
+			if state, _ := supressorState(ctx); state == loaded {
+				infof("Re-adopted still loaded supressor after reconnect\n")
+				lostAt = time.Time{}
+			} else if time.Since(lostAt) >= grace {
+				warnf("Supressor didn't come back within %s, rebuilding\n", grace)
+				lostAt = time.Time{}
+			}
+		}
+
+		waiting := !lostAt.IsZero()
+		if connected(ctx) && !waiting && opt.setupFile != "" {
+			if setup, err := readSetup(opt.setupFile); err != nil {
+				errorf("Couldn't read setup file: %v\n", err)
+			} else if err := reconcile(ctx, setup); err != nil {
+				errorf("Couldn't apply setup file: %v\n", err)
+			}
+		} else if connected(ctx) && !waiting {
+			if state, _ := supressorState(ctx); state != loaded {
+				if err := daemonLoad(ctx, opt); err != nil {
+					errorf("Couldn't load supressor: %v\n", err)
+					fmt.Fprintf(os.Stderr, "Couldn't load supressor: %v\n", err)
+				}
+			}
+		}
+
+		select {
+		case sig := <-sigs:
+			if sig == syscall.SIGHUP {
+				infof("Received %s, reloading the config\n", sig)
+				reloadDaemonConfig(ctx, opt)
+				continue
+			}
+			infof("Received %s, unloading\n", sig)
+			if err := stopAlsaFallback(); err != nil {
+				errorf("Couldn't stop the ALSA filter: %v\n", err)
+			}
+			if connected(ctx) {
+				restoreRouting(ctx, nil)
+				if err := turnOffSupressor(ctx); err != nil {
+					fmt.Fprintf(os.Stderr, "Error unloading PulseAudio Module: %+v\n", err)
+				}
+			}
+			hooksRunning.Wait()
+			return
+		case <-takeover:
+			// the loaded filters stay, the new instance picks them up
+			infof("Another instance takes over, exiting\n")
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// applyDaemonOpts overrides the config with the command line of the daemon.
+func applyDaemonOpts(ctx *ntcontext, opt CLIOpts) {
+	ctx.config.FilterInput = !opt.loadOutput
+	ctx.config.FilterOutput = opt.loadOutput
+	if opt.threshold > 0 {
+		ctx.config.Threshold = clampThreshold(opt.threshold)
+	}
+}
+
+func daemonLoad(ctx *ntcontext, opt CLIOpts) error {
+	if state, _ := supressorState(ctx); state == inconsistent {
+		if err := unloadSupressor(ctx); err != nil {
+			return err
+		}
+	}
+
+	var devices []device
+	var preselect string
+	var fallback func(AudioServer) (string, error)
+	if opt.loadOutput {
+		devices, preselect, fallback = getSinks(ctx, ctx.paClient), ctx.config.LastUsedOutput, getDefaultSinkID
+	} else {
+		devices, preselect, fallback = getSources(ctx, ctx.paClient), ctx.config.LastUsedInput, getDefaultSourceID
+	}
+	if opt.sinkName != "" {
+		preselect = opt.sinkName
+	}
+
+	for _, d := range preselectDevice(ctx, devices, preselect, fallback) {
+		if !d.checked {
+			continue
+		}
+		if opt.sinkName != "" && d.ID != opt.sinkName {
+			break
+		}
+		infof("Loading supressor for %s\n", d.ID)
+		if opt.loadOutput {
+			return loadSupressor(ctx, &device{}, &d)
+		}
+		return loadSupressor(ctx, &d, &device{})
+	}
+	return fmt.Errorf("device not found: %s", preselect)
+}
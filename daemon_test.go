@@ -0,0 +1,61 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDaemon(t *testing.T) {
+	s := newFakeServer(false)
+	attempts := 0
+	dialAudioServer = func() (AudioServer, error) {
+		// the server isn't up yet when the daemon starts, like at login
+		if attempts++; attempts == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return s, nil
+	}
+	defer func() { dialAudioServer = newAudioServer }()
+
+	ctx := newTestContext(t, s)
+	ctx.paClient = nil
+	socket := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "daemon-test.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		runDaemon(ctx, CLIOpts{daemon: true}, l)
+		close(done)
+	}()
+
+	for deadline := time.Now().Add(10 * time.Second); countModules(t, s, "module-ladspa-sink") == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the daemon didn't load the filter: %q", moduleArgs(t, s))
+		}
+	}
+
+	// taking over leaves the chain loaded
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	conn.Write([]byte("replace\n"))
+	conn.Close()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the daemon didn't exit when taken over")
+	}
+	if state, _ := supressorState(ctx); state != loaded {
+		t.Errorf("chain is %s after the daemon exited, want loaded", stateName(state))
+	}
+}
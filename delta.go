@@ -0,0 +1,165 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Delta updates download a bsdiff patch from the running version to the new one instead of the whole
+// release, and only replace the binary. The patch is signed and checked before it's parsed, so the
+// parser only ever sees patches we built. The patched binary is signed too, so a binary that was
+// modified locally can't produce anything we'd install. Whenever a delta can't be used the updater
+// falls back to the full release.
+//
+// Releases provide, next to NoiseTorch_<arch>_<version>.tgz:
+//
+//	NoiseTorch_<arch>_<from>_<version>.bsdiff      patch from <from> to <version>
+//	NoiseTorch_<arch>_<from>_<version>.bsdiff.sig  signature of the patch
+//	NoiseTorch_<arch>_<version>.bin.sig            signature of the binary of <version>
+
+// maxGrowth limits the size of the patched binary to this many times the current one, a release
+// never grows that much and a patch can't make us allocate more.
+const maxGrowth = 4
+
+func deltaFile(from, to string) string {
+	return fmt.Sprintf("NoiseTorch_%s_%s_%s.bsdiff", releaseArch(), from, to)
+}
+
+func binarySigFile(version string) string {
+	return fmt.Sprintf("NoiseTorch_%s_%s.bin.sig", releaseArch(), version)
+}
+
+// installedBinary is where the release tarball puts the binary.
+func installedBinary() string {
+	return filepath.Join(os.Getenv("HOME"), ".local", "bin", "noisetorch")
+}
+
+// deltaUpdate updates the binary to version through a patch from the running version.
+func deltaUpdate(from, version string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if exe != installedBinary() {
+		return fmt.Errorf("running %s, not the binary the updater installs", exe)
+	}
+	old, err := os.ReadFile(exe)
+	if err != nil {
+		return err
+	}
+
+	patch, err := fetchFile(version, deltaFile(from, version))
+	if err != nil {
+		return err
+	}
+	patchSig, err := fetchFile(version, deltaFile(from, version)+".sig")
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publickey(), patch, patchSig) {
+		return fmt.Errorf("the patch doesn't match its signature")
+	}
+	sig, err := fetchFile(version, binarySigFile(version))
+	if err != nil {
+		return err
+	}
+	patched, err := bspatch(old, patch, maxGrowth*int64(len(old)))
+	if err != nil {
+		return fmt.Errorf("couldn't apply the patch: %w", err)
+	}
+	if !ed25519.Verify(publickey(), patched, sig) {
+		return fmt.Errorf("the patched binary doesn't match its signature")
+	}
+	infof("Patched binary verified\n")
+
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, patched, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// bspatch applies a patch in the format of bsdiff 4: a header, then bzip2 compressed control,
+// diff and extra blocks. Each control entry adds the next x bytes of the diff block to the old
+// file, copies the next y bytes of the extra block, and then seeks z bytes in the old file.
+// Patches producing more than maxSize bytes are refused. Lengths are checked one at a time against
+// what's left, sums of them could overflow.
+func bspatch(old, patch []byte, maxSize int64) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != "BSDIFF40" {
+		return nil, fmt.Errorf("not a bsdiff patch")
+	}
+	ctrlLen := offtin(patch[8:])
+	diffLen := offtin(patch[16:])
+	newSize := offtin(patch[24:])
+	if ctrlLen < 0 || ctrlLen > int64(len(patch))-32 {
+		return nil, fmt.Errorf("corrupt patch header")
+	}
+	if diffLen < 0 || diffLen > int64(len(patch))-32-ctrlLen {
+		return nil, fmt.Errorf("corrupt patch header")
+	}
+	if newSize < 0 || newSize > maxSize {
+		return nil, fmt.Errorf("patched binary of %d bytes is too large", newSize)
+	}
+	ctrl := bzip2.NewReader(bytes.NewReader(patch[32 : 32+ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(patch[32+ctrlLen : 32+ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(patch[32+ctrlLen+diffLen:]))
+
+	out := make([]byte, newSize)
+	var buf [24]byte
+	var oldpos, newpos int64
+	for newpos < newSize {
+		if _, err := io.ReadFull(ctrl, buf[:]); err != nil {
+			return nil, fmt.Errorf("corrupt control block: %w", err)
+		}
+		x, y, z := offtin(buf[0:]), offtin(buf[8:]), offtin(buf[16:])
+		if x < 0 || x > newSize-newpos {
+			return nil, fmt.Errorf("corrupt control block")
+		}
+		if y < 0 || y > newSize-newpos-x {
+			return nil, fmt.Errorf("corrupt control block")
+		}
+
+		if _, err := io.ReadFull(diff, out[newpos:newpos+x]); err != nil {
+			return nil, fmt.Errorf("corrupt diff block: %w", err)
+		}
+		for i := int64(0); i < x; i++ {
+			if oldpos+i >= 0 && oldpos+i < int64(len(old)) {
+				out[newpos+i] += old[oldpos+i]
+			}
+		}
+		newpos += x
+		oldpos += x
+
+		if _, err := io.ReadFull(extra, out[newpos:newpos+y]); err != nil {
+			return nil, fmt.Errorf("corrupt extra block: %w", err)
+		}
+		newpos += y
+		oldpos += z
+	}
+	return out, nil
+}
+
+// offtin decodes the sign and magnitude little endian integers of bsdiff.
+func offtin(b []byte) int64 {
+	y := int64(binary.LittleEndian.Uint64(b) &^ (1 << 63))
+	if b[7]&0x80 != 0 {
+		return -y
+	}
+	return y
+}
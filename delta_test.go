@@ -0,0 +1,183 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// bzip2 compressed blocks, there's no compressor in the standard library
+const (
+	// control entry 6, 5, 0
+	ctrlValid = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x66\x1a\x90\x0c\x00\x00\x05\x40\x00\x4b\x08\x20\x00\x21\x86\x81\x9a\x00\xad\x9a\xf1\x77\x24\x53\x85\x09\x06\x61\xa9\x00\xc0"
+	// control entry -1, 0, 0
+	ctrlNegativeDiff = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x90\x2f\x7b\x96\x00\x00\x04\x40\x40\x70\x04\x40\x00\x20\x00\x21\x83\x41\x9a\x08\x54\xc8\x8e\x2e\xe4\x8a\x70\xa1\x21\x20\x5e\xf7\x2c"
+	// control entry 6, -5, 0
+	ctrlNegativeExtra = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xa4\x32\x4e\xe4\x00\x00\x08\x40\x40\x5f\x00\x40\x00\x20\x00\x21\xa6\x8c\xd4\x21\x80\x94\xe4\xb2\x87\x8b\xb9\x22\x9c\x28\x48\x52\x19\x27\x72\x00"
+	// control entry 100, 0, 0
+	ctrlOversizedDiff = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x50\x66\x54\xb7\x00\x00\x02\xe1\x00\x40\x00\x08\x00\x04\x00\x20\x00\x21\x26\x41\x98\x90\xb8\xbb\x92\x29\xc2\x84\x82\x83\x32\xa5\xb8"
+	// control entry 6, 100, 0
+	ctrlOversizedExtra = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x6e\x3b\xa6\xfc\x00\x00\x04\xc1\x00\x49\x08\x04\x00\x20\x00\x30\xcd\x00\xc1\xa5\x2b\x64\x38\xbb\x92\x29\xc2\x84\x83\x71\xdd\x37\xe0"
+	// six zero bytes, keeping "hello "
+	diffHello = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xc5\x85\x43\x8d\x00\x00\x00\x40\x00\x50\x00\x20\x00\x21\x00\x82\x83\x17\x72\x45\x38\x50\x90\xc5\x85\x43\x8d"
+	// "there"
+	extraThere = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xfd\xd4\xd8\x82\x00\x00\x02\x01\x80\x02\x40\x14\x00\x20\x00\x21\x9a\x68\x33\x4d\x0c\xb3\x8b\xb9\x22\x9c\x28\x48\x7e\xea\x6c\x41\x00"
+	bzipEmpty  = "\x42\x5a\x68\x39\x17\x72\x45\x38\x50\x90\x00\x00\x00\x00"
+)
+
+// offtout encodes like offtin decodes.
+func offtout(b []byte, v int64) {
+	if v < 0 {
+		binary.LittleEndian.PutUint64(b, uint64(-v)|1<<63)
+		return
+	}
+	binary.LittleEndian.PutUint64(b, uint64(v))
+}
+
+// testPatch puts a header in front of the blocks, with their lengths unless ctrlLen is given.
+func testPatch(newSize, ctrlLen int64, ctrl, diff, extra string) []byte {
+	if ctrlLen == 0 {
+		ctrlLen = int64(len(ctrl))
+	}
+	patch := make([]byte, 32)
+	copy(patch, "BSDIFF40")
+	offtout(patch[8:], ctrlLen)
+	offtout(patch[16:], int64(len(diff)))
+	offtout(patch[24:], newSize)
+	return append(patch, ctrl+diff+extra...)
+}
+
+func TestBspatch(t *testing.T) {
+	old := []byte("hello world")
+	tests := []struct {
+		name    string
+		patch   []byte
+		maxSize int64
+		want    string
+		err     string
+	}{
+		{
+			name:    "valid",
+			patch:   testPatch(11, 0, ctrlValid, diffHello, extraThere),
+			maxSize: 44,
+			want:    "hello there",
+		},
+		{
+			name:    "exactly the largest allowed size",
+			patch:   testPatch(11, 0, ctrlValid, diffHello, extraThere),
+			maxSize: 11,
+			want:    "hello there",
+		},
+		{
+			name:    "larger than allowed",
+			patch:   testPatch(11, 0, ctrlValid, diffHello, extraThere),
+			maxSize: 10,
+			err:     "too large",
+		},
+		{
+			name:    "truncated header",
+			patch:   testPatch(11, 0, ctrlValid, diffHello, extraThere)[:31],
+			maxSize: 44,
+			err:     "not a bsdiff patch",
+		},
+		{
+			name:    "wrong magic",
+			patch:   append([]byte("BSDIFF39"), testPatch(11, 0, ctrlValid, diffHello, extraThere)[8:]...),
+			maxSize: 44,
+			err:     "not a bsdiff patch",
+		},
+		{
+			name:    "negative control length",
+			patch:   testPatch(11, -1, ctrlValid, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt patch header",
+		},
+		{
+			name:    "control block longer than the patch",
+			patch:   testPatch(11, 1000, ctrlValid, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt patch header",
+		},
+		{
+			name:    "diff block longer than the patch",
+			patch:   testPatch(11, 0, ctrlValid, diffHello, "")[:32+len(ctrlValid)+10],
+			maxSize: 44,
+			err:     "corrupt patch header",
+		},
+		{
+			name:    "negative size",
+			patch:   testPatch(-11, 0, ctrlValid, diffHello, extraThere),
+			maxSize: 44,
+			err:     "too large",
+		},
+		{
+			name:    "control block isn't bzip2",
+			patch:   testPatch(11, 0, strings.Repeat("x", len(ctrlValid)), diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt control block",
+		},
+		{
+			name:    "control block ends early",
+			patch:   testPatch(11, 0, bzipEmpty, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt control block",
+		},
+		{
+			name:    "negative diff length",
+			patch:   testPatch(11, 0, ctrlNegativeDiff, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt control block",
+		},
+		{
+			name:    "negative extra length",
+			patch:   testPatch(11, 0, ctrlNegativeExtra, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt control block",
+		},
+		{
+			name:    "diff past the end",
+			patch:   testPatch(11, 0, ctrlOversizedDiff, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt control block",
+		},
+		{
+			name:    "extra past the end",
+			patch:   testPatch(11, 0, ctrlOversizedExtra, diffHello, extraThere),
+			maxSize: 44,
+			err:     "corrupt control block",
+		},
+		{
+			name:    "diff block ends early",
+			patch:   testPatch(11, 0, ctrlValid, bzipEmpty, extraThere),
+			maxSize: 44,
+			err:     "corrupt diff block",
+		},
+		{
+			name:    "extra block ends early",
+			patch:   testPatch(11, 0, ctrlValid, diffHello, bzipEmpty),
+			maxSize: 44,
+			err:     "corrupt extra block",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bspatch(old, tt.patch, tt.maxSize)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("bspatch() error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bspatch: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("bspatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"noisetorch/buildinfo"
+	"sort"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/clipboard"
+)
+
+// detailsui is the detail pane of the device expanded in a device list, at most one at a time.
+type detailsui struct {
+	id     string
+	editor nucular.TextEditor
+}
+
+var sampleFormatNames = []string{"u8", "alaw", "ulaw", "s16le", "s16be", "float32le", "float32be",
+	"s32le", "s32be", "s24le", "s24be", "s24-32le", "s24-32be"}
+
+func sampleFormatName(f byte) string {
+	if int(f) < len(sampleFormatNames) {
+		return sampleFormatNames[f]
+	}
+	return fmt.Sprintf("format %d", f)
+}
+
+// deviceDetails describes the source or sink with the given ID, meant to be pasted into bug reports.
+func deviceDetails(ctx *ntcontext, id string, sink bool) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "NoiseTorch %s (%s), %s %d.%d.%d\n", buildinfo.Version, buildinfo.Distribution,
+		ctx.serverInfo.name, ctx.serverInfo.major, ctx.serverInfo.minor, ctx.serverInfo.patch)
+
+	// sources and sinks share most fields, but are distinct types
+	var (
+		name, desc, driver, activePort string
+		spec                           string
+		channelMap                     string
+		flags                          uint32
+		dynamicLatency                 bool
+		latency                        uint64
+		ports                          []string
+		props                          map[string]string
+	)
+	if sink {
+		sinks, err := ctx.paClient.Sinks()
+		if err != nil {
+			return "", err
+		}
+		found := false
+		for _, s := range sinks {
+			if s.Name != id {
+				continue
+			}
+			found = true
+			name, desc, driver, activePort, props = s.Name, s.Description, s.Driver, s.ActivePortName, s.PropList
+			spec = fmt.Sprintf("%s %dch %dHz", sampleFormatName(s.SampleSpec.Format), s.SampleSpec.Channels, s.SampleSpec.Rate)
+			channelMap = channelMapString(s.ChannelMap)
+			flags, latency = s.Flags, s.Latency
+			// PA_SINK_DYNAMIC_LATENCY = 0x0080U
+			dynamicLatency = s.Flags&uint32(0x0080) != 0
+			for _, p := range s.Ports {
+				ports = append(ports, fmt.Sprintf("%s: %s (priority %d, available %d)", p.Name, p.Description, p.Pririty, p.Available))
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("sink %s not found", id)
+		}
+	} else {
+		sources, err := ctx.paClient.Sources()
+		if err != nil {
+			return "", err
+		}
+		found := false
+		for _, s := range sources {
+			if s.Name != id {
+				continue
+			}
+			found = true
+			name, desc, driver, activePort, props = s.Name, s.Description, s.Driver, s.ActivePortName, s.PropList
+			spec = fmt.Sprintf("%s %dch %dHz", sampleFormatName(s.SampleSpec.Format), s.SampleSpec.Channels, s.SampleSpec.Rate)
+			channelMap = channelMapString(s.ChannelMap)
+			flags, latency = s.Flags, s.Latency
+			//PA_SOURCE_DYNAMIC_LATENCY = 0x0040U
+			dynamicLatency = s.Flags&uint32(0x0040) != 0
+			for _, p := range s.Ports {
+				ports = append(ports, fmt.Sprintf("%s: %s (priority %d, available %d)", p.Name, p.Description, p.Pririty, p.Available))
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("source %s not found", id)
+		}
+	}
+
+	fmt.Fprintf(&b, "ID: %s\n", name)
+	fmt.Fprintf(&b, "Description: %s\n", desc)
+	fmt.Fprintf(&b, "Driver: %s\n", driver)
+	fmt.Fprintf(&b, "Sample spec: %s\n", spec)
+	fmt.Fprintf(&b, "Channel map: %s\n", channelMap)
+	fmt.Fprintf(&b, "Flags: %#x (dynamic latency: %t)\n", flags, dynamicLatency)
+	fmt.Fprintf(&b, "Latency: %dus\n", latency)
+	fmt.Fprintf(&b, "Active port: %s\n", activePort)
+	if len(ports) > 0 {
+		b.WriteString("Ports:\n")
+		for _, p := range ports {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b.WriteString("Properties:\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "  %s = %q\n", k, props[k])
+	}
+	return b.String(), nil
+}
+
+// detailsButton toggles the detail pane of el.
+func detailsButton(ctx *ntcontext, w *nucular.Window, el *device, sink bool) {
+	w.LayoutSetWidth(45)
+	txt := tr("Info")
+	if ctx.details.id == el.ID {
+		txt = tr("Hide")
+	}
+	if !focusable(ctx, w, w.ButtonText(txt)) {
+		return
+	}
+	if ctx.details.id == el.ID {
+		ctx.details = detailsui{}
+		return
+	}
+	text, err := deviceDetails(ctx, el.ID, sink)
+	if err != nil {
+		text = err.Error()
+	}
+	ctx.details = detailsui{id: el.ID}
+	ctx.details.editor.Flags = nucular.EditMultiline | nucular.EditReadOnly | nucular.EditSelectable | nucular.EditClipboard
+	ctx.details.editor.Buffer = []rune(text)
+}
+
+// detailsView shows the detail pane below the row of el, if it is expanded.
+func detailsView(ctx *ntcontext, w *nucular.Window, el *device) {
+	if ctx.details.id != el.ID {
+		return
+	}
+	deviceFlagsView(ctx, w, el)
+	w.Row(150).Dynamic(1)
+	focusableEdit(ctx, w, &ctx.details.editor, ctx.details.editor.Edit(w))
+	w.Row(25).Ratio(0.7, 0.3)
+	w.Spacing(1)
+	if focusable(ctx, w, w.ButtonText(tr("Copy to clipboard"))) {
+		clipboard.Set(string(ctx.details.editor.Buffer))
+	}
+}
@@ -0,0 +1,75 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deviceName picks a human readable name for a device. The description is what the audio server
+// shows in its own UIs, the other keys are fallbacks for devices that don't have one.
+func deviceName(ctx *ntcontext, description string, props map[string]string) string {
+	candidates := []string{description, props["device.product.name"], props["node.nick"], props["alsa.card_name"]}
+	if ctx.serverInfo.servertype == servertype_pulse {
+		candidates[0] = props["device.description"]
+	}
+	for _, c := range candidates {
+		if c = strings.TrimSpace(c); c != "" {
+			return c
+		}
+	}
+	return ""
+}
+
+// deviceQualifiers returns details telling apart devices with the same name, most readable first,
+// e.g. two "Family 17h/19h HD Audio Controller" that are really the line in and the front microphone.
+func deviceQualifiers(port string, props map[string]string) []string {
+	return []string{
+		port,
+		props["device.profile.description"],
+		props["alsa.card_name"],
+		props["node.nick"],
+		props["device.product.name"],
+	}
+}
+
+// dedupeNames appends the first qualifier that tells them apart to devices sharing a name, falling
+// back to their ID. quals[i] belongs to devices[i].
+func dedupeNames(devices []device, quals [][]string) {
+	groups := make(map[string][]int)
+	for i, d := range devices {
+		groups[d.Name] = append(groups[d.Name], i)
+	}
+
+	for name, idx := range groups {
+		if len(idx) < 2 {
+			continue
+		}
+		chosen := -1
+		for k := range quals[idx[0]] {
+			seen := make(map[string]bool)
+			distinct := true
+			for _, i := range idx {
+				q := quals[i][k]
+				if q == "" || q == name || seen[q] {
+					distinct = false
+					break
+				}
+				seen[q] = true
+			}
+			if distinct {
+				chosen = k
+				break
+			}
+		}
+		for _, i := range idx {
+			if chosen >= 0 {
+				devices[i].Name = fmt.Sprintf("%s (%s)", name, quals[i][chosen])
+			} else {
+				devices[i].Name = fmt.Sprintf("%s (%s)", name, devices[i].ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,472 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aarzilli/nucular"
+	"github.com/noisetorch/pulseaudio"
+)
+
+// The self-test goes through everything that has to work for the filter to do its job, in the order
+// things usually break, and tells users what to do about what doesn't. It runs from "Troubleshoot"
+// in the GUI and as `noisetorch doctor` (or -doctor).
+
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+const (
+	doctorSampleTime = time.Second
+	doctorTimeout    = 5 * time.Second // for things the audio server should do right away
+)
+
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// doctorui is the state of the troubleshooting view.
+type doctorui struct {
+	running bool
+	results []checkResult
+}
+
+type doctor struct {
+	ctx      *ntcontext
+	results  []checkResult
+	progress func([]checkResult)
+}
+
+func (d *doctor) report(name, status, detail, hint string) {
+	d.results = append(d.results, checkResult{Name: name, Status: status, Detail: detail, Hint: hint})
+	debugf("Self-test: %s: %s %s\n", name, status, detail)
+	if d.progress != nil {
+		d.progress(append([]checkResult(nil), d.results...))
+	}
+}
+
+// runDoctor runs all checks, progress is called with the results so far after each of them.
+// It loads the filter for a moment if it isn't loaded yet, and leaves everything as it was.
+func runDoctor(ctx *ntcontext, progress func([]checkResult)) []checkResult {
+	d := &doctor{ctx: ctx, progress: progress}
+
+	reachable := connected(ctx)
+	if reachable {
+		d.report("Audio server reachable", checkPass, ctx.serverInfo.name, "")
+		d.checkVersion()
+	} else {
+		d.report("Audio server reachable", checkFail, "couldn't connect to PulseAudio or pipewire-pulse",
+			"Make sure PulseAudio, or PipeWire with pipewire-pulse, runs for your user, e.g. 'systemctl --user status pipewire-pulse'.")
+		d.report("Audio server supported", checkSkip, "needs the audio server", "")
+	}
+	d.checkPlugin()
+	d.checkCapability()
+	if !reachable {
+		for _, name := range []string{"No conflicting filters", "Modules loadable", "Filtered microphone appears", "Microphone delivers audio", "Filtered microphone delivers audio"} {
+			d.report(name, checkSkip, "needs the audio server", "")
+		}
+		return d.results
+	}
+	d.checkConflicts()
+	if !d.checkModules() {
+		for _, name := range []string{"Filtered microphone appears", "Microphone delivers audio", "Filtered microphone delivers audio"} {
+			d.report(name, checkSkip, "needs modules to load", "")
+		}
+		return d.results
+	}
+	d.checkFilter()
+	return d.results
+}
+
+func (d *doctor) checkVersion() {
+	s := d.ctx.serverInfo
+	version := fmt.Sprintf("%s %d.%d.%d", s.name, s.major, s.minor, s.patch)
+	if s.outdatedPipeWire {
+		d.report("Audio server supported", checkFail, version,
+			"NoiseTorch-ng needs PipeWire 0.3.28 or newer, please update it.")
+		return
+	}
+	d.report("Audio server supported", checkPass, version, "")
+}
+
+// checkPlugin looks at the plugin we dumped without loading it, we can't dlopen without cgo. The
+// audio server opens it when the filter is loaded, which is checked further down.
+func (d *doctor) checkPlugin() {
+	const name = "Plugin written and loadable"
+	path := d.ctx.librnnoise
+	if err := checkPluginFile(path); err != nil {
+		d.report(name, checkFail, err.Error(),
+			fmt.Sprintf("The plugin is written to %s, make sure it has free space and isn't mounted noexec, or set XDG_RUNTIME_DIR to a directory that is.", filepath.Dir(path)))
+		return
+	}
+	d.report(name, checkPass, path, "")
+}
+
+var elfMachines = map[string]elf.Machine{
+	"amd64": elf.EM_X86_64,
+	"386":   elf.EM_386,
+	"arm64": elf.EM_AARCH64,
+	"arm":   elf.EM_ARM,
+}
+
+func checkPluginFile(path string) error {
+	if path == "" {
+		return fmt.Errorf("the plugin wasn't written")
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return err
+	}
+	// statfs reports the mount flags with the values mount(2) uses
+	if st.Flags&syscall.MS_NOEXEC != 0 {
+		return fmt.Errorf("%s is on a filesystem mounted noexec, the audio server can't load it", path)
+	}
+	f, err := elf.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid library: %w", path, err)
+	}
+	defer f.Close()
+	if m, ok := elfMachines[runtime.GOARCH]; ok && f.Machine != m {
+		return fmt.Errorf("%s is built for %s, but this system is %s", path, f.Machine, runtime.GOARCH)
+	}
+	syms, err := f.DynamicSymbols()
+	if err != nil {
+		return fmt.Errorf("%s has no symbols: %w", path, err)
+	}
+	for _, s := range syms {
+		if s.Name == "ladspa_descriptor" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a LADSPA plugin", path)
+}
+
+// only PulseAudio without RealtimeKit needs CAP_SYS_RESOURCE, to lift its rlimit while loading the filter
+func (d *doctor) checkCapability() {
+	const name = "CAP_SYS_RESOURCE"
+	if d.ctx.serverInfo.servertype == servertype_pulse && rtkitAvailable() {
+		d.report(name, checkPass, "not needed, RealtimeKit keeps PulseAudio running while loading", "")
+		return
+	}
+	if hasCapSysResource(getCurrentCaps()) {
+		d.report(name, checkPass, "granted", "")
+		return
+	}
+	if d.ctx.serverInfo.servertype == servertype_pipewire {
+		d.report(name, checkPass, "not granted, PipeWire doesn't need it", "")
+		return
+	}
+	if sandboxed != "" {
+		d.report(name, checkSkip, fmt.Sprintf("not available in %s", sandboxed),
+			"Filters are loaded through the PulseAudio socket only, if loading fails install NoiseTorch-ng outside the sandbox.")
+		return
+	}
+	if hasCapSysResource(getSelfFileCaps()) {
+		d.report(name, checkFail, "the binary has the capability, but the process doesn't",
+			"The filesystem of the binary is probably mounted nosuid. Click \"Fix permissions\" in the GUI or run: noisetorch fix-permissions")
+		return
+	}
+	d.report(name, checkFail, "not granted, PulseAudio can't load the filter without it",
+		"Click \"Fix permissions\" in the GUI or run: noisetorch fix-permissions")
+}
+
+// checkModules loads a module we don't need anything else for, to tell a broken module loader
+// apart from problems with our filter.
+// checkConflicts looks for other filters on the devices we filter, see conflicts.go.
+func (d *doctor) checkConflicts() {
+	const name = "No conflicting filters"
+	c := d.ctx.config
+	list, err := findConflicts(d.ctx, c.FilterInput, c.FilterOutput)
+	if err != nil {
+		d.report(name, checkSkip, err.Error(), "")
+		return
+	}
+	if len(list) == 0 {
+		d.report(name, checkPass, "", "")
+		return
+	}
+	var texts []string
+	for _, k := range list {
+		text, _ := k.describe(fmt.Sprintf)
+		texts = append(texts, text)
+	}
+	_, hint := list[0].describe(fmt.Sprintf)
+	d.report(name, checkFail, strings.Join(texts, "; "), hint)
+}
+
+func (d *doctor) checkModules() bool {
+	const name = "Modules loadable"
+	ctx, c := d.ctx, d.ctx.paClient
+	idx, err := c.LoadModule("module-null-sink",
+		fmt.Sprintf(`sink_name=nui_doctor_%d sink_properties="device.description='NoiseTorch self-test' %s"`, os.Getpid(), transientTags(ctx)))
+	if err != nil {
+		d.report(name, checkFail, err.Error(),
+			"The audio server refuses to load modules. With PipeWire, make sure pipewire-pulse is installed and running.")
+		return false
+	}
+	if err := c.UnloadModule(idx); err != nil {
+		errorf("Couldn't unload self-test module %d: %v\n", idx, err)
+	}
+	d.report(name, checkPass, "", "")
+	return true
+}
+
+// checkFilter makes sure the filtered microphone shows up and that audio goes through it. If it
+// isn't loaded yet, it's loaded for the last used or the default microphone and unloaded afterwards.
+func (d *doctor) checkFilter() {
+	ctx := d.ctx
+	inp, loaded, err := d.doctorInput()
+	if err != nil {
+		d.report("Filtered microphone appears", checkFail, err.Error(),
+			"Connect a microphone, or pick one in the main window.")
+		d.report("Microphone delivers audio", checkSkip, "no microphone", "")
+		d.report("Filtered microphone delivers audio", checkSkip, "no microphone", "")
+		return
+	}
+
+	if !loaded {
+		if err := loadInputSupressor(ctx, &inp); err != nil {
+			d.report("Filtered microphone appears", checkFail, fmt.Sprintf("loading the filter for %s failed: %v", inp.Name, err),
+				"The audio server's log usually tells why, see 'journalctl --user -u pipewire-pulse' or 'journalctl --user -u pulseaudio'.")
+			d.report("Microphone delivers audio", checkSkip, "filter didn't load", "")
+			d.report("Filtered microphone delivers audio", checkSkip, "filter didn't load", "")
+			return
+		}
+		defer func() {
+			if err := unloadInputSupressor(ctx, &inp); err != nil {
+				errorf("Couldn't unload the self-test filter: %v\n", err)
+			}
+		}()
+	}
+
+	virt, ok := waitVirtualSource(ctx, &inp)
+	if !ok {
+		d.report("Filtered microphone appears", checkFail, fmt.Sprintf("the filter for %s loaded, but no filtered microphone showed up", inp.Name),
+			"Check the log under About > Logs and the audio server's log for errors.")
+		d.report("Microphone delivers audio", checkSkip, "no filtered microphone", "")
+		d.report("Filtered microphone delivers audio", checkSkip, "no filtered microphone", "")
+		return
+	}
+	d.report("Filtered microphone appears", checkPass, virt.Name, "")
+
+	// both at the same time, the filtered one only gets audio while the microphone is recorded from anyway
+	type sample struct {
+		peak int
+		err  error
+	}
+	raw, filtered := make(chan sample, 1), make(chan sample, 1)
+	go func() {
+		peak, err := sampleSource(inp.ID, doctorSampleTime)
+		raw <- sample{peak, err}
+	}()
+	go func() {
+		peak, err := sampleSource(virt.Name, doctorSampleTime)
+		filtered <- sample{peak, err}
+	}()
+
+	r := <-raw
+	switch {
+	case r.err != nil:
+		d.report("Microphone delivers audio", checkFail, r.err.Error(), "Make sure parec is installed, it comes with pulseaudio-utils.")
+	case r.peak == 0:
+		d.report("Microphone delivers audio", checkFail, fmt.Sprintf("%s records only silence", inp.Name),
+			"Check that the microphone isn't muted, in hardware or in the sound settings, and that its volume is up.")
+	default:
+		d.report("Microphone delivers audio", checkPass, fmt.Sprintf("peak %.0f%%", 100*float64(r.peak)/32768), "")
+	}
+
+	f := <-filtered
+	switch {
+	case f.err != nil:
+		d.report("Filtered microphone delivers audio", checkFail, f.err.Error(),
+			"The filter is loaded but doesn't produce audio, check the log under About > Logs.")
+	case f.peak == 0:
+		// the voice activation gate outputs digital silence while nobody speaks
+		d.report("Filtered microphone delivers audio", checkPass, "silent, speak during the test to hear the filter open up", "")
+	default:
+		d.report("Filtered microphone delivers audio", checkPass, fmt.Sprintf("peak %.0f%%", 100*float64(f.peak)/32768), "")
+	}
+}
+
+// doctorInput picks the microphone to test, the filtered one if there is one.
+func (d *doctor) doctorInput() (device, bool, error) {
+	ctx := d.ctx
+	sources := getSources(ctx, ctx.paClient)
+	if chain, err := getRunningChain(ctx); err == nil && len(chain.inputs) > 0 {
+		if inp, ok := findDevice(sources, chain.inputs[0]); ok {
+			if _, ok := findVirtualSource(ctx, &inp); ok {
+				return inp, true, nil
+			}
+		}
+	}
+	if inp, ok := findDevice(sources, ctx.config.LastUsedInput); ok {
+		return inp, false, nil
+	}
+	id, err := getDefaultSourceID(ctx.paClient)
+	if err != nil {
+		return device{}, false, fmt.Errorf("couldn't find the default microphone: %w", err)
+	}
+	if inp, ok := findDevice(sources, id); ok {
+		return inp, false, nil
+	}
+	return device{}, false, fmt.Errorf("no microphone found")
+}
+
+// waitVirtualSource waits for the filtered microphone of a chain that was just loaded to show up.
+func waitVirtualSource(ctx *ntcontext, inp *device) (pulseaudio.Source, bool) {
+	virt, ok := findVirtualSource(ctx, inp)
+	for deadline := time.Now().Add(doctorTimeout); !ok && time.Now().Before(deadline); {
+		time.Sleep(200 * time.Millisecond)
+		virt, ok = findVirtualSource(ctx, inp)
+	}
+	return virt, ok
+}
+
+// sampleSource records d of audio from source and returns the highest absolute sample value.
+func sampleSource(source string, d time.Duration) (int, error) {
+	pcm, err := recordSource(source, "NoiseTorch self-test", d)
+	if err != nil {
+		return 0, err
+	}
+	peak := 0
+	for _, s := range pcm {
+		v := int(s)
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+	}
+	return peak, nil
+}
+
+// recordSource records d of mono audio at filterRate from source.
+func recordSource(source, client string, d time.Duration) ([]int16, error) {
+	cmd := audioCommand("parec", "--raw", "--format=s16le", "--channels=1",
+		fmt.Sprintf("--rate=%d", filterRate), "--client-name="+client, "-d", source)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("couldn't start parec: %w", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+	// a source that never delivers anything would block the read forever
+	timer := time.AfterFunc(d+doctorTimeout, func() { cmd.Process.Kill() })
+	defer timer.Stop()
+
+	data := make([]byte, int(d.Seconds()*filterRate)*2)
+	if _, err := io.ReadFull(bufio.NewReader(stdout), data); err != nil {
+		return nil, fmt.Errorf("no audio from %s within %s", source, d+doctorTimeout)
+	}
+	pcm := make([]int16, len(data)/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(data[2*i:]))
+	}
+	return pcm, nil
+}
+
+// doctorFailed tells whether any check failed.
+func doctorFailed(results []checkResult) bool {
+	for _, r := range results {
+		if r.Status == checkFail {
+			return true
+		}
+	}
+	return false
+}
+
+func printDoctor(results []checkResult) {
+	for _, r := range results {
+		line := fmt.Sprintf("[%s] %s", map[string]string{checkPass: "PASS", checkFail: "FAIL", checkSkip: "SKIP"}[r.Status], r.Name)
+		if r.Detail != "" {
+			line += ": " + r.Detail
+		}
+		fmt.Println(line)
+		if r.Hint != "" {
+			fmt.Printf("       %s\n", r.Hint)
+		}
+	}
+}
+
+func openDoctorView(ctx *ntcontext) {
+	ctx.views.Push(doctorView)
+	go uiRunDoctor(ctx)
+}
+
+func uiRunDoctor(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	ctx.doctor = doctorui{running: true}
+	(*ctx.masterWindow).Changed()
+	results := runDoctor(ctx, func(results []checkResult) {
+		ctx.doctor.results = results
+		(*ctx.masterWindow).Changed()
+	})
+	ctx.doctor = doctorui{results: results}
+	(*ctx.masterWindow).Changed()
+}
+
+func doctorView(ctx *ntcontext, w *nucular.Window) {
+	doc := ctx.doctor
+	w.Row(15).Dynamic(1)
+	if doc.running {
+		w.Label(tr("Checking, please talk into your microphone..."), "LC")
+	} else if doctorFailed(doc.results) {
+		w.LabelColored(tr("Some checks failed, see below for what to do about them."), "LC", red)
+	} else {
+		w.LabelColored(tr("Everything works."), "LC", green)
+	}
+
+	for _, r := range doc.results {
+		w.Row(15).Ratio(0.1, 0.9)
+		switch r.Status {
+		case checkPass:
+			w.LabelColored(tr("OK"), "LC", green)
+		case checkFail:
+			w.LabelColored(tr("FAIL"), "LC", red)
+		default:
+			w.LabelColored(tr("SKIP"), "LC", lightBlue)
+		}
+		text := tr(r.Name)
+		if r.Detail != "" {
+			text += ": " + tr(r.Detail)
+		}
+		w.Label(text, "LC")
+		if r.Hint != "" {
+			w.Row(30).Ratio(0.1, 0.9)
+			w.Spacing(1)
+			w.LabelWrapColored(tr(r.Hint), orange)
+		}
+	}
+
+	w.Row(25).Dynamic(2)
+	if doc.running {
+		w.Spacing(1)
+	} else if focusable(ctx, w, w.ButtonText(tr("Run again"))) {
+		go uiRunDoctor(ctx)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) {
+		ctx.views.Pop()
+	}
+}
@@ -0,0 +1,89 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"noisetorch/buildinfo"
+)
+
+// `noisetorch load --dry-run` prints what loading would do instead of doing it, as commands to run
+// by hand: pactl for every module, or the config and the command of the native PipeWire filter-chain.
+// For setups we don't support, e.g. containers or embedded distributions, and for debugging routing.
+
+// shellQuote quotes s for sh. pactl joins its arguments with spaces, so one quoted argument holding
+// all module arguments is the same as the module arguments.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func printPactl(w io.Writer, mods []chainModule) {
+	for _, m := range mods {
+		fmt.Fprintf(w, "# %s\npactl load-module %s %s\n", m.what, m.name, shellQuote(m.args))
+	}
+}
+
+// dryRun prints the commands loading the filter for inp and out would run.
+func dryRun(ctx *ntcontext, inp, out *device) error {
+	w := os.Stdout
+	fmt.Fprintf(w, "# NoiseTorch-ng %s on %s %d.%d.%d\n", buildinfo.Version, ctx.serverInfo.name,
+		ctx.serverInfo.major, ctx.serverInfo.minor, ctx.serverInfo.patch)
+	native := ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire && inp.checked
+	if !native || out.checked {
+		fmt.Fprintf(w, "# plugin=%s is a copy of the LADSPA plugin removed when another version of\n"+
+			"# NoiseTorch-ng starts, point it at a copy of your own.\n", ctx.librnnoise)
+	}
+
+	if inp.checked {
+		switch {
+		case native:
+			plugin, err := filterChainPlugin(ctx)
+			if err != nil {
+				return err
+			}
+			conf, err := pipeWireNativeConfig(ctx, plugin, inp)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "# the filter-chain expects its own copy of the LADSPA plugin at %s\n", plugin)
+			fmt.Fprintf(w, "cat > filter-chain.conf <<'EOF'\n%s\nEOF\npipewire -c filter-chain.conf &\n", strings.TrimSpace(conf))
+		case ctx.serverInfo.servertype == servertype_pipewire:
+			printPactl(w, []chainModule{pipeWireInputModule(ctx, inp)})
+		default:
+			fmt.Fprintf(w, "# while loading, NoiseTorch-ng also lifts the realtime limit of pulseaudio:\n"+
+				"# prlimit --rttime=unlimited --pid $(pidof pulseaudio)\n")
+			mods, err := pulseInputModules(ctx, inp, false)
+			if err != nil {
+				return err
+			}
+			printPactl(w, mods)
+			if ctx.config.KeepAwake {
+				printPactl(w, pulseKeepAwakeModules(ctx, inp))
+			}
+		}
+		if ctx.config.OutputGain != 0 {
+			source := inputChainFor(inp).remap
+			switch {
+			case native:
+				source = pipeWireNodeName
+			case ctx.serverInfo.servertype == servertype_pipewire:
+				source = pipeWireInputSource(inp)
+			}
+			fmt.Fprintf(w, "# output gain\npactl set-source-volume %s %ddB\n", shellQuote(source), ctx.config.OutputGain)
+		}
+	}
+
+	if out.checked {
+		if ctx.serverInfo.servertype == servertype_pipewire {
+			printPactl(w, []chainModule{pipeWireOutputModule(ctx, out)})
+		} else {
+			printPactl(w, pulseOutputModules(ctx, out, false))
+		}
+	}
+	return nil
+}
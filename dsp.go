@@ -0,0 +1,399 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Some servers can't load module-ladspa-sink at all. With InProcessDSP we run RNNoise ourselves
+// instead: parec records the microphone, we filter it and pacat plays it into the denoised null
+// sink, whose monitor is remapped into the filtered microphone like in the pulseaudio chain. For the
+// headphones it's the other way around, from the Filtered Headphones null sink to the real ones.
+// The filter only runs as long as we do, so its modules carry our pid (see stale.go) and are
+// unloaded when we exit. It needs a build with -tags inprocess_dsp, see dsp_rnnoise.go.
+
+const (
+	dspFrame       = 480 // samples RNNoise takes at once, 10ms at filterRate
+	dspGracePeriod = 20  // frames the gate stays open after speech, see VAD_GRACE_PERIOD in module.c
+	dspOutputSink  = "nui_out_in_sink"
+)
+
+// dspStream filters from a source into a sink.
+type dspStream struct {
+	rec, play *exec.Cmd
+	done      chan struct{}
+	vad       uint32 // float32 bits of the last voice probability, accessed atomically
+	gateOpen  uint32 // accessed atomically
+	updated   int64  // unix ms of the last frame, accessed atomically
+	threshold uint32 // float32 bits, accessed atomically so it can change while running
+	wet       uint32 // float32 bits of the share of filtered signal, accessed atomically
+}
+
+var (
+	dspMu      sync.Mutex
+	dspStreams = make(map[string]*dspStream) // by the null sink of their chain
+)
+
+// inProcessMode reports whether the filter runs in-process.
+func inProcessMode(ctx *ntcontext) bool {
+	return inProcessSupported && ctx.config.InProcessDSP && !jackMode(ctx)
+}
+
+func dspStreamArgs(ctx *ntcontext, device string) []string {
+	return []string{"--raw", "--format=float32le", "--channels=1", fmt.Sprintf("--rate=%d", filterRate),
+		"--client-name=NoiseTorch filter", fmt.Sprintf("--property=%s=%s", tagID, ctx.chainID), "-d", device}
+}
+
+// startDSPStream filters from source into sink, as the stream of the chain with the null sink chain.
+func startDSPStream(ctx *ntcontext, chain, source, sink string) error {
+	stopDSPStream(chain)
+	c := ctx.config
+	st, err := newRNNoise(activeModel(ctx))
+	if err != nil {
+		return err
+	}
+	chainTags(ctx) // makes sure there's a chain id
+	s := &dspStream{
+		rec:  audioCommand("parec", append([]string{"--latency-msec=10"}, dspStreamArgs(ctx, source)...)...),
+		play: audioCommand("pacat", append([]string{"--playback", fmt.Sprintf("--latency-msec=%d", c.BufferLatency)}, dspStreamArgs(ctx, sink)...)...),
+		done: make(chan struct{}),
+	}
+	s.setControls(c.Threshold, c.Suppression)
+	r, err := s.rec.StdoutPipe()
+	if err != nil {
+		st.free()
+		return err
+	}
+	w, err := s.play.StdinPipe()
+	if err != nil {
+		st.free()
+		return err
+	}
+	debugf("Calling: %s\n", s.rec.String())
+	if err := s.rec.Start(); err != nil {
+		st.free()
+		return fmt.Errorf("couldn't start parec: %w", err)
+	}
+	debugf("Calling: %s\n", s.play.String())
+	if err := s.play.Start(); err != nil {
+		s.rec.Process.Kill()
+		s.rec.Wait()
+		st.free()
+		return fmt.Errorf("couldn't start pacat: %w", err)
+	}
+
+	dspMu.Lock()
+	dspStreams[chain] = s
+	dspMu.Unlock()
+	go s.run(st, r, w)
+	return nil
+}
+
+func (s *dspStream) setControls(threshold, suppression int) {
+	atomic.StoreUint32(&s.threshold, math.Float32bits(float32(threshold)/100))
+	atomic.StoreUint32(&s.wet, math.Float32bits(float32(suppression)/100))
+}
+
+// setDSPControls changes the threshold and suppression of the running streams. It reports whether
+// there were any.
+func setDSPControls(threshold, suppression int) bool {
+	dspMu.Lock()
+	defer dspMu.Unlock()
+	for _, s := range dspStreams {
+		s.setControls(threshold, suppression)
+	}
+	return len(dspStreams) > 0
+}
+
+// run filters until either side goes away, like runFilter in module.c does.
+func (s *dspStream) run(st *rnnoiseState, r io.Reader, w io.WriteCloser) {
+	defer close(s.done)
+	defer st.free()
+	buf := make([]byte, dspFrame*4)
+	in := make([]float32, dspFrame)
+	out := make([]float32, dspFrame)
+	prev := make([]float32, dspFrame) // RNNoise returns the frame before, the raw one is mixed in as late
+	grace := dspGracePeriod
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			break
+		}
+		for i := range in {
+			in[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:])) * 32767
+		}
+		prob := st.process(out, in)
+		threshold := math.Float32frombits(atomic.LoadUint32(&s.threshold))
+		wet := math.Float32frombits(atomic.LoadUint32(&s.wet))
+		if prob > threshold {
+			grace = dspGracePeriod
+		}
+		open := grace >= 0
+		if open {
+			grace--
+		}
+		for i := range out {
+			// a closed gate mutes the raw signal too, not only the denoised one
+			var v float32
+			if open {
+				v = (wet*out[i] + (1-wet)*prev[i]) / 32767
+			}
+			binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+		}
+		copy(prev, in)
+		s.publish(prob, grace >= 0)
+		if _, err := w.Write(buf); err != nil {
+			break
+		}
+	}
+	w.Close()
+	s.rec.Process.Kill()
+	s.play.Process.Kill()
+	s.rec.Wait()
+	s.play.Wait()
+}
+
+func (s *dspStream) publish(prob float32, gateOpen bool) {
+	atomic.StoreUint32(&s.vad, math.Float32bits(prob))
+	var open uint32
+	if gateOpen {
+		open = 1
+	}
+	atomic.StoreUint32(&s.gateOpen, open)
+	atomic.StoreInt64(&s.updated, time.Now().UnixNano()/int64(time.Millisecond))
+}
+
+func stopDSPStream(chain string) {
+	dspMu.Lock()
+	s, ok := dspStreams[chain]
+	delete(dspStreams, chain)
+	dspMu.Unlock()
+	if !ok {
+		return
+	}
+	debugf("Stopping the in-process filter of %s\n", chain)
+	s.rec.Process.Kill()
+	s.play.Process.Kill()
+	<-s.done
+}
+
+func stopDSPStreams() {
+	dspMu.Lock()
+	var chains []string
+	for chain := range dspStreams {
+		chains = append(chains, chain)
+	}
+	dspMu.Unlock()
+	for _, chain := range chains {
+		stopDSPStream(chain)
+	}
+}
+
+func dspStreamRunning(chain string) bool {
+	dspMu.Lock()
+	s, ok := dspStreams[chain]
+	dspMu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// inProcessVADStatus returns the voice activity of the in-process filters, like readVADStatus.
+func inProcessVADStatus() (status vadStatus, ok bool) {
+	dspMu.Lock()
+	defer dspMu.Unlock()
+	for _, s := range dspStreams {
+		updated := time.Unix(0, atomic.LoadInt64(&s.updated)*int64(time.Millisecond))
+		if time.Since(updated) > vadStaleAfter {
+			continue
+		}
+		ok = true
+		if prob := math.Float32frombits(atomic.LoadUint32(&s.vad)); prob > status.prob {
+			status.prob = prob
+		}
+		status.gateOpen = status.gateOpen || atomic.LoadUint32(&s.gateOpen) != 0
+	}
+	return status, ok
+}
+
+func loadInProcess(ctx *ntcontext, inp, out *device) error {
+	if !currentEngine(ctx).rnnoise {
+		return fmt.Errorf("the in-process filter only runs RNNoise")
+	}
+	if inp.checked {
+		if err := loadInProcessInput(ctx, inp); err != nil {
+			errorf("Error loading input: %v\n", err)
+			return err
+		}
+	}
+	if out.checked {
+		if err := loadInProcessOutput(ctx, out); err != nil {
+			errorf("Error loading output: %v\n", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func loadInProcessInput(ctx *ntcontext, inp *device) error {
+	infof("Loading in-process supressor for %s\n", inp.ID)
+	names := inputChainFor(inp)
+	err := loadChainModules(ctx, []chainModule{
+		{name: "module-null-sink", what: "null sink",
+			args: fmt.Sprintf(`sink_name=%s rate=%d channels=1 sink_properties="%s"`, names.denoised, filterRate, transientTags(ctx))},
+		{name: "module-remap-source", what: "remap source",
+			args: fmt.Sprintf(`master=%s.monitor `+
+				`source_name=%s source_properties="device.description='Filtered Microphone for %s' %s %s"`,
+				names.denoised, names.remap, inp.Name, devicePresence("microphone"), transientTags(ctx))},
+	})
+	if err != nil {
+		return err
+	}
+	return startDSPStream(ctx, names.denoised, inp.ID, names.denoised)
+}
+
+func loadInProcessOutput(ctx *ntcontext, out *device) error {
+	infof("Loading in-process supressor for %s\n", out.ID)
+	err := loadChainModules(ctx, []chainModule{
+		{name: "module-null-sink", what: "filtered headphones null sink",
+			args: fmt.Sprintf(`sink_name=%s rate=%d channels=1 sink_properties="device.description='Filtered Headphones' %s %s"`,
+				dspOutputSink, filterRate, devicePresence("headphone"), transientTags(ctx))},
+	})
+	if err != nil {
+		return err
+	}
+	// pacat plays into the device directly, bypassing the routing to the null sink
+	return startDSPStream(ctx, dspOutputSink, dspOutputSink+".monitor", out.ID)
+}
+
+// inProcessState is supressorState for the in-process filter.
+func inProcessState(ctx *ntcontext) (int, bool) {
+	inpLoaded, outLoaded := true, true
+	var inc, inUse bool
+	if ctx.config.FilterInput {
+		inps := inputSelections(ctx)
+		nLoaded := 0
+		for i := range inps {
+			loaded, partly, used := inProcessInputState(ctx, &inps[i])
+			if loaded {
+				nLoaded++
+			}
+			inc = inc || partly
+			inUse = inUse || used
+		}
+		if len(inps) > 0 {
+			inpLoaded = nLoaded == len(inps)
+			inc = inc || (nLoaded > 0 && !inpLoaded)
+		} else {
+			// nothing selected, e.g. when running headless
+			inpLoaded = anyInProcessInputRunning()
+		}
+	}
+	if ctx.config.FilterOutput {
+		loaded, partly, used := inProcessOutputState(ctx)
+		outLoaded = loaded
+		inc = inc || partly
+		inUse = inUse || used
+	}
+
+	switch {
+	case inpLoaded && outLoaded && !inc:
+		return loaded, inUse
+	case (inpLoaded && ctx.config.FilterInput) || (outLoaded && ctx.config.FilterOutput) || inc:
+		return inconsistent, inUse
+	}
+	return unloaded, inUse
+}
+
+func anyInProcessInputRunning() bool {
+	dspMu.Lock()
+	var chains []string
+	for chain := range dspStreams {
+		if chain != dspOutputSink {
+			chains = append(chains, chain)
+		}
+	}
+	dspMu.Unlock()
+	for _, chain := range chains {
+		if dspStreamRunning(chain) {
+			return true
+		}
+	}
+	return false
+}
+
+// inProcessInputState is inputChainState for the in-process filter.
+func inProcessInputState(ctx *ntcontext, inp *device) (bool, bool, bool) {
+	names := inputChainFor(inp)
+	_, nullsink, err := findChainModule(ctx, "module-null-sink", "sink_name="+names.denoised+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-null-sink: %v\n", err)
+	}
+	module, remap, err := findChainModule(ctx, "module-remap-source", "master="+names.denoised+".monitor source_name="+names.remap+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-remap-source: %v\n", err)
+	}
+	running := dspStreamRunning(names.denoised)
+	loaded := nullsink && remap && running
+	return loaded, !loaded && (nullsink || remap || running), module.NUsed != 0
+}
+
+// inProcessOutputState reports whether the in-process headphones filter is loaded, partially
+// loaded and in use.
+func inProcessOutputState(ctx *ntcontext) (bool, bool, bool) {
+	module, nullsink, err := findChainModule(ctx, "module-null-sink", "sink_name="+dspOutputSink+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-null-sink: %v\n", err)
+	}
+	running := dspStreamRunning(dspOutputSink)
+	return nullsink && running, nullsink != running, module.NUsed != 0
+}
+
+func unloadInProcessInput(ctx *ntcontext, inp *device) error {
+	names := inputChainFor(inp)
+	stopDSPStream(names.denoised)
+	for _, mod := range []struct{ name, match string }{
+		{"module-remap-source", "source_name=" + names.remap + " "},
+		{"module-null-sink", "sink_name=" + names.denoised + " "},
+	} {
+		m, found, err := findChainModule(ctx, mod.name, mod.match)
+		if err != nil {
+			return err
+		}
+		if found {
+			debugf("Found %s at id [%d], sending unload command\n", mod.name, m.Index)
+			ctx.paClient.UnloadModule(m.Index)
+		}
+	}
+	return nil
+}
+
+func inProcessSettingsView(ctx *ntcontext, w *nucular.Window) {
+	if !inProcessSupported {
+		return
+	}
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Filter in NoiseTorch instead of the audio server"), &ctx.config.InProcessDSP)) {
+		go writeConfig(ctx.config)
+		ctx.reloadRequired = ctx.noiseSupressorState == loaded
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("For audio servers that can't load LADSPA plugins. Only runs RNNoise, and only while NoiseTorch-ng runs."))
+	}
+}
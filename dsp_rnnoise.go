@@ -0,0 +1,65 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+//go:build inprocess_dsp
+// +build inprocess_dsp
+
+package main
+
+// The in-process engine links RNNoise itself, built by "make -C c/ladspa static". It's not part of
+// the release builds, which are static and built without cgo.
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/c/rnnoise/include
+#cgo LDFLAGS: ${SRCDIR}/c/ladspa/librnnoise.a -lm
+#include <stdio.h>
+#include <stdlib.h>
+#include "rnnoise.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+const inProcessSupported = true
+
+type rnnoiseState struct {
+	st    *C.DenoiseState
+	model *C.RNNModel
+}
+
+// newRNNoise creates a denoiser using the model file at model, or the built-in model if it's empty.
+func newRNNoise(model string) (*rnnoiseState, error) {
+	r := &rnnoiseState{}
+	if model != "" {
+		path := C.CString(model)
+		mode := C.CString("r")
+		f := C.fopen(path, mode)
+		C.free(unsafe.Pointer(path))
+		C.free(unsafe.Pointer(mode))
+		if f == nil {
+			return nil, fmt.Errorf("couldn't open model %s", model)
+		}
+		r.model = C.rnnoise_model_from_file(f)
+		C.fclose(f)
+		if r.model == nil {
+			return nil, fmt.Errorf("couldn't load model %s", model)
+		}
+	}
+	r.st = C.rnnoise_create(r.model)
+	return r, nil
+}
+
+// process denoises one frame of dspFrame samples scaled to 16 bit and returns the voice probability.
+func (r *rnnoiseState) process(out, in []float32) float32 {
+	return float32(C.rnnoise_process_frame(r.st, (*C.float)(&out[0]), (*C.float)(&in[0])))
+}
+
+func (r *rnnoiseState) free() {
+	C.rnnoise_destroy(r.st)
+	if r.model != nil {
+		C.rnnoise_model_free(r.model)
+	}
+}
@@ -0,0 +1,24 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+//go:build !inprocess_dsp
+// +build !inprocess_dsp
+
+package main
+
+import "fmt"
+
+// Without -tags inprocess_dsp there's no in-process engine, the option isn't offered.
+const inProcessSupported = false
+
+type rnnoiseState struct{}
+
+func newRNNoise(model string) (*rnnoiseState, error) {
+	return nil, fmt.Errorf("built without the in-process engine")
+}
+
+func (r *rnnoiseState) process(out, in []float32) float32 {
+	return 0
+}
+
+func (r *rnnoiseState) free() {}
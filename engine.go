@@ -0,0 +1,210 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// engine is a LADSPA plugin that can do the noise suppression.
+type engine struct {
+	id      string // as stored in the config
+	name    string
+	label   string                          // LADSPA label of the plugin
+	lib     func(c *config) ([]byte, error) // the plugin, either embedded or found on the system
+	control engineControl                   // the control port we expose
+	rnnoise bool                            // supports the RNNoise-only features, like VAD status and model files
+}
+
+// engineControl is the single input control port of an engine. Its value lives in the config.
+type engineControl struct {
+	port    string // as named by the plugin
+	name    string
+	tooltip string
+	min     int
+	max     int
+	format  string
+	value   func(c *config) *int
+}
+
+// There's no Speex engine, speexdsp's noise suppression isn't available as a LADSPA plugin the
+// chains could load.
+var engines = []engine{
+	{
+		id:    "rnnoise",
+		name:  "RNNoise",
+		label: "nt-filter",
+		lib:   rnnoiseLib,
+		control: engineControl{
+			port:    "VAD %%",
+			name:    "Voice Activation Threshold",
+			tooltip: "If you have a decent microphone, you can usually turn this all the way up.",
+			min:     0,
+			max:     95,
+			format:  "%d%%",
+			value:   func(c *config) *int { return &c.Threshold },
+		},
+		rnnoise: true,
+	},
+	{
+		id:    "deepfilternet",
+		name:  "DeepFilterNet",
+		label: "deep_filter_mono",
+		lib:   findDeepFilterNet,
+		control: engineControl{
+			port:    "Attenuation Limit (dB)",
+			name:    "Attenuation Limit",
+			tooltip: "How much noise may be removed at most. Lower values sound more natural, 100 removes as much as possible.",
+			min:     0,
+			max:     100,
+			format:  "%ddB",
+			value:   func(c *config) *int { return &c.AttenuationLimit },
+		},
+	},
+}
+
+func engineByID(id string) engine {
+	for _, e := range engines {
+		if e.id == id {
+			return e
+		}
+	}
+	return engines[0]
+}
+
+func currentEngine(ctx *ntcontext) engine {
+	return engineByID(ctx.config.Engine)
+}
+
+// ladspaDirs returns where LADSPA plugins are installed.
+func ladspaDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("LADSPA_PATH")) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, "/usr/lib/ladspa", "/usr/lib64/ladspa", "/usr/local/lib/ladspa",
+		"/usr/lib/x86_64-linux-gnu/ladspa", "/usr/lib/aarch64-linux-gnu/ladspa",
+		"/usr/lib/arm-linux-gnueabihf/ladspa")
+}
+
+// DeepFilterNet isn't shipped with NoiseTorch, it has to be installed from
+// https://github.com/Rikorose/DeepFilterNet
+func findDeepFilterNet(c *config) ([]byte, error) {
+	const name = "libdeep_filter_ladspa.so"
+	for _, dir := range ladspaDirs() {
+		if data, err := ioutil.ReadFile(filepath.Join(dir, name)); err == nil {
+			debugf("Using %s from %s\n", name, dir)
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("%s not found, install DeepFilterNet's LADSPA plugin or set LADSPA_PATH", name)
+}
+
+// suppressionPort mixes the raw signal back in, in our RNNoise plugin only. DeepFilterNet's
+// attenuation limit does the same.
+const suppressionPort = "Suppression (%)"
+
+// ladspaArgs returns the module arguments selecting the engine's plugin and its control values.
+func ladspaArgs(ctx *ntcontext) string {
+	e := currentEngine(ctx)
+	control := strconv.Itoa(*e.control.value(ctx.config))
+	if e.rnnoise {
+		control += "," + strconv.Itoa(ctx.config.Suppression)
+	}
+	return fmt.Sprintf("label=%s plugin=%s control=%s", e.label, ctx.librnnoise, control)
+}
+
+// switchEngine switches to the plugin of the newly selected engine.
+func switchEngine(ctx *ntcontext, id string) error {
+	lib, err := dumpLib(engineByID(id), ctx.config)
+	if err != nil {
+		return err
+	}
+	ctx.librnnoise = lib
+	ctx.config.Engine = id
+	return nil
+}
+
+func engineView(ctx *ntcontext, w *nucular.Window) {
+	names := make([]string, len(engines))
+	selected := 0
+	for i, e := range engines {
+		names[i] = e.name
+		if e.id == currentEngine(ctx).id {
+			selected = i
+		}
+	}
+	w.Row(25).Ratio(0.5, 0.5)
+	w.Label(tr("Engine"), "LC")
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected {
+		if err := switchEngine(ctx, engines[sel].id); err != nil {
+			errorf("Couldn't switch engine: %v\n", err)
+			ctx.views.Push(makeErrorView(ctx, err.Error()))
+			return
+		}
+		ctx.reloadRequired = true
+		go writeConfig(ctx.config)
+	}
+	if ctx.noiseSupressorState == loaded && !swapsInPlace(ctx) {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(tr("Switching the engine removes the filtered devices for a moment."), "LC", orange)
+	}
+
+	c := currentEngine(ctx).control
+	defaults := defaultConfig()
+	w.Row(25).Ratio(0.4, 0.38, 0.08, 0.14)
+	w.Label(tr(c.name), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr(c.tooltip))
+	}
+	v := c.value(ctx.config)
+	changed := focusableSlider(ctx, w, w.SliderInt(c.min, v, c.max, 1), v, c.min, c.max, 1)
+	w.Label(trf(c.format, *v), "RC")
+	if focusable(ctx, w, w.ButtonText(tr("Default"))) && *v != *c.value(&defaults) {
+		*v = *c.value(&defaults)
+		changed = true
+	}
+	if changed {
+		go writeConfig(ctx.config)
+		requestLiveControls(ctx)
+	}
+	effectiveValueView(ctx, w, ctx.chain.threshold, *v, c.format)
+
+	if !currentEngine(ctx).rnnoise {
+		return
+	}
+	w.Row(25).Ratio(0.4, 0.38, 0.08, 0.14)
+	w.Label(tr("Suppression"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("How much noise is removed. If your voice sounds robotic, lower it to mix some of the unfiltered microphone back in."))
+	}
+	changed = focusableSlider(ctx, w, w.SliderInt(0, &ctx.config.Suppression, 100, 5), &ctx.config.Suppression, 0, 100, 5)
+	w.Label(fmt.Sprintf("%d%%", ctx.config.Suppression), "RC")
+	if focusable(ctx, w, w.ButtonText(tr("Default"))) && ctx.config.Suppression != defaults.Suppression {
+		ctx.config.Suppression = defaults.Suppression
+		changed = true
+	}
+	if changed {
+		go writeConfig(ctx.config)
+		requestLiveControls(ctx)
+	}
+	effectiveValueView(ctx, w, ctx.chain.suppression, ctx.config.Suppression, "%d%%")
+}
+
+func engineIDs() string {
+	ids := make([]string, len(engines))
+	for i, e := range engines {
+		ids[i] = e.id
+	}
+	return strings.Join(ids, ", ")
+}
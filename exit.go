@@ -0,0 +1,107 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"github.com/aarzilli/nucular"
+)
+
+// The filters are modules of the audio server, so they stay loaded when the window closes: the
+// filtered microphone keeps working for the applications using it. Many don't expect that, so with
+// UnloadOnExit closing the window unloads them. Quit in the menu asks which one to do while filters
+// are loaded, the window manager's close button can't be intercepted and follows UnloadOnExit.
+
+type exitui struct {
+	handled      bool // the filters were already taken care of, e.g. another instance took over
+	unload       bool // what the quit dialog does
+	dontAskAgain bool
+}
+
+// quit closes the window, asking first what to do with loaded filters if ConfirmQuit.
+func quit(ctx *ntcontext) {
+	if ctx.noiseSupressorState == unloaded || !ctx.config.ConfirmQuit || inProcessMode(ctx) {
+		(*ctx.masterWindow).Close()
+		return
+	}
+	ctx.exit.unload, ctx.exit.dontAskAgain = ctx.config.UnloadOnExit, false
+	ctx.views.Push(quitView)
+}
+
+func quitView(ctx *ntcontext, w *nucular.Window) {
+	e := &ctx.exit
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Quit"), "CB")
+	w.Row(30).Dynamic(1)
+	w.LabelWrap(tr("The filters are loaded. Applications can keep using the filtered devices after NoiseTorch-ng quits, until you unload them or log out."))
+
+	w.Row(20).Dynamic(1)
+	if focusable(ctx, w, w.OptionText(tr("Keep filtering"), !e.unload)) {
+		e.unload = false
+	}
+	w.Row(20).Dynamic(1)
+	if focusable(ctx, w, w.OptionText(tr("Unload the filters"), e.unload)) {
+		e.unload = true
+	}
+	w.Row(20).Dynamic(1)
+	focusable(ctx, w, w.CheckboxText(tr("Don't ask again"), &e.dontAskAgain))
+
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Cancel"))) {
+		ctx.views.Pop()
+		return
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Quit"))) {
+		ctx.views.Pop()
+		if e.dontAskAgain {
+			ctx.config.ConfirmQuit = false
+			ctx.config.UnloadOnExit = e.unload
+			writeConfig(ctx.config)
+		}
+		if e.unload {
+			go func() {
+				uiUnloadFilters(ctx)
+				e.handled = true
+				(*ctx.masterWindow).Close()
+			}()
+			return
+		}
+		e.handled = true
+		(*ctx.masterWindow).Close()
+	}
+}
+
+// exitGUI applies UnloadOnExit once the window is closed. The in-process filter stops with us
+// anyway, so its devices are always unloaded. Chains that stay loaded get back the filter stages
+// they run without while idle.
+func exitGUI(ctx *ntcontext) {
+	if ctx.exit.handled || !(ctx.config.UnloadOnExit || inProcessMode(ctx)) {
+		if connected(ctx) {
+			wakeIdleChains(ctx)
+		}
+		return
+	}
+	if state, _ := supressorState(ctx); state == unloaded {
+		return
+	}
+	infof("Unloading the filters on exit\n")
+	endBypass(ctx)
+	restoreRouting(ctx, nil)
+	if err := turnOffSupressor(ctx); err != nil {
+		errorf("Couldn't unload the filters on exit: %v\n", err)
+	}
+}
+
+func exitSettingsView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Unload the filters when closing the window"), &ctx.config.UnloadOnExit)) {
+		go writeConfig(ctx.config)
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Otherwise they stay loaded and applications keep using the filtered devices after NoiseTorch-ng quits."))
+	}
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Ask what to do with loaded filters on Quit"), &ctx.config.ConfirmQuit)) {
+		go writeConfig(ctx.config)
+	}
+}
@@ -0,0 +1,293 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/noisetorch/pulseaudio"
+)
+
+// fakeServer is an AudioServer in memory. It starts with a microphone and speakers, and loading
+// the modules of our chains creates and removes their devices like the real server does: sinks and
+// sources need their master, and go away with it, together with the modules using them. Nothing
+// plays or records, and what we run pactl or pw-cli for fails, as there's no server behind them.
+
+type fakeServer struct {
+	mu        sync.Mutex
+	pipewire  bool
+	connected bool
+	next      uint32 // index of the next module or device
+	modules   []pulseaudio.Module
+	sources   []pulseaudio.Source
+	sinks     []pulseaudio.Sink
+	info      pulseaudio.Server
+	updates   chan struct{}
+}
+
+func newFakeServer(pipewire bool) *fakeServer {
+	s := &fakeServer{pipewire: pipewire, connected: true, next: 1, updates: make(chan struct{}, 1)}
+	s.info = pulseaudio.Server{PackageName: "pulseaudio", PackageVersion: "16.1", User: "fake", Hostname: "fake"}
+	if pipewire {
+		s.info.PackageName, s.info.PackageVersion = "pulseaudio (on PipeWire 1.0.5)", "15.0.0"
+	}
+	s.addSource("alsa_input.fake-microphone", "Fake Microphone", noIndex, 1, nil)
+	s.addSink("alsa_output.fake-speakers", "Fake Speakers", noIndex, 2, nil)
+	s.info.DefaultSource, s.info.DefaultSink = "alsa_input.fake-microphone", "alsa_output.fake-speakers"
+	return s
+}
+
+func (s *fakeServer) index() uint32 {
+	i := s.next
+	s.next++
+	return i
+}
+
+// props copies the properties of a device, on PipeWire every device is a node with an id.
+func (s *fakeServer) props(index uint32, description string, props map[string]string) map[string]string {
+	res := map[string]string{"device.description": description}
+	for k, v := range props {
+		res[k] = v
+	}
+	if s.pipewire {
+		res["object.id"] = strconv.Itoa(int(index))
+	}
+	return res
+}
+
+func (s *fakeServer) addSource(name, description string, module uint32, channels int, props map[string]string) {
+	index := s.index()
+	src := pulseaudio.Source{
+		Index:              index,
+		Name:               name,
+		Description:        description,
+		ModuleIndex:        module,
+		MonitorSourceIndex: noIndex,
+		Flags:              0x0040, // PA_SOURCE_DYNAMIC_LATENCY
+		PropList:           s.props(index, description, props),
+	}
+	src.SampleSpec.Channels, src.SampleSpec.Rate = byte(channels), filterRate
+	s.sources = append(s.sources, src)
+}
+
+// addSink adds the sink and its monitor source.
+func (s *fakeServer) addSink(name, description string, module uint32, channels int, props map[string]string) {
+	index := s.index()
+	sink := pulseaudio.Sink{
+		Index:       index,
+		Name:        name,
+		Description: description,
+		ModuleIndex: module,
+		Flags:       0x0080, // PA_SINK_DYNAMIC_LATENCY
+		PropList:    s.props(index, description, props),
+	}
+	sink.SampleSpec.Channels, sink.SampleSpec.Rate = byte(channels), filterRate
+
+	monitorIndex := s.index()
+	monitor := pulseaudio.Source{
+		Index:              monitorIndex,
+		Name:               name + ".monitor",
+		Description:        "Monitor of " + description,
+		ModuleIndex:        module,
+		MonitorSourceIndex: sink.Index,
+		MonitorSourceName:  name,
+		PropList:           s.props(monitorIndex, "Monitor of "+description, nil),
+	}
+	monitor.SampleSpec = sink.SampleSpec
+	sink.MonitorSourceIndex, sink.MonitorSourceName = monitor.Index, monitor.Name
+	s.sinks = append(s.sinks, sink)
+	s.sources = append(s.sources, monitor)
+}
+
+func (s *fakeServer) hasSource(name string) bool {
+	for _, src := range s.sources {
+		if src.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeServer) hasSink(name string) bool {
+	for _, sink := range s.sinks {
+		if sink.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeServer) changed() {
+	select {
+	case s.updates <- struct{}{}:
+	default:
+	}
+}
+
+func (s *fakeServer) ServerInfo() (*pulseaudio.Server, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return nil, fmt.Errorf("connection closed")
+	}
+	info := s.info
+	return &info, nil
+}
+
+func (s *fakeServer) Sources() ([]pulseaudio.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return nil, fmt.Errorf("connection closed")
+	}
+	return append([]pulseaudio.Source{}, s.sources...), nil
+}
+
+func (s *fakeServer) Sinks() ([]pulseaudio.Sink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return nil, fmt.Errorf("connection closed")
+	}
+	return append([]pulseaudio.Sink{}, s.sinks...), nil
+}
+
+func (s *fakeServer) ModuleList() ([]pulseaudio.Module, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return nil, fmt.Errorf("connection closed")
+	}
+	return append([]pulseaudio.Module{}, s.modules...), nil
+}
+
+// LoadModule creates the devices of the modules we load, checking the arguments they refer to.
+func (s *fakeServer) LoadModule(name, argument string) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return 0, fmt.Errorf("connection closed")
+	}
+	if _, err := parseModuleArgs(argument); err != nil {
+		return 0, fmt.Errorf("%s: %v", name, err)
+	}
+	args := moduleArgMap(argument)
+	channels := 2
+	if c, err := strconv.Atoi(args["channels"]); err == nil {
+		channels = c
+	}
+	fail := func(format string, a ...interface{}) (uint32, error) {
+		return 0, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, a...))
+	}
+
+	switch name {
+	case "module-null-sink", "module-ladspa-sink":
+		sink := args["sink_name"]
+		if master, ok := args["sink_master"]; ok && !s.hasSink(master) {
+			return fail("no sink %s", master)
+		}
+		if sink == "" || s.hasSink(sink) {
+			return fail("invalid sink_name '%s'", sink)
+		}
+	case "module-remap-source", "module-ladspa-source":
+		if !s.hasSource(args["master"]) {
+			return fail("no source %s", args["master"])
+		}
+		if src := args["source_name"]; src == "" || s.hasSource(src) {
+			return fail("invalid source_name '%s'", src)
+		}
+	case "module-loopback":
+		if src, ok := args["source"]; ok && !s.hasSource(src) {
+			return fail("no source %s", src)
+		}
+		if sink, ok := args["sink"]; ok && !s.hasSink(sink) {
+			return fail("no sink %s", sink)
+		}
+	}
+
+	m := pulseaudio.Module{Index: s.index(), Name: name, Argument: argument, PropList: map[string]string{}}
+	s.modules = append(s.modules, m)
+	switch name {
+	case "module-null-sink", "module-ladspa-sink":
+		props := moduleArgMap(args["sink_properties"])
+		s.addSink(args["sink_name"], props["device.description"], m.Index, channels, props)
+	case "module-remap-source", "module-ladspa-source":
+		props := moduleArgMap(args["source_properties"])
+		s.addSource(args["source_name"], props["device.description"], m.Index, channels, props)
+	}
+	s.changed()
+	return m.Index, nil
+}
+
+// UnloadModule removes the module and its devices, and then the modules using them.
+func (s *fakeServer) UnloadModule(index uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return fmt.Errorf("connection closed")
+	}
+	if !s.unload(index) {
+		return fmt.Errorf("no module %d", index)
+	}
+	s.changed()
+	return nil
+}
+
+func (s *fakeServer) unload(index uint32) bool {
+	found := false
+	for i, m := range s.modules {
+		if m.Index == index {
+			s.modules = append(s.modules[:i], s.modules[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	var sources []pulseaudio.Source
+	for _, src := range s.sources {
+		if src.ModuleIndex != index {
+			sources = append(sources, src)
+		}
+	}
+	var sinks []pulseaudio.Sink
+	for _, sink := range s.sinks {
+		if sink.ModuleIndex != index {
+			sinks = append(sinks, sink)
+		}
+	}
+	s.sources, s.sinks = sources, sinks
+
+	for _, m := range append([]pulseaudio.Module{}, s.modules...) {
+		parsed, _ := parseModuleArgs(m.Argument)
+		for _, a := range parsed {
+			gone := ((a.key == "master" || a.key == "source") && !s.hasSource(a.value)) ||
+				((a.key == "sink_master" || a.key == "sink") && !s.hasSink(a.value))
+			if gone {
+				s.unload(m.Index)
+				break
+			}
+		}
+	}
+	return true
+}
+
+func (s *fakeServer) Updates() (<-chan struct{}, error) {
+	return s.updates, nil
+}
+
+func (s *fakeServer) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected
+}
+
+func (s *fakeServer) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected = false
+}
@@ -0,0 +1,106 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"github.com/aarzilli/nucular"
+)
+
+// Favorite devices are listed first, hidden ones (HDMI outputs, a webcam microphone that's never
+// used) only with ShowHiddenDevices. A selected device is always listed, so nothing filtered can
+// disappear from view.
+
+type deviceFlags struct {
+	Favorite bool
+	Hidden   bool
+}
+
+func deviceFlagsFor(ctx *ntcontext, dev *device) deviceFlags {
+	return ctx.config.DeviceFlags[dev.ID]
+}
+
+func updateDeviceFlags(ctx *ntcontext, dev *device, f func(fl *deviceFlags)) {
+	if ctx.config.DeviceFlags == nil {
+		ctx.config.DeviceFlags = make(map[string]deviceFlags)
+	}
+	fl := ctx.config.DeviceFlags[dev.ID]
+	f(&fl)
+	if fl == (deviceFlags{}) {
+		delete(ctx.config.DeviceFlags, dev.ID)
+	} else {
+		ctx.config.DeviceFlags[dev.ID] = fl
+	}
+	ctx.sourceListColdWidthIndex++ // recompute the width, the list changed
+	go writeConfig(ctx.config)
+}
+
+// listedDevices returns the indices of the devices to list, favorites first.
+func listedDevices(ctx *ntcontext, devices []device) []int {
+	var favorites, rest []int
+	for i := range devices {
+		d := &devices[i]
+		if d.isMonitor && !ctx.config.DisplayMonitorSources {
+			continue
+		}
+		fl := deviceFlagsFor(ctx, d)
+		switch {
+		case fl.Hidden && !d.checked && !ctx.config.ShowHiddenDevices:
+		case fl.Favorite:
+			favorites = append(favorites, i)
+		default:
+			rest = append(rest, i)
+		}
+	}
+	return append(favorites, rest...)
+}
+
+func hiddenDeviceCount(ctx *ntcontext, devices []device) int {
+	n := 0
+	for i := range devices {
+		if deviceFlagsFor(ctx, &devices[i]).Hidden {
+			n++
+		}
+	}
+	return n
+}
+
+// listedName is how el appears in the device list.
+func listedName(ctx *ntcontext, el *device, name string) string {
+	fl := deviceFlagsFor(ctx, el)
+	if fl.Favorite {
+		name = "* " + name
+	}
+	if el.isMonitor {
+		name = trf("%s (monitor)", name)
+	}
+	if fl.Hidden {
+		name = trf("%s (hidden)", name)
+	}
+	return name
+}
+
+// showHiddenView toggles ShowHiddenDevices below a device list, if it hides anything.
+func showHiddenView(ctx *ntcontext, w *nucular.Window, devices []device) {
+	n := hiddenDeviceCount(ctx, devices)
+	if n == 0 {
+		return
+	}
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(trf("Show hidden devices (%d)", n), &ctx.config.ShowHiddenDevices)) {
+		ctx.sourceListColdWidthIndex++
+		go writeConfig(ctx.config)
+	}
+}
+
+// deviceFlagsView offers pinning and hiding el in its detail pane.
+func deviceFlagsView(ctx *ntcontext, w *nucular.Window, el *device) {
+	fl := deviceFlagsFor(ctx, el)
+	w.Row(20).Dynamic(2)
+	if focusable(ctx, w, w.CheckboxText(tr("Favorite, list first"), &fl.Favorite)) {
+		updateDeviceFlags(ctx, el, func(f *deviceFlags) { f.Favorite = fl.Favorite })
+	}
+	if focusable(ctx, w, w.CheckboxText(tr("Hide from the list"), &fl.Hidden)) {
+		updateDeviceFlags(ctx, el, func(f *deviceFlags) { f.Hidden = fl.Hidden })
+	}
+}
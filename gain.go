@@ -0,0 +1,56 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The filtered microphone is often quieter than the raw one. We compensate with the software volume
+// of the virtual source, which works the same on pulseaudio and pipewire, for every engine, and can
+// be changed without reloading the filter.
+
+const (
+	minGain = -20 // dB
+	maxGain = 20  // dB
+)
+
+// applyGain sets the volume of all filtered microphones to the configured gain. Like muting, this
+// isn't possible with our pulseaudio library, so it goes through pactl.
+func applyGain(ctx *ntcontext) error {
+	sources := findVirtualSources(ctx)
+	if len(sources) == 0 {
+		return fmt.Errorf("filtered microphone is not loaded")
+	}
+	for _, source := range sources {
+		cmd := audioCommand("pactl", "set-source-volume", source.Name, fmt.Sprintf("%ddB", ctx.config.OutputGain))
+		debugf("Calling: %s\n", cmd.String())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("pactl set-source-volume failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+func gainView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(25).Ratio(0.5, 0.4, 0.1)
+	w.Label(tr("Output Gain"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Makes the filtered microphone louder or quieter. Applied immediately."))
+	}
+	if focusableSlider(ctx, w, w.SliderInt(minGain, &ctx.config.OutputGain, maxGain, 1), &ctx.config.OutputGain, minGain, maxGain, 1) {
+		go writeConfig(ctx.config)
+		if ctx.noiseSupressorState == loaded {
+			go func() {
+				if err := applyGain(ctx); err != nil {
+					errorf("Couldn't apply gain: %v\n", err)
+				}
+			}()
+		}
+	}
+	w.Label(fmt.Sprintf("%+ddB", ctx.config.OutputGain), "RC")
+}
@@ -0,0 +1,97 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The noise gate is an optional stage after the filter. RNNoise passes everything while it detects
+// voice, so keyboard clicks between words still come through. The gate mutes whatever stays below
+// its threshold for longer than the release time.
+//
+// It lives in our own plugin next to the filter, but is loaded from a separate copy so it can be
+// combined with any engine.
+
+const gateLabel = "nt-gate"
+
+// the ranges of the control ports of the gate, see module.c
+const (
+	minGateThreshold = -80  // dB
+	maxGateThreshold = 0    // dB
+	maxGateAttack    = 100  // ms
+	maxGateRelease   = 2000 // ms
+)
+
+// gatePlugin writes the copy of our plugin the gate is loaded from, see dumpFile.
+func gatePlugin(c *config) (string, error) {
+	lib, err := rnnoiseLib(c)
+	if err != nil {
+		return "", err
+	}
+	plugin, err := dumpFile(hashedName("libntgate", lib), lib)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write the gate plugin where the audio server can load it: %w", err)
+	}
+	return plugin, nil
+}
+
+// gateArgs returns the module arguments of the gate stage.
+func gateArgs(ctx *ntcontext) (string, error) {
+	plugin, err := gatePlugin(ctx.config)
+	if err != nil {
+		return "", err
+	}
+	c := ctx.config
+	return fmt.Sprintf("label=%s plugin=%s control=%d,%d,%d", gateLabel, plugin, c.GateThreshold, c.GateAttack, c.GateRelease), nil
+}
+
+// gateSupported reports whether the gate can be added to the input chain. The pulse compatibility
+// modules of PipeWire only allow a single plugin per source.
+func gateSupported(ctx *ntcontext) bool {
+	if inProcessMode(ctx) {
+		return false
+	}
+	return ctx.serverInfo.servertype != servertype_pipewire || ctx.config.NativePipeWire || jackMode(ctx)
+}
+
+func gateView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Noise gate after the filter"), &ctx.config.Gate)) {
+		go writeConfig(ctx.config)
+		ctx.reloadRequired = true
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Mutes residual noise like keyboard clicks that passes the filter between words."))
+	}
+	if !ctx.config.Gate {
+		return
+	}
+	if !gateSupported(ctx) {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(tr("The noise gate requires the native PipeWire filter-chain."), "LC", orange)
+		return
+	}
+
+	for _, s := range []struct {
+		name     string
+		min, max int
+		value    *int
+		format   string
+	}{
+		{"Gate Threshold", minGateThreshold, maxGateThreshold, &ctx.config.GateThreshold, "%ddB"},
+		{"Gate Attack", 0, maxGateAttack, &ctx.config.GateAttack, "%dms"},
+		{"Gate Release", 0, maxGateRelease, &ctx.config.GateRelease, "%dms"},
+	} {
+		w.Row(25).Ratio(0.5, 0.4, 0.1)
+		w.Label(tr(s.name), "LC")
+		if focusableSlider(ctx, w, w.SliderInt(s.min, s.value, s.max, 1), s.value, s.min, s.max, 1) {
+			go writeConfig(ctx.config)
+			ctx.reloadRequired = true
+		}
+		w.Label(trf(s.format, *s.value), "RC")
+	}
+}
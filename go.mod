@@ -3,19 +3,25 @@ module noisetorch
 go 1.16
 
 require (
+	fyne.io/systray v1.11.0
 	gioui.org v0.0.0-20220105104929-8d8aeef66bef // indirect
 	github.com/BurntSushi/toml v1.3.2
-	github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc // indirect
-	github.com/BurntSushi/xgbutil v0.0.0-20190907113008-ad855c713046
+	github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc
 	github.com/aarzilli/nucular v0.0.0-20210408133902-d3dd7b05a80a
 	github.com/blang/semver/v4 v4.0.0
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20211213063430-748e38ca8aec // indirect
+	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/hashicorp/golang-lru v0.5.4 // indirect
 	github.com/noisetorch/pulseaudio v0.0.0-20220603053345-9303200c3861
 	github.com/syndtr/gocapability v0.0.0-20200815063812-42c35b437635
 	golang.org/x/crypto v0.14.0
-	golang.org/x/exp v0.0.0-20220104160115-025e73f80486 // indirect
-	golang.org/x/image v0.5.0 // indirect
-	golang.org/x/mobile v0.0.0-20220104184238-4a8be17bd2e3 // indirect
+	golang.org/x/exp v0.0.0-20220104160115-025e73f80486
+	golang.org/x/image v0.5.0
+	golang.org/x/mobile v0.0.0-20220104184238-4a8be17bd2e3
+	golang.org/x/sys v0.15.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+// adds window class and icon hints to shiny's X11 driver, see third_party/README.md
+replace golang.org/x/exp => ./third_party/exp
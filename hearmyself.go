@@ -0,0 +1,90 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aarzilli/nucular"
+)
+
+const (
+	hearMyselfLatency = 50 // ms, of the loopback to the speakers
+	rnnoiseFrameTime  = 10 // ms
+)
+
+// hearMyself plays the filtered microphone on the default sink, so users can listen to what
+// others will hear.
+type hearMyself struct {
+	enabled bool
+	module  uint32
+	latency int // ms, estimated from microphone to speakers
+}
+
+func startHearMyself(ctx *ntcontext) {
+	inp, ok := inputSelection(ctx)
+	if !ok {
+		return
+	}
+	source, ok := findVirtualSource(ctx, &inp)
+	if !ok {
+		errorf("Couldn't start monitor: filtered microphone is not loaded\n")
+		ctx.hear.enabled = false
+		return
+	}
+
+	// no sink argument, so it plays on (and follows) the default sink
+	idx, err := loadModule(ctx, "module-loopback",
+		fmt.Sprintf(`source=%s latency_msec=%d source_dont_move=true `+
+			`sink_input_properties="media.name='NoiseTorch monitor' %s" source_output_properties="%s"`,
+			source.Name, hearMyselfLatency, transientTags(ctx), transientTags(ctx)))
+	if err != nil {
+		errorf("Couldn't load monitor loopback: %v\n", err)
+		ctx.hear.enabled = false
+		return
+	}
+	debugf("Loaded monitor loopback as idx: %d\n", idx)
+
+	inputLatency := 50
+	if inp.dynamicLatency {
+		inputLatency = 1
+	}
+	if !ctx.hear.enabled { // toggled off while we were loading
+		ctx.paClient.UnloadModule(idx)
+		return
+	}
+	ctx.hear.module = idx
+	ctx.hear.latency = inputLatency + rnnoiseFrameTime + hearMyselfLatency
+	(*ctx.masterWindow).Changed()
+}
+
+func stopHearMyself(ctx *ntcontext) {
+	if ctx.hear.module == 0 {
+		return
+	}
+	debugf("Unloading monitor loopback at idx: %d\n", ctx.hear.module)
+	if err := ctx.paClient.UnloadModule(ctx.hear.module); err != nil {
+		errorf("Couldn't unload monitor loopback: %v\n", err)
+	}
+	ctx.hear.module = 0
+}
+
+func hearMyselfView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Ratio(0.5, 0.5)
+	if focusable(ctx, w, w.CheckboxText(tr("Hear myself"), &ctx.hear.enabled)) {
+		if ctx.hear.enabled {
+			go startHearMyself(ctx)
+		} else {
+			go stopHearMyself(ctx)
+		}
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Plays the filtered microphone on your speakers. Use headphones to avoid feedback."))
+	}
+	if ctx.hear.enabled && ctx.hear.module != 0 {
+		w.Label(trf("Latency: ~%dms", ctx.hear.latency), "RC")
+	} else {
+		w.Spacing(1)
+	}
+}
@@ -0,0 +1,105 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hooks are shell commands from the config run when the filter is loaded, turned off or fails to,
+// e.g. to switch an OBS scene or light a mute LED. They run in the background with what happened in
+// their environment:
+//
+//	NOISETORCH_EVENT        load, unload or fail
+//	NOISETORCH_DEVICE       ID of the device, empty when everything was unloaded
+//	NOISETORCH_DEVICE_NAME  its name as shown in NoiseTorch
+//	NOISETORCH_DIRECTION    input or output, empty when everything was unloaded
+//	NOISETORCH_STATE        loaded or unloaded afterwards
+//	NOISETORCH_ERROR        why loading or unloading failed, for fail
+//
+// Unloading to load again, e.g. for a reload, another device or while idle, doesn't run the unload
+// hook, only turning the filter off or quitting with it loaded does. A hook that takes longer than
+// hookTimeout is killed.
+
+const (
+	hookLoad   = "load"
+	hookUnload = "unload"
+	hookFail   = "fail"
+)
+
+var hookEvents = []string{hookLoad, hookUnload, hookFail}
+
+const hookTimeout = 30 * time.Second
+
+// hooksRunning lets the command line wait for the hooks before exiting
+var hooksRunning sync.WaitGroup
+
+func knownHook(event string) bool {
+	for _, e := range hookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// hooksAfterLoad runs the load hook, or the fail hook if loading failed with err.
+func hooksAfterLoad(ctx *ntcontext, direction string, d *device, err error) {
+	if err != nil {
+		runHook(ctx, hookFail, direction, d, "unloaded", err)
+		return
+	}
+	runHook(ctx, hookLoad, direction, d, "loaded", nil)
+}
+
+// hooksAfterUnload runs the unload hook, or the fail hook if unloading failed with err.
+func hooksAfterUnload(ctx *ntcontext, direction string, d *device, err error) {
+	if err != nil {
+		runHook(ctx, hookFail, direction, d, "loaded", err)
+		return
+	}
+	runHook(ctx, hookUnload, direction, d, "unloaded", nil)
+}
+
+func runHook(ctx *ntcontext, event, direction string, d *device, state string, err error) {
+	command := strings.TrimSpace(ctx.config.Hooks[event])
+	if command == "" {
+		return
+	}
+	env := append(os.Environ(),
+		"NOISETORCH_EVENT="+event,
+		"NOISETORCH_DIRECTION="+direction,
+		"NOISETORCH_STATE="+state)
+	if d != nil {
+		env = append(env, "NOISETORCH_DEVICE="+d.ID, "NOISETORCH_DEVICE_NAME="+d.Name)
+	}
+	if err != nil {
+		env = append(env, "NOISETORCH_ERROR="+err.Error())
+	}
+
+	hooksRunning.Add(1)
+	go func() {
+		defer hooksRunning.Done()
+		hookCtx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(hookCtx, "sh", "-c", command)
+		cmd.Env = env
+		debugf("Calling: %s\n", cmd.String())
+		out, err := cmd.CombinedOutput()
+		if hookCtx.Err() == context.DeadlineExceeded {
+			warnf("The %s hook took longer than %s and was killed\n", event, hookTimeout)
+			return
+		}
+		if err != nil {
+			warnf("The %s hook failed: %v: %s\n", event, err, strings.TrimSpace(string(out)))
+			return
+		}
+		debugf("The %s hook ran: %s\n", event, strings.TrimSpace(string(out)))
+	}()
+}
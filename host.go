@@ -0,0 +1,117 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// The JACK backend and the ALSA mode don't load the plugin into the audio server. c/host is a small
+// program running the chain of plugins itself, as a JACK client or between two ALSA devices. We
+// embed it like the plugin and run it detached, like the native PipeWire filter-chain, so the
+// filter keeps running after we exit.
+
+const hostName = "noisetorch-host"
+
+//go:embed c/host/bin
+var hostFiles embed.FS
+
+func hostBinary() []byte {
+	native, _ := hostFiles.ReadFile("c/host/bin/native/" + hostName)
+	return bundledFor(hostFiles, "c/host/bin", hostName, native)
+}
+
+// dumpHost writes the host program where it can be run from, like the plugin.
+func dumpHost() (string, error) {
+	data := hostBinary()
+	if len(data) == 0 {
+		return "", fmt.Errorf("this build doesn't include the filter host for %s", runtime.GOARCH)
+	}
+	sum := sha256.Sum256(data)
+	path, err := dumpFile(hostName+"-"+hex.EncodeToString(sum[:8]), data)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write the filter host: %w", err)
+	}
+	pruneLibs(path)
+	return path, nil
+}
+
+// hostChainArgs returns the arguments of the host running the filter, and the gate after it, with
+// the current settings.
+func hostChainArgs(ctx *ntcontext) ([]string, error) {
+	c := ctx.config
+	e := currentEngine(ctx)
+	args := []string{"-r", strconv.Itoa(filterRate),
+		"-p", ctx.librnnoise + ":" + e.label,
+		"-C", fmt.Sprintf("%s=%d", e.control.port, *e.control.value(c))}
+	if e.rnnoise {
+		args = append(args, "-C", fmt.Sprintf("%s=%d", suppressionPort, c.Suppression))
+	}
+	if c.Gate {
+		plugin, err := gatePlugin(c)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-p", plugin+":"+gateLabel,
+			"-C", fmt.Sprintf("Threshold (dB)=%d", c.GateThreshold),
+			"-C", fmt.Sprintf("Attack (ms)=%d", c.GateAttack),
+			"-C", fmt.Sprintf("Release (ms)=%d", c.GateRelease))
+	}
+	return args, nil
+}
+
+// startHost starts the host detached from us and waits until it runs, or returns why it didn't.
+// The pid goes to pidFile, for stopDetached.
+func startHost(cmd *exec.Cmd, pidFile string) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// the host says when it's running, or exits telling why not
+	ready := make(chan bool, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		ready <- strings.TrimSpace(line) == "ready"
+	}()
+	select {
+	case ok := <-ready:
+		if !ok {
+			cmd.Wait()
+			return fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("not running after 10s")
+	}
+	stdout.Close()
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(pid)), 0600); err != nil {
+		return err
+	}
+	debugf("Started %s as pid: %d\n", hostName, pid)
+	return nil
+}
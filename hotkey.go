@@ -0,0 +1,225 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// parseHotkey understands specs like "ctrl+alt+n" or "super+f9".
+func parseHotkey(spec string) (uint16, xproto.Keysym, error) {
+	var mods uint16
+	parts := strings.Split(strings.ToLower(spec), "+")
+	for _, p := range parts[:len(parts)-1] {
+		switch strings.TrimSpace(p) {
+		case "ctrl", "control":
+			mods |= xproto.ModMaskControl
+		case "alt":
+			mods |= xproto.ModMask1
+		case "shift":
+			mods |= xproto.ModMaskShift
+		case "super", "win":
+			mods |= xproto.ModMask4
+		default:
+			return 0, 0, fmt.Errorf("unknown modifier %q in hotkey %q", p, spec)
+		}
+	}
+
+	key := strings.TrimSpace(parts[len(parts)-1])
+	switch {
+	case len(key) == 1 && (key[0] >= 'a' && key[0] <= 'z' || key[0] >= '0' && key[0] <= '9'):
+		return mods, xproto.Keysym(key[0]), nil
+	case key == "space":
+		return mods, xproto.Keysym(' '), nil
+	case len(key) > 1 && key[0] == 'f':
+		n, err := strconv.Atoi(key[1:])
+		if err == nil && n >= 1 && n <= 24 {
+			return mods, xproto.Keysym(0xffbe + n - 1), nil // XK_F1
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown key %q in hotkey %q", key, spec)
+}
+
+func keycodeFor(X *xgb.Conn, sym xproto.Keysym) (xproto.Keycode, error) {
+	setup := xproto.Setup(X)
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+	mapping, err := xproto.GetKeyboardMapping(X, setup.MinKeycode, count).Reply()
+	if err != nil {
+		return 0, err
+	}
+	per := int(mapping.KeysymsPerKeycode)
+	for i, s := range mapping.Keysyms {
+		if s == sym {
+			return setup.MinKeycode + xproto.Keycode(i/per), nil
+		}
+	}
+	return 0, fmt.Errorf("no key produces keysym %#x", sym)
+}
+
+// hotkeyAction is something a global shortcut can trigger. The shortcut itself is stored in
+// the config by id.
+type hotkeyAction struct {
+	id   string
+	name string
+}
+
+var hotkeyActions = []hotkeyAction{
+	{id: "toggle", name: "Toggle noise suppression"},
+	{id: "mute", name: "Mute/unmute the filtered microphone"},
+	{id: "cough", name: "Cough (mute 2s)"},
+	{id: "hold", name: "Mute while held"},
+	{id: "quickswitch", name: "Switch quick switch state"},
+	{id: "bypass", name: "Bypass the filter to compare"},
+}
+
+func (a hotkeyAction) run(ctx *ntcontext) {
+	switch a.id {
+	case "toggle":
+		uiToggleFilters(ctx)
+	case "mute":
+		uiToggleMute(ctx)
+	case "cough":
+		coughMute(ctx)
+	case "quickswitch":
+		uiSwitchQuickState(ctx)
+	case "bypass":
+		uiToggleBypass(ctx)
+	}
+}
+
+func defaultHotkeys() map[string]string {
+	return map[string]string{"quickswitch": "ctrl+alt+n"}
+}
+
+type grabbedHotkey struct {
+	mods   uint16
+	code   xproto.Keycode
+	action hotkeyAction
+}
+
+// hotkeys owns the X connection all global shortcuts are grabbed on.
+// This only works on X11 (and XWayland for as long as an X11 window has focus).
+type hotkeys struct {
+	mu      sync.Mutex
+	X       *xgb.Conn
+	root    xproto.Window
+	grabbed []grabbedHotkey
+	errs    map[string]string // by action id, shown on the shortcuts page
+}
+
+// CapsLock and NumLock must not break hotkeys, so every hotkey is grabbed with all their combinations
+var lockMasks = []uint16{0, xproto.ModMaskLock, xproto.ModMask2, xproto.ModMaskLock | xproto.ModMask2}
+
+// startHotkeys connects to X and grabs the configured hotkeys. It blocks until the X connection goes away.
+func startHotkeys(ctx *ntcontext) error {
+	X, err := xgb.NewConn()
+	if err != nil {
+		return err
+	}
+	defer X.Close()
+
+	h := &hotkeys{X: X, root: xproto.Setup(X).DefaultScreen(X).Root}
+	ctx.hotkeys = h
+	h.bind(ctx)
+
+	for {
+		ev, xerr := X.WaitForEvent()
+		if ev == nil && xerr == nil {
+			return fmt.Errorf("X connection closed")
+		}
+		switch ev := ev.(type) {
+		case xproto.KeyPressEvent:
+			state := ev.State &^ (xproto.ModMaskLock | xproto.ModMask2)
+			h.mu.Lock()
+			for _, g := range h.grabbed {
+				if g.code != ev.Detail || g.mods != state {
+					continue
+				}
+				if g.action.id == "hold" {
+					// right away, to stay in order with the release
+					coughPress(ctx)
+					continue
+				}
+				debugf("Hotkey for %s pressed\n", g.action.id)
+				go g.action.run(ctx)
+			}
+			h.mu.Unlock()
+		case xproto.KeyReleaseEvent:
+			// the modifiers may be let go first, only the key counts
+			h.mu.Lock()
+			for _, g := range h.grabbed {
+				if g.code == ev.Detail && g.action.id == "hold" {
+					coughRelease(ctx, holdReleaseDelay)
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// bind replaces the grabbed hotkeys with the ones currently configured.
+func (h *hotkeys) bind(ctx *ntcontext) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, g := range h.grabbed {
+		for _, extra := range lockMasks {
+			xproto.UngrabKey(h.X, g.code, h.root, g.mods|extra)
+		}
+	}
+	h.grabbed = nil
+	h.errs = make(map[string]string)
+
+	for _, a := range hotkeyActions {
+		spec := ctx.config.Hotkeys[a.id]
+		if spec == "" {
+			continue
+		}
+		g, err := h.grab(spec)
+		if err != nil {
+			warnf("Hotkey for %s unavailable: %v\n", a.id, err)
+			h.errs[a.id] = err.Error()
+			continue
+		}
+		g.action = a
+		h.grabbed = append(h.grabbed, g)
+		debugf("Listening for hotkey %s (%s)\n", spec, a.id)
+	}
+}
+
+func (h *hotkeys) grab(spec string) (grabbedHotkey, error) {
+	mods, sym, err := parseHotkey(spec)
+	if err != nil {
+		return grabbedHotkey{}, err
+	}
+	code, err := keycodeFor(h.X, sym)
+	if err != nil {
+		return grabbedHotkey{}, err
+	}
+	for i, extra := range lockMasks {
+		err := xproto.GrabKeyChecked(h.X, true, h.root, mods|extra, code, xproto.GrabModeAsync, xproto.GrabModeAsync).Check()
+		if err != nil {
+			for _, undo := range lockMasks[:i] {
+				xproto.UngrabKey(h.X, code, h.root, mods|undo)
+			}
+			return grabbedHotkey{}, fmt.Errorf("couldn't grab %s, is it used by another application? %v", spec, err)
+		}
+	}
+	return grabbedHotkey{mods: mods, code: code}, nil
+}
+
+func (h *hotkeys) err(id string) string {
+	if h == nil {
+		return ""
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.errs[id]
+}
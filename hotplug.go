@@ -0,0 +1,87 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+// USB microphones come and go. When one we filter is unplugged, the audio server takes down the
+// loopback feeding our chain, leaving the rest of it behind. We unload the remains, remember the
+// microphone, and load the chain again as soon as it is plugged back in.
+//
+// Our pulseaudio library only tells us that something changed, not what, so we diff the source list.
+
+type hotplug struct {
+	known   map[string]device // sources present at the last update, by ID
+	waiting map[string]device // unplugged microphones we were filtering, by ID
+}
+
+// rememberedInput reports whether id is a microphone the user filters.
+func rememberedInput(ctx *ntcontext, id string) bool {
+	if id == ctx.config.LastUsedInput {
+		return true
+	}
+	for _, extra := range ctx.config.AdditionalInputs {
+		if id == extra {
+			return true
+		}
+	}
+	return false
+}
+
+// trackHotplug is called on every audio server update.
+func trackHotplug(ctx *ntcontext) {
+	sources, err := ctx.paClient.Sources()
+	if err != nil {
+		errorf("Couldn't fetch sources to track hotplug: %v\n", err)
+		return
+	}
+	present := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		present[s.Name] = true
+	}
+
+	h := &ctx.hotplug
+	if h.known == nil {
+		// first update after connecting, nothing to compare against yet
+		h.known = make(map[string]device)
+		h.waiting = make(map[string]device)
+		for _, d := range getSources(ctx, ctx.paClient) {
+			h.known[d.ID] = d
+		}
+		return
+	}
+
+	for id, d := range h.known {
+		if present[id] || !rememberedInput(ctx, id) {
+			continue
+		}
+		infof("Microphone %s was unplugged\n", d.Name)
+		loaded, inconsistent, _ := inputChainState(ctx, &d)
+		if ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire {
+			loaded, _ = pipeWireNativeInputLoaded(ctx)
+		}
+		if loaded || inconsistent {
+			if err := unloadInputSupressor(ctx, &d); err != nil {
+				errorf("Couldn't unload the chain of the unplugged microphone: %v\n", err)
+			}
+			h.waiting[id] = d
+		}
+	}
+
+	devices := getSources(ctx, ctx.paClient)
+	h.known = make(map[string]device, len(devices))
+	for _, d := range devices {
+		h.known[d.ID] = d
+		if _, ok := h.waiting[d.ID]; !ok {
+			continue
+		}
+		delete(h.waiting, d.ID)
+		if !ctx.config.ReloadOnHotplug {
+			continue
+		}
+		infof("Microphone %s is back, reloading its filter\n", d.Name)
+		d.checked = true
+		if err := loadInputSupressor(ctx, &d); err != nil {
+			errorf("Couldn't reload the filter: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/aarzilli/nucular"
+)
+
+// UI and help texts are written in English and passed through tr, which looks them up in the
+// catalog of the current language. A catalog is a TOML file in assets/locale mapping the English
+// text to its translation, texts missing from it stay English. To add a language, copy an
+// existing catalog, translate it and add the language to the list below.
+//
+// The language follows LC_ALL, LC_MESSAGES and LANG unless one is picked in the settings. The
+// command line help is printed before the config is read, it always follows the environment.
+
+//go:embed assets/locale/*.toml
+var localeFiles embed.FS
+
+type language struct {
+	id   string // file name in assets/locale, "en" has none
+	name string // in the language itself
+}
+
+var languages = []language{
+	{"en", "English"},
+	{"de", "Deutsch"},
+}
+
+var catalog map[string]string // of the current language, nil for English
+
+func init() {
+	if err := setLanguage(envLanguage()); err != nil {
+		warnf("%v\n", err)
+	}
+}
+
+// tr returns the translation of s.
+func tr(s string) string {
+	if t, ok := catalog[s]; ok && t != "" {
+		return t
+	}
+	return s
+}
+
+// trf is fmt.Sprintf with a translated format.
+func trf(format string, args ...interface{}) string {
+	return fmt.Sprintf(tr(format), args...)
+}
+
+// envLanguage returns the language the environment asks for, e.g. "de" for LANG=de_DE.UTF-8.
+func envLanguage() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(v)
+		if locale == "" {
+			continue
+		}
+		if i := strings.IndexAny(locale, "_.@"); i >= 0 {
+			locale = locale[:i]
+		}
+		if locale == "C" || locale == "POSIX" {
+			return "en"
+		}
+		return strings.ToLower(locale)
+	}
+	return "en"
+}
+
+func knownLanguage(id string) bool {
+	for _, l := range languages {
+		if l.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// setLanguage switches to the language id, unknown ones fall back to English.
+func setLanguage(id string) error {
+	if id == "en" || !knownLanguage(id) {
+		catalog = nil
+		return nil
+	}
+	c, err := loadCatalog(id)
+	if err != nil {
+		catalog = nil
+		return fmt.Errorf("Couldn't load the %s translation: %w", id, err)
+	}
+	catalog = c
+	return nil
+}
+
+func loadCatalog(id string) (map[string]string, error) {
+	buf, err := localeFiles.ReadFile("assets/locale/" + id + ".toml")
+	if err != nil {
+		return nil, err
+	}
+	var c map[string]string
+	if _, err := toml.Decode(string(buf), &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// applyLanguage switches to the language of the config.
+func applyLanguage(c *config) {
+	id := c.Language
+	if id == "" {
+		id = envLanguage()
+	}
+	if err := setLanguage(id); err != nil {
+		warnf("%v\n", err)
+	}
+}
+
+func languageView(ctx *ntcontext, w *nucular.Window) {
+	names := []string{tr("Same as the desktop")}
+	selected := 0
+	for i, l := range languages {
+		names = append(names, l.name)
+		if l.id == ctx.config.Language {
+			selected = i + 1
+		}
+	}
+	w.Row(25).Ratio(0.5, 0.5)
+	w.Label(tr("Language"), "LC")
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected {
+		ctx.config.Language = ""
+		if sel > 0 {
+			ctx.config.Language = languages[sel-1].id
+		}
+		go writeConfig(ctx.config)
+		applyLanguage(ctx.config)
+	}
+}
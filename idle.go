@@ -0,0 +1,252 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The filter keeps running while nothing records from the filtered microphone, the loopback records
+// from the real one all the time. With IdleUnload, a chain nothing recorded from for
+// IdleUnloadMinutes loses its filter stage, the ladspa sinks and the loopback, like swapFilters
+// replaces them. The denoised null sink and the filtered microphone stay, so applications still find
+// it, and the filter stage is loaded again as soon as one records from it. The audio server tells us
+// about new recording streams like about any other change, see updateNoiseSupressorLoaded.
+//
+// Only the pulseaudio chain needs this, PipeWire doesn't run filters nobody records from anyway.
+
+const (
+	idleTick           = 15 * time.Second
+	minIdleMinutes     = 1
+	maxIdleMinutes     = 120
+	defaultIdleMinutes = 10
+)
+
+type idleUnload struct {
+	sync.Mutex
+	lastUsed map[string]time.Time // by microphone ID
+	idle     map[string]bool      // microphones whose filter stage is unloaded
+	busy     bool                 // loading or unloading filter stages
+}
+
+// idleUnloadLoop looks for chains that have been idle long enough, once per idleTick.
+func idleUnloadLoop(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	for {
+		time.Sleep(idleTick)
+		checkIdle(ctx)
+	}
+}
+
+// idleUnloadPossible is whether the filter stage of the chain can be unloaded by itself.
+func idleUnloadPossible(ctx *ntcontext) bool {
+	return ctx.serverInfo.servertype == servertype_pulse && ctx.config.FilterInput && !jackMode(ctx) && !inProcessMode(ctx)
+}
+
+// checkIdle unloads the filter stages of chains nothing recorded from for IdleUnloadMinutes, and
+// loads them again once something records, or the option is turned off.
+func checkIdle(ctx *ntcontext) {
+	iu := &ctx.idle
+	iu.Lock()
+	defer iu.Unlock()
+	if iu.busy || (!ctx.config.IdleUnload && len(iu.idle) == 0) {
+		return
+	}
+	if ctx.noiseSupressorState != loaded {
+		// idle chains count as loaded, so this is a chain loaded afresh later
+		iu.lastUsed = nil
+		return
+	}
+	if bypassActive(ctx) || recoveryRunning(ctx) {
+		return
+	}
+	enabled := ctx.config.IdleUnload && idleUnloadPossible(ctx)
+	outputs, err := listSourceOutputs()
+	if err != nil {
+		errorf("Couldn't list recording applications: %v\n", err)
+		return
+	}
+	if iu.lastUsed == nil {
+		iu.lastUsed = make(map[string]time.Time)
+	}
+
+	now := time.Now()
+	timeout := time.Duration(ctx.config.IdleUnloadMinutes) * time.Minute
+	var sleep, wake []device
+	for _, inp := range inputSelections(ctx) {
+		virt, ok := findVirtualSource(ctx, &inp)
+		if !ok {
+			delete(iu.idle, inp.ID)
+			delete(iu.lastUsed, inp.ID)
+			continue
+		}
+		_, running, err := findChainModule(ctx, "module-loopback", "sink="+inputChainFor(&inp).raw+" ")
+		if err != nil {
+			errorf("Couldn't fetch module list to check for module-loopback: %v\n", err)
+			return
+		}
+		used := recordingOthers(outputs, virt.Index)
+		switch {
+		case iu.idle[inp.ID] && running:
+			// loaded again from elsewhere, e.g. reloaded with other settings
+			delete(iu.idle, inp.ID)
+			iu.lastUsed[inp.ID] = now
+		case iu.idle[inp.ID]:
+			if used || !enabled {
+				wake = append(wake, inp)
+			}
+		case used || iu.lastUsed[inp.ID].IsZero():
+			iu.lastUsed[inp.ID] = now
+		case enabled && running && now.Sub(iu.lastUsed[inp.ID]) >= timeout:
+			sleep = append(sleep, inp)
+			// before anything is unloaded, so the chain never looks incomplete
+			if iu.idle == nil {
+				iu.idle = make(map[string]bool)
+			}
+			iu.idle[inp.ID] = true
+		}
+	}
+	if len(sleep) > 0 || len(wake) > 0 {
+		iu.busy = true
+		go switchIdle(ctx, sleep, wake)
+	}
+}
+
+func switchIdle(ctx *ntcontext, sleep, wake []device) {
+	defer recoverCrash(ctx)
+	iu := &ctx.idle
+	defer func() {
+		iu.Lock()
+		iu.busy = false
+		iu.Unlock()
+		(*ctx.masterWindow).Changed()
+	}()
+
+	for i := range sleep {
+		infof("Nothing recorded from the filtered microphone for %s in %d minutes, unloading its filter until something does\n",
+			sleep[i].Name, ctx.config.IdleUnloadMinutes)
+		if err := unloadPulseInputFilter(ctx, &sleep[i]); err != nil {
+			errorf("Couldn't unload the idle filter for %s: %v\n", sleep[i].Name, err)
+			// whatever is left is incomplete, the recovery manager takes care of it
+			iu.Lock()
+			delete(iu.idle, sleep[i].ID)
+			iu.Unlock()
+		}
+	}
+
+	if len(wake) == 0 {
+		return
+	}
+	restore, err := liftPulseRlimit()
+	if err != nil {
+		errorf("Couldn't load the idle filter again: %v\n", err)
+		restore = func() {}
+	}
+	defer restore()
+	for i := range wake {
+		infof("Loading the idle filter for %s again\n", wake[i].Name)
+		// first whatever may be left from unloading it
+		err := unloadPulseInputFilter(ctx, &wake[i])
+		if err == nil {
+			err = loadPulseInputFilter(ctx, &wake[i])
+		}
+		if err != nil {
+			errorf("Couldn't load the idle filter for %s again: %v\n", wake[i].Name, err)
+		}
+		iu.Lock()
+		delete(iu.idle, wake[i].ID)
+		if iu.lastUsed != nil {
+			iu.lastUsed[wake[i].ID] = time.Now()
+		}
+		iu.Unlock()
+	}
+}
+
+// wakeIdleChains loads the filter stages of the idle chains again, before we exit with the chains
+// loaded. Nothing would be left to do it once something records, and without one the chain reads as
+// incomplete on the next start.
+func wakeIdleChains(ctx *ntcontext) {
+	iu := &ctx.idle
+	iu.Lock()
+	for iu.busy {
+		// the idle loop is switching chains, wait for it to finish
+		iu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		iu.Lock()
+	}
+	var wake []device
+	for _, inp := range inputSelections(ctx) {
+		if _, ok := findVirtualSource(ctx, &inp); ok && iu.idle[inp.ID] {
+			wake = append(wake, inp)
+		}
+	}
+	if len(wake) == 0 {
+		iu.Unlock()
+		return
+	}
+	iu.busy = true
+	iu.Unlock()
+	switchIdle(ctx, nil, wake)
+}
+
+// inputIdle is whether the filter stage of the chain of the microphone is unloaded for being idle.
+func inputIdle(ctx *ntcontext, id string) bool {
+	ctx.idle.Lock()
+	defer ctx.idle.Unlock()
+	return ctx.idle.idle[id]
+}
+
+// idleInputs returns the microphones whose chain is idle.
+func idleInputs(ctx *ntcontext) []string {
+	ctx.idle.Lock()
+	defer ctx.idle.Unlock()
+	var res []string
+	for id := range ctx.idle.idle {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// withIdleInputs adds the idle chains to the running chain. Their filter stage is loaded from the
+// config when needed, so it never differs from it.
+func withIdleInputs(ctx *ntcontext, chain runningChain) runningChain {
+	idle := idleInputs(ctx)
+	if len(idle) == 0 {
+		return chain
+	}
+	for _, id := range idle {
+		found := false
+		for _, c := range chain.inputs {
+			found = found || c == id
+		}
+		if !found {
+			chain.inputs = append(chain.inputs, id)
+		}
+	}
+	if chain.label == "" {
+		chain.gate = ctx.config.Gate
+	}
+	return chain
+}
+
+func idleUnloadView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(25).Ratio(0.6, 0.4)
+	if focusable(ctx, w, w.CheckboxText(tr("Unload the filter while nothing records"), &ctx.config.IdleUnload)) {
+		ctx.idle.Lock()
+		ctx.idle.lastUsed = nil // counting starts now, idle filters load again with the next tick
+		ctx.idle.Unlock()
+		go writeConfig(ctx.config)
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Saves CPU: stops the filter when no application recorded from the filtered microphone for this many minutes, and starts it again as soon as one does."))
+	}
+	if focusableSlider(ctx, w, w.PropertyInt("min:", minIdleMinutes, &ctx.config.IdleUnloadMinutes, maxIdleMinutes, 1, 1), &ctx.config.IdleUnloadMinutes, minIdleMinutes, maxIdleMinutes, 1) {
+		go writeConfig(ctx.config)
+	}
+}
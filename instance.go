@@ -0,0 +1,205 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Only one GUI or daemon per user manages the filters. The running one listens on an abstract unix
+// socket, which the kernel removes when the process dies, so a crash never leaves a stale lock.
+// Starting a second one shows the window of the first, or with -replace asks it to exit and takes
+// over the loaded filters from it.
+//
+// The protocol is one line each way: we send "focus" or "replace", the running instance answers with
+// what it is ("gui" or "daemon") and its pid.
+//
+// Abstract sockets have no permissions, any user can connect to or take the name. Both sides check
+// the uid of the other, so other users can neither make us exit nor pass for our running instance.
+// If another user took the name, we run without the check, under a name nobody can guess.
+
+const instanceTakeoverTimeout = 5 * time.Second
+
+func instanceSocket() string {
+	return fmt.Sprintf("@noisetorch-%d", os.Getuid())
+}
+
+// errForeignInstance is returned by askInstance when the socket is held by another user.
+type errForeignInstance struct {
+	uid uint32
+}
+
+func (e errForeignInstance) Error() string {
+	return fmt.Sprintf("the instance socket %s is held by uid %d", instanceSocket(), e.uid)
+}
+
+// peerUID returns the uid of the process at the other end of conn.
+func peerUID(conn net.Conn) (uint32, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("not a unix socket")
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, credErr
+	}
+	return cred.Uid, nil
+}
+
+// sameUser reports whether the process at the other end of conn runs as our user.
+func sameUser(conn net.Conn) (uint32, bool) {
+	uid, err := peerUID(conn)
+	if err != nil {
+		warnf("Couldn't check who is on the other end of the instance socket: %v\n", err)
+		return 0, false
+	}
+	return uid, uid == uint32(os.Getuid())
+}
+
+// privateInstanceSocket listens under a random name, for when another user holds ours.
+func privateInstanceSocket() (net.Listener, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", fmt.Sprintf("%s-%x", instanceSocket(), b))
+}
+
+// errAlreadyRunning is returned by acquireInstance when another instance runs and we don't replace it.
+type errAlreadyRunning struct {
+	kind string
+	pid  string
+}
+
+func (e errAlreadyRunning) Error() string {
+	return fmt.Sprintf("NoiseTorch-ng is already running (%s, pid %s)", e.kind, e.pid)
+}
+
+// acquireInstance makes us the running instance. If there already is one, it's asked to show its
+// window, or with replace to exit, in which case we wait until it has.
+func acquireInstance(replace bool) (net.Listener, error) {
+	l, err := net.Listen("unix", instanceSocket())
+	if err == nil {
+		return l, nil
+	}
+
+	cmd := "focus"
+	if replace {
+		cmd = "replace"
+	}
+	kind, pid, err := askInstance(cmd)
+	if foreign, ok := err.(errForeignInstance); ok {
+		warnf("Can't check for another instance, %v\n", foreign)
+		return privateInstanceSocket()
+	}
+	if err != nil {
+		// it exited in between
+		return net.Listen("unix", instanceSocket())
+	}
+	if !replace {
+		return nil, errAlreadyRunning{kind, pid}
+	}
+
+	infof("Taking over from the running %s (pid %s)\n", kind, pid)
+	deadline := time.Now().Add(instanceTakeoverTimeout)
+	for {
+		l, err := net.Listen("unix", instanceSocket())
+		if err == nil {
+			return l, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the running instance (pid %s) didn't exit: %w", pid, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func askInstance(cmd string) (kind, pid string, err error) {
+	conn, err := net.DialTimeout("unix", instanceSocket(), time.Second)
+	if err != nil {
+		return "", "", err
+	}
+	defer conn.Close()
+	if uid, ok := sameUser(conn); !ok {
+		return "", "", errForeignInstance{uid}
+	}
+	conn.SetDeadline(time.Now().Add(instanceTakeoverTimeout))
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return "", "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Fields(reply)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("unexpected answer from the running instance: %q", reply)
+	}
+	return fields[0], fields[1], nil
+}
+
+// serveInstance answers other instances until l is closed. handle is called with "focus" or
+// "replace"; after a replace it's up to handle to close l and exit.
+func serveInstance(l net.Listener, kind string, handle func(cmd string)) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		if uid, ok := sameUser(conn); !ok {
+			warnf("Ignoring a connection to the instance socket from uid %d\n", uid)
+			conn.Close()
+			continue
+		}
+		conn.SetDeadline(time.Now().Add(time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		cmd := strings.TrimSpace(line)
+		if err != nil || (cmd != "focus" && cmd != "replace") {
+			conn.Close()
+			continue
+		}
+		fmt.Fprintf(conn, "%s %d\n", kind, os.Getpid())
+		conn.Close()
+		debugf("Another instance asked us to %s\n", cmd)
+		handle(cmd)
+	}
+}
+
+// guiInstance handles requests of other instances while the GUI runs.
+func guiInstance(ctx *ntcontext, l net.Listener) {
+	serveInstance(l, "gui", func(cmd string) {
+		switch cmd {
+		case "focus":
+			if err := activateWindow(); err != nil {
+				warnf("Couldn't bring the window to the front: %v\n", err)
+			}
+		case "replace":
+			infof("Another instance takes over, exiting\n")
+			// the loaded filters stay, the new instance picks them up
+			if connected(ctx) {
+				wakeIdleChains(ctx)
+			}
+			ctx.exit.handled = true
+			l.Close()
+			(*ctx.masterWindow).Close()
+		}
+	})
+}
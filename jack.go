@@ -0,0 +1,323 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Pro-audio setups route audio between JACK clients rather than devices of the audio server. With
+// JackClient the filter is a JACK client of its own, with the ports in_N and out_N, instead of the
+// filtered microphone and headphones. It's run by the filter host, see host.go. It works with
+// jackd, and with PipeWire through pw-jack.
+//
+// The connection rules are JACK port name regular expressions: the ports matching JackInputs feed
+// the filter, those matching JackOutputs are fed by it, spread over the channels in order. The
+// host applies them when it starts and whenever ports appear, so the patchbay can still be used on
+// top of them.
+
+const (
+	jackClientName = "NoiseTorch"
+
+	jackServerJackd    = "jackd"
+	jackServerPipeWire = "pipewire-jack"
+
+	maxJackChannels = 2 // see MAX_CHANNELS in host.c
+)
+
+// jackServer returns how to reach a JACK server: jackServerJackd if jackd runs, jackServerPipeWire
+// if PipeWire provides JACK, or empty if there's none.
+func jackServer(servertype uint) string {
+	if jackdRunning() {
+		return jackServerJackd
+	}
+	if servertype == servertype_pipewire && pipeWireJackInstalled() {
+		return jackServerPipeWire
+	}
+	return ""
+}
+
+func jackdRunning() bool {
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return false
+	}
+	for _, p := range procs {
+		if _, err := strconv.Atoi(p.Name()); err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", p.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(comm)) {
+		case "jackd", "jackdbus":
+			return true
+		}
+	}
+	return false
+}
+
+func pipeWireJackInstalled() bool {
+	if _, err := exec.LookPath("pw-jack"); err == nil {
+		return true
+	}
+	for _, pattern := range []string{"/usr/lib*/pipewire-0.3/jack/libjack.so.0", "/usr/lib/*/pipewire-0.3/jack/libjack.so.0"} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// jackMode reports whether the filter runs as a JACK client.
+func jackMode(ctx *ntcontext) bool {
+	return ctx.config.JackClient && ctx.serverInfo.jack != ""
+}
+
+func jackPidFile() string {
+	return filepath.Join(pipeWireRuntimeDir(), "jack-client.pid")
+}
+
+// jackCommand runs the host with the libjack of the server we found.
+func jackCommand(ctx *ntcontext, host string, args ...string) *exec.Cmd {
+	if ctx.serverInfo.jack == jackServerPipeWire {
+		if pwjack, err := exec.LookPath("pw-jack"); err == nil {
+			return exec.Command(pwjack, append([]string{host}, args...)...)
+		}
+	}
+	return exec.Command(host, args...)
+}
+
+func loadJackClient(ctx *ntcontext) error {
+	infof("Loading supressor as JACK client (%s)\n", ctx.serverInfo.jack)
+	if err := unloadJackClient(); err != nil {
+		errorf("Couldn't stop the previous JACK client: %v\n", err)
+	}
+	host, err := dumpHost()
+	if err != nil {
+		return err
+	}
+	chain, err := hostChainArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(pipeWireRuntimeDir(), 0700); err != nil {
+		return err
+	}
+	c := ctx.config
+	args := []string{"-n", jackClientName, "-c", strconv.Itoa(c.JackChannels)}
+	for _, r := range c.JackInputs {
+		args = append(args, "-i", r)
+	}
+	for _, r := range c.JackOutputs {
+		args = append(args, "-o", r)
+	}
+	if err := startHost(jackCommand(ctx, host, append(args, chain...)...), jackPidFile()); err != nil {
+		return fmt.Errorf("the JACK client didn't start: %w", err)
+	}
+	return nil
+}
+
+func unloadJackClient() error {
+	return stopDetached(jackPidFile(), hostName)
+}
+
+func jackClientLoaded() bool {
+	_, running := detachedPid(jackPidFile(), hostName)
+	return running
+}
+
+// jackPort is a port of the JACK server, as listed by the host.
+type jackPort struct {
+	name  string
+	input bool // other clients write to it
+}
+
+func jackPorts(ctx *ntcontext) ([]jackPort, error) {
+	host, err := dumpHost()
+	if err != nil {
+		return nil, err
+	}
+	out, err := jackCommand(ctx, host, "-l").Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ports []jackPort
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 || strings.HasPrefix(fields[0], jackClientName+":") {
+			continue
+		}
+		ports = append(ports, jackPort{name: fields[0], input: fields[1] == "in"})
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].name < ports[j].name })
+	return ports, nil
+}
+
+// jackui is the state of the JACK connection rules view, of the input or the output side.
+type jackui struct {
+	outputs bool
+	ports   []jackPort
+	editor  nucular.TextEditor
+	err     string
+}
+
+// rules returns the connection rules of the side shown.
+func (j *jackui) rules(ctx *ntcontext) *[]string {
+	if j.outputs {
+		return &ctx.config.JackOutputs
+	}
+	return &ctx.config.JackInputs
+}
+
+func openJackView(ctx *ntcontext, outputs bool) {
+	ctx.jack = jackui{outputs: outputs}
+	ctx.jack.editor.Flags = nucular.EditField | nucular.EditSigEnter
+	ctx.views.Push(jackView)
+	go refreshJackPorts(ctx)
+}
+
+func refreshJackPorts(ctx *ntcontext) {
+	ports, err := jackPorts(ctx)
+	if err != nil {
+		ctx.jack.err = err.Error()
+	} else {
+		ctx.jack.ports = ports
+	}
+	(*ctx.masterWindow).Changed()
+}
+
+func setJackRule(ctx *ntcontext, rules *[]string, rule string, enabled bool) {
+	i := -1
+	for j, r := range *rules {
+		if r == rule {
+			i = j
+		}
+	}
+	switch {
+	case enabled && i < 0:
+		*rules = append(*rules, rule)
+	case !enabled && i >= 0:
+		*rules = append((*rules)[:i:i], (*rules)[i+1:]...)
+	}
+	ctx.reloadRequired = ctx.noiseSupressorState == loaded
+	go writeConfig(ctx.config)
+}
+
+func jackView(ctx *ntcontext, w *nucular.Window) {
+	j := &ctx.jack
+	rules := j.rules(ctx)
+	w.Row(15).Dynamic(1)
+	w.Label(tr("JACK Connections"), "CB")
+	w.Row(30).Dynamic(1)
+	if j.outputs {
+		w.LabelWrap(trf("Checked ports are connected to the outputs of %s, also when they appear later.", jackClientName))
+	} else {
+		w.LabelWrap(trf("Checked ports are connected to the inputs of %s, also when they appear later.", jackClientName))
+	}
+
+	// the rules, and the ports that could be connected right now
+	names := append([]string(nil), *rules...)
+	for _, p := range j.ports {
+		if p.input != j.outputs {
+			continue
+		}
+		known := false
+		for _, r := range *rules {
+			known = known || r == p.name
+		}
+		if !known {
+			names = append(names, p.name)
+		}
+	}
+	for _, name := range names {
+		enabled := false
+		for _, r := range *rules {
+			enabled = enabled || r == name
+		}
+		w.Row(15).Dynamic(1)
+		if focusable(ctx, w, w.CheckboxText(name, &enabled)) {
+			setJackRule(ctx, rules, name, enabled)
+		}
+	}
+
+	w.Row(25).Ratio(0.7, 0.3)
+	ev := focusableEdit(ctx, w, &j.editor, j.editor.Edit(w))
+	add := focusable(ctx, w, w.ButtonText(tr("Add")))
+	if rule := strings.TrimSpace(string(j.editor.Buffer)); (add || ev&nucular.EditCommitted != 0) && rule != "" {
+		setJackRule(ctx, rules, rule, true)
+		j.editor.Buffer = nil
+	}
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Add a port name or regular expression, e.g. system:capture_.*"), "LC")
+
+	if j.err != "" {
+		w.Row(30).Dynamic(1)
+		w.LabelWrapColored(j.err, red)
+	}
+
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Refresh"))) {
+		j.err = ""
+		go refreshJackPorts(ctx)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) {
+		ctx.views.Pop()
+	}
+}
+
+// jackMainView takes the place of the device selection while the filter is a JACK client.
+func jackMainView(ctx *ntcontext, w *nucular.Window) {
+	if !w.TreePush(nucular.TreeTab, tr("JACK"), true) {
+		return
+	}
+	w.Row(30).Dynamic(1)
+	w.LabelWrap(trf("The filter is the JACK client %s. Connect its ports in your patchbay, or here:", jackClientName))
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Inputs"))) {
+		openJackView(ctx, false)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Outputs"))) {
+		openJackView(ctx, true)
+	}
+	w.TreePop()
+}
+
+func jackSettingsView(ctx *ntcontext, w *nucular.Window) {
+	if ctx.serverInfo.jack == "" {
+		return
+	}
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Filter as a JACK client instead of through devices"), &ctx.config.JackClient)) {
+		go writeConfig(ctx.config)
+		ctx.reloadRequired = ctx.noiseSupressorState == loaded
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("For pro-audio setups routing audio between JACK clients. JACK has to run at 48 kHz."))
+	}
+	if !ctx.config.JackClient {
+		return
+	}
+	w.Row(20).Ratio(0.4, 0.3, 0.3)
+	w.Label(tr("Channels"), "LC")
+	for n, label := range []string{tr("Mono"), tr("Stereo")} {
+		if focusable(ctx, w, w.OptionText(label, ctx.config.JackChannels == n+1)) && ctx.config.JackChannels != n+1 {
+			ctx.config.JackChannels = n + 1
+			ctx.reloadRequired = ctx.noiseSupressorState == loaded
+			go writeConfig(ctx.config)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"image"
+	"reflect"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/rect"
+	"golang.org/x/mobile/event/key"
+	"golang.org/x/mobile/event/mouse"
+)
+
+// nucular only knows the mouse, so keyboard navigation is ours: every interactive widget is drawn
+// through focusable (or one of its variants), in drawing order, which is the tab order. Tab and
+// shift+tab, or the up and down arrows, move the focus, space and enter activate the focused
+// widget by clicking it, which works the same for every kind of widget. Within a device list the
+// arrows move between the devices. Left and right change sliders, up and down combo boxes. Escape
+// drops the focus, or leaves a text field. Any real click drops the focus too.
+//
+// Widgets are only known once drawn, so all of this works on the previous frame's widgets.
+
+type keyboardui struct {
+	focus   int           // index of the focused widget, -1 for none
+	widgets []focusWidget // drawn in the previous frame
+	drawing []focusWidget // drawn in this frame so far
+	view    uintptr       // the view the widgets belong to
+	moved   bool          // the focus moved this frame, scroll it into view
+	adjust  int           // -1 or 1, for the focused slider or combo box
+	click   int           // phase of a synthesized click, see keyboardBegin
+	clickAt image.Point
+	mouseAt image.Point // where the real mouse was before the click
+}
+
+type focusWidget struct {
+	bounds rect.Rect
+	group  string              // the arrows move within a group, if set
+	editor *nucular.TextEditor // if it's a text field
+}
+
+const (
+	clickNone = iota
+	clickPressed
+	clickReleased
+)
+
+// keyboardBegin handles the keys at the start of every frame, before the view is drawn.
+func keyboardBegin(ctx *ntcontext, w *nucular.Window) {
+	kb := &ctx.keyboard
+	if view := reflect.ValueOf(ctx.views.Peek()).Pointer(); view != kb.view {
+		// another view, another set of widgets
+		kb.view, kb.focus, kb.drawing = view, -1, nil
+	}
+	kb.widgets, kb.drawing = kb.drawing, kb.widgets[:0]
+	kb.moved, kb.adjust = false, 0
+
+	in := w.Input()
+	// a click takes three frames: pressing, releasing and putting the real mouse back
+	switch kb.click {
+	case clickPressed:
+		pressButton(in, kb.clickAt, false)
+		kb.click = clickReleased
+		(*ctx.masterWindow).Changed()
+		return
+	case clickReleased:
+		in.Mouse.Pos = kb.mouseAt
+		kb.click = clickNone
+	default:
+		if in.Mouse.Buttons[mouse.ButtonLeft].Clicked {
+			kb.focus = -1
+		}
+	}
+
+	if kb.focus >= len(kb.widgets) {
+		kb.focus = -1
+	}
+	var focused *focusWidget
+	if kb.focus >= 0 {
+		focused = &kb.widgets[kb.focus]
+	}
+	for _, fw := range kb.widgets {
+		if fw.editor != nil && fw.editor.Active && fw.editor.Flags&nucular.EditReadOnly == 0 {
+			// the keys are text, except escape
+			if in.Keyboard.Pressed(key.CodeEscape) {
+				fw.editor.Active = false
+			}
+			return
+		}
+	}
+
+	for _, k := range in.Keyboard.Keys {
+		switch k.Code {
+		case key.CodeTab:
+			if k.Modifiers&key.ModShift != 0 {
+				moveFocus(kb, -1, "")
+			} else {
+				moveFocus(kb, 1, "")
+			}
+		case key.CodeUpArrow, key.CodeDownArrow:
+			dir := 1
+			if k.Code == key.CodeUpArrow {
+				dir = -1
+			}
+			if focused != nil && focused.group == comboGroup {
+				kb.adjust = dir
+			} else if focused != nil {
+				moveFocus(kb, dir, focused.group)
+			} else {
+				moveFocus(kb, dir, "")
+			}
+		case key.CodeLeftArrow:
+			kb.adjust = -1
+		case key.CodeRightArrow:
+			kb.adjust = 1
+		case key.CodeSpacebar, key.CodeReturnEnter, key.CodeKeypadEnter:
+			if focused != nil {
+				b := focused.bounds
+				kb.clickAt = image.Pt(b.X+b.W/2, b.Y+b.H/2)
+				kb.mouseAt = in.Mouse.Pos
+				pressButton(in, kb.clickAt, true)
+				kb.click = clickPressed
+			}
+		case key.CodeEscape:
+			kb.focus = -1
+		default:
+			continue
+		}
+		(*ctx.masterWindow).Changed()
+	}
+	if kb.moved && kb.focus >= 0 {
+		scrollIntoView(w, kb.widgets[kb.focus].bounds)
+	}
+}
+
+// pressButton makes it look like the left mouse button was just pressed, or released, at p.
+func pressButton(in *nucular.Input, p image.Point, down bool) {
+	in.Mouse.Pos = p
+	b := &in.Mouse.Buttons[mouse.ButtonLeft]
+	b.Down, b.Clicked, b.ClickedPos = down, true, p
+}
+
+// moveFocus moves the focus by dir, to the next widget of group if it's set.
+func moveFocus(kb *keyboardui, dir int, group string) {
+	n := len(kb.widgets)
+	if n == 0 {
+		return
+	}
+	kb.moved = true
+	if kb.focus < 0 {
+		if dir > 0 {
+			kb.focus = 0
+		} else {
+			kb.focus = n - 1
+		}
+		return
+	}
+	for i := 1; i < n; i++ {
+		next := ((kb.focus+dir*i)%n + n) % n
+		if group == "" || kb.widgets[next].group == group {
+			kb.focus = next
+			return
+		}
+	}
+}
+
+func scrollIntoView(w *nucular.Window, b rect.Rect) {
+	switch {
+	case b.Y < w.Bounds.Y:
+		w.Scrollbar.Y -= w.Bounds.Y - b.Y
+	case b.Y+b.H > w.Bounds.Y+w.Bounds.H:
+		w.Scrollbar.Y += b.Y + b.H - w.Bounds.Y - w.Bounds.H
+	}
+	if w.Scrollbar.Y < 0 {
+		w.Scrollbar.Y = 0
+	}
+}
+
+// register adds the widget just drawn to the tab order and reports whether it has the focus.
+func register(ctx *ntcontext, w *nucular.Window, fw focusWidget) bool {
+	kb := &ctx.keyboard
+	fw.bounds = w.LastWidgetBounds
+	kb.drawing = append(kb.drawing, fw)
+	if len(kb.drawing)-1 != kb.focus {
+		return false
+	}
+	// the focus ring
+	b, c := fw.bounds, lightBlue
+	out := w.Commands()
+	out.StrokeLine(image.Pt(b.X-1, b.Y-1), image.Pt(b.X+b.W, b.Y-1), 2, c)
+	out.StrokeLine(image.Pt(b.X+b.W, b.Y-1), image.Pt(b.X+b.W, b.Y+b.H), 2, c)
+	out.StrokeLine(image.Pt(b.X+b.W, b.Y+b.H), image.Pt(b.X-1, b.Y+b.H), 2, c)
+	out.StrokeLine(image.Pt(b.X-1, b.Y+b.H), image.Pt(b.X-1, b.Y-1), 2, c)
+	return true
+}
+
+// focusable makes the button, checkbox or option just drawn reachable by keyboard. It passes
+// through what the widget returned, so it wraps the call: if focusable(ctx, w, w.ButtonText(...)).
+func focusable(ctx *ntcontext, w *nucular.Window, changed bool) bool {
+	register(ctx, w, focusWidget{})
+	return changed
+}
+
+// focusableIn is focusable for widgets in a group, like the devices of a list.
+func focusableIn(ctx *ntcontext, w *nucular.Window, group string, changed bool) bool {
+	register(ctx, w, focusWidget{group: group})
+	return changed
+}
+
+// focusableSlider also lets the arrows change the value of the slider or property just drawn.
+func focusableSlider(ctx *ntcontext, w *nucular.Window, changed bool, v *int, min, max, step int) bool {
+	if !register(ctx, w, focusWidget{}) || ctx.keyboard.adjust == 0 {
+		return changed
+	}
+	nv := *v + ctx.keyboard.adjust*step
+	if nv < min {
+		nv = min
+	}
+	if nv > max {
+		nv = max
+	}
+	ctx.keyboard.adjust = 0
+	if nv == *v {
+		return changed
+	}
+	*v = nv
+	return true
+}
+
+const comboGroup = "\x00combo"
+
+// focusableCombo also lets the arrows change the selection of the combo box just drawn, out of n.
+func focusableCombo(ctx *ntcontext, w *nucular.Window, selected, n int) int {
+	if !register(ctx, w, focusWidget{group: comboGroup}) || ctx.keyboard.adjust == 0 {
+		return selected
+	}
+	next := selected + ctx.keyboard.adjust
+	ctx.keyboard.adjust = 0
+	if next < 0 || next >= n {
+		return selected
+	}
+	return next
+}
+
+// focusableEdit makes the text field just drawn reachable. Activating it puts the cursor in it,
+// escape leaves it again.
+func focusableEdit(ctx *ntcontext, w *nucular.Window, ed *nucular.TextEditor, ev nucular.EditEvents) nucular.EditEvents {
+	register(ctx, w, focusWidget{editor: ed})
+	return ev
+}
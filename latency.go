@@ -0,0 +1,107 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+const maxLatencyOffset = 500 // msec
+
+// The loopbacks feeding the filters buffer BufferLatency ms of audio. Gamers want that as low as
+// possible, slow CPUs (e.g. on ARM boards) need more to not drop out.
+const (
+	minBufferLatency     = 1   // ms
+	maxBufferLatency     = 500 // ms
+	defaultBufferLatency = 50  // ms
+)
+
+var latencyPresets = []struct {
+	name string
+	msec int
+}{
+	{"Low latency", 20},
+	{"Balanced", defaultBufferLatency},
+	{"Safe", 200},
+}
+
+// inputLoopbackLatency is the latency_msec of the loopback from a microphone. Devices with dynamic
+// latency hand over audio as soon as they have it, so they only buffer more when asked to.
+func inputLoopbackLatency(ctx *ntcontext, inp *device) int {
+	if inp.dynamicLatency && ctx.config.BufferLatency <= defaultBufferLatency {
+		return 1
+	}
+	return ctx.config.BufferLatency
+}
+
+func bufferLatencyView(ctx *ntcontext, w *nucular.Window) {
+	names := make([]string, 0, len(latencyPresets)+1)
+	selected := len(latencyPresets)
+	for i, p := range latencyPresets {
+		names = append(names, fmt.Sprintf("%s (%dms)", tr(p.name), p.msec))
+		if p.msec == ctx.config.BufferLatency {
+			selected = i
+		}
+	}
+	names = append(names, tr("Custom"))
+
+	w.Row(25).Ratio(0.5, 0.3, 0.2)
+	w.Label(tr("Buffer Latency"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Lower means less delay, raise it if the filtered audio crackles or drops out."))
+	}
+	changed := false
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected && sel < len(latencyPresets) {
+		ctx.config.BufferLatency = latencyPresets[sel].msec
+		changed = true
+	}
+	if focusableSlider(ctx, w, w.PropertyInt("ms:", minBufferLatency, &ctx.config.BufferLatency, maxBufferLatency, 5, 1), &ctx.config.BufferLatency, minBufferLatency, maxBufferLatency, 5) {
+		changed = true
+	}
+	if changed {
+		go writeConfig(ctx.config)
+		ctx.reloadRequired = true
+	}
+}
+
+func latencyOffset(ctx *ntcontext, dev *device) int {
+	p, _ := profileFor(ctx, dev)
+	return p.LatencyOffset
+}
+
+func setLatencyOffset(ctx *ntcontext, dev *device, offset int) {
+	updateProfile(ctx, dev, func(p *deviceProfile) { p.LatencyOffset = offset })
+}
+
+// applyLatencyOffset makes the filtered microphone report the configured latency of the device
+// it was created for, so applications doing A/V sync can compensate for the processing delay.
+// Only PipeWire lets us set the reported latency of a node, pulseaudio only supports
+// offsets on hardware ports, which our virtual devices don't have.
+func applyLatencyOffset(ctx *ntcontext, inp *device) error {
+	if ctx.serverInfo.servertype != servertype_pipewire {
+		return nil
+	}
+	offset := latencyOffset(ctx, inp)
+
+	source, ok := findVirtualSource(ctx, inp)
+	if !ok {
+		return fmt.Errorf("filtered microphone is not loaded")
+	}
+	id, ok := source.PropList["object.id"]
+	if !ok {
+		return fmt.Errorf("filtered microphone has no PipeWire object id")
+	}
+
+	ns := int64(offset) * 1000000
+	cmd := exec.Command("pw-cli", "set-param", id, "ProcessLatency", fmt.Sprintf("{ ns = %d }", ns))
+	debugf("Calling: %s\n", cmd.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pw-cli set-param failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
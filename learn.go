@@ -0,0 +1,95 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"github.com/aarzilli/nucular"
+)
+
+// learningStats is what we observe about how a threshold works out for a device.
+// Nothing ever leaves the config file.
+type learningStats struct {
+	Loads         int // number of times the filter was loaded
+	Adjustments   int // how many of those came with a changed threshold
+	AdjustmentSum int // sum of the threshold changes, signed
+	GateSwitches  int // times the gate opened or closed while the level meters were running
+	GateSeconds   int // for how long the level meters were running
+}
+
+const (
+	minLearningLoads = 5
+	chatterPerMinute = 40 // gate switches per minute above which we consider it chattering
+)
+
+func recordLoad(p *deviceProfile, newThreshold int) {
+	p.Learning.Loads++
+	if delta := newThreshold - p.Threshold; delta != 0 {
+		p.Learning.Adjustments++
+		p.Learning.AdjustmentSum += delta
+	}
+}
+
+func recordGateActivity(ctx *ntcontext, dev *device, switches int, seconds int) {
+	if !ctx.config.LearnThreshold || seconds <= 0 {
+		return
+	}
+	updateProfile(ctx, dev, func(p *deviceProfile) {
+		p.Learning.GateSwitches += switches
+		p.Learning.GateSeconds += seconds
+	})
+}
+
+// suggestThreshold returns a better threshold for the profile if what we've seen suggests one.
+func suggestThreshold(p deviceProfile) (int, string, bool) {
+	l := p.Learning
+	if l.GateSeconds >= 60 && l.GateSwitches*60/l.GateSeconds > chatterPerMinute {
+		return clampSuggestion(p.Threshold - 5), "the filter often cuts in and out while you talk", true
+	}
+	if l.Loads >= minLearningLoads && l.Adjustments*2 >= l.Loads {
+		avg := l.AdjustmentSum / l.Adjustments
+		if avg != 0 {
+			return clampSuggestion(p.Threshold + avg), "you adjust the threshold frequently", true
+		}
+	}
+	return 0, "", false
+}
+
+func clampSuggestion(t int) int {
+	if t < 0 {
+		return 0
+	}
+	if t > 95 {
+		return 95
+	}
+	return t
+}
+
+func learningHintView(ctx *ntcontext, w *nucular.Window) {
+	if !ctx.config.LearnThreshold {
+		return
+	}
+	dev, ok := primarySelection(ctx)
+	if !ok {
+		return
+	}
+	p, _ := profileFor(ctx, &dev)
+	suggested, reason, ok := suggestThreshold(p)
+	if !ok || suggested == ctx.config.Threshold {
+		return
+	}
+
+	w.Row(20).Dynamic(1)
+	w.LabelColored(trf("Hint: %s, a threshold of %d%% may work better.", reason, suggested), "LC", lightBlue)
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Dismiss"))) {
+		updateProfile(ctx, &dev, func(p *deviceProfile) { p.Learning = learningStats{} })
+		go writeConfig(ctx.config)
+	}
+	if focusable(ctx, w, w.ButtonText(trf("Use %d%%", suggested))) {
+		ctx.config.Threshold = suggested
+		requestLiveControls(ctx)
+		updateProfile(ctx, &dev, func(p *deviceProfile) { p.Learning = learningStats{} })
+		go writeConfig(ctx.config)
+	}
+}
@@ -0,0 +1,260 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The control of the engine, e.g. the threshold, and the suppression change while the filter runs,
+// without reloading it and interrupting the microphone. PipeWire takes new values for its
+// filter-chains through pw-cli set-param, PulseAudio through the D-Bus interface of
+// module-ladspa-sink, which needs module-dbus-protocol loaded, and the in-process engine just
+// picks them up. Where none of that works, the change waits for a reload as before.
+//
+// The arguments of the modules keep the values they were loaded with, so the values set since are
+// remembered by module and take precedence in getRunningChain.
+
+type livecontrols struct {
+	sync.Mutex
+	once    sync.Once
+	pending chan struct{}
+	modules map[uint32]liveValues // by index of the module running the engine
+}
+
+type liveValues struct {
+	control, suppression int
+}
+
+// requestLiveControls applies the control and suppression of the config to the running filter, or
+// marks a reload as required if that's not possible. Calls while a change is applied are merged,
+// a dragged slider changes them many times a second.
+func requestLiveControls(ctx *ntcontext) {
+	if ctx.noiseSupressorState != loaded {
+		return // the next load uses them anyway
+	}
+	lc := &ctx.liveControls
+	lc.once.Do(func() {
+		lc.pending = make(chan struct{}, 1)
+		go liveControlsWorker(ctx)
+	})
+	select {
+	case lc.pending <- struct{}{}:
+	default:
+	}
+}
+
+func liveControlsWorker(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	for range ctx.liveControls.pending {
+		if err := applyLiveControls(ctx); err != nil {
+			infof("Couldn't change the running filter, the change applies with the next reload: %v\n", err)
+			ctx.reloadRequired = true
+		}
+		(*ctx.masterWindow).Changed()
+	}
+}
+
+func applyLiveControls(ctx *ntcontext) error {
+	e := currentEngine(ctx)
+	v := liveValues{*e.control.value(ctx.config), -1}
+	if e.rnnoise {
+		v.suppression = ctx.config.Suppression
+	}
+	if inProcessMode(ctx) {
+		if !setDSPControls(v.control, v.suppression) {
+			return fmt.Errorf("the in-process filter isn't running")
+		}
+		return nil
+	}
+	if ctx.chain.label != "" && ctx.chain.label != e.label {
+		return fmt.Errorf("another engine is loaded")
+	}
+	if ctx.serverInfo.servertype == servertype_pipewire {
+		return applyPipeWireControls(ctx, e, v)
+	}
+	return applyPulseControls(ctx, e, v)
+}
+
+// engineModules returns the indices of the ladspa modules running e.
+func engineModules(ctx *ntcontext, e engine) (map[uint32]bool, error) {
+	mods, err := ctx.paClient.ModuleList()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint32]bool)
+	for _, m := range mods {
+		if (m.Name == "module-ladspa-sink" || m.Name == "module-ladspa-source") &&
+			strings.Contains(m.Argument, "label="+e.label+" ") {
+			res[m.Index] = true
+		}
+	}
+	return res, nil
+}
+
+// applyPipeWireControls sets the control ports of the filter-chains running e, the native one
+// and those PipeWire makes of the ladspa modules.
+func applyPipeWireControls(ctx *ntcontext, e engine, v liveValues) error {
+	type node struct {
+		id     string
+		prefix string // of the control names, the name of the node in the filter graph
+		module uint32
+	}
+	var nodes []node
+	if ctx.config.NativePipeWire {
+		for _, s := range findVirtualSources(ctx) {
+			if s.Name == pipeWireNodeName {
+				nodes = append(nodes, node{s.PropList["object.id"], "rnnoise:", 0})
+			}
+		}
+	} else {
+		mods, err := engineModules(ctx, e)
+		if err != nil {
+			return err
+		}
+		sources, err := ctx.paClient.Sources()
+		if err != nil {
+			return err
+		}
+		for _, s := range sources {
+			if mods[s.ModuleIndex] {
+				nodes = append(nodes, node{s.PropList["object.id"], "", s.ModuleIndex})
+			}
+		}
+		sinks, err := ctx.paClient.Sinks()
+		if err != nil {
+			return err
+		}
+		for _, s := range sinks {
+			if mods[s.ModuleIndex] {
+				nodes = append(nodes, node{s.PropList["object.id"], "", s.ModuleIndex})
+			}
+		}
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no filter found")
+	}
+
+	for _, n := range nodes {
+		if n.id == "" {
+			return fmt.Errorf("the filter has no PipeWire object id")
+		}
+		params := fmt.Sprintf("%q %d", n.prefix+e.control.port, v.control)
+		if v.suppression >= 0 {
+			params += fmt.Sprintf(" %q %d", n.prefix+suppressionPort, v.suppression)
+		}
+		cmd := exec.Command("pw-cli", "set-param", n.id, "Props", "{ params = [ "+params+" ] }")
+		debugf("Calling: %s\n", cmd.String())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("pw-cli set-param failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		if !ctx.config.NativePipeWire {
+			rememberLiveValues(ctx, n.module, v)
+		}
+	}
+	return nil
+}
+
+// applyPulseControls sets the control ports of the ladspa sinks running e over PulseAudio's D-Bus
+// interface. All control ports have to be given, so only our RNNoise plugin works.
+func applyPulseControls(ctx *ntcontext, e engine, v liveValues) error {
+	if !e.rnnoise {
+		return fmt.Errorf("only supported for %s", engines[0].name)
+	}
+	addr, err := pulseDBusAddress()
+	if err != nil {
+		return err
+	}
+	mods, err := engineModules(ctx, e)
+	if err != nil {
+		return err
+	}
+	sinks, err := ctx.paClient.Sinks()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, s := range sinks {
+		if !mods[s.ModuleIndex] {
+			continue
+		}
+		found = true
+		cmd := exec.Command("gdbus", "call", "--address", addr,
+			"--object-path", fmt.Sprintf("/org/pulseaudio/core1/sink%d", s.Index),
+			"--method", "org.freedesktop.DBus.Properties.Set", "org.PulseAudio.Ext.Ladspa1", "AlgorithmParameters",
+			fmt.Sprintf("<([%d.0, %d.0], [false, false])>", v.control, v.suppression))
+		debugf("Calling: %s\n", cmd.String())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("gdbus call failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		rememberLiveValues(ctx, s.ModuleIndex, v)
+	}
+	if !found && len(idleInputs(ctx)) > 0 {
+		return nil // loaded again with the new values, see idle.go
+	}
+	if !found {
+		return fmt.Errorf("no filter found")
+	}
+	return nil
+}
+
+// pulseDBusAddress asks the session bus where PulseAudio's D-Bus server is. It's only there with
+// module-dbus-protocol loaded.
+func pulseDBusAddress() (string, error) {
+	cmd := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.PulseAudio1",
+		"--object-path", "/org/pulseaudio/server_lookup1",
+		"--method", "org.freedesktop.DBus.Properties.Get", "org.PulseAudio.ServerLookup1", "Address")
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("PulseAudio's D-Bus server not found: %v", err)
+	}
+	// (<'unix:path=/run/user/1000/pulse/dbus-socket'>,)
+	s := string(out)
+	start, end := strings.Index(s, "'"), strings.LastIndex(s, "'")
+	if start < 0 || end <= start {
+		return "", fmt.Errorf("unexpected answer from PulseAudio: %s", strings.TrimSpace(s))
+	}
+	return s[start+1 : end], nil
+}
+
+func rememberLiveValues(ctx *ntcontext, module uint32, v liveValues) {
+	lc := &ctx.liveControls
+	lc.Lock()
+	defer lc.Unlock()
+	if lc.modules == nil {
+		lc.modules = make(map[uint32]liveValues)
+	}
+	lc.modules[module] = v
+	if ctx.chain.threshold >= 0 {
+		ctx.chain.threshold = v.control
+	}
+	if ctx.chain.suppression >= 0 {
+		ctx.chain.suppression = v.suppression
+	}
+}
+
+// liveValuesFor returns the values set on the module since it was loaded, if any.
+func liveValuesFor(ctx *ntcontext, module uint32) (liveValues, bool) {
+	lc := &ctx.liveControls
+	lc.Lock()
+	defer lc.Unlock()
+	v, ok := lc.modules[module]
+	return v, ok
+}
+
+// effectiveValueView says what the running filter uses, if that's not the value set.
+func effectiveValueView(ctx *ntcontext, w *nucular.Window, running, value int, format string) {
+	if ctx.noiseSupressorState != loaded || running < 0 || running == value {
+		return
+	}
+	w.Row(15).Dynamic(1)
+	w.LabelColored(trf("In effect: %s, applies with the next reload", fmt.Sprintf(format, running)), "LC", orange)
+}
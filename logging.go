@@ -0,0 +1,198 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Log lines carry a level and go to a log file in the cache directory, a rolling buffer for the log
+// viewer, and with -log to stdout. Lines below -log-level are dropped everywhere.
+
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+const (
+	logBufferLines = 2000
+	maxLogSize     = 1 << 20 // bytes, before the log file is rotated
+	logBackups     = 3       // rotated files kept, noisetorch.log.1 is the newest
+)
+
+type logLine struct {
+	level int
+	text  string
+}
+
+// logBuffer is the io.Writer behind the log package.
+type logBuffer struct {
+	mu       sync.Mutex
+	minLevel int
+	out      io.Writer // stdout with -log
+	file     *os.File
+	path     string
+	size     int64
+	lines    []logLine // the last logBufferLines lines
+	partial  []byte
+	gen      int // bumped on every new line
+}
+
+var appLog = &logBuffer{minLevel: levelInfo, out: io.Discard}
+
+func parseLevel(name string) (int, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level '%s', must be one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// setupLogging routes the log package through appLog. The log file is skipped when running as
+// root for -setcap, it would end up owned by root.
+func setupLogging(level int, stdout, file bool) {
+	appLog.minLevel = level
+	if stdout {
+		appLog.out = os.Stdout
+	}
+	log.SetOutput(appLog)
+
+	if !file {
+		return
+	}
+	dir := cacheDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		warnf("Couldn't create log directory: %v\n", err)
+		return
+	}
+	appLog.mu.Lock()
+	defer appLog.mu.Unlock()
+	appLog.path = filepath.Join(dir, "noisetorch.log")
+	if err := appLog.openFile(); err != nil {
+		appLog.path = ""
+		fmt.Fprintf(os.Stderr, "Couldn't open log file: %v\n", err)
+	}
+}
+
+// cacheDir is where the log and crash reports go. Unlike configDir it doesn't log, the log isn't
+// set up yet when it's first needed.
+func cacheDir() string {
+	cache := os.Getenv("XDG_CACHE_HOME")
+	if cache == "" {
+		cache = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(cache, "noisetorch")
+}
+
+func (b *logBuffer) openFile() error {
+	if info, err := os.Stat(b.path); err == nil && info.Size() >= maxLogSize {
+		rotateLogs(b.path)
+	}
+	f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	b.file, b.size = f, info.Size()
+	return nil
+}
+
+func rotateLogs(path string) {
+	for i := logBackups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	os.Rename(path, path+".1")
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		text := string(b.partial[:i])
+		b.partial = b.partial[i+1:]
+		if len(b.lines) == logBufferLines {
+			copy(b.lines, b.lines[1:])
+			b.lines = b.lines[:len(b.lines)-1]
+		}
+		b.lines = append(b.lines, logLine{lineLevel(text), text})
+		b.gen++
+	}
+
+	if b.file != nil {
+		n, _ := b.file.Write(p)
+		b.size += int64(n)
+		if b.size >= maxLogSize {
+			b.file.Close()
+			b.file = nil
+			if err := b.openFile(); err != nil {
+				fmt.Fprintf(os.Stderr, "Couldn't rotate log file: %v\n", err)
+			}
+		}
+	}
+	return b.out.Write(p)
+}
+
+// lineLevel reads the level back from a line. Lines not logged through logf, e.g. by log.Fatalf,
+// are errors.
+func lineLevel(text string) int {
+	for i, n := range levelNames {
+		if strings.Contains(text, " ["+n+"] ") {
+			return i
+		}
+	}
+	return levelError
+}
+
+// text returns the buffered lines of at least the given level.
+func (b *logBuffer) text(level int) (string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var s strings.Builder
+	for _, l := range b.lines {
+		if l.level >= level {
+			s.WriteString(l.text)
+			s.WriteByte('\n')
+		}
+	}
+	return s.String(), b.gen
+}
+
+func logf(level int, format string, v ...interface{}) {
+	if level < appLog.minLevel {
+		return
+	}
+	log.Output(3, "["+levelNames[level]+"] "+fmt.Sprintf(format, v...))
+}
+
+// debugf is for details only needed to debug, like the commands we run and the modules we find.
+func debugf(format string, v ...interface{}) { logf(levelDebug, format, v...) }
+
+func infof(format string, v ...interface{}) { logf(levelInfo, format, v...) }
+
+// warnf is for problems we work around.
+func warnf(format string, v ...interface{}) { logf(levelWarn, format, v...) }
+
+// errorf is for problems that make something the user asked for fail.
+func errorf(format string, v ...interface{}) { logf(levelError, format, v...) }
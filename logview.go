@@ -0,0 +1,74 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/clipboard"
+)
+
+// logui is the state of the log viewer while it is open.
+type logui struct {
+	level  int
+	gen    int
+	editor nucular.TextEditor
+	status string
+}
+
+func openLogView(ctx *ntcontext) {
+	ctx.logs = logui{level: appLog.minLevel, gen: -1}
+	ctx.logs.editor.Flags = nucular.EditMultiline | nucular.EditReadOnly | nucular.EditSelectable | nucular.EditClipboard
+	ctx.views.Push(logView)
+}
+
+func logView(ctx *ntcontext, w *nucular.Window) {
+	l := &ctx.logs
+	w.Row(25).Ratio(0.3, 0.3, 0.4)
+	w.Label(tr("Logs"), "LC")
+	w.Label(tr("Minimum level"), "RC")
+	if sel := focusableCombo(ctx, w, w.ComboSimple(levelNames, l.level, 25), len(levelNames)); sel != l.level {
+		l.level = sel
+		l.gen = -1
+	}
+
+	if text, gen := appLog.text(l.level); gen != l.gen || l.gen == -1 {
+		l.gen = gen
+		l.editor.Buffer = []rune(text)
+		// follow the newest lines
+		l.editor.Cursor = len(l.editor.Buffer)
+	}
+	w.Row(255).Dynamic(1)
+	focusableEdit(ctx, w, &l.editor, l.editor.Edit(w))
+
+	w.Row(15).Dynamic(1)
+	w.Label(l.status, "LC")
+
+	w.Row(25).Dynamic(3)
+	if focusable(ctx, w, w.ButtonText(tr("Copy to clipboard"))) {
+		clipboard.Set(string(l.editor.Buffer))
+		l.status = "Copied to the clipboard."
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Save to file"))) {
+		if path, err := saveLog(string(l.editor.Buffer)); err != nil {
+			l.status = fmt.Sprintf("Couldn't save the log: %v", err)
+		} else {
+			l.status = "Saved to " + path
+		}
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) {
+		ctx.views.Pop()
+	}
+}
+
+// saveLog writes the log into the home directory, where users will find it for attaching to a report.
+func saveLog(text string) (string, error) {
+	name := fmt.Sprintf("noisetorch-%s.log", time.Now().Format("20060102-150405"))
+	path := filepath.Join(os.Getenv("HOME"), name)
+	return path, os.WriteFile(path, []byte(text), 0600)
+}
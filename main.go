@@ -6,32 +6,20 @@ package main
 import (
 	"fmt"
 	"image"
-	"io"
 	"log"
+	"net"
+	"noisetorch/buildinfo"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/BurntSushi/xgbutil"
-	"github.com/BurntSushi/xgbutil/ewmh"
-	"github.com/BurntSushi/xgbutil/icccm"
-	"github.com/aarzilli/nucular/font"
-
-	"github.com/noisetorch/pulseaudio"
-
-	_ "embed"
-
 	"github.com/aarzilli/nucular"
-	"github.com/aarzilli/nucular/style"
 )
 
 //go:generate go run scripts/embedlicenses.go
 
-//go:embed c/ladspa/rnnoise_ladspa.so
-var libRNNoise []byte
-
 type device struct {
 	ID             string
 	Name           string
@@ -39,144 +27,245 @@ type device struct {
 	checked        bool
 	dynamicLatency bool
 	rate           uint32
+	channels       int
+	channelMap     string
 }
 
 var appName = "NoiseTorch-ng"
 
-var nameSuffix = ""         // will be changed by build
-var version = "unknown"     // ditto
-var distribution = "custom" // ditto
-var updateURL = ""          // ditto
-var publicKeyString = ""    // ditto
-var websiteURL = ""         // ditto
-
 func main() {
-	if nameSuffix != "" {
-		appName += strings.Replace(nameSuffix, "_", " ", -1)
+	if buildinfo.NameSuffix != "" {
+		appName += strings.Replace(buildinfo.NameSuffix, "_", " ", -1)
 	}
 
 	opt := parseCLIOpts()
 
-	if opt.doLog {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(io.Discard)
+	if opt.buildinfo {
+		fmt.Print(buildinfo.String())
+		os.Exit(0)
+	}
+	if opt.helpAll {
+		writeHelpAll(os.Stdout)
+		os.Exit(0)
+	}
+	if opt.man {
+		writeManPage(os.Stdout)
+		os.Exit(0)
+	}
+
+	level, err := parseLevel(opt.logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	setupLogging(level, opt.doLog, !opt.setcap)
+	infof("Application starting. Version: %s (%s)\n", buildinfo.Version, buildinfo.Distribution)
+	infof("CAP_SYS_RESOURCE: %t\n", hasCapSysResource(getCurrentCaps()))
+
+	if opt.connect != "" {
+		doRemoteCLI(opt)
+		runRemoteGUI(opt.connect, remoteToken(opt), remoteFingerprint(opt))
+		return
 	}
-	log.Printf("Application starting. Version: %s (%s)\n", version, distribution)
-	log.Printf("CAP_SYS_RESOURCE: %t\n", hasCapSysResource(getCurrentCaps()))
 
 	initializeConfigIfNot()
-	rnnoisefile := dumpLib()
-	defer removeLib(rnnoisefile)
 
 	ctx := ntcontext{}
+	defer recoverCrash(&ctx)
 	ctx.config = readConfig()
+	applyLanguage(ctx.config)
+	if opt.engine != "" {
+		ctx.config.Engine = opt.engine
+	}
+	rnnoisefile, err := dumpLib(engineByID(ctx.config.Engine), ctx.config)
+	if err != nil && ctx.config.Engine != engines[0].id {
+		warnf("Couldn't load engine %s, falling back to %s: %v\n", ctx.config.Engine, engines[0].name, err)
+		fmt.Fprintf(os.Stderr, "Couldn't load engine %s, falling back to %s: %v\n", ctx.config.Engine, engines[0].name, err)
+		ctx.config.Engine = engines[0].id
+		rnnoisefile, err = dumpLib(engines[0], ctx.config)
+	}
+	if err != nil {
+		log.Fatalf("Couldn't write plugin: %v\n", err)
+	}
 	ctx.librnnoise = rnnoisefile
 
-	doCLI(opt, ctx.config, ctx.librnnoise)
+	if opt.alsa {
+		alsaCLI(&ctx, opt)
+	}
+	if opt.bench {
+		benchCLI(&ctx, opt)
+	}
 
-	if ctx.config.EnableUpdates {
-		go updateCheck(&ctx)
+	if opt.daemon {
+		instance := singleInstance(&ctx, opt)
+		pruneLibs(ctx.librnnoise)
+		if opt.metrics != "" {
+			go serveMetrics(&ctx, opt.metrics, opt.metricsPublic)
+		}
+		runDaemon(&ctx, opt, instance)
+		return
 	}
 
+	doCLI(opt, ctx.config, ctx.librnnoise)
+
+	instance := singleInstance(&ctx, opt)
+	pruneLibs(ctx.librnnoise)
+
 	ctx.haveCapabilities = hasCapSysResource(getCurrentCaps())
 	ctx.capsMismatch = hasCapSysResource(getCurrentCaps()) != hasCapSysResource(getSelfFileCaps())
 
+	ctx.mini = opt.mini || ctx.config.LastView == "mini"
 	resetUI(&ctx)
 
-	wnd := nucular.NewMasterWindowSize(0, appName, image.Point{600, 400}, func(w *nucular.Window) {
+	setWindowHints()
+	if p := ctx.config.WindowPosition; len(p) == 2 {
+		placeWindow(image.Pt(p[0], p[1]))
+	}
+	size := image.Pt(ctx.config.WindowWidth, ctx.config.WindowHeight)
+	if ctx.mini {
+		size = miniWindowSize
+	}
+	wnd := nucular.NewMasterWindowSize(0, appName, size, func(w *nucular.Window) {
 		updatefn(&ctx, w)
 	})
 
 	ctx.masterWindow = &wnd
 	(*ctx.masterWindow).Changed()
 
+	if ctx.config.EnableUpdates && updateable() {
+		go updateCheck(&ctx)
+	}
+
+	go guiInstance(&ctx, instance)
+	go closeOnSignal(&ctx)
 	go paConnectionWatchdog(&ctx)
+	go vadWatcher(&ctx)
+	go streamWatchdogLoop(&ctx)
+	go idleUnloadLoop(&ctx)
+	if buildinfo.Enabled().Tray {
+		go startTray(&ctx)
+	}
+	go cpuWatcher(&ctx)
+	go watchColorScheme(&ctx)
+	go watchWindowPosition(&ctx)
 
-	style := style.FromTheme(style.DarkTheme, 2.0)
-	style.Font = font.DefaultFont(16, 1)
-	wnd.SetStyle(style)
+	ctx.obs.changed = make(chan struct{}, 1)
+	go obsWatcher(&ctx)
 
-	//this is a disgusting hack that searches for the noisetorch window
-	//and then fixes up the WM_CLASS attribute so it displays
-	//properly in the taskbar
-	go fixWindowClass()
-	wnd.Main()
+	// picks up a moved binary after an update and removes files left over when the option was disabled
+	go func() {
+		if err := syncAutostart(&ctx); err != nil {
+			errorf("Couldn't update start on login: %v\n", err)
+		}
+	}()
 
-}
+	if buildinfo.Enabled().Hotkeys {
+		go func() {
+			if err := startHotkeys(&ctx); err != nil {
+				warnf("Hotkeys unavailable: %v\n", err)
+			}
+		}()
+	}
 
-func dumpLib() string {
-	f, err := os.CreateTemp("", "librnnoise-*.so")
-	if err != nil {
-		log.Fatalf("Couldn't open temp file for librnnoise\n")
+	if opt.listen != "" && buildinfo.Enabled().WebUI {
+		go serveControlAPI(&ctx, opt.listen)
+	}
+
+	if opt.metrics != "" {
+		go serveMetrics(&ctx, opt.metrics, opt.metricsPublic)
 	}
-	f.Write(libRNNoise)
-	log.Printf("Wrote temp librnnoise to: %s\n", f.Name())
-	return f.Name()
+
+	setUIStyle(wnd, ctx.config)
+
+	wnd.Main()
+
+	saveWindowState(&ctx)
+	stopHearMyself(&ctx)
+	exitGUI(&ctx)
+
 }
 
-func removeLib(file string) {
-	err := os.Remove(file)
+// singleInstance makes us the running instance, or exits if there is one and we don't replace it.
+func singleInstance(ctx *ntcontext, opt CLIOpts) net.Listener {
+	l, err := acquireInstance(opt.replace)
+	if running, ok := err.(errAlreadyRunning); ok && running.kind == "gui" && !opt.daemon {
+		fmt.Println("NoiseTorch-ng is already running, showing its window.")
+		cleanupExit(0)
+	}
 	if err != nil {
-		log.Printf("Couldn't delete temp librnnoise: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v. Use -replace to take over from it.\n", err)
+		cleanupExit(1)
 	}
-	log.Printf("Deleted temp librnnoise: %s\n", file)
+	return l
 }
 
-func getSources(ctx *ntcontext, client *pulseaudio.Client) []device {
+func getSources(ctx *ntcontext, client AudioServer) []device {
 	sources, err := client.Sources()
 	if err != nil {
-		log.Printf("Couldn't fetch sources from pulseaudio\n")
+		errorf("Couldn't fetch sources from pulseaudio\n")
 	}
 
 	outputs := make([]device, 0)
+	var quals [][]string
 	for i := range sources {
-		if strings.Contains(sources[i].Name, "nui_") || strings.Contains(sources[i].Name, "Filtered") {
+		if isNoiseTorchDevice(sources[i].Name, sources[i].PropList) {
 			continue
 		}
 
 		var inp device
 
 		inp.ID = sources[i].Name
-		if ctx.serverInfo.servertype == servertype_pulse {
-			inp.Name = sources[i].PropList["device.description"]
-		} else {
-			inp.Name = sources[i].Description
+		inp.Name = deviceName(ctx, sources[i].Description, sources[i].PropList)
+		port := ""
+		for _, p := range sources[i].Ports {
+			if p.Name == sources[i].ActivePortName {
+				port = p.Description
+			}
 		}
+		quals = append(quals, deviceQualifiers(port, sources[i].PropList))
 		inp.isMonitor = (sources[i].MonitorSourceIndex != 0xffffffff)
+		if inp.isMonitor && ourSinkMonitor(sources[i].MonitorSourceName) {
+			// filtering what we play into our own sinks would feed the filter back into itself
+			continue
+		}
 		inp.rate = sources[i].SampleSpec.Rate
+		inp.channels = int(sources[i].SampleSpec.Channels)
+		inp.channelMap = channelMapString(sources[i].ChannelMap)
 
 		//PA_SOURCE_DYNAMIC_LATENCY = 0x0040U
 		inp.dynamicLatency = sources[i].Flags&uint32(0x0040) != 0
 
 		outputs = append(outputs, inp)
 	}
+	dedupeNames(outputs, quals)
 
 	return outputs
 }
 
-func getSinks(ctx *ntcontext, client *pulseaudio.Client) []device {
+func getSinks(ctx *ntcontext, client AudioServer) []device {
 	sources, err := client.Sinks()
 	if err != nil {
-		log.Printf("Couldn't fetch sources from pulseaudio\n")
+		errorf("Couldn't fetch sources from pulseaudio\n")
 	}
 
 	inputs := make([]device, 0)
+	var quals [][]string
 	for i := range sources {
-		if strings.Contains(sources[i].Name, "nui_") || strings.Contains(sources[i].Name, "Filtered") {
+		if isNoiseTorchDevice(sources[i].Name, sources[i].PropList) {
 			continue
 		}
 
-		log.Printf("Output %s, %+v\n", sources[i].Name, sources[i])
-
 		var inp device
 
 		inp.ID = sources[i].Name
-		if ctx.serverInfo.servertype == servertype_pulse {
-			inp.Name = sources[i].PropList["device.description"]
-		} else {
-			inp.Name = sources[i].Description
+		inp.Name = deviceName(ctx, sources[i].Description, sources[i].PropList)
+		port := ""
+		for _, p := range sources[i].Ports {
+			if p.Name == sources[i].ActivePortName {
+				port = p.Description
+			}
 		}
+		quals = append(quals, deviceQualifiers(port, sources[i].PropList))
 		inp.rate = sources[i].SampleSpec.Rate
 
 		// PA_SINK_DYNAMIC_LATENCY = 0x0080U
@@ -184,57 +273,147 @@ func getSinks(ctx *ntcontext, client *pulseaudio.Client) []device {
 
 		inputs = append(inputs, inp)
 	}
+	dedupeNames(inputs, quals)
 
 	return inputs
 }
 
+// paConnectionWatchdog connects to the audio server and reconnects whenever the connection is lost.
 func paConnectionWatchdog(ctx *ntcontext) {
+	defer recoverCrash(ctx)
 	for {
-		if ctx.paClient.Connected() {
-			time.Sleep(500 * time.Millisecond)
-			continue
-		}
-
 		ctx.views.Push(connectView)
-		(*ctx.masterWindow).Changed()
+		showConnecting(ctx, tr("Connecting to pulseaudio..."))
 
-		paClient, err := pulseaudio.NewClient()
+		paClient, err := newAudioServer()
 		if err != nil {
-			log.Printf("Couldn't create pulseaudio client: %v\n", err)
+			errorf("Couldn't create pulseaudio client: %v\n", err)
 			fmt.Fprintf(os.Stderr, "Couldn't create pulseaudio client: %v\n", err)
+			// there is no server to subscribe to yet, retry until it shows up
+			hideConnecting(ctx)
+			ctx.views.Pop()
+			time.Sleep(500 * time.Millisecond)
+			continue
 		}
 
-		info, err := serverInfo(paClient)
-		if err != nil {
-			log.Printf("Couldn't fetch audio server info: %s\n", err)
-		}
-		ctx.serverInfo = info
+		showConnecting(ctx, tr("Loading devices..."))
+		md := fetchMetadata(ctx, paClient)
+		hideConnecting(ctx)
 
-		log.Printf("Connected to audio server. Server name '%s'\n", info.name)
+		infof("Connected to audio server. Server name '%s'\n", ctx.serverInfo.name)
+		notifyConnected(ctx)
+		if ctx.startupDone {
+			metricsReconnected(ctx)
+		}
 
 		ctx.paClient = paClient
-		go updateNoiseSupressorLoaded(ctx)
+		ctx.hotplug = hotplug{}
 
-		ctx.inputList = preselectDevice(ctx, getSources(ctx, paClient), ctx.config.LastUsedInput, getDefaultSourceID)
-		ctx.outputList = preselectDevice(ctx, getSinks(ctx, paClient), ctx.config.LastUsedOutput, getDefaultSinkID)
+		ctx.inputList = preselectInputs(ctx, md.sources, defaultFunc(md.defaultSource))
+		ctx.outputList = preselectDevice(ctx, md.sinks, ctx.config.LastUsedOutput, defaultFunc(md.defaultSink))
 
 		resetUI(ctx)
 		(*ctx.masterWindow).Changed()
+		recoveryConnected(ctx)
+
+		if !ctx.startupDone {
+			ctx.startupDone = true
+			go func() {
+				offerStaleCleanup(ctx)
+				if !ctx.config.Onboarded {
+					openOnboarding(ctx)
+					return
+				}
+				loadOnStart(ctx)
+				restoreView(ctx)
+			}()
+		}
 
-		time.Sleep(500 * time.Millisecond)
+		// returns once the connection is gone
+		updateNoiseSupressorLoaded(ctx)
+		infof("Lost connection to the audio server\n")
+		notifyConnectionLost(ctx)
+		recoveryConnectionLost(ctx)
 	}
 }
 
-func serverInfo(paClient *pulseaudio.Client) (audioserverinfo, error) {
+// refreshDevices picks up devices that were added or removed since we last looked, keeping the selection.
+func refreshDevices(ctx *ntcontext) {
+	sources := keepSelection(ctx.inputList, getSources(ctx, ctx.paClient))
+	sinks := keepSelection(ctx.outputList, getSinks(ctx, ctx.paClient))
+	// nothing was known yet, e.g. the server didn't list the devices in time at startup
+	if len(ctx.inputList) == 0 {
+		sources = preselectInputs(ctx, sources, getDefaultSourceID)
+	}
+	if len(ctx.outputList) == 0 {
+		sinks = preselectDevice(ctx, sinks, ctx.config.LastUsedOutput, getDefaultSinkID)
+	}
+
+	if def, err := getDefaultSourceID(ctx.paClient); err == nil && def != ctx.defaultSource {
+		infof("Default source changed to %s\n", def)
+		ctx.defaultSource = def
+		// follow the default source, unless the user picked a microphone
+		anyChecked := false
+		for _, d := range sources {
+			anyChecked = anyChecked || d.checked
+		}
+		if !anyChecked {
+			sources = preselectDevice(ctx, sources, def, getDefaultSourceID)
+		}
+	}
+
+	if !sameDevices(ctx.inputList, sources) || !sameDevices(ctx.outputList, sinks) {
+		ctx.inputList = sources
+		ctx.outputList = sinks
+		ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
+		(*ctx.masterWindow).Changed()
+	}
+}
+
+// preselectInputs checks the microphones filtered last time.
+func preselectInputs(ctx *ntcontext, sources []device, fallback func(AudioServer) (string, error)) []device {
+	sources = preselectDevice(ctx, sources, ctx.config.LastUsedInput, fallback)
+	for i := range sources {
+		for _, id := range ctx.config.AdditionalInputs {
+			sources[i].checked = sources[i].checked || sources[i].ID == id
+		}
+	}
+	return sources
+}
+
+func keepSelection(old, fresh []device) []device {
+	for i := range fresh {
+		for _, d := range old {
+			if d.ID == fresh[i].ID {
+				fresh[i].checked = d.checked
+			}
+		}
+	}
+	return fresh
+}
+
+func sameDevices(a, b []device) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func serverInfo(paClient AudioServer) (audioserverinfo, error) {
 	info, err := paClient.ServerInfo()
 	if err != nil {
-		log.Printf("Couldn't fetch pulse server info: %v\n", err)
+		errorf("Couldn't fetch pulse server info: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Couldn't fetch pulse server info: %v\n", err)
 	}
 
 	pkgname := info.PackageName
-	log.Printf("Audioserver package name: %s\n", pkgname)
-	log.Printf("Audioserver package version: %s\n", info.PackageVersion)
+	debugf("Audioserver package name: %s\n", pkgname)
+	debugf("Audioserver package version: %s\n", info.PackageVersion)
 	isPipewire := strings.Contains(pkgname, "PipeWire")
 
 	var servername string
@@ -250,18 +429,18 @@ func serverInfo(paClient *pulseaudio.Client) (audioserverinfo, error) {
 		servertype = servertype_pipewire
 		versionRegex = regexp.MustCompile(`.*?on PipeWire (\d+)\.(\d+)\.(\d+).*?`)
 		versionString = pkgname
-		log.Printf("Detected PipeWire\n")
+		infof("Detected PipeWire\n")
 	} else {
 		servername = "PulseAudio"
 		servertype = servertype_pulse
 		versionRegex = regexp.MustCompile(`.*?(\d+)\.(\d+)\.?(\d+)?.*?`)
 		versionString = info.PackageVersion
-		log.Printf("Detected PulseAudio\n")
+		infof("Detected PulseAudio\n")
 	}
 
 	res := versionRegex.FindStringSubmatch(versionString)
 	if len(res) != 4 {
-		log.Printf("couldn't parse server version, regexp didn't match version: %s\n", versionString)
+		warnf("couldn't parse server version, regexp didn't match version: %s\n", versionString)
 		return audioserverinfo{servertype: servertype}, nil
 	}
 	// the server version did not match the standard `major.minor.patch` pattern
@@ -282,11 +461,17 @@ func serverInfo(paClient *pulseaudio.Client) (audioserverinfo, error) {
 		return audioserverinfo{servertype: servertype}, err
 	}
 	if isPipewire && major <= 0 && minor <= 3 && patch < 28 {
-		log.Printf("pipewire version %d.%d.%d too old.\n", major, minor, patch)
+		warnf("pipewire version %d.%d.%d too old.\n", major, minor, patch)
 		outdatedPipeWire = true
 	}
 
+	jack := jackServer(servertype)
+	if jack != "" {
+		infof("Detected JACK (%s)\n", jack)
+	}
+
 	return audioserverinfo{
+		jack:             jack,
 		servertype:       servertype,
 		name:             servername,
 		major:            major,
@@ -296,7 +481,7 @@ func serverInfo(paClient *pulseaudio.Client) (audioserverinfo, error) {
 }
 
 func preselectDevice(ctx *ntcontext, devices []device, preselectID string,
-	fallbackFunc func(client *pulseaudio.Client) (string, error)) []device {
+	fallbackFunc func(client AudioServer) (string, error)) []device {
 
 	deviceExists := false
 	for _, input := range devices {
@@ -306,7 +491,7 @@ func preselectDevice(ctx *ntcontext, devices []device, preselectID string,
 	if !deviceExists {
 		defaultDevice, err := fallbackFunc(ctx.paClient)
 		if err != nil {
-			log.Printf("Failed to load default device: %+v\n", err)
+			errorf("Failed to load default device: %+v\n", err)
 		} else {
 			preselectID = defaultDevice
 		}
@@ -319,7 +504,7 @@ func preselectDevice(ctx *ntcontext, devices []device, preselectID string,
 	return devices
 }
 
-func getDefaultSourceID(client *pulseaudio.Client) (string, error) {
+func getDefaultSourceID(client AudioServer) (string, error) {
 	server, err := client.ServerInfo()
 	if err != nil {
 		return "", err
@@ -327,42 +512,10 @@ func getDefaultSourceID(client *pulseaudio.Client) (string, error) {
 	return server.DefaultSource, nil
 }
 
-func getDefaultSinkID(client *pulseaudio.Client) (string, error) {
+func getDefaultSinkID(client AudioServer) (string, error) {
 	server, err := client.ServerInfo()
 	if err != nil {
 		return "", err
 	}
 	return server.DefaultSink, nil
 }
-
-// this is disgusting
-func fixWindowClass() {
-	xu, err := xgbutil.NewConn()
-	defer xu.Conn().Close()
-	if err != nil {
-		log.Printf("Couldn't create XU xdg conn: %+v\n", err)
-		return
-	}
-	for i := 0; i < 100; i++ {
-		wnds, _ := ewmh.ClientListGet(xu)
-		for _, w := range wnds {
-			n, _ := ewmh.WmNameGet(xu, w)
-			if n == appName {
-				_, err := icccm.WmClassGet(xu, w)
-				//if we have *NO* WM_CLASS, then the above call errors. We *want* to make sure this errors
-				if err == nil {
-					continue
-				}
-
-				class := icccm.WmClass{}
-				class.Class = appName
-				class.Instance = appName
-				icccm.WmClassSet(xu, w, &class)
-				return
-			}
-
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-}
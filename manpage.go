@@ -0,0 +1,180 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"noisetorch/buildinfo"
+)
+
+// `noisetorch -help-all` and `noisetorch -man` document every command and flag, generated from the
+// subcommand table and the flag definitions like the completion scripts, so they can't drift from
+// what the binary accepts. `make man` writes the man page to bin/noisetorch.1.
+
+type example struct {
+	cmd  string
+	help string
+}
+
+var examples = []example{
+	{"noisetorch devices", "List the device IDs of the microphones and headphones"},
+	{"noisetorch load -s DEVICE -t 80", "Filter the microphone DEVICE with a voice activation threshold of 80, without opening the window"},
+	{"noisetorch -i -s DEVICE -t 80", "The same with the flags of older versions, for existing scripts"},
+	{"noisetorch -i", "Filter the default microphone with the saved threshold"},
+	{"noisetorch -o -s DEVICE", "Filter what plays on the headphones DEVICE"},
+	{"noisetorch -u", "Unload all filters"},
+	{"noisetorch -daemon -s DEVICE -t 80", "Keep the filter loaded without a window, e.g. from a systemd user service"},
+	{"noisetorch preset apply Meeting", "Load the devices and settings of the preset Meeting"},
+	{"noisetorch config set Threshold 80", "Change a setting from the terminal"},
+	{"noisetorch alsa -capture hw:1,0 -playback hw:Loopback,0", "Filter a microphone without an audio server, applications record from hw:Loopback,1"},
+	{"noisetorch bench", "Find out whether this machine, e.g. a Raspberry Pi, is fast enough for the filter"},
+	{"noisetorch selftest", "Check that the filter removes noise, without using the microphone"},
+}
+
+// flagDoc is one flag as the help shows it.
+type flagDoc struct {
+	name  string
+	arg   string // name of the value, "" for boolean flags
+	usage string
+	def   string // "" if the zero value
+}
+
+func flagDocs(fs *flag.FlagSet, include func(name string) bool) []flagDoc {
+	var res []flagDoc
+	fs.VisitAll(func(f *flag.Flag) {
+		if !include(f.Name) {
+			return
+		}
+		arg, usage := flag.UnquoteUsage(f)
+		d := flagDoc{name: f.Name, arg: arg, usage: usage}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			d.def = f.DefValue
+		}
+		res = append(res, d)
+	})
+	return res
+}
+
+func subcommandFlagDocs(c subcommand) []flagDoc {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	if c.flags != nil {
+		c.flags(fs, &CLIOpts{})
+	}
+	return flagDocs(fs, func(string) bool { return true })
+}
+
+func globalFlagDocs() []flagDoc {
+	return flagDocs(flag.CommandLine, func(name string) bool { return !legacyFlags[name] && name != "setcap" })
+}
+
+func legacyFlagDocs() []flagDoc {
+	return flagDocs(flag.CommandLine, func(name string) bool { return legacyFlags[name] })
+}
+
+func writeHelpFlags(w io.Writer, indent string, flags []flagDoc) {
+	for _, f := range flags {
+		fmt.Fprintf(w, "%s-%s", indent, f.name)
+		if f.arg != "" {
+			fmt.Fprintf(w, " %s", f.arg)
+		}
+		fmt.Fprintf(w, "\n%s    %s", indent, tr(f.usage))
+		if f.def != "" {
+			fmt.Fprintf(w, tr(" (default %s)"), f.def)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// writeHelpAll prints the help of all commands with their flags, the global flags and examples.
+func writeHelpAll(w io.Writer) {
+	fmt.Fprintf(w, tr("Usage: %s [flags]              start the GUI\n"), os.Args[0])
+	fmt.Fprintf(w, tr("       %s [flags] COMMAND [command flags] [args]\n\nCommands:\n"), os.Args[0])
+	for _, c := range subcommands {
+		fmt.Fprintf(w, "\n  %s", c.name)
+		if c.args != "" {
+			fmt.Fprintf(w, " %s", c.args)
+		}
+		fmt.Fprintf(w, "\n      %s\n", tr(c.help))
+		writeHelpFlags(w, "      ", subcommandFlagDocs(c))
+	}
+	fmt.Fprintf(w, tr("\nFlags:\n"))
+	writeHelpFlags(w, "  ", globalFlagDocs())
+	fmt.Fprintf(w, tr("\nFlags of older versions, still accepted:\n"))
+	writeHelpFlags(w, "  ", legacyFlagDocs())
+	fmt.Fprintf(w, tr("\nExamples:\n"))
+	for _, e := range examples {
+		fmt.Fprintf(w, "  %s\n      %s\n", e.cmd, tr(e.help))
+	}
+}
+
+// roff escapes s for a man page.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+func writeManFlags(w io.Writer, flags []flagDoc) {
+	for _, f := range flags {
+		if f.arg != "" {
+			fmt.Fprintf(w, ".TP\n.BI \"\\-%s \" %s\n", roff(f.name), roff(f.arg))
+		} else {
+			fmt.Fprintf(w, ".TP\n.B \\-%s\n", roff(f.name))
+		}
+		fmt.Fprintf(w, "%s", roff(f.usage))
+		if f.def != "" {
+			fmt.Fprintf(w, " (default %s)", roff(f.def))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// writeManPage prints the man page in roff, in English like the flag definitions.
+func writeManPage(w io.Writer) {
+	fmt.Fprintf(w, ".TH NOISETORCH 1 \"\" \"%s %s\" \"User Commands\"\n", roff(appName), roff(buildinfo.Version))
+	fmt.Fprintf(w, ".SH NAME\nnoisetorch \\- real-time microphone noise suppression for PulseAudio and PipeWire\n")
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B noisetorch\n[\\fIflags\\fR]\n.br\n.B noisetorch\n"+
+		"[\\fIflags\\fR] \\fICOMMAND\\fR [\\fIcommand flags\\fR] [\\fIargs\\fR]\n")
+	fmt.Fprintf(w, ".SH DESCRIPTION\n%s creates a virtual microphone that suppresses noise in any application. "+
+		"Without a command it opens its window, the commands load and manage the filter from the terminal "+
+		"and scripts.\n", roff(appName))
+
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, c := range subcommands {
+		fmt.Fprintf(w, ".TP\n.B %s", roff(c.name))
+		if c.args != "" {
+			fmt.Fprintf(w, "\n.I %s", roff(c.args))
+		}
+		fmt.Fprintf(w, "\n%s\n", roff(c.help))
+		if flags := subcommandFlagDocs(c); len(flags) > 0 {
+			fmt.Fprintf(w, ".RS\n")
+			writeManFlags(w, flags)
+			fmt.Fprintf(w, ".RE\n")
+		}
+	}
+
+	fmt.Fprintf(w, ".SH OPTIONS\n")
+	writeManFlags(w, globalFlagDocs())
+	fmt.Fprintf(w, ".SH LEGACY OPTIONS\nThe flags of older versions are still accepted, the commands replace them.\n")
+	writeManFlags(w, legacyFlagDocs())
+
+	fmt.Fprintf(w, ".SH EXAMPLES\n")
+	for _, e := range examples {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roff(e.cmd), roff(e.help))
+	}
+	fmt.Fprintf(w, ".SH FILES\n.TP\n.I $XDG_CONFIG_HOME/noisetorch/%s\nThe settings, see \\fBnoisetorch config\\fR. "+
+		"Defaults to ~/.config if XDG_CONFIG_HOME is unset.\n", configFile)
+	fmt.Fprintf(w, ".TP\n.I ~/.cache/noisetorch/noisetorch.log\nThe log.\n")
+	fmt.Fprintf(w, ".SH SEE ALSO\n.BR pactl (1),\n.BR pipewire (1)\n")
+	if buildinfo.WebsiteURL != "" {
+		fmt.Fprintf(w, ".PP\n%s\n", roff(buildinfo.WebsiteURL))
+	}
+}
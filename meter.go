@@ -0,0 +1,194 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+const (
+	meterRate   = 16000
+	meterWindow = meterRate / 20 // 50ms
+	meterFloor  = -60.0          // dBFS shown as an empty meter
+)
+
+// levelMeter records from a source and keeps track of its current peak level.
+// The pulseaudio library we use can't open record streams, so we let parec do that.
+type levelMeter struct {
+	cmd      *exec.Cmd
+	level    uint32 // 0-100, accessed atomically
+	switches uint32 // how often the level went from silence to sound or back, accessed atomically
+	started  time.Time
+}
+
+func startLevelMeter(source string) (*levelMeter, error) {
+	cmd := audioCommand("parec", "--raw", "--format=float32le", "--channels=1",
+		fmt.Sprintf("--rate=%d", meterRate), "--latency-msec=50", "--client-name=NoiseTorch level meter", "-d", source)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("couldn't start parec: %w", err)
+	}
+
+	m := &levelMeter{cmd: cmd, started: time.Now()}
+	go m.run(bufio.NewReader(stdout))
+	return m, nil
+}
+
+func (m *levelMeter) run(r io.Reader) {
+	buf := make([]byte, meterWindow*4)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			atomic.StoreUint32(&m.level, 0)
+			m.cmd.Wait()
+			return
+		}
+		var peak float64
+		for i := 0; i < len(buf); i += 4 {
+			s := math.Abs(float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i:]))))
+			peak = math.Max(peak, s)
+		}
+		level := uint32(peakToPercent(peak))
+		if (level == 0) != (atomic.LoadUint32(&m.level) == 0) {
+			atomic.AddUint32(&m.switches, 1)
+		}
+		atomic.StoreUint32(&m.level, level)
+	}
+}
+
+func peakToPercent(peak float64) int {
+	if peak <= 0 {
+		return 0
+	}
+	db := 20 * math.Log10(peak)
+	if db < meterFloor {
+		return 0
+	}
+	if db > 0 {
+		return 100
+	}
+	return int(100 * (1 - db/meterFloor))
+}
+
+func (m *levelMeter) Level() int {
+	if m == nil {
+		return 0
+	}
+	return int(atomic.LoadUint32(&m.level))
+}
+
+func (m *levelMeter) Stop() {
+	if m == nil || m.cmd.Process == nil {
+		return
+	}
+	m.cmd.Process.Kill()
+}
+
+type meters struct {
+	enabled  bool
+	device   device
+	raw      *levelMeter
+	filtered *levelMeter
+	stop     chan struct{}
+}
+
+func startMeters(ctx *ntcontext) {
+	inp, ok := inputSelection(ctx)
+	if !ok {
+		return
+	}
+	virt, ok := findVirtualSource(ctx, &inp)
+	if !ok {
+		errorf("Couldn't start level meters: filtered microphone is not loaded\n")
+		return
+	}
+
+	raw, err := startLevelMeter(inp.ID)
+	if err != nil {
+		errorf("Couldn't start level meter: %v\n", err)
+		return
+	}
+	filtered, err := startLevelMeter(virt.Name)
+	if err != nil {
+		raw.Stop()
+		errorf("Couldn't start level meter: %v\n", err)
+		return
+	}
+
+	if !ctx.meters.enabled { // toggled off while we were starting
+		raw.Stop()
+		filtered.Stop()
+		return
+	}
+	stop := make(chan struct{})
+	ctx.meters.raw, ctx.meters.filtered, ctx.meters.stop = raw, filtered, stop
+	ctx.meters.device = inp
+	go func() {
+		t := time.NewTicker(time.Second / 15)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				(*ctx.masterWindow).Changed()
+			}
+		}
+	}()
+}
+
+func stopMeters(ctx *ntcontext) {
+	if ctx.meters.stop != nil {
+		close(ctx.meters.stop)
+	}
+	if f := ctx.meters.filtered; f != nil {
+		// the filtered signal is digital silence whenever the gate is closed
+		recordGateActivity(ctx, &ctx.meters.device, int(atomic.LoadUint32(&f.switches)), int(time.Since(f.started).Seconds()))
+		go writeConfig(ctx.config)
+	}
+	ctx.meters.raw.Stop()
+	ctx.meters.filtered.Stop()
+	ctx.meters.raw, ctx.meters.filtered, ctx.meters.stop = nil, nil, nil
+}
+
+func metersView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(25).Ratio(0.7, 0.3)
+	toggled := focusable(ctx, w, w.CheckboxText(tr("Show level meters"), &ctx.meters.enabled))
+	if focusable(ctx, w, w.ButtonText(tr("Spectrogram"))) {
+		openSpectrogram(ctx)
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Shows what the filter removes, e.g. the hum of a fan."))
+	}
+	if toggled {
+		if ctx.meters.enabled {
+			go startMeters(ctx)
+		} else {
+			stopMeters(ctx)
+		}
+	}
+	if !ctx.meters.enabled {
+		return
+	}
+
+	raw, filtered := ctx.meters.raw.Level(), ctx.meters.filtered.Level()
+	w.Row(15).Ratio(0.2, 0.8)
+	w.Label(tr("Raw"), "LC")
+	w.Progress(&raw, 100, false)
+	w.Row(15).Ratio(0.2, 0.8)
+	w.Label(tr("Filtered"), "LC")
+	w.Progress(&filtered, 100, false)
+}
@@ -0,0 +1,183 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// With -metrics, the GUI or the daemon serve /metrics in the Prometheus text format, so the health
+// of the microphone can be graphed, e.g. in Grafana. Unlike the control API there's no
+// authentication, so it only listens on loopback addresses unless -metrics-public is given.
+//
+// A dropout is the filter not processing audio for more than a second while an application records
+// from the filtered microphone. It publishes its voice activity as it processes, see vad.go, that
+// stopping is how we notice.
+
+const (
+	metricsSampleInterval = 100 * time.Millisecond
+	// the voice activity ratio is over this long
+	metricsVADWindow = time.Minute
+)
+
+type metrics struct {
+	sync.Mutex
+	loaded        bool
+	inUse         bool
+	reconnects    int
+	dropouts      int
+	watchdog      int     // reloads of a stuck chain
+	processing    bool    // the filter published its voice activity recently
+	voiceSeconds  float64 // while loaded
+	activeSeconds float64 // while loaded and processing
+	window        []bool  // gate open per sample over metricsVADWindow, newest last
+	cpu           float64
+	cpuKnown      bool
+}
+
+// metricsReconnected counts reconnects to the audio server.
+func metricsReconnected(ctx *ntcontext) {
+	m := &ctx.metrics
+	m.Lock()
+	m.reconnects++
+	m.Unlock()
+}
+
+// metricsWatchdogReloaded counts reloads by the stream watchdog.
+func metricsWatchdogReloaded(ctx *ntcontext) {
+	m := &ctx.metrics
+	m.Lock()
+	m.watchdog++
+	m.Unlock()
+}
+
+// metricsWatcher samples what the metrics are computed from.
+func metricsWatcher(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	m := &ctx.metrics
+	lastState, lastCPU := time.Time{}, time.Time{}
+	windowSize := int(metricsVADWindow / metricsSampleInterval)
+	for {
+		time.Sleep(metricsSampleInterval)
+		if !connected(ctx) {
+			continue
+		}
+		if time.Since(lastState) >= time.Second {
+			// the daemon doesn't keep ctx.noiseSupressorState up to date, ask the server
+			state, inUse := supressorState(ctx)
+			m.Lock()
+			m.loaded, m.inUse = state == loaded, inUse
+			m.Unlock()
+			lastState = time.Now()
+		}
+		if time.Since(lastCPU) >= cpuSampleInterval {
+			lastCPU = time.Now()
+			if pid, err := filterHostPid(ctx); err == nil {
+				if usage, err := cpuUsage(pid, time.Second); err == nil {
+					m.Lock()
+					m.cpu, m.cpuKnown = usage, true
+					m.Unlock()
+				}
+			}
+		}
+
+		status, ok := readVADStatus()
+		m.Lock()
+		if !m.loaded {
+			m.processing = false
+			m.window = m.window[:0]
+			m.Unlock()
+			continue
+		}
+		if m.processing && !ok && m.inUse {
+			m.dropouts++
+			warnf("The filter stopped processing audio while the filtered microphone is in use\n")
+		}
+		m.processing = ok
+		if ok {
+			m.activeSeconds += metricsSampleInterval.Seconds()
+			if status.gateOpen {
+				m.voiceSeconds += metricsSampleInterval.Seconds()
+			}
+			m.window = append(m.window, status.gateOpen)
+			if len(m.window) > windowSize {
+				m.window = m.window[len(m.window)-windowSize:]
+			}
+		}
+		m.Unlock()
+	}
+}
+
+func boolMetric(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (m *metrics) write(w http.ResponseWriter) {
+	m.Lock()
+	defer m.Unlock()
+	metric := func(name, kind, help string, value interface{}) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	metric("noisetorch_filter_loaded", "gauge", "Whether the filter is loaded.", boolMetric(m.loaded))
+	metric("noisetorch_filter_in_use", "gauge", "Whether an application records from the filtered microphone.", boolMetric(m.inUse))
+	metric("noisetorch_filter_processing", "gauge", "Whether the filter processed audio within the last second.", boolMetric(m.processing))
+	voice := 0.0
+	for _, open := range m.window {
+		if open {
+			voice++
+		}
+	}
+	if len(m.window) > 0 {
+		voice /= float64(len(m.window))
+	}
+	metric("noisetorch_voice_activity_ratio", "gauge", "Share of the last minute of processed audio the filter let through as voice.", voice)
+	metric("noisetorch_voice_seconds_total", "counter", "Seconds of audio the filter let through as voice.", m.voiceSeconds)
+	metric("noisetorch_processed_seconds_total", "counter", "Seconds the filter processed audio.", m.activeSeconds)
+	metric("noisetorch_dropouts_total", "counter", "Times the filter stopped processing audio while the filtered microphone was in use.", m.dropouts)
+	metric("noisetorch_watchdog_reloads_total", "counter", "Times the filter was reloaded because the filtered microphone got stuck.", m.watchdog)
+	metric("noisetorch_reconnects_total", "counter", "Reconnects to the audio server.", m.reconnects)
+	if m.cpuKnown {
+		metric("noisetorch_filter_host_cpu_percent", "gauge", "CPU usage of the process running the filter, in percent of one core.", m.cpu)
+	}
+}
+
+// loopbackAddr reports whether addr only listens on this machine. Without a host it listens everywhere.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func serveMetrics(ctx *ntcontext, addr string, public bool) {
+	if !public && !loopbackAddr(addr) {
+		errorf("Not serving metrics on %s, it isn't a loopback address\n", addr)
+		fmt.Fprintf(os.Stderr, "Refusing to serve metrics on %s, anyone reaching it could read them. Use a loopback address like 127.0.0.1, or add -metrics-public.\n", addr)
+		return
+	}
+	go metricsWatcher(ctx)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		ctx.metrics.write(w)
+	})
+	infof("Serving metrics on http://%s/metrics\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		errorf("Metrics endpoint stopped: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Couldn't serve metrics on %s: %v\n", addr, err)
+	}
+}
@@ -0,0 +1,97 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"image"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The mini view is for keeping NoiseTorch-ng in a corner during calls: the microphone, its level,
+// switching the filter on and off and muting, with the full view one click away. The window
+// shrinks to fit while it's shown and gets its size back afterwards.
+
+var miniWindowSize = image.Point{300, 110}
+
+func openMiniView(ctx *ntcontext) {
+	if ctx.mini {
+		return
+	}
+	ctx.mini = true
+	ctx.views.Push(miniView)
+	resizeWindow(ctx, miniWindowSize)
+	(*ctx.masterWindow).Changed()
+}
+
+func closeMiniView(ctx *ntcontext) {
+	ctx.mini = false
+	ctx.views.Pop()
+	ctx.window.mu.Lock()
+	size := ctx.window.size
+	ctx.window.mu.Unlock()
+	if size == (image.Point{}) {
+		// started in the mini view
+		size = image.Pt(ctx.config.WindowWidth, ctx.config.WindowHeight)
+	}
+	resizeWindow(ctx, size)
+	(*ctx.masterWindow).Changed()
+}
+
+func miniView(ctx *ntcontext, w *nucular.Window) {
+	inp, inpOk := inputSelection(ctx)
+	_, outOk := outputSelection(ctx)
+	filtering := ctx.noiseSupressorState == loaded
+	input := filtering && ctx.config.FilterInput && !jackMode(ctx)
+
+	w.Row(20).Ratio(0.7, 0.3)
+	name := tr("No microphone selected")
+	if inpOk {
+		name = inp.Name
+	}
+	switch {
+	case ctx.noiseSupressorState == inconsistent:
+		w.LabelColored(name, "LC", orange)
+	case filtering:
+		w.LabelColored(name, "LC", green)
+	default:
+		w.Label(name, "LC")
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Full view"))) {
+		closeMiniView(ctx)
+		return
+	}
+
+	// the filtered level, the meters only run while the filter is loaded
+	if input && !ctx.meters.enabled {
+		ctx.meters.enabled = true
+		go startMeters(ctx)
+	} else if !input && ctx.meters.enabled {
+		ctx.meters.enabled = false
+		stopMeters(ctx)
+	}
+	level := ctx.meters.filtered.Level()
+	w.Row(10).Dynamic(1)
+	w.Progress(&level, 100, false)
+
+	w.Row(25).Dynamic(2)
+	if validConfiguration(ctx, inpOk, outOk) || ctx.noiseSupressorState == inconsistent {
+		if focusable(ctx, w, w.CheckboxText(tr("Filter"), &filtering)) {
+			go uiToggleFilters(ctx)
+		}
+	} else {
+		w.Spacing(1)
+	}
+	if input {
+		txt := tr("Mute")
+		if ctx.muted && !coughing(ctx) {
+			txt = tr("Unmute")
+		}
+		if focusable(ctx, w, w.ButtonText(txt)) {
+			go uiToggleMute(ctx)
+		}
+	} else {
+		w.Spacing(1)
+	}
+}
@@ -0,0 +1,153 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The plugin looks for "<path of the plugin>.rnnn" when it is instantiated and falls back to
+// the built-in model if there is none. Keep in sync with module.c
+const modelSuffix = ".rnnn"
+
+const modelHeader = "rnnoise-nu model file version 1"
+
+const maxModelSize = 16 * 1024 * 1024
+
+func modelsDir() string {
+	return filepath.Join(configDir(), "models")
+}
+
+// listModels returns the model files users put into modelsDir.
+func listModels() []string {
+	files, err := filepath.Glob(filepath.Join(modelsDir(), "*"+modelSuffix))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(files)
+	return files
+}
+
+func modelName(path string) string {
+	if path == "" {
+		return "Built-in"
+	}
+	return strings.TrimSuffix(filepath.Base(path), modelSuffix)
+}
+
+// validateModel checks that path is an RNNoise model in the text format rnnoise_model_from_file reads.
+func validateModel(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Size() > maxModelSize {
+		return fmt.Errorf("%s is too large to be an RNNoise model", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	if !s.Scan() || strings.TrimSpace(s.Text()) != modelHeader {
+		return fmt.Errorf("%s is not an RNNoise model (expected header %q)", path, modelHeader)
+	}
+	s.Split(bufio.ScanWords)
+	n := 0
+	for s.Scan() {
+		if _, err := strconv.Atoi(s.Text()); err != nil {
+			return fmt.Errorf("%s is corrupt: unexpected %q", path, s.Text())
+		}
+		n++
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s contains no weights", path)
+	}
+	return nil
+}
+
+// installModel places the configured model next to the plugin at lib, or removes it to use
+// the built-in model.
+func installModel(lib, model string) error {
+	target := lib + modelSuffix
+	if model == "" {
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := validateModel(model); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(model)
+	if err != nil {
+		return err
+	}
+	// a running chain may instantiate the plugin at any time, never let it see a partial file
+	if err := ioutil.WriteFile(target+".tmp", data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(target+".tmp", target); err != nil {
+		return err
+	}
+	infof("Installed model %s for %s\n", model, lib)
+	return nil
+}
+
+func modelView(ctx *ntcontext, w *nucular.Window) {
+	models := listModels()
+	names := []string{modelName("")}
+	selected := 0
+	for i, m := range models {
+		names = append(names, modelName(m))
+		if m == ctx.config.Model {
+			selected = i + 1
+		}
+	}
+
+	w.Row(25).Ratio(0.5, 0.5)
+	w.Label(tr("Denoise Model"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(trf("Put RNNoise model files (.rnnn) into %s to choose them here.", modelsDir()))
+	}
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected {
+		model := ""
+		if sel > 0 {
+			model = models[sel-1]
+		}
+		if model != "" {
+			if err := validateModel(model); err != nil {
+				warnf("Rejecting model: %v\n", err)
+				ctx.views.Push(makeErrorView(ctx, err.Error()))
+				return
+			}
+		}
+		ctx.config.Model = model
+		ctx.reloadRequired = true
+		go writeConfig(ctx.config)
+	}
+}
+
+// activeModel returns the model file to install, model files only exist for RNNoise.
+func activeModel(ctx *ntcontext) string {
+	if !currentEngine(ctx).rnnoise {
+		return ""
+	}
+	return ctx.config.Model
+}
@@ -5,8 +5,9 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"hash/fnv"
 	"strings"
+	"time"
 
 	"github.com/noisetorch/pulseaudio"
 )
@@ -19,6 +20,11 @@ const (
 
 // the ugly and (partially) repeated strings are unforunately difficult to avoid, as it's what pulse audio expects
 
+// our pulseaudio library doesn't tell us when the connection drops, this is how often we check
+const connectionCheckInterval = 2 * time.Second
+
+// updateNoiseSupressorLoaded keeps the state shown in the UI in sync with the audio server, reacting
+// to its change events. It returns when the connection is lost.
 func updateNoiseSupressorLoaded(ctx *ntcontext) {
 	c := ctx.paClient
 	upd, err := c.Updates()
@@ -27,53 +33,83 @@ func updateNoiseSupressorLoaded(ctx *ntcontext) {
 	}
 
 	for {
+		refreshDevices(ctx)
+		trackHotplug(ctx)
+		prev := ctx.noiseSupressorState
 		ctx.noiseSupressorState, ctx.virtualDeviceInUse = supressorState(ctx)
-		if !c.Connected() {
-			break
+		if prev != loaded && ctx.noiseSupressorState == loaded && ctx.config.NativePipeWire && ctx.config.OutputGain != 0 {
+			// the filter-chain creates its source asynchronously, so the gain can only be set once it shows up
+			if err := applyGain(ctx); err != nil {
+				errorf("Couldn't apply gain: %v\n", err)
+			}
+		}
+		if chain, err := getRunningChain(ctx); err == nil {
+			ctx.chain = chain
+		}
+		notifyFilterState(ctx)
+		trackRecovery(ctx)
+		checkIdle(ctx)
+		updateConflicts(ctx)
+		ctx.muted = virtualSourceMuted(ctx)
+		updateRouting(ctx)
+		updateOutputRouting(ctx)
+		(*ctx.masterWindow).Changed()
+
+		if !waitForUpdate(c, upd) {
+			return
 		}
+	}
+}
 
-		<-upd
+// waitForUpdate blocks until the audio server reports a change, or returns false if the connection is lost.
+func waitForUpdate(c AudioServer, upd <-chan struct{}) bool {
+	for {
+		select {
+		case <-upd:
+			return true
+		case <-time.After(connectionCheckInterval):
+			if !c.Connected() {
+				return false
+			}
+		}
 	}
 }
 
 func supressorState(ctx *ntcontext) (int, bool) {
+	if jackMode(ctx) {
+		if jackClientLoaded() {
+			return loaded, false
+		}
+		return unloaded, false
+	}
+	if inProcessMode(ctx) {
+		return inProcessState(ctx)
+	}
 	//perform some checks to see if it looks like the noise supressor is loaded
-	c := ctx.paClient
 	var inpLoaded, outLoaded, inputInc, outputInc bool
 	var virtualDeviceInUse bool = false
 	if ctx.config.FilterInput {
-		if ctx.serverInfo.servertype == servertype_pipewire {
-			module, ladspasource, err := findModule(c, "module-ladspa-source", "source_name='Filtered Microphone")
-			if err != nil {
-				log.Printf("Couldn't fetch module list to check for module-ladspa-source: %v\n", err)
-			}
-			virtualDeviceInUse = virtualDeviceInUse || (module.NUsed != 0)
-			inpLoaded = ladspasource
+		if ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire {
+			inpLoaded, virtualDeviceInUse = pipeWireNativeInputLoaded(ctx)
 			inputInc = false
 		} else {
-			_, nullsink, err := findModule(c, "module-null-sink", "sink_name=nui_mic_denoised_out")
-			if err != nil {
-				log.Printf("Couldn't fetch module list to check for module-null-sink: %v\n", err)
-			}
-			_, ladspasink, err := findModule(c, "module-ladspa-sink", "sink_name=nui_mic_raw_in sink_master=nui_mic_denoised_out")
-			if err != nil {
-				log.Printf("Couldn't fetch module list to check for module-ladspa-sink: %v\n", err)
+			inps := inputSelections(ctx)
+			if len(inps) == 0 {
+				// nothing selected, e.g. when running headless: look for any input chain
+				inpLoaded, virtualDeviceInUse = anyInputChainLoaded(ctx)
 			}
-			_, loopback, err := findModule(c, "module-loopback", "sink=nui_mic_raw_in")
-			if err != nil {
-				log.Printf("Couldn't fetch module list to check for module-loopback: %v\n", err)
+			nLoaded := 0
+			for i := range inps {
+				loaded, inc, inUse := inputChainState(ctx, &inps[i])
+				if loaded {
+					nLoaded++
+				}
+				inputInc = inputInc || inc
+				virtualDeviceInUse = virtualDeviceInUse || inUse
 			}
-			module, remap, err := findModule(c, "module-remap-source", "master=nui_mic_denoised_out.monitor source_name=nui_mic_remap")
-			if err != nil {
-				log.Printf("Couldn't fetch module list to check for module-remap-source: %v\n", err)
-			}
-
-			virtualDeviceInUse = virtualDeviceInUse || (module.NUsed != 0)
-
-			if nullsink && ladspasink && loopback && remap {
-				inpLoaded = true
-			} else if nullsink || ladspasink || loopback || remap {
-				inputInc = true
+			if len(inps) > 0 {
+				inpLoaded = nLoaded == len(inps)
+				inputInc = inputInc || (nLoaded > 0 && !inpLoaded)
 			}
 		}
 	} else {
@@ -82,34 +118,34 @@ func supressorState(ctx *ntcontext) (int, bool) {
 
 	if ctx.config.FilterOutput {
 		if ctx.serverInfo.servertype == servertype_pipewire {
-			module, ladspasink, err := findModule(c, "module-ladspa-sink", "sink_name='Filtered Headphones'")
+			module, ladspasink, err := findChainModule(ctx, "module-ladspa-sink", "sink_name='Filtered Headphones'")
 			if err != nil {
-				log.Printf("Couldn't fetch module list to check for module-ladspa-sink: %v\n", err)
+				errorf("Couldn't fetch module list to check for module-ladspa-sink: %v\n", err)
 			}
 			virtualDeviceInUse = virtualDeviceInUse || (module.NUsed != 0)
 			outLoaded = ladspasink
 			outputInc = false
 		} else {
-			_, out, err := findModule(c, "module-null-sink", "sink_name=nui_out_out_sink")
+			_, out, err := findChainModule(ctx, "module-null-sink", "sink_name=nui_out_out_sink")
 			if err != nil {
-				log.Printf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
+				errorf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
 			}
-			_, lad, err := findModule(c, "module-ladspa-sink", "sink_name=nui_out_ladspa")
+			_, lad, err := findChainModule(ctx, "module-ladspa-sink", "sink_name=nui_out_ladspa")
 			if err != nil {
-				log.Printf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
+				errorf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
 			}
-			_, loop, err := findModule(c, "module-loopback", "source=nui_out_out_sink.monitor")
+			_, loop, err := findChainModule(ctx, "module-loopback", "source=nui_out_out_sink.monitor")
 			if err != nil {
-				log.Printf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
+				errorf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
 			}
-			module, outin, err := findModule(c, "module-null-sink", "sink_name=nui_out_in_sink")
+			module, outin, err := findChainModule(ctx, "module-null-sink", "sink_name=nui_out_in_sink")
 			if err != nil {
-				log.Printf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
+				errorf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
 			}
 			virtualDeviceInUse = virtualDeviceInUse || (module.NUsed != 0)
-			_, loop2, err := findModule(c, "module-loopback", "source=nui_out_in_sink.monitor")
+			_, loop2, err := findChainModule(ctx, "module-loopback", "source=nui_out_in_sink.monitor")
 			if err != nil {
-				log.Printf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
+				errorf("Couldn't fetch module list to check for output module-ladspa-sink: %v\n", err)
 			}
 
 			outLoaded = out && lad && loop && outin && loop2
@@ -130,40 +166,126 @@ func supressorState(ctx *ntcontext) (int, bool) {
 	return unloaded, virtualDeviceInUse
 }
 
-func loadSupressor(ctx *ntcontext, inp *device, out *device) error {
-	if ctx.serverInfo.servertype == servertype_pulse {
-		log.Printf("Querying pulse rlimit\n")
-		pid, err := getPulsePid()
+func anyInputChainLoaded(ctx *ntcontext) (bool, bool) {
+	name, match := "module-remap-source", "source_name=nui_mic_remap"
+	if ctx.serverInfo.servertype == servertype_pipewire {
+		name, match = "module-ladspa-source", "source_name=nui_mic_filtered_"
+	}
+	module, found, err := findChainModule(ctx, name, match)
+	if !found && err == nil && ctx.serverInfo.servertype == servertype_pipewire {
+		// named after the microphone by older versions
+		module, found, err = findChainModule(ctx, name, "source_name='Filtered Microphone")
+	}
+	if err != nil {
+		errorf("Couldn't fetch module list to check for %s: %v\n", name, err)
+	}
+	return found, module.NUsed != 0
+}
+
+// inputChainState reports whether the chain filtering inp is fully loaded, partially loaded (inconsistent)
+// and whether an application is recording from it.
+func inputChainState(ctx *ntcontext, inp *device) (bool, bool, bool) {
+	if ctx.serverInfo.servertype == servertype_pipewire {
+		module, ladspasource, err := findChainModule(ctx, "module-ladspa-source", pipeWireInputSourceName(inp)+" ")
 		if err != nil {
-			return err
+			errorf("Couldn't fetch module list to check for module-ladspa-source: %v\n", err)
 		}
+		return ladspasource, false, module.NUsed != 0
+	}
 
-		lim, err := getRlimit(pid)
-		if err != nil {
-			return err
+	names := inputChainFor(inp)
+	_, nullsink, err := findChainModule(ctx, "module-null-sink", "sink_name="+names.denoised+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-null-sink: %v\n", err)
+	}
+	_, ladspasink, err := findChainModule(ctx, "module-ladspa-sink", "sink_name="+names.raw+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-ladspa-sink: %v\n", err)
+	}
+	_, loopback, err := findChainModule(ctx, "module-loopback", "sink="+names.raw+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-loopback: %v\n", err)
+	}
+	module, remap, err := findChainModule(ctx, "module-remap-source", "master="+names.denoised+".monitor source_name="+names.remap+" ")
+	if err != nil {
+		errorf("Couldn't fetch module list to check for module-remap-source: %v\n", err)
+	}
+
+	// an idle chain runs without its filter stage, see idle.go
+	loaded := nullsink && remap && ((ladspasink && loopback) || inputIdle(ctx, inp.ID))
+	return loaded, !loaded && (nullsink || ladspasink || loopback || remap), module.NUsed != 0
+}
+
+// liftPulseRlimit keeps RLIMIT_RTTIME from killing the pulseaudio daemon while it loads our modules,
+// through RealtimeKit if possible and by removing the limit otherwise. The returned function
+// restores the previous state.
+func liftPulseRlimit() (func(), error) {
+	if sandboxed != "" {
+		// we can't see the server's process, loading only goes through what its socket allows
+		debugf("Running in %s, not touching the pulse rlimit\n", sandboxed)
+		return func() {}, nil
+	}
+	if rtkitAvailable() {
+		restore, err := demoteRealtime()
+		if err == nil {
+			return restore, nil
 		}
-		log.Printf("Rlimit: %+v. Trying to remove.\n", lim)
+		warnf("Couldn't demote the real-time threads of pulseaudio, removing the rlimit instead: %v\n", err)
+	}
+	debugf("Querying pulse rlimit\n")
+	pid, err := getPulsePid()
+	if err != nil {
+		return nil, err
+	}
+
+	lim, err := getRlimit(pid)
+	if err != nil {
+		return nil, err
+	}
+	debugf("Rlimit: %+v. Trying to remove.\n", lim)
 
-		removeRlimit(pid)
+	removeRlimit(pid)
+
+	newLim, err := getRlimit(pid)
+	if err != nil {
+		setRlimit(pid, &lim)
+		return nil, err
+	}
+	debugf("Rlimit: %+v\n", newLim)
 
-		defer setRlimit(pid, &lim) // lowering RLIMIT doesn't require root
+	// lowering RLIMIT doesn't require root
+	return func() { setRlimit(pid, &lim) }, nil
+}
 
-		newLim, err := getRlimit(pid)
+func loadSupressor(ctx *ntcontext, inp *device, out *device) (err error) {
+	defer func() {
+		if inp.checked {
+			hooksAfterLoad(ctx, "input", inp, err)
+		}
+		if out.checked {
+			hooksAfterLoad(ctx, "output", out, err)
+		}
+	}()
+	if err := installModel(ctx.librnnoise, activeModel(ctx)); err != nil {
+		return err
+	}
+	if jackMode(ctx) {
+		return loadJackClient(ctx)
+	}
+	if inProcessMode(ctx) {
+		return loadInProcess(ctx, inp, out)
+	}
+	if ctx.serverInfo.servertype == servertype_pulse {
+		restore, err := liftPulseRlimit()
 		if err != nil {
 			return err
 		}
-		log.Printf("Rlimit: %+v\n", newLim)
+		defer restore()
 	}
 
 	if inp.checked {
-		var err error
-		if ctx.serverInfo.servertype == servertype_pipewire {
-			err = loadPipeWireInput(ctx, inp)
-		} else {
-			err = loadPulseInput(ctx, inp)
-		}
-		if err != nil {
-			log.Printf("Error loading input: %v\n", err)
+		if err := loadInput(ctx, inp); err != nil {
+			errorf("Error loading input: %v\n", err)
 			return err
 		}
 	}
@@ -176,7 +298,7 @@ func loadSupressor(ctx *ntcontext, inp *device, out *device) error {
 			err = loadPulseOutput(ctx, out)
 		}
 		if err != nil {
-			log.Printf("Error loading output: %v\n", err)
+			errorf("Error loading output: %v\n", err)
 			return err
 		}
 	}
@@ -184,119 +306,418 @@ func loadSupressor(ctx *ntcontext, inp *device, out *device) error {
 	return nil
 }
 
+func loadInput(ctx *ntcontext, inp *device) error {
+	if ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire {
+		return loadPipeWireNativeInput(ctx, inp)
+	}
+	var err error
+	if ctx.serverInfo.servertype == servertype_pipewire {
+		err = loadPipeWireInput(ctx, inp)
+		if err == nil && latencyOffset(ctx, inp) != 0 {
+			if err := applyLatencyOffset(ctx, inp); err != nil {
+				errorf("Couldn't apply latency offset: %v\n", err)
+			}
+		}
+	} else {
+		err = loadPulseInput(ctx, inp)
+	}
+	if err == nil && ctx.config.OutputGain != 0 {
+		if err := applyGain(ctx); err != nil {
+			errorf("Couldn't apply gain: %v\n", err)
+		}
+	}
+	return err
+}
+
+// loadInputSupressor loads the chain for one more microphone, leaving the other chains alone.
+func loadInputSupressor(ctx *ntcontext, inp *device) (err error) {
+	defer func() { hooksAfterLoad(ctx, "input", inp, err) }()
+	if jackMode(ctx) {
+		return fmt.Errorf("the JACK client filters its own ports, not microphones")
+	}
+	if ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire {
+		if loaded, _ := pipeWireNativeInputLoaded(ctx); loaded {
+			return fmt.Errorf("the native PipeWire filter-chain only supports one microphone")
+		}
+	}
+	if err := installModel(ctx.librnnoise, activeModel(ctx)); err != nil {
+		return err
+	}
+	if inProcessMode(ctx) {
+		return loadInProcessInput(ctx, inp)
+	}
+	if ctx.serverInfo.servertype == servertype_pulse {
+		restore, err := liftPulseRlimit()
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+	return loadInput(ctx, inp)
+}
+
 func loadModule(ctx *ntcontext, module, args string) (uint32, error) {
 	idx, err := ctx.paClient.LoadModule(module, args)
 
 	//14 = module initialisation failed
 	if paErr, ok := err.(*pulseaudio.Error); ok && paErr.Code == 14 {
 		resetUI(ctx)
-		ctx.views.Push(makeErrorView(ctx, fmt.Sprintf("Could not load module '%s'. This is likely a problem with your system or distribution.", module)))
+		ctx.views.Push(makeErrorView(ctx, trf("Could not load module '%s'. This is likely a problem with your system or distribution.", module)))
 	}
 	return idx, err
 }
 
 func loadPipeWireInput(ctx *ntcontext, inp *device) error {
-	log.Printf("Loading supressor for pipewire\n")
-	idx, err := loadModule(ctx, "module-ladspa-source",
-		fmt.Sprintf("source_name='Filtered Microphone for %s' master=%s "+
-			"rate=48000 channels=1 "+
-			"label=nt-filter plugin=%s control=%d", inp.Name, inp.ID, ctx.librnnoise, ctx.config.Threshold))
+	infof("Loading supressor for pipewire\n")
+	return loadChainModules(ctx, []chainModule{pipeWireInputModule(ctx, inp)})
+}
 
-	if err != nil {
-		return err
+// pipeWireInputModule is the ladspa source filtering inp on PipeWire.
+func pipeWireInputModule(ctx *ntcontext, inp *device) chainModule {
+	return customModule(ctx, inp, "module-ladspa-source", "ladspa source",
+		fmt.Sprintf("%s master=%s "+
+			"rate=48000 %s "+
+			"%s source_properties=\"device.description='Filtered Microphone for %s' %s %s%s\"",
+			pipeWireInputSourceName(inp), inp.ID, inputChannelArgs(inp), ladspaArgs(ctx), inp.Name, devicePresence("microphone"), chainTags(ctx), keepAwakeProps(ctx)))
+}
+
+// chainModule is a module of the chain together with the arguments it's loaded with.
+type chainModule struct {
+	name   string
+	what   string // for the log
+	args   string
+	custom bool // the custom arguments of the device are appended, see moduleargs.go
+}
+
+func loadChainModules(ctx *ntcontext, mods []chainModule) error {
+	for _, m := range mods {
+		idx, err := loadModule(ctx, m.name, m.args)
+		if err != nil {
+			return err
+		}
+		debugf("Loaded %s as idx: %d\n", m.what, idx)
 	}
-	log.Printf("Loaded ladspa source as idx: %d\n", idx)
 	return nil
 }
 
 func loadPipeWireOutput(ctx *ntcontext, out *device) error {
-	log.Printf("Loading supressor for pipewire\n")
-	idx, err := loadModule(ctx, "module-ladspa-sink",
-		fmt.Sprintf("sink_name='Filtered Headphones' master=%s "+
+	infof("Loading supressor for pipewire\n")
+	return loadChainModules(ctx, []chainModule{pipeWireOutputModule(ctx, out)})
+}
+
+// pipeWireOutputModule is the ladspa sink filtering out on PipeWire.
+func pipeWireOutputModule(ctx *ntcontext, out *device) chainModule {
+	return chainModule{name: "module-ladspa-sink", what: "ladspa sink",
+		args: fmt.Sprintf("sink_name='Filtered Headphones' master=%s "+
 			"rate=48000 channels=1 "+
-			"label=nt-filter plugin=%s control=%d", out.ID, ctx.librnnoise, ctx.config.Threshold))
+			"%s sink_properties=\"%s %s\"",
+			out.ID, ladspaArgs(ctx), devicePresence("headphone"), chainTags(ctx))}
+}
 
-	if err != nil {
-		return err
+// pipeWireInputSourceName is the source_name argument of the ladspa source filtering inp.
+// pipeWireInputSource is the name of the ladspa source filtering inp on PipeWire.
+func pipeWireInputSource(inp *device) string {
+	return "nui_mic_filtered_" + deviceSlug(inp)
+}
+
+func pipeWireInputSourceName(inp *device) string {
+	return "source_name=" + pipeWireInputSource(inp)
+}
+
+// inputChain names the devices making up the pulseaudio input chain of one microphone, so several
+// microphones can be filtered at the same time.
+type inputChain struct {
+	denoised string // null sink the filter writes into
+	raw      string // ladspa sink fed by the loopback from the microphone
+	gate     string // optional ladspa sink between raw and denoised
+	remap    string // the filtered microphone applications record from
+	awake    string // optional null sink recording from denoised, so it never suspends
+}
+
+func inputChainFor(inp *device) inputChain {
+	slug := deviceSlug(inp)
+	return inputChain{
+		denoised: "nui_mic_denoised_" + slug,
+		raw:      "nui_mic_raw_" + slug,
+		gate:     "nui_mic_gate_" + slug,
+		remap:    "nui_mic_remap_" + slug,
+		awake:    "nui_mic_awake_" + slug,
 	}
-	log.Printf("Loaded ladspa source as idx: %d\n", idx)
-	return nil
+}
+
+// deviceSlug turns the device into something usable in a pulseaudio name that stays the same
+// across restarts, e.g. "1f2e3d4c". It's derived from the ID only, the name shown for a device can
+// change, e.g. when another one with the same name is plugged in.
+func deviceSlug(inp *device) string {
+	h := fnv.New32a()
+	h.Write([]byte(inp.ID))
+	return fmt.Sprintf("%08x", h.Sum32())
 }
 
 func loadPulseInput(ctx *ntcontext, inp *device) error {
-	log.Printf("Loading supressor for pulse\n")
-	idx, err := loadModule(ctx, "module-null-sink", "sink_name=nui_mic_denoised_out rate=48000")
+	infof("Loading supressor for pulse, source has %d channels (%s) at %dHz, filtering %d channels at %dHz\n",
+		inp.channels, inp.channelMap, inp.rate, inputChannels(inp), filterRate)
+	mods, err := pulseInputModules(ctx, inp, false)
 	if err != nil {
 		return err
 	}
-	log.Printf("Loaded null sink as idx: %d\n", idx)
+	if err := loadChainModules(ctx, mods); err != nil {
+		return err
+	}
+
+	if ctx.config.KeepAwake {
+		return loadPulseKeepAwake(ctx, inp)
+	}
+	return nil
+}
 
-	idx, err = loadModule(ctx, "module-ladspa-sink",
-		fmt.Sprintf("sink_name=nui_mic_raw_in sink_master=nui_mic_denoised_out "+
-			"label=nt-filter plugin=%s control=%d", ctx.librnnoise, ctx.config.Threshold))
+// Desktop microphone indicators look at who is recording from the real microphone. Our loopback
+// always is, so tag its stream as belonging to NoiseTorch and mark it virtual. Indicators then
+// attribute it correctly (or hide it), and the applications recording from the filtered microphone
+// remain what actually shows up as "microphone in use".
+func loopbackStreamProperties(ctx *ntcontext) string {
+	return fmt.Sprintf(`source_output_properties="%s node.virtual=true %s" `+
+		`sink_input_properties="%s %s"`, streamPresence(), chainTags(ctx), streamPresence(), chainTags(ctx))
+}
+
+// loadPulseInputFilter loads the middle stage of the input chain: the ladspa sink writing into
+// the denoised null sink (through the gate, if enabled) and the loopback feeding it from the real microphone.
+func loadPulseInputFilter(ctx *ntcontext, inp *device) error {
+	mods, err := pulseInputModules(ctx, inp, true)
 	if err != nil {
 		return err
 	}
-	log.Printf("Loaded ladspa sink as idx: %d\n", idx)
+	return loadChainModules(ctx, mods)
+}
 
-	if inp.dynamicLatency {
-		idx, err = loadModule(ctx, "module-loopback",
-			fmt.Sprintf("source=%s sink=nui_mic_raw_in channels=1 latency_msec=1 source_dont_move=true sink_dont_move=true", inp.ID))
+// pulseInputModules returns the modules of the pulseaudio input chain of inp in the order they're
+// loaded. filterOnly leaves out the null sink and the remap source, see swapFilters.
+func pulseInputModules(ctx *ntcontext, inp *device, filterOnly bool) ([]chainModule, error) {
+	names := inputChainFor(inp)
+	var mods []chainModule
+	if !filterOnly {
+		mods = append(mods, customModule(ctx, inp, "module-null-sink", "null sink",
+			fmt.Sprintf(`sink_name=%s rate=48000 %s sink_properties="%s"`, names.denoised, inputChannelArgs(inp), chainTags(ctx))))
+	}
+
+	master := names.denoised
+	if ctx.config.Gate {
+		args, err := gateArgs(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		log.Printf("Loaded loopback as idx: %d\n", idx)
+		mods = append(mods, chainModule{name: "module-ladspa-sink", what: "gate ladspa sink",
+			args: fmt.Sprintf("sink_name=%s sink_master=%s %s sink_properties=\"%s\"",
+				names.gate, names.denoised, args, chainTags(ctx))})
+		master = names.gate
+	}
+
+	mods = append(mods, customModule(ctx, inp, "module-ladspa-sink", "ladspa sink",
+		fmt.Sprintf("sink_name=%s sink_master=%s "+
+			"%s sink_properties=\"%s\"",
+			names.raw, master, ladspaArgs(ctx), chainTags(ctx))))
+
+	if inp.dynamicLatency {
+		mods = append(mods, customModule(ctx, inp, "module-loopback", "loopback",
+			fmt.Sprintf("source=%s sink=%s %s %s latency_msec=%d source_dont_move=true sink_dont_move=true"+
+				" "+loopbackStreamProperties(ctx), inp.ID, names.raw, loopbackChannelArgs(inp), loopbackRateArgs(ctx), inputLoopbackLatency(ctx, inp))))
 	} else {
-		idx, err = loadModule(ctx, "module-loopback",
-			fmt.Sprintf("source=%s sink=nui_mic_raw_in channels=1 latency_msec=50 source_dont_move=true sink_dont_move=true adjust_time=1", inp.ID))
+		mods = append(mods, customModule(ctx, inp, "module-loopback", "fixed latency loopback",
+			fmt.Sprintf("source=%s sink=%s %s %s latency_msec=%d source_dont_move=true sink_dont_move=true adjust_time=1"+
+				" "+loopbackStreamProperties(ctx), inp.ID, names.raw, loopbackChannelArgs(inp), loopbackRateArgs(ctx), inputLoopbackLatency(ctx, inp))))
+	}
+
+	if !filterOnly {
+		mods = append(mods, customModule(ctx, inp, "module-remap-source", "remap source",
+			fmt.Sprintf(`master=%s.monitor `+
+				`source_name=%s source_properties="device.description='Filtered Microphone for %s' %s %s"`,
+				names.denoised, names.remap, inp.Name, devicePresence("microphone"), chainTags(ctx))))
+	}
+	return mods, nil
+}
+
+// swapsInPlace reports whether the chains have a filter stage separate from the devices applications
+// use, which only the pulseaudio chains have. Everywhere else the filter is the device, and switching
+// e.g. the engine removes it for a moment.
+func swapsInPlace(ctx *ntcontext) bool {
+	return ctx.serverInfo.servertype == servertype_pulse && !jackMode(ctx) && !inProcessMode(ctx)
+}
+
+// canSwapFilters reports whether the loaded chains can have their filter stages replaced in place,
+// i.e. pulseaudio is running exactly the chains asked for: one for each selected microphone, fed
+// from it, and the headphones chain playing on out if it's checked.
+func canSwapFilters(ctx *ntcontext, out *device) bool {
+	if !swapsInPlace(ctx) || ctx.noiseSupressorState != loaded {
+		return false
+	}
+	// adding or removing the gate changes what the filter writes into
+	if ctx.chain.gate != ctx.config.Gate {
+		return false
+	}
+	mods, err := findTaggedModules(ctx)
+	if err != nil {
+		errorf("Couldn't fetch module list to check the loaded chains: %v\n", err)
+		return false
+	}
+	chains := 0
+	for _, m := range mods {
+		if m.Name == "module-remap-source" && strings.Contains(m.Argument, "source_name=nui_mic_remap_") {
+			chains++
+		}
+	}
+	inps := inputSelections(ctx)
+	if chains != len(inps) {
+		return false
+	}
+	for _, inp := range inps {
+		inp := inp
+		_, found, err := findChainModule(ctx, "module-loopback", fmt.Sprintf("source=%s sink=%s ", inp.ID, inputChainFor(&inp).raw))
 		if err != nil {
-			return err
+			errorf("Couldn't fetch module list to check for module-loopback: %v\n", err)
+			return false
+		}
+		if !found {
+			return false
 		}
-		log.Printf("Loaded fixed latency loopback as idx: %d\n", idx)
 	}
 
-	idx, err = loadModule(ctx, "module-remap-source", fmt.Sprintf(`master=nui_mic_denoised_out.monitor `+
-		`source_name=nui_mic_remap source_properties="device.description='Filtered Microphone for %s'"`, inp.Name))
+	match := "source=nui_out_out_sink.monitor sink="
+	if out.checked {
+		match += out.ID + " "
+	}
+	_, found, err := findChainModule(ctx, "module-loopback", match)
 	if err != nil {
-		return err
+		errorf("Couldn't fetch module list to check for module-loopback: %v\n", err)
+		return false
 	}
-	log.Printf("Loaded remap source as idx: %d\n", idx)
-	return nil
+	return found == out.checked
 }
 
-func loadPulseOutput(ctx *ntcontext, out *device) error {
-	_, err := loadModule(ctx, "module-null-sink", `sink_name=nui_out_out_sink`)
-	if err != nil {
+// swapFilters replaces the ladspa sinks and the loopbacks feeding them of the loaded chains with
+// freshly configured ones. The null sinks, the remap sources and the loopback to the headphones stay
+// loaded, so applications using the filtered devices never see them disappear.
+func swapFilters(ctx *ntcontext, out *device) error {
+	debugf("Swapping filter stages for pulse\n")
+	if err := installModel(ctx.librnnoise, activeModel(ctx)); err != nil {
 		return err
 	}
-
-	_, err = loadModule(ctx, "module-null-sink", `sink_name=nui_out_in_sink sink_properties="device.description='Filtered Headphones'"`)
+	restore, err := liftPulseRlimit()
 	if err != nil {
 		return err
 	}
+	defer restore()
 
-	_, err = loadModule(ctx, "module-ladspa-sink", fmt.Sprintf(`sink_name=nui_out_ladspa sink_master=nui_out_out_sink `+
-		`label=nt-filter channels=1 plugin=%s control=%d rate=%d`,
-		ctx.librnnoise, ctx.config.Threshold, 48000))
-	if err != nil {
+	for _, inp := range inputSelections(ctx) {
+		inp := inp
+		if err := unloadPulseInputFilter(ctx, &inp); err != nil {
+			return err
+		}
+		if err := loadPulseInputFilter(ctx, &inp); err != nil {
+			return err
+		}
+	}
+	if !out.checked {
+		return nil
+	}
+	if err := unloadPulseOutputFilter(ctx); err != nil {
 		return err
 	}
+	return loadChainModules(ctx, pulseOutputModules(ctx, out, true))
+}
 
-	_, err = loadModule(ctx, "module-loopback",
-		fmt.Sprintf("source=nui_out_out_sink.monitor sink=%s channels=2 latency_msec=50 source_dont_move=true sink_dont_move=true", out.ID))
+// unloadPulseInputFilter unloads what loadPulseInputFilter loads.
+func unloadPulseInputFilter(ctx *ntcontext, inp *device) error {
+	c := ctx.paClient
+	names := inputChainFor(inp)
+	m, found, err := findChainModule(ctx, "module-loopback", "sink="+names.raw+" ")
 	if err != nil {
 		return err
 	}
+	if found {
+		debugf("Found loopback at id [%d], sending unload command\n", m.Index)
+		c.UnloadModule(m.Index)
+	}
 
-	_, err = loadModule(ctx, "module-loopback",
-		fmt.Sprintf("source=nui_out_in_sink.monitor sink=nui_out_ladspa channels=1 latency_msec=50 source_dont_move=true sink_dont_move=true"))
-	if err != nil {
-		return err
+	for _, sink := range []string{names.raw, names.gate} {
+		m, found, err = findChainModule(ctx, "module-ladspa-sink", "sink_name="+sink+" ")
+		if err != nil {
+			return err
+		}
+		if found {
+			debugf("Found ladspa-sink at id [%d], sending unload command\n", m.Index)
+			c.UnloadModule(m.Index)
+		}
 	}
 	return nil
 }
 
-func unloadSupressor(ctx *ntcontext) error {
+// unloadPulseOutputFilter unloads the filter stage of the output chain, see swapFilters.
+func unloadPulseOutputFilter(ctx *ntcontext) error {
+	for _, mod := range []struct{ name, match string }{
+		{"module-loopback", "sink=nui_out_ladspa "},
+		{"module-ladspa-sink", "sink_name=nui_out_ladspa "},
+	} {
+		m, found, err := findChainModule(ctx, mod.name, mod.match)
+		if err != nil {
+			return err
+		}
+		if found {
+			debugf("Found %s at id [%d], sending unload command\n", mod.name, m.Index)
+			ctx.paClient.UnloadModule(m.Index)
+		}
+	}
+	return nil
+}
+
+func loadPulseOutput(ctx *ntcontext, out *device) error {
+	return loadChainModules(ctx, pulseOutputModules(ctx, out, false))
+}
+
+// pulseOutputModules returns the modules of the pulseaudio output chain in the order they're loaded.
+// filterOnly leaves out the null sinks and the loopback to the headphones, see swapFilters.
+func pulseOutputModules(ctx *ntcontext, out *device, filterOnly bool) []chainModule {
+	var mods []chainModule
+	if !filterOnly {
+		mods = append(mods,
+			chainModule{name: "module-null-sink", what: "output null sink",
+				args: fmt.Sprintf(`sink_name=nui_out_out_sink sink_properties="%s"`, chainTags(ctx))},
+			chainModule{name: "module-null-sink", what: "filtered headphones null sink",
+				args: fmt.Sprintf(`sink_name=nui_out_in_sink sink_properties="device.description='Filtered Headphones' %s %s"`,
+					devicePresence("headphone"), chainTags(ctx))})
+	}
+	mods = append(mods, chainModule{name: "module-ladspa-sink", what: "output ladspa sink",
+		args: fmt.Sprintf(`sink_name=nui_out_ladspa sink_master=nui_out_out_sink `+
+			`channels=1 %s rate=%d sink_properties="%s"`,
+			ladspaArgs(ctx), 48000, chainTags(ctx))})
+	if !filterOnly {
+		mods = append(mods, chainModule{name: "module-loopback", what: "output loopback",
+			args: fmt.Sprintf("source=nui_out_out_sink.monitor sink=%s channels=2 latency_msec=%d source_dont_move=true sink_dont_move=true "+
+				`sink_input_properties="%s %s" source_output_properties="%s"`, out.ID, ctx.config.BufferLatency, streamPresence(), chainTags(ctx), chainTags(ctx))})
+	}
+	return append(mods, chainModule{name: "module-loopback", what: "filtered headphones loopback",
+		args: fmt.Sprintf("source=nui_out_in_sink.monitor sink=nui_out_ladspa channels=1 latency_msec=%d source_dont_move=true sink_dont_move=true "+
+			`sink_input_properties="%s" source_output_properties="%s"`, ctx.config.BufferLatency, chainTags(ctx), chainTags(ctx))})
+}
+
+// turnOffSupressor unloads the filters because the user turned them off or we exit. Only then the
+// unload hook runs, not when they're unloaded to be loaded again.
+func turnOffSupressor(ctx *ntcontext) (err error) {
+	defer func() { hooksAfterUnload(ctx, "", nil, err) }()
+	return unloadSupressor(ctx)
+}
+
+// turnOffInputSupressor unloads the chain filtering inp because the user turned it off.
+func turnOffInputSupressor(ctx *ntcontext, inp *device) (err error) {
+	defer func() { hooksAfterUnload(ctx, "input", inp, err) }()
+	return unloadInputSupressor(ctx, inp)
+}
+
+func unloadSupressor(ctx *ntcontext) (err error) {
+	// also when it isn't selected anymore, it may still run from before
+	if err := unloadJackClient(); err != nil {
+		errorf("Couldn't stop the JACK client: %v\n", err)
+	}
+	stopDSPStreams()
 	if ctx.serverInfo.servertype == servertype_pipewire {
 		return unloadSupressorPipeWire(ctx)
 	} else {
@@ -304,152 +725,196 @@ func unloadSupressor(ctx *ntcontext) error {
 	}
 }
 
+// unloadInputSupressor unloads the chain filtering inp, leaving the other chains alone.
+func unloadInputSupressor(ctx *ntcontext, inp *device) (err error) {
+	if jackMode(ctx) {
+		return unloadJackClient()
+	}
+	if inProcessMode(ctx) {
+		return unloadInProcessInput(ctx, inp)
+	}
+	c := ctx.paClient
+	if ctx.serverInfo.servertype == servertype_pipewire {
+		if ctx.config.NativePipeWire {
+			return unloadPipeWireNativeInput()
+		}
+		m, found, err := findChainModule(ctx, "module-ladspa-source", pipeWireInputSourceName(inp)+" ")
+		if err != nil {
+			return err
+		}
+		if found {
+			debugf("Found module-ladspa-source at id [%d], sending unload command\n", m.Index)
+			c.UnloadModule(m.Index)
+		}
+		return nil
+	}
+
+	restore, err := liftPulseRlimit()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	names := inputChainFor(inp)
+	// unload back to front, so nothing gets moved to another device in between
+	for _, mod := range []struct{ name, match string }{
+		{"module-loopback", "sink=" + names.denoised + " "}, // bypass
+		{"module-loopback", "sink=" + names.awake + " "},
+		{"module-null-sink", "sink_name=" + names.awake + " "},
+		{"module-remap-source", "source_name=" + names.remap + " "},
+		{"module-loopback", "sink=" + names.raw + " "},
+		{"module-ladspa-sink", "sink_name=" + names.raw + " "},
+		{"module-ladspa-sink", "sink_name=" + names.gate + " "},
+		{"module-null-sink", "sink_name=" + names.denoised + " "},
+	} {
+		m, found, err := findChainModule(ctx, mod.name, mod.match)
+		if err != nil {
+			return err
+		}
+		if found {
+			debugf("Found %s at id [%d], sending unload command\n", mod.name, m.Index)
+			c.UnloadModule(m.Index)
+		}
+	}
+	return nil
+}
+
 func unloadSupressorPipeWire(ctx *ntcontext) error {
-	log.Printf("Unloading modules for pipewire\n")
+	debugf("Unloading modules for pipewire\n")
 
-	log.Printf("Searching for module-ladspa-source\n")
+	if err := unloadPipeWireNativeInput(); err != nil {
+		errorf("Couldn't stop filter-chain: %v\n", err)
+	}
+
+	if err := unloadTaggedModules(ctx); err != nil {
+		return err
+	}
+
+	debugf("Searching for module-ladspa-source\n")
 	c := ctx.paClient
-	m, found, err := findModule(c, "module-ladspa-source", "source_name='Filtered Microphone")
+	m, found, err := findChainModule(ctx, "module-ladspa-source", "source_name='Filtered Microphone")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found module-ladspa-source at id [%d], sending unload command\n", m.Index)
+		debugf("Found module-ladspa-source at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for module-ladspa-sink\n")
-	m, found, err = findModule(c, "module-ladspa-sink", "sink_name='Filtered Headphones'")
+	debugf("Searching for module-ladspa-sink\n")
+	m, found, err = findChainModule(ctx, "module-ladspa-sink", "sink_name='Filtered Headphones'")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found module-ladspa-sink at id [%d], sending unload command\n", m.Index)
+		debugf("Found module-ladspa-sink at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 	return nil
 }
 
 func unloadSupressorPulse(ctx *ntcontext) error {
-	log.Printf("Unloading modules for pulseaudio\n")
-
-	if pid, err := getPulsePid(); err == nil {
-		if lim, err := getRlimit(pid); err == nil {
-			log.Printf("Trying to remove rlimit. Limit is: %+v\n", lim)
-			removeRlimit(pid)
-			newLim, _ := getRlimit(pid)
-			log.Printf("Rlimit: %+v\n", newLim)
-			defer setRlimit(pid, &lim)
-		}
+	debugf("Unloading modules for pulseaudio\n")
 
+	if restore, err := liftPulseRlimit(); err == nil {
+		defer restore()
 	}
 
-	log.Printf("Searching for null-sink\n")
 	c := ctx.paClient
-	m, found, err := findModule(c, "module-null-sink", "sink_name=nui_mic_denoised_out")
+	if err := unloadTaggedModules(ctx); err != nil {
+		return err
+	}
+
+	// chains loaded by older versions aren't tagged and use fixed names
+	debugf("Searching for null-sink\n")
+	m, found, err := findChainModule(ctx, "module-null-sink", "sink_name=nui_mic_denoised_out")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found null-sink at id [%d], sending unload command\n", m.Index)
+		debugf("Found null-sink at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for ladspa-sink\n")
-	m, found, err = findModule(c, "module-ladspa-sink", "sink_name=nui_mic_raw_in sink_master=nui_mic_denoised_out")
+	debugf("Searching for ladspa-sink\n")
+	m, found, err = findChainModule(ctx, "module-ladspa-sink", "sink_name=nui_mic_raw_in sink_master=nui_mic_denoised_out")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found ladspa-sink at id [%d], sending unload command\n", m.Index)
+		debugf("Found ladspa-sink at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for loopback\n")
-	m, found, err = findModule(c, "module-loopback", "sink=nui_mic_raw_in")
+	debugf("Searching for loopback\n")
+	m, found, err = findChainModule(ctx, "module-loopback", "sink=nui_mic_raw_in")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found loopback at id [%d], sending unload command\n", m.Index)
+		debugf("Found loopback at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for remap-source\n")
-	m, found, err = findModule(c, "module-remap-source", "master=nui_mic_denoised_out.monitor source_name=nui_mic_remap")
+	debugf("Searching for remap-source\n")
+	m, found, err = findChainModule(ctx, "module-remap-source", "master=nui_mic_denoised_out.monitor source_name=nui_mic_remap")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found remap source at id [%d], sending unload command\n", m.Index)
+		debugf("Found remap source at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for output module-null-sink\n")
-	m, found, err = findModule(c, "module-null-sink", "sink_name=nui_out_out_sink")
+	debugf("Searching for output module-null-sink\n")
+	m, found, err = findChainModule(ctx, "module-null-sink", "sink_name=nui_out_out_sink")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found output null sink at id [%d], sending unload command\n", m.Index)
+		debugf("Found output null sink at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for output module-null-sink\n")
-	m, found, err = findModule(c, "module-null-sink", "sink_name=nui_out_in_sink")
+	debugf("Searching for output module-null-sink\n")
+	m, found, err = findChainModule(ctx, "module-null-sink", "sink_name=nui_out_in_sink")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found output null sink at id [%d], sending unload command\n", m.Index)
+		debugf("Found output null sink at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for output module-ladspa-sink\n")
-	m, found, err = findModule(c, "module-ladspa-sink", "sink_name=nui_out_ladspa")
+	debugf("Searching for output module-ladspa-sink\n")
+	m, found, err = findChainModule(ctx, "module-ladspa-sink", "sink_name=nui_out_ladspa")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found output ladspa sink at id [%d], sending unload command\n", m.Index)
+		debugf("Found output ladspa sink at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for output module-loopback\n")
-	m, found, err = findModule(c, "module-loopback", "source=nui_out_out_sink.monitor")
+	debugf("Searching for output module-loopback\n")
+	m, found, err = findChainModule(ctx, "module-loopback", "source=nui_out_out_sink.monitor")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found output loopback at id [%d], sending unload command\n", m.Index)
+		debugf("Found output loopback at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
-	log.Printf("Searching for output module-loopback\n")
-	m, found, err = findModule(c, "module-loopback", "source=nui_out_in_sink.monitor")
+	debugf("Searching for output module-loopback\n")
+	m, found, err = findChainModule(ctx, "module-loopback", "source=nui_out_in_sink.monitor")
 	if err != nil {
 		return err
 	}
 	if found {
-		log.Printf("Found output loopback at id [%d], sending unload command\n", m.Index)
+		debugf("Found output loopback at id [%d], sending unload command\n", m.Index)
 		c.UnloadModule(m.Index)
 	}
 
 	return nil
 }
-
-// Finds a module by exactly matching the module name, and checking if the second string is a substring of the argument
-func findModule(c *pulseaudio.Client, name string, argMatch string) (module pulseaudio.Module, found bool, err error) {
-	lst, err := c.ModuleList()
-
-	if err != nil {
-		return pulseaudio.Module{}, false, err
-	}
-	for _, m := range lst {
-		if m.Name == name && strings.Contains(m.Argument, argMatch) {
-			return m, true, nil
-		}
-	}
-
-	return pulseaudio.Module{}, false, nil
-}
@@ -0,0 +1,374 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+func TestMain(m *testing.M) {
+	// the config, profiles and pid files go to a directory of their own
+	dir, err := ioutil.TempDir("", "noisetorch-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	os.Setenv("XDG_RUNTIME_DIR", dir)
+	// there's no server process whose rlimit could be lifted, like in a sandbox
+	sandboxed = "test"
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testWindow stands in for the window, the chain code only tells it to redraw.
+type testWindow struct {
+	nucular.MasterWindow
+}
+
+func (testWindow) Changed() {}
+func (testWindow) Lock()    {}
+func (testWindow) Unlock()  {}
+
+// newTestContext connects to s and selects its microphone and speakers, like connecting does.
+func newTestContext(t *testing.T, s *fakeServer) *ntcontext {
+	t.Helper()
+	c := defaultConfig()
+	c.FilterInput, c.FilterOutput = true, true
+	c.NotifyFilter, c.NotifyConnection, c.NotifyUpdates = false, false, false
+	c.ReconnectGracePeriod = 0
+
+	var w nucular.MasterWindow = testWindow{}
+	ctx := &ntcontext{config: &c, librnnoise: "/nonexistent/rnnoise_ladspa.so", masterWindow: &w, views: NewViewStack()}
+	connectTestServer(t, ctx, s)
+	return ctx
+}
+
+func connectTestServer(t *testing.T, ctx *ntcontext, s *fakeServer) {
+	t.Helper()
+	info, err := serverInfo(s)
+	if err != nil {
+		t.Fatalf("serverInfo: %v", err)
+	}
+	ctx.paClient, ctx.serverInfo = s, info
+	ctx.inputList = getSources(ctx, s)
+	ctx.outputList = getSinks(ctx, s)
+	for i := range ctx.inputList {
+		ctx.inputList[i].checked = ctx.inputList[i].ID == "alsa_input.fake-microphone"
+	}
+	for i := range ctx.outputList {
+		ctx.outputList[i].checked = ctx.outputList[i].ID == "alsa_output.fake-speakers"
+	}
+	if _, ok := inputSelection(ctx); !ok {
+		t.Fatalf("no microphone in %+v", ctx.inputList)
+	}
+}
+
+// testLoad loads the filter for the selection, and keeps the state up to date like the update loop does.
+func testLoad(t *testing.T, ctx *ntcontext) {
+	t.Helper()
+	inp, _ := inputSelection(ctx)
+	out, _ := outputSelection(ctx)
+	if err := loadSupressor(ctx, &inp, &out); err != nil {
+		t.Fatalf("loadSupressor: %v", err)
+	}
+	ctx.noiseSupressorState, _ = supressorState(ctx)
+	trackRecovery(ctx)
+}
+
+func moduleArgs(t *testing.T, s *fakeServer) []string {
+	t.Helper()
+	mods, err := s.ModuleList()
+	if err != nil {
+		t.Fatalf("ModuleList: %v", err)
+	}
+	var res []string
+	for _, m := range mods {
+		res = append(res, m.Name+" "+m.Argument)
+	}
+	return res
+}
+
+func countModules(t *testing.T, s *fakeServer, match string) int {
+	t.Helper()
+	n := 0
+	for _, m := range moduleArgs(t, s) {
+		if strings.Contains(m, match) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipewire bool
+		// run does something to the loaded chain, and returns the server it ends up on
+		run     func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer
+		state   int
+		modules int
+	}{
+		{
+			name: "load pulseaudio",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				return s
+			},
+			state:   loaded,
+			modules: 9,
+		},
+		{
+			name:     "load pipewire",
+			pipewire: true,
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				if n := countModules(t, s, "source_name=nui_mic_filtered_"); n != 1 {
+					t.Errorf("%d filtered microphones, want 1", n)
+				}
+				return s
+			},
+			state:   loaded,
+			modules: 2,
+		},
+		{
+			name: "unload pulseaudio",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				if err := unloadSupressor(ctx); err != nil {
+					t.Fatalf("unloadSupressor: %v", err)
+				}
+				return s
+			},
+			state:   unloaded,
+			modules: 0,
+		},
+		{
+			name:     "unload pipewire",
+			pipewire: true,
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				if err := unloadSupressor(ctx); err != nil {
+					t.Fatalf("unloadSupressor: %v", err)
+				}
+				return s
+			},
+			state:   unloaded,
+			modules: 0,
+		},
+		{
+			name: "reload pulseaudio with another threshold",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				ctx.config.Threshold = 42
+				inp, _ := inputSelection(ctx)
+				out, _ := outputSelection(ctx)
+				uiReloadFilters(ctx, inp, out)
+				if n := countModules(t, s, "control=42"); n != 2 {
+					t.Errorf("%d filters with the new threshold, want 2 in %q", n, moduleArgs(t, s))
+				}
+				return s
+			},
+			state:   loaded,
+			modules: 9,
+		},
+		{
+			name: "reload pulseaudio keeps the filtered devices",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				devices := func() map[uint32]bool {
+					lst, err := s.ModuleList()
+					if err != nil {
+						t.Fatalf("ModuleList: %v", err)
+					}
+					res := map[uint32]bool{}
+					for _, m := range lst {
+						if strings.Contains(m.Argument, "source_name=nui_mic_remap_") || strings.Contains(m.Argument, "sink_name=nui_out_in_sink ") {
+							res[m.Index] = true
+						}
+					}
+					return res
+				}
+				before := devices()
+				ctx.config.Threshold = 42
+				inp, _ := inputSelection(ctx)
+				out, _ := outputSelection(ctx)
+				uiReloadFilters(ctx, inp, out)
+				if after := devices(); len(before) != 2 || !reflect.DeepEqual(before, after) {
+					t.Errorf("filtered devices %v after the reload, want %v", after, before)
+				}
+				return s
+			},
+			state:   loaded,
+			modules: 9,
+		},
+		{
+			name:     "reload pipewire with another threshold",
+			pipewire: true,
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				ctx.config.Threshold = 42
+				inp, _ := inputSelection(ctx)
+				out, _ := outputSelection(ctx)
+				uiReloadFilters(ctx, inp, out)
+				if n := countModules(t, s, "control=42"); n != 2 {
+					t.Errorf("%d filters with the new threshold, want 2 in %q", n, moduleArgs(t, s))
+				}
+				return s
+			},
+			state:   loaded,
+			modules: 2,
+		},
+		{
+			name: "unload leaves the chains of others alone",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				if _, err := s.LoadModule("module-null-sink", `sink_name=nui_out_out_sink_other sink_properties="noisetorch.id=00000000-0000-4000-8000-000000000000"`); err != nil {
+					t.Fatalf("LoadModule: %v", err)
+				}
+				if err := unloadSupressor(ctx); err != nil {
+					t.Fatalf("unloadSupressor: %v", err)
+				}
+				return s
+			},
+			state:   unloaded,
+			modules: 1,
+		},
+		{
+			name: "found again after a restart",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				restarted := newTestContext(t, s)
+				if state, _ := supressorState(restarted); state != loaded {
+					t.Errorf("chain is %s after a restart, want loaded", stateName(state))
+				}
+				if err := unloadSupressor(restarted); err != nil {
+					t.Fatalf("unloadSupressor: %v", err)
+				}
+				return s
+			},
+			state:   unloaded,
+			modules: 0,
+		},
+		{
+			name: "idle unload and wake up",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				inp, _ := inputSelection(ctx)
+				raw := "sink=" + inputChainFor(&inp).raw + " "
+				ctx.idle.idle = map[string]bool{inp.ID: true}
+				switchIdle(ctx, []device{inp}, nil)
+				if n := countModules(t, s, raw); n != 0 {
+					t.Errorf("%d loopbacks into the filter of the idle chain, want 0", n)
+				}
+				if state, _ := supressorState(ctx); state != loaded {
+					t.Errorf("idle chain is %s, want loaded", stateName(state))
+				}
+				switchIdle(ctx, nil, []device{inp})
+				if n := countModules(t, s, raw); n != 1 {
+					t.Errorf("%d loopbacks into the filter after waking up, want 1", n)
+				}
+				if inputIdle(ctx, inp.ID) {
+					t.Errorf("chain still idle after waking up")
+				}
+				return s
+			},
+			state:   loaded,
+			modules: 9,
+		},
+		{
+			name: "quitting wakes idle chains",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				inp, _ := inputSelection(ctx)
+				ctx.idle.idle = map[string]bool{inp.ID: true}
+				switchIdle(ctx, []device{inp}, nil)
+				ctx.config.UnloadOnExit = false
+				exitGUI(ctx)
+				if inputIdle(ctx, inp.ID) {
+					t.Errorf("chain still idle after quitting")
+				}
+				return s
+			},
+			state:   loaded,
+			modules: 9,
+		},
+		{
+			name: "reconnect after the server restarted",
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				s.Close()
+				recoveryConnectionLost(ctx)
+				restarted := newFakeServer(false)
+				connectTestServer(t, ctx, restarted)
+				recoveryConnected(ctx)
+				waitRecovery(t, ctx)
+				return restarted
+			},
+			state:   loaded,
+			modules: 9,
+		},
+		{
+			name:     "reconnect after pipewire restarted",
+			pipewire: true,
+			run: func(t *testing.T, ctx *ntcontext, s *fakeServer) *fakeServer {
+				s.Close()
+				recoveryConnectionLost(ctx)
+				restarted := newFakeServer(true)
+				connectTestServer(t, ctx, restarted)
+				recoveryConnected(ctx)
+				waitRecovery(t, ctx)
+				return restarted
+			},
+			state:   loaded,
+			modules: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeServer(tt.pipewire)
+			ctx := newTestContext(t, s)
+			testLoad(t, ctx)
+			if ctx.noiseSupressorState != loaded {
+				t.Fatalf("chain is %s after loading, want loaded, modules %q", stateName(ctx.noiseSupressorState), moduleArgs(t, s))
+			}
+
+			s = tt.run(t, ctx, s)
+			if state, _ := supressorState(ctx); state != tt.state {
+				t.Errorf("chain is %s, want %s", stateName(state), stateName(tt.state))
+			}
+			if mods := moduleArgs(t, s); len(mods) != tt.modules {
+				t.Errorf("%d modules, want %d: %q", len(mods), tt.modules, mods)
+			}
+		})
+	}
+}
+
+func waitRecovery(t *testing.T, ctx *ntcontext) {
+	t.Helper()
+	for deadline := time.Now().Add(15 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if !recoveryRunning(ctx) {
+			return
+		}
+	}
+	t.Fatalf("the chain wasn't rebuilt")
+}
+
+func TestUnloadHook(t *testing.T) {
+	marker := os.Getenv("XDG_RUNTIME_DIR") + "/unload-hook"
+	s := newFakeServer(false)
+	ctx := newTestContext(t, s)
+	ctx.config.Hooks = map[string]string{hookUnload: "touch " + marker}
+	testLoad(t, ctx)
+
+	inp, _ := inputSelection(ctx)
+	out, _ := outputSelection(ctx)
+	uiReloadFilters(ctx, inp, out)
+	hooksRunning.Wait()
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("unload hook ran on reload")
+	}
+
+	uiUnloadFilters(ctx)
+	hooksRunning.Wait()
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("unload hook didn't run when turned off: %v", err)
+	}
+}
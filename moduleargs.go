@@ -0,0 +1,262 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Power users sometimes need arguments we don't set, e.g. a different channel map or a larger
+// buffer on the loopback. The module arguments view shows the exact argument strings the input chain
+// of a microphone is loaded with, and custom arguments appended to them are stored in the profile of
+// the device. The gate isn't part of it, it's shared between microphones.
+
+// customModule returns the module with the custom arguments of inp appended.
+func customModule(ctx *ntcontext, inp *device, name, what, args string) chainModule {
+	if extra := customArgs(ctx, inp, name); extra != "" {
+		args += " " + extra
+	}
+	return chainModule{name: name, what: what, args: args, custom: true}
+}
+
+func customArgs(ctx *ntcontext, inp *device, module string) string {
+	p, _ := profileFor(ctx, inp)
+	return p.ModuleArgs[module]
+}
+
+// inputModules returns the modules the chain filtering inp loads on the current server.
+func inputModules(ctx *ntcontext, inp *device) ([]chainModule, error) {
+	switch {
+	case ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire:
+		return nil, fmt.Errorf("the native PipeWire filter-chain doesn't load modules")
+	case ctx.serverInfo.servertype == servertype_pipewire:
+		return []chainModule{pipeWireInputModule(ctx, inp)}, nil
+	}
+	return pulseInputModules(ctx, inp, false)
+}
+
+type moduleArg struct {
+	key, value string // the value without its quotes
+}
+
+// parseModuleArgs splits a module argument string, like pulseaudio's modargs parser it accepts
+// key=value pairs with optionally quoted values. Property lists, e.g. sink_properties, are
+// written the same way.
+func parseModuleArgs(args string) ([]moduleArg, error) {
+	var res []moduleArg
+	for i := 0; i < len(args); {
+		if args[i] == ' ' {
+			i++
+			continue
+		}
+		eq := strings.IndexAny(args[i:], "= ")
+		if eq < 0 || args[i+eq] != '=' {
+			return nil, fmt.Errorf("expected key=value at '%s'", args[i:])
+		}
+		arg := moduleArg{key: args[i : i+eq]}
+		i += eq + 1
+		if i < len(args) && (args[i] == '"' || args[i] == '\'') {
+			end := strings.IndexByte(args[i+1:], args[i])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in '%s'", args[i:])
+			}
+			arg.value = args[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			start := i
+			for i < len(args) && args[i] != ' ' {
+				i++
+			}
+			arg.value = args[start:i]
+		}
+		res = append(res, arg)
+	}
+	return res, nil
+}
+
+// moduleArgMap returns the values of a module argument string by key, or of a property list. What
+// doesn't parse is left out.
+func moduleArgMap(args string) map[string]string {
+	res := make(map[string]string)
+	parsed, _ := parseModuleArgs(args)
+	for _, a := range parsed {
+		res[a.key] = a.value
+	}
+	return res
+}
+
+// moduleArgKeys returns the keys of a module argument string.
+func moduleArgKeys(args string) ([]string, error) {
+	parsed, err := parseModuleArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, a := range parsed {
+		keys = append(keys, a.key)
+	}
+	return keys, nil
+}
+
+// validateCustomArgs checks that extra parses and doesn't set what base already does, pulseaudio
+// refuses to load modules with duplicate arguments.
+func validateCustomArgs(base, extra string) error {
+	baseKeys, err := moduleArgKeys(base)
+	if err != nil {
+		return err
+	}
+	keys, err := moduleArgKeys(extra)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	for _, k := range baseKeys {
+		set[k] = true
+	}
+	for _, k := range keys {
+		if set[k] {
+			return fmt.Errorf("%s is set twice", k)
+		}
+		set[k] = true
+	}
+	return nil
+}
+
+type moduleargsui struct {
+	dev      device
+	modules  []chainModule
+	previews []nucular.TextEditor // by module
+	editors  map[string]*nucular.TextEditor
+	errs     map[string]string
+	err      string
+}
+
+func openModuleArgs(ctx *ntcontext, inp device) {
+	ui := &ctx.moduleArgs
+	*ui = moduleargsui{dev: inp, editors: make(map[string]*nucular.TextEditor), errs: make(map[string]string)}
+	refreshModuleArgs(ctx)
+	ctx.views.Push(moduleArgsView)
+}
+
+// refreshModuleArgs computes the argument strings with the saved custom arguments.
+func refreshModuleArgs(ctx *ntcontext) {
+	ui := &ctx.moduleArgs
+	mods, err := inputModules(ctx, &ui.dev)
+	ui.modules, ui.err = mods, ""
+	if err != nil {
+		ui.err = err.Error()
+	}
+	ui.previews = make([]nucular.TextEditor, len(mods))
+	for i, m := range mods {
+		ui.previews[i].Flags = nucular.EditMultiline | nucular.EditReadOnly | nucular.EditSelectable | nucular.EditClipboard
+		ui.previews[i].Buffer = []rune(m.args)
+		if !m.custom || ui.editors[m.name] != nil {
+			continue
+		}
+		ed := &nucular.TextEditor{}
+		ed.Flags = nucular.EditField | nucular.EditSelectable | nucular.EditClipboard
+		ed.Buffer = []rune(customArgs(ctx, &ui.dev, m.name))
+		ui.editors[m.name] = ed
+	}
+}
+
+// saveModuleArgs validates the custom arguments and stores them in the profile of the device.
+func saveModuleArgs(ctx *ntcontext) {
+	ui := &ctx.moduleArgs
+	args := make(map[string]string)
+	ok := true
+	for _, m := range ui.modules {
+		ed := ui.editors[m.name]
+		if !m.custom || ed == nil {
+			continue
+		}
+		extra := strings.TrimSpace(string(ed.Buffer))
+		base := strings.TrimSpace(strings.TrimSuffix(m.args, customArgs(ctx, &ui.dev, m.name)))
+		delete(ui.errs, m.name)
+		if err := validateCustomArgs(base, extra); err != nil {
+			ui.errs[m.name] = err.Error()
+			ok = false
+			continue
+		}
+		if extra != "" {
+			args[m.name] = extra
+		}
+	}
+	if !ok {
+		return
+	}
+	if len(args) == 0 {
+		args = nil
+	}
+	updateProfile(ctx, &ui.dev, func(p *deviceProfile) { p.ModuleArgs = args })
+	infof("Custom module arguments of %s: %v\n", ui.dev.ID, args)
+	go writeConfig(ctx.config)
+	ctx.reloadRequired = ctx.noiseSupressorState == loaded
+	refreshModuleArgs(ctx)
+}
+
+func moduleArgsView(ctx *ntcontext, w *nucular.Window) {
+	ui := &ctx.moduleArgs
+	w.Row(15).Dynamic(1)
+	w.Label(trf("Module arguments for %s", ui.dev.Name), "CB")
+	if ui.err != "" {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(ui.err, "LC", red)
+	}
+
+	for i, m := range ui.modules {
+		w.Row(15).Dynamic(1)
+		w.Label(m.name, "LC")
+		w.Row(45).Dynamic(1)
+		focusableEdit(ctx, w, &ui.previews[i], ui.previews[i].Edit(w))
+		if !m.custom {
+			continue
+		}
+		ed := ui.editors[m.name]
+		if ed == nil {
+			continue
+		}
+		w.Row(25).Ratio(0.3, 0.7)
+		w.Label(tr("Custom arguments"), "LC")
+		focusableEdit(ctx, w, ed, ed.Edit(w))
+		if err := ui.errs[m.name]; err != "" {
+			w.Row(15).Dynamic(1)
+			w.LabelColored(err, "LC", red)
+		}
+	}
+
+	w.Row(25).Dynamic(3)
+	if focusable(ctx, w, w.ButtonText(tr("Save"))) {
+		saveModuleArgs(ctx)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Reset to default"))) {
+		for _, ed := range ui.editors {
+			ed.Buffer = nil
+		}
+		saveModuleArgs(ctx)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) {
+		ctx.views.Pop()
+	}
+}
+
+// moduleArgsSettingsView is the entry in the advanced settings.
+func moduleArgsSettingsView(ctx *ntcontext, w *nucular.Window) {
+	inp, ok := inputSelection(ctx)
+	if !ok {
+		return
+	}
+	w.Row(25).Ratio(0.7, 0.3)
+	if p, _ := profileFor(ctx, &inp); len(p.ModuleArgs) > 0 {
+		w.LabelColored(trf("Custom module arguments for %s", inp.Name), "LC", orange)
+	} else {
+		w.Label(trf("Module arguments for %s", inp.Name), "LC")
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Edit"))) {
+		openModuleArgs(ctx, inp)
+	}
+}
@@ -0,0 +1,205 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/nucular"
+	"github.com/noisetorch/pulseaudio"
+)
+
+const (
+	coughDuration = 2 * time.Second
+	// X repeats a held key as releases and presses, the unmute waits for a press that may follow
+	holdReleaseDelay = 150 * time.Millisecond
+)
+
+// coughState is the temporary mute of the cough button and shortcuts. The UI, the hotkeys and the
+// timers ending it run on goroutines of their own: they change what's wanted under the lock, and
+// applyCough mutes or unmutes to match, one at a time, so a late unmute never undoes a newer mute.
+type coughState struct {
+	sync.Mutex
+	apply  sync.Mutex // held while pactl runs, see applyCough
+	active bool       // the filtered microphone is muted for a cough
+	held   bool       // the button or the hold shortcut is down
+	until  time.Time  // end of the countdown after a cough or a release
+	button bool       // the cough button is down, only used by the UI
+}
+
+// wanted is whether the microphone should be muted for a cough now.
+func (c *coughState) wanted() bool {
+	return c.held || time.Now().Before(c.until)
+}
+
+// findVirtualSources returns all filtered microphones that are loaded.
+func findVirtualSources(ctx *ntcontext) []pulseaudio.Source {
+	sources, err := ctx.paClient.Sources()
+	if err != nil {
+		errorf("Couldn't fetch sources from pulseaudio: %v\n", err)
+		return nil
+	}
+	var res []pulseaudio.Source
+	for _, s := range sources {
+		if strings.HasPrefix(s.Name, "nui_mic_remap") || strings.HasPrefix(s.Name, "nui_mic_filtered") || s.Name == pipeWireNodeName || strings.HasPrefix(s.Name, "Filtered Microphone") {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// findVirtualSource returns the filtered microphone for inp, if loaded.
+func findVirtualSource(ctx *ntcontext, inp *device) (pulseaudio.Source, bool) {
+	for _, s := range findVirtualSources(ctx) {
+		if s.Name == inputChainFor(inp).remap || s.Name == pipeWireInputSource(inp) ||
+			s.Name == pipeWireNodeName || s.Name == "nui_mic_remap" {
+			return s, true
+		}
+	}
+	return pulseaudio.Source{}, false
+}
+
+// the pulseaudio library we use has no way to mute a source, so shell out to pactl which
+// works on both pulseaudio and pipewire-pulse
+func setVirtualSourceMute(ctx *ntcontext, mute bool) error {
+	sources := findVirtualSources(ctx)
+	if len(sources) == 0 {
+		return fmt.Errorf("filtered microphone is not loaded")
+	}
+	state := "0"
+	if mute {
+		state = "1"
+	}
+	for _, source := range sources {
+		cmd := audioCommand("pactl", "set-source-mute", source.Name, state)
+		debugf("Calling: %s\n", cmd.String())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("pactl set-source-mute failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// virtualSourceMuted reports whether the filtered microphones are muted.
+func virtualSourceMuted(ctx *ntcontext) bool {
+	sources := findVirtualSources(ctx)
+	for _, s := range sources {
+		if !s.Muted {
+			return false
+		}
+	}
+	return len(sources) > 0
+}
+
+func uiToggleMute(ctx *ntcontext) {
+	c := &ctx.cough
+	c.apply.Lock()
+	c.Lock()
+	// the button reads "Mute" during a cough, so it turns it into a hard mute instead of unmuting
+	cough := c.active || c.wanted()
+	c.active, c.held, c.until = false, false, time.Time{}
+	c.Unlock()
+	err := setVirtualSourceMute(ctx, cough || !virtualSourceMuted(ctx))
+	c.apply.Unlock()
+	if err != nil {
+		errorf("Couldn't toggle mute: %v\n", err)
+	}
+	(*ctx.masterWindow).Changed()
+}
+
+func muteButton(ctx *ntcontext, w *nucular.Window) {
+	txt := tr("Mute microphone")
+	if ctx.muted && !coughing(ctx) {
+		txt = tr("Unmute microphone")
+	}
+	if key := ctx.config.Hotkeys["mute"]; key != "" {
+		txt += " (" + key + ")"
+	}
+	w.Row(35).Dynamic(1)
+	if focusable(ctx, w, w.ButtonText(txt)) {
+		go uiToggleMute(ctx)
+	}
+}
+
+// coughing is whether the filtered microphone is muted for a cough.
+func coughing(ctx *ntcontext) bool {
+	ctx.cough.Lock()
+	defer ctx.cough.Unlock()
+	return ctx.cough.active
+}
+
+// hardMuted is whether the microphone is muted other than for a cough, unmuting it after one would
+// be a surprise. The caller holds the lock.
+func hardMuted(ctx *ntcontext) bool {
+	return ctx.muted && !ctx.cough.active && !ctx.cough.wanted()
+}
+
+// coughMute mutes the filtered microphone for coughDuration. Pressing it again while muted
+// restarts the countdown.
+func coughMute(ctx *ntcontext) {
+	c := &ctx.cough
+	c.Lock()
+	if hardMuted(ctx) {
+		c.Unlock()
+		return
+	}
+	c.until = time.Now().Add(coughDuration)
+	c.Unlock()
+	time.AfterFunc(coughDuration, func() { applyCough(ctx) })
+	go applyCough(ctx)
+}
+
+// coughPress mutes the filtered microphone until coughRelease. Both return right away, so a press
+// and its release stay in order.
+func coughPress(ctx *ntcontext) {
+	c := &ctx.cough
+	c.Lock()
+	if hardMuted(ctx) {
+		c.Unlock()
+		return
+	}
+	c.held = true
+	c.Unlock()
+	go applyCough(ctx)
+}
+
+// coughRelease unmutes the filtered microphone after the given time, unless it's pressed again.
+func coughRelease(ctx *ntcontext, after time.Duration) {
+	c := &ctx.cough
+	c.Lock()
+	if !c.held {
+		c.Unlock()
+		return
+	}
+	c.held = false
+	c.until = time.Now().Add(after)
+	c.Unlock()
+	time.AfterFunc(after, func() { applyCough(ctx) })
+	go applyCough(ctx)
+}
+
+// applyCough mutes or unmutes the filtered microphone as the cough wants it now.
+func applyCough(ctx *ntcontext) {
+	c := &ctx.cough
+	c.apply.Lock()
+	defer c.apply.Unlock()
+	c.Lock()
+	want := c.wanted()
+	if want == c.active {
+		c.Unlock()
+		return
+	}
+	c.Unlock()
+	if err := setVirtualSourceMute(ctx, want); err != nil {
+		errorf("Couldn't mute or unmute filtered microphone: %v\n", err)
+		return
+	}
+	c.Lock()
+	c.active = want
+	c.Unlock()
+	(*ctx.masterWindow).Changed()
+}
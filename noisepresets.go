@@ -0,0 +1,160 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Noise presets are starting points for common surroundings, the threshold, suppression, gain and
+// noise gate that work well there. Unlike the presets of presets.go they leave the devices alone.
+// Users can save their own next to the built-in ones. Which one is active isn't stored, it's the
+// one matching the current settings, so changing any of them by hand shows "Custom".
+
+type noisePreset struct {
+	Name        string
+	Threshold   int
+	Suppression int
+	OutputGain  int // dB
+	Gate        bool
+}
+
+var builtinNoisePresets = []noisePreset{
+	// the gate mutes the clicks between words that sound enough like voice to pass
+	{Name: "Mechanical keyboard", Threshold: 90, Suppression: 100, Gate: true},
+	// steady noise, RNNoise removes it well by itself
+	{Name: "Fan hum", Threshold: 70, Suppression: 100},
+	// traffic is loud and changing, a bit of gain makes up for the quieter voice
+	{Name: "Street noise", Threshold: 85, Suppression: 100, OutputGain: 3},
+	// a low threshold lets the quieter voices further away through, a little room keeps them natural
+	{Name: "Multiple speakers", Threshold: 40, Suppression: 85},
+}
+
+type noisepresetui struct {
+	editor nucular.TextEditor
+	err    string
+}
+
+func allNoisePresets(c *config) []noisePreset {
+	return append(append([]noisePreset{}, builtinNoisePresets...), c.NoisePresets...)
+}
+
+func builtinNoisePreset(name string) bool {
+	for _, p := range builtinNoisePresets {
+		if strings.EqualFold(p.Name, name) || strings.EqualFold(tr(p.Name), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingNoisePreset returns the index into allNoisePresets of the preset matching the current
+// settings, or -1.
+func matchingNoisePreset(c *config) int {
+	for i, p := range allNoisePresets(c) {
+		if p.Threshold == c.Threshold && p.Suppression == c.Suppression && p.OutputGain == c.OutputGain && p.Gate == c.Gate {
+			return i
+		}
+	}
+	return -1
+}
+
+func applyNoisePreset(ctx *ntcontext, p noisePreset) {
+	infof("Switching to noise preset %s\n", p.Name)
+	gateChanged := ctx.config.Gate != p.Gate
+	ctx.config.Threshold = p.Threshold
+	ctx.config.Suppression = p.Suppression
+	ctx.config.OutputGain = p.OutputGain
+	ctx.config.Gate = p.Gate
+	go writeConfig(ctx.config)
+	if ctx.noiseSupressorState != loaded {
+		return
+	}
+	requestLiveControls(ctx)
+	if gateChanged {
+		ctx.reloadRequired = true
+	}
+	if ctx.config.FilterInput {
+		go func() {
+			if err := applyGain(ctx); err != nil {
+				errorf("Couldn't apply gain: %v\n", err)
+			}
+		}()
+	}
+}
+
+// saveNoisePreset stores the current settings under name, replacing a saved preset of the same name.
+func saveNoisePreset(c *config, name string) {
+	p := noisePreset{
+		Name:        name,
+		Threshold:   c.Threshold,
+		Suppression: c.Suppression,
+		OutputGain:  c.OutputGain,
+		Gate:        c.Gate,
+	}
+	for i := range c.NoisePresets {
+		if strings.EqualFold(c.NoisePresets[i].Name, name) {
+			c.NoisePresets[i] = p
+			return
+		}
+	}
+	c.NoisePresets = append(c.NoisePresets, p)
+}
+
+func noisePresetView(ctx *ntcontext, w *nucular.Window) {
+	ui := &ctx.noisePresets
+	presets := allNoisePresets(ctx.config)
+	names := make([]string, 0, len(presets)+1)
+	for i, p := range presets {
+		if i < len(builtinNoisePresets) {
+			names = append(names, tr(p.Name))
+		} else {
+			names = append(names, p.Name)
+		}
+	}
+	names = append(names, tr("Custom"))
+	selected := matchingNoisePreset(ctx.config)
+	if selected < 0 {
+		selected = len(presets)
+	}
+
+	w.Row(25).Ratio(0.5, 0.35, 0.15)
+	w.Label(tr("Noise type"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Sets the threshold, suppression, gain and noise gate for your surroundings."))
+	}
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected && sel < len(presets) {
+		applyNoisePreset(ctx, presets[sel])
+	}
+	if user := selected - len(builtinNoisePresets); user >= 0 && user < len(ctx.config.NoisePresets) {
+		if focusable(ctx, w, w.ButtonText(tr("Delete"))) {
+			ctx.config.NoisePresets = append(ctx.config.NoisePresets[:user], ctx.config.NoisePresets[user+1:]...)
+			go writeConfig(ctx.config)
+		}
+	} else {
+		w.Spacing(1)
+	}
+
+	ui.editor.Flags = nucular.EditField | nucular.EditSigEnter
+	w.Row(25).Ratio(0.5, 0.35, 0.15)
+	w.Label(tr("Save the current settings as"), "LC")
+	ev := focusableEdit(ctx, w, &ui.editor, ui.editor.Edit(w))
+	save := focusable(ctx, w, w.ButtonText(tr("Save")))
+	if name := strings.TrimSpace(string(ui.editor.Buffer)); (save || ev&nucular.EditCommitted != 0) && name != "" {
+		if builtinNoisePreset(name) {
+			ui.err = trf("%s is built in, pick another name.", name)
+		} else {
+			saveNoisePreset(ctx.config, name)
+			go writeConfig(ctx.config)
+			ui.editor.Buffer = nil
+			ui.err = ""
+		}
+	}
+	if ui.err != "" {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(ui.err, "LC", orange)
+	}
+}
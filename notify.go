@@ -0,0 +1,152 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Desktop notifications go through org.freedesktop.Notifications with gdbus, like the rest of our
+// D-Bus calls. Each kind replaces its previous notification instead of piling up, and can be turned
+// off in the settings.
+
+const (
+	notifyFilter = iota
+	notifyConnection
+	notifyUpdate
+)
+
+// the filter is unloaded and loaded again when switching devices, only its state after that is shown
+const notifyFilterSettle = 1500 * time.Millisecond
+
+type notifier struct {
+	sync.Mutex
+	ids         map[int]uint32 // of the last notification of each kind
+	filterState int
+	filterKnown bool
+	filterTimer *time.Timer
+	lost        bool
+}
+
+// the id in GVariant text format, e.g. (uint32 12,)
+var notificationID = regexp.MustCompile(`uint32 (\d+)`)
+
+func notifyEnabled(c *config, kind int) bool {
+	switch kind {
+	case notifyFilter:
+		return c.NotifyFilter
+	case notifyConnection:
+		return c.NotifyConnection
+	case notifyUpdate:
+		return c.NotifyUpdates
+	}
+	return false
+}
+
+// notify shows a notification of the kind if the user wants them.
+func notify(ctx *ntcontext, kind int, summary, body string) {
+	if !notifyEnabled(ctx.config, kind) {
+		return
+	}
+	n := &ctx.notifier
+	n.Lock()
+	defer n.Unlock()
+	if n.ids == nil {
+		n.ids = make(map[int]uint32)
+	}
+	cmd := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString(appName), strconv.FormatUint(uint64(n.ids[kind]), 10), gvariantString("noisetorch"),
+		gvariantString(summary), gvariantString(body), "[]", "{}", "5000")
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		debugf("Couldn't send notification: %v\n", err)
+		return
+	}
+	if match := notificationID.FindStringSubmatch(string(out)); match != nil {
+		id, _ := strconv.ParseUint(match[1], 10, 32)
+		n.ids[kind] = uint32(id)
+	}
+}
+
+// notifyFilterState tells about the filter getting loaded or unloaded once its state settled.
+func notifyFilterState(ctx *ntcontext) {
+	n := &ctx.notifier
+	n.Lock()
+	defer n.Unlock()
+	if !n.filterKnown {
+		// what was there when we connected, nothing changed
+		n.filterKnown = true
+		n.filterState = ctx.noiseSupressorState
+		return
+	}
+	if n.filterTimer != nil {
+		n.filterTimer.Stop()
+	}
+	n.filterTimer = time.AfterFunc(notifyFilterSettle, func() {
+		n.Lock()
+		state := ctx.noiseSupressorState
+		changed := state != n.filterState && state != inconsistent
+		if changed {
+			n.filterState = state
+		}
+		n.Unlock()
+		if !changed {
+			return
+		}
+		if state == loaded {
+			notify(ctx, notifyFilter, tr("Noise suppression on"), tr("The filtered microphone is loaded."))
+		} else {
+			notify(ctx, notifyFilter, tr("Noise suppression off"), tr("The filtered microphone was removed."))
+		}
+	})
+}
+
+func notifyConnectionLost(ctx *ntcontext) {
+	n := &ctx.notifier
+	n.Lock()
+	n.lost = true
+	n.filterKnown = false
+	if n.filterTimer != nil {
+		n.filterTimer.Stop()
+	}
+	n.Unlock()
+	notify(ctx, notifyConnection, tr("Audio server connection lost"), tr("NoiseTorch-ng reconnects as soon as it is back."))
+}
+
+func notifyConnected(ctx *ntcontext) {
+	n := &ctx.notifier
+	n.Lock()
+	lost := n.lost
+	n.lost = false
+	n.Unlock()
+	if lost {
+		notify(ctx, notifyConnection, tr("Audio server connection restored"), trf("Connected to %s.", ctx.serverInfo.name))
+	}
+}
+
+func notificationsView(ctx *ntcontext, w *nucular.Window) {
+	for _, o := range []struct {
+		text string
+		v    *bool
+	}{
+		{"Notify when the filter is loaded or unloaded", &ctx.config.NotifyFilter},
+		{"Notify when the connection to the audio server is lost or restored", &ctx.config.NotifyConnection},
+		{"Notify when an update is available", &ctx.config.NotifyUpdates},
+	} {
+		w.Row(15).Dynamic(1)
+		if focusable(ctx, w, w.CheckboxText(tr(o.text), o.v)) {
+			go writeConfig(ctx.config)
+		}
+	}
+}
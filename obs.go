@@ -0,0 +1,332 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The OBS integration connects to obs-websocket (built into OBS Studio since 28) and loads the
+// filter for the selected devices when streaming or recording starts. When both stopped it unloads
+// the filter again, but only if it loaded it: a filter the user loaded stays.
+
+const (
+	obsOpHello           = 0
+	obsOpIdentify        = 1
+	obsOpIdentified      = 2
+	obsOpEvent           = 5
+	obsOpRequest         = 6
+	obsOpRequestResponse = 7
+
+	obsRPCVersion         = 1
+	obsSubscribeOutputs   = 1 << 6
+	obsAuthFailed         = 4009
+	obsDialTimeout        = 5 * time.Second
+	obsReconnectInterval  = 10 * time.Second
+	defaultOBSPort        = 4455
+	obsStreamStateChanged = "StreamStateChanged"
+	obsRecordStateChanged = "RecordStateChanged"
+)
+
+type obsMessage struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type obsIntegration struct {
+	sync.Mutex
+	conn       *wsConn
+	status     string // shown in the settings
+	statusErr  bool
+	closing    bool // we dropped the connection to apply new settings
+	streaming  bool
+	recording  bool
+	loadedByUs bool
+	changed    chan struct{} // the settings changed, reconnect
+
+	// editors of the settings
+	host, port, password nucular.TextEditor
+	edited               bool
+}
+
+// obsWatcher keeps the connection to OBS while the integration is enabled.
+func obsWatcher(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	o := &ctx.obs
+	for {
+		if ctx.config.OBS {
+			err := obsSession(ctx)
+			debugf("OBS connection ended: %v\n", err)
+			o.Lock()
+			if o.closing {
+				o.status, o.statusErr = "", false
+			} else {
+				o.status, o.statusErr = trf("Not connected: %v", err), true
+			}
+			o.conn, o.closing = nil, false
+			o.Unlock()
+			// OBS closing ends streaming and recording just the same
+			obsOutputsChanged(ctx, false, false)
+		}
+		select {
+		case <-o.changed:
+		case <-time.After(obsReconnectInterval):
+		}
+	}
+}
+
+// obsReconnect applies changed settings, dropping the current connection.
+func obsReconnect(ctx *ntcontext) {
+	o := &ctx.obs
+	o.Lock()
+	if o.conn != nil {
+		o.closing = true
+		o.conn.Close()
+	}
+	o.Unlock()
+	select {
+	case o.changed <- struct{}{}:
+	default:
+	}
+}
+
+func obsSession(ctx *ntcontext) error {
+	o := &ctx.obs
+	addr := net.JoinHostPort(ctx.config.OBSHost, strconv.Itoa(ctx.config.OBSPort))
+	conn, err := dialWebSocket(addr, "/", obsDialTimeout)
+	if err != nil {
+		return err
+	}
+	o.Lock()
+	o.conn = conn
+	o.Unlock()
+	defer conn.Close()
+
+	hello, err := obsRead(conn, obsOpHello)
+	if err != nil {
+		return err
+	}
+	var h struct {
+		Authentication *struct {
+			Challenge string `json:"challenge"`
+			Salt      string `json:"salt"`
+		} `json:"authentication"`
+	}
+	if err := json.Unmarshal(hello, &h); err != nil {
+		return err
+	}
+	identify := map[string]interface{}{"rpcVersion": obsRPCVersion, "eventSubscriptions": obsSubscribeOutputs}
+	if h.Authentication != nil {
+		identify["authentication"] = obsAuthentication(ctx.config.OBSPassword, h.Authentication.Salt, h.Authentication.Challenge)
+	}
+	if err := obsWrite(conn, obsOpIdentify, identify); err != nil {
+		return err
+	}
+	if _, err := obsRead(conn, obsOpIdentified); err != nil {
+		if closeErr, ok := err.(*wsCloseError); ok && closeErr.code == obsAuthFailed {
+			return fmt.Errorf("wrong password")
+		}
+		return err
+	}
+	infof("Connected to OBS at %s\n", addr)
+	o.Lock()
+	o.status, o.statusErr = tr("Connected"), false
+	o.Unlock()
+	(*ctx.masterWindow).Changed()
+
+	// the outputs may already be running
+	for _, request := range []string{"GetStreamStatus", "GetRecordStatus"} {
+		if err := obsWrite(conn, obsOpRequest, map[string]string{"requestType": request, "requestId": request}); err != nil {
+			return err
+		}
+	}
+
+	streaming, recording := false, false
+	for {
+		buf, err := conn.ReadText()
+		if err != nil {
+			return err
+		}
+		var msg obsMessage
+		if err := json.Unmarshal(buf, &msg); err != nil {
+			return err
+		}
+		switch msg.Op {
+		case obsOpEvent:
+			var ev struct {
+				EventType string `json:"eventType"`
+				EventData struct {
+					OutputActive bool `json:"outputActive"`
+				} `json:"eventData"`
+			}
+			if err := json.Unmarshal(msg.D, &ev); err != nil {
+				return err
+			}
+			switch ev.EventType {
+			case obsStreamStateChanged:
+				streaming = ev.EventData.OutputActive
+			case obsRecordStateChanged:
+				recording = ev.EventData.OutputActive
+			default:
+				continue
+			}
+		case obsOpRequestResponse:
+			var resp struct {
+				RequestID    string `json:"requestId"`
+				ResponseData struct {
+					OutputActive bool `json:"outputActive"`
+				} `json:"responseData"`
+			}
+			if err := json.Unmarshal(msg.D, &resp); err != nil {
+				return err
+			}
+			switch resp.RequestID {
+			case "GetStreamStatus":
+				streaming = resp.ResponseData.OutputActive
+			case "GetRecordStatus":
+				recording = resp.ResponseData.OutputActive
+			}
+		default:
+			continue
+		}
+		obsOutputsChanged(ctx, streaming, recording)
+	}
+}
+
+// obsAuthentication answers the challenge of obs-websocket, see its protocol documentation.
+func obsAuthentication(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
+
+func obsWrite(conn *wsConn, op int, d interface{}) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(obsMessage{Op: op, D: data})
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(buf)
+}
+
+// obsRead returns the data of the next message, which must have the given op.
+func obsRead(conn *wsConn, op int) (json.RawMessage, error) {
+	buf, err := conn.ReadText()
+	if err != nil {
+		return nil, err
+	}
+	var msg obsMessage
+	if err := json.Unmarshal(buf, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Op != op {
+		return nil, fmt.Errorf("unexpected message from OBS with op %d", msg.Op)
+	}
+	return msg.D, nil
+}
+
+// obsOutputsChanged loads the filter when OBS started streaming or recording, and unloads it when
+// both stopped if we loaded it.
+func obsOutputsChanged(ctx *ntcontext, streaming, recording bool) {
+	o := &ctx.obs
+	o.Lock()
+	wasActive := o.streaming || o.recording
+	o.streaming, o.recording = streaming, recording
+	active := streaming || recording
+	load := active && !wasActive && ctx.noiseSupressorState == unloaded
+	unload := !active && wasActive && o.loadedByUs
+	if load {
+		o.loadedByUs = true
+	}
+	if !active {
+		o.loadedByUs = false
+	}
+	o.Unlock()
+
+	if load {
+		inp, inpOk := inputSelection(ctx)
+		out, outOk := outputSelection(ctx)
+		if !validConfiguration(ctx, inpOk, outOk) {
+			warnf("OBS started, but no devices are selected to load the filter for\n")
+			return
+		}
+		infof("OBS started streaming or recording, loading the filter\n")
+		uiReloadFilters(ctx, inp, out)
+	}
+	if unload && ctx.noiseSupressorState != unloaded {
+		infof("OBS stopped streaming and recording, unloading the filter\n")
+		uiUnloadFilters(ctx)
+	}
+}
+
+func integrationsView(ctx *ntcontext, w *nucular.Window) {
+	o := &ctx.obs
+	if !o.edited {
+		o.host.Flags = nucular.EditField | nucular.EditSelectable | nucular.EditClipboard
+		o.port.Flags = nucular.EditField | nucular.EditSelectable | nucular.EditClipboard
+		o.password.Flags = nucular.EditField | nucular.EditSelectable | nucular.EditClipboard
+		o.password.PasswordChar = '*'
+		o.host.Buffer = []rune(ctx.config.OBSHost)
+		o.port.Buffer = []rune(strconv.Itoa(ctx.config.OBSPort))
+		o.password.Buffer = []rune(ctx.config.OBSPassword)
+		o.edited = true
+	}
+
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Load the filter while OBS streams or records"), &ctx.config.OBS)) {
+		go writeConfig(ctx.config)
+		go obsReconnect(ctx)
+	}
+	if !ctx.config.OBS {
+		return
+	}
+	w.Row(25).Ratio(0.3, 0.7)
+	w.Label(tr("Host"), "LC")
+	focusableEdit(ctx, w, &o.host, o.host.Edit(w))
+	w.Row(25).Ratio(0.3, 0.7)
+	w.Label(tr("Port"), "LC")
+	focusableEdit(ctx, w, &o.port, o.port.Edit(w))
+	w.Row(25).Ratio(0.3, 0.7)
+	w.Label(tr("Password"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("From Tools > WebSocket Server Settings in OBS. Leave empty if authentication is off."))
+	}
+	focusableEdit(ctx, w, &o.password, o.password.Edit(w))
+
+	o.Lock()
+	status, statusErr := o.status, o.statusErr
+	o.Unlock()
+	w.Row(25).Ratio(0.7, 0.3)
+	if statusErr {
+		w.LabelColored(status, "LC", orange)
+	} else {
+		w.Label(status, "LC")
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Connect"))) {
+		port, err := strconv.Atoi(string(o.port.Buffer))
+		if err != nil || port < 1 || port > 65535 {
+			o.Lock()
+			o.status, o.statusErr = tr("The port must be a number between 1 and 65535."), true
+			o.Unlock()
+			return
+		}
+		ctx.config.OBSHost = string(o.host.Buffer)
+		ctx.config.OBSPort = port
+		ctx.config.OBSPassword = string(o.password.Buffer)
+		go writeConfig(ctx.config)
+		go obsReconnect(ctx)
+	}
+}
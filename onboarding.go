@@ -0,0 +1,200 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The first start walks through setting up the filter: pick the microphone, load the filter and
+// listen to the surroundings to suggest a threshold, then whether to start on login. It can be
+// run again from the About menu.
+
+const (
+	onboardWelcome = iota
+	onboardMicrophone
+	onboardNoiseTest
+	onboardThreshold
+	onboardAutostart
+)
+
+type onboardingui struct {
+	step    int
+	mic     int // index into ctx.inputList
+	testing bool
+	result  calibrationResult
+	err     error
+}
+
+func openOnboarding(ctx *ntcontext) {
+	ctx.onboarding = onboardingui{mic: -1}
+	for i, d := range ctx.inputList {
+		if d.checked && !d.isMonitor {
+			ctx.onboarding.mic = i
+			break
+		}
+	}
+	ctx.views.Push(onboardingView)
+	(*ctx.masterWindow).Changed()
+}
+
+func finishOnboarding(ctx *ntcontext) {
+	ctx.config.Onboarded = true
+	go writeConfig(ctx.config)
+	ctx.views.Pop()
+}
+
+// onboardLoad loads the filter for the picked microphone and listens to the surroundings.
+func onboardLoad(ctx *ntcontext) {
+	o := &ctx.onboarding
+	inp := ctx.inputList[o.mic]
+	ctx.config.FilterInput = true
+	out, _ := outputSelection(ctx)
+	uiReloadFilters(ctx, inp, out)
+
+	if !currentEngine(ctx).rnnoise {
+		// nothing to calibrate, the other engines don't have a threshold
+		o.step = onboardAutostart
+		o.testing = false
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	o.result, o.err = calibrate(inp.ID)
+	o.testing = false
+	(*ctx.masterWindow).Changed()
+}
+
+func onboardingView(ctx *ntcontext, w *nucular.Window) {
+	o := &ctx.onboarding
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Welcome to NoiseTorch-ng"), "CB")
+	w.Row(10).Dynamic(1)
+
+	switch o.step {
+	case onboardWelcome:
+		w.Row(90).Dynamic(1)
+		w.LabelWrap(tr("NoiseTorch-ng removes background noise from your microphone. It adds a virtual microphone called " +
+			"\"Filtered Microphone\": choose it in your voice chat, recording or streaming application instead of your " +
+			"real microphone, and everything you say goes through the filter first. Loading the filter creates that " +
+			"microphone, unloading removes it again. Let's set it up."))
+
+	case onboardMicrophone:
+		w.Row(15).Dynamic(1)
+		w.Label(tr("Which microphone do you want to filter?"), "LC")
+		for i := range ctx.inputList {
+			d := &ctx.inputList[i]
+			if d.isMonitor {
+				continue
+			}
+			w.Row(20).Dynamic(1)
+			if focusable(ctx, w, w.OptionText(d.Name, o.mic == i)) {
+				o.mic = i
+			}
+		}
+
+	case onboardNoiseTest:
+		if o.testing {
+			w.Row(30).Dynamic(1)
+			w.LabelWrap(tr("Loading the filter. Please stay quiet for 5 seconds while we listen to your surroundings..."))
+			break
+		}
+		if o.err != nil {
+			w.Row(30).Dynamic(1)
+			w.LabelWrapColored(trf("The noise test failed: %v", o.err), red)
+			break
+		}
+		w.Row(15).Dynamic(1)
+		w.Label(trf("Noise floor: %.1f dBFS, peak: %.1f dBFS", o.result.noiseFloor, o.result.peak), "LC")
+		w.Row(15).Dynamic(1)
+		w.Label(trf("Your surroundings look like voice with up to %.0f%% probability.", o.result.vadP95*100), "LC")
+		w.Row(30).Dynamic(1)
+		w.LabelWrap(tr("The filter is loaded now. Select \"Filtered Microphone\" in your applications to use it."))
+
+	case onboardThreshold:
+		w.Row(45).Dynamic(1)
+		w.LabelWrap(tr("The voice activation threshold is how sure the filter has to be that you're speaking before it lets " +
+			"sound through. Higher is stricter, lower it if you get cut off while talking."))
+		c := engineByID("rnnoise").control
+		w.Row(25).Ratio(0.5, 0.4, 0.1)
+		w.Label(tr(c.name), "LC")
+		focusableSlider(ctx, w, w.SliderInt(c.min, &ctx.config.Threshold, c.max, 1), &ctx.config.Threshold, c.min, c.max, 1)
+		w.Label(fmt.Sprintf("%d%%", ctx.config.Threshold), "RC")
+
+	case onboardAutostart:
+		w.Row(15).Dynamic(1)
+		w.Label(tr("Almost done."), "LC")
+		w.Row(15).Dynamic(1)
+		focusable(ctx, w, w.CheckboxText(tr("Start on login (loads the filter for the last used microphone)"), &ctx.config.Autostart))
+	}
+
+	onboardingButtons(ctx, w)
+}
+
+func onboardingButtons(ctx *ntcontext, w *nucular.Window) {
+	o := &ctx.onboarding
+	w.Row(25).Dynamic(2)
+	if o.testing {
+		return
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Skip setup"))) {
+		finishOnboarding(ctx)
+		return
+	}
+
+	switch o.step {
+	case onboardMicrophone:
+		if o.mic < 0 {
+			w.Spacing(1)
+			return
+		}
+		if focusable(ctx, w, w.ButtonText(tr("Load and test"))) {
+			for i := range ctx.inputList {
+				ctx.inputList[i].checked = i == o.mic
+			}
+			applyProfile(ctx, &ctx.inputList[o.mic])
+			o.step, o.testing, o.err = onboardNoiseTest, true, nil
+			go onboardLoad(ctx)
+		}
+	case onboardNoiseTest:
+		if o.err != nil {
+			if focusable(ctx, w, w.ButtonText(tr("Back"))) {
+				o.step = onboardMicrophone
+			}
+			return
+		}
+		if focusable(ctx, w, w.ButtonText(tr("Next"))) {
+			ctx.config.Threshold = o.result.suggested
+			o.step = onboardThreshold
+		}
+	case onboardThreshold:
+		if focusable(ctx, w, w.ButtonText(tr("Next"))) {
+			// the filter was loaded with the old threshold, this also saves the new one to the profile
+			out, _ := outputSelection(ctx)
+			go uiReloadFilters(ctx, ctx.inputList[o.mic], out)
+			o.step = onboardAutostart
+		}
+	case onboardAutostart:
+		if focusable(ctx, w, w.ButtonText(tr("Finish"))) {
+			if ctx.config.Autostart {
+				inp := ctx.inputList[o.mic]
+				updateProfile(ctx, &inp, func(p *deviceProfile) { p.EnableOnStart = true })
+				go func() {
+					if err := syncAutostart(ctx); err != nil {
+						errorf("Couldn't set up start on login: %v\n", err)
+						ctx.config.Autostart = false
+						ctx.views.Push(makeErrorView(ctx, err.Error()))
+					}
+					writeConfig(ctx.config)
+				}()
+			}
+			finishOnboarding(ctx)
+		}
+	default:
+		if focusable(ctx, w, w.ButtonText(tr("Next"))) {
+			o.step++
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"github.com/noisetorch/pulseaudio"
+)
+
+// Playback applications on the OutputApps list are moved to the filtered headphones as soon as they
+// start playing, e.g. only the browser running a meeting and not the music player. While the list
+// isn't empty, the other applications ending up on the filtered headphones, e.g. because they're the
+// default, are moved to the headphones they're filtered for. Like on the recording side each stream
+// is moved only once, if the user moves it back we leave it there.
+//
+// When the filter is unloaded the server moves the streams of the filtered headphones to its default.
+
+// outputRouting keeps track of the playback streams we moved.
+type outputRouting struct {
+	moved map[uint32]bool // by sink input index
+}
+
+func listSinkInputs() ([]stream, error) {
+	return listStreams("sink-inputs", "Sink Input #", "Sink")
+}
+
+// findVirtualSink returns the filtered headphones, if loaded.
+func findVirtualSink(ctx *ntcontext) (pulseaudio.Sink, bool) {
+	sinks, err := ctx.paClient.Sinks()
+	if err != nil {
+		errorf("Couldn't fetch sinks from pulseaudio: %v\n", err)
+		return pulseaudio.Sink{}, false
+	}
+	for _, s := range sinks {
+		if s.Name == "nui_out_in_sink" || s.Name == "Filtered Headphones" {
+			return s, true
+		}
+	}
+	return pulseaudio.Sink{}, false
+}
+
+// updateOutputRouting is called on every audio server update.
+func updateOutputRouting(ctx *ntcontext) {
+	if ctx.noiseSupressorState != loaded || !ctx.config.FilterOutput || len(ctx.config.OutputApps) == 0 || jackMode(ctx) {
+		return
+	}
+	virt, ok := findVirtualSink(ctx)
+	if !ok {
+		return
+	}
+	out, outOk := outputSelection(ctx)
+	inputs, err := listSinkInputs()
+	if err != nil {
+		errorf("Couldn't list playing applications: %v\n", err)
+		return
+	}
+
+	r := &ctx.outputRouting
+	if r.moved == nil {
+		r.moved = make(map[uint32]bool)
+	}
+	present := make(map[uint32]bool, len(inputs))
+	for _, o := range inputs {
+		present[o.index] = true
+		if _, ours := o.props[tagID]; ours || r.moved[o.index] {
+			continue
+		}
+		listed := o.matches(ctx.config.OutputApps)
+		switch {
+		case listed && o.device != virt.Index:
+			infof("Moving %s to the filtered headphones\n", o.appName())
+			if err := moveStream("sink-input", o.index, virt.Name); err != nil {
+				errorf("Couldn't move %s to the filtered headphones: %v\n", o.appName(), err)
+			}
+		case !listed && o.device == virt.Index && outOk:
+			infof("Moving %s to %s, it isn't on the list of filtered applications\n", o.appName(), out.ID)
+			if err := moveStream("sink-input", o.index, out.ID); err != nil {
+				errorf("Couldn't move %s away from the filtered headphones: %v\n", o.appName(), err)
+			}
+		default:
+			continue
+		}
+		r.moved[o.index] = true
+	}
+	for idx := range r.moved {
+		if !present[idx] {
+			delete(r.moved, idx)
+		}
+	}
+}
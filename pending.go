@@ -0,0 +1,73 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// pendingChanges lists the settings that differ from the currently loaded chain.
+func pendingChanges(ctx *ntcontext) []string {
+	if ctx.noiseSupressorState == unloaded {
+		return nil
+	}
+	var changes []string
+	chain := ctx.chain
+
+	if inps := inputSelections(ctx); len(inps) > 0 {
+		if len(chain.inputs) > 0 && !sameInputs(inps, chain.inputs) {
+			changes = append(changes, "microphone")
+		}
+	} else if len(chain.inputs) > 0 {
+		changes = append(changes, "microphone filter disabled")
+	}
+	if out, ok := outputSelection(ctx); ok {
+		if chain.output != "" && chain.output != out.ID {
+			changes = append(changes, "headphones")
+		}
+	} else if chain.output != "" {
+		changes = append(changes, "headphones filter disabled")
+	}
+	if chain.label != "" && chain.label != currentEngine(ctx).label {
+		changes = append(changes, "engine")
+	} else {
+		if chain.threshold >= 0 && chain.threshold != ctx.config.Threshold {
+			changes = append(changes, "threshold")
+		}
+		if chain.suppression >= 0 && chain.suppression != ctx.config.Suppression {
+			changes = append(changes, "suppression")
+		}
+	}
+	if chain.gate != ctx.config.Gate && ctx.serverInfo.servertype == servertype_pulse && len(chain.inputs) > 0 {
+		changes = append(changes, "noise gate")
+	}
+	if len(changes) == 0 && ctx.reloadRequired {
+		// settings we can't read back from the running chain, e.g. the native PipeWire filter-chain
+		changes = append(changes, "settings")
+	}
+	return changes
+}
+
+func sameInputs(inps []device, ids []string) bool {
+	if len(inps) != len(ids) {
+		return false
+	}
+	for i := range inps {
+		found := false
+		for _, id := range ids {
+			found = found || inps[i].ID == id
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func pendingChangesView(ctx *ntcontext, w *nucular.Window, changes []string) {
+	w.Row(20).Dynamic(1)
+	w.LabelColored(trf("Changes pending (%s) — Apply to take effect.", strings.Join(changes, ", ")), "LC", orange)
+}
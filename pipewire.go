@@ -0,0 +1,250 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"noisetorch/buildinfo"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// The native PipeWire backend doesn't go through the pulseaudio compatibility layer to load
+// module-ladspa-source. Instead it runs a dedicated pipewire instance hosting a filter-chain,
+// the same way distributions ship their own filter-chain services. This gives us a properly
+// named node and avoids the quirks of the emulated pulse modules.
+//
+// The filter-chain process is detached from us, so the filter keeps running after NoiseTorch exits.
+
+const pipeWireNodeName = "noisetorch_mic_filtered"
+
+// pipeWireRuntimeDir holds the configs and pid files of the processes we start detached. Without
+// XDG_RUNTIME_DIR it's in the cache of the user like the plugins, never in a shared directory like
+// /tmp, where another user could create it first and swap what we load or signal.
+func pipeWireRuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "noisetorch")
+	}
+	return filepath.Join(cacheDir(), "run")
+}
+
+func pipeWirePidFile() string {
+	return filepath.Join(pipeWireRuntimeDir(), "filter-chain.pid")
+}
+
+// pipeWireGateNode returns the filter graph entries adding the noise gate after the filter.
+func pipeWireGateNode(ctx *ntcontext, plugin string) string {
+	c := ctx.config
+	return fmt.Sprintf(`
+                    {
+                        type    = ladspa
+                        name    = gate
+                        plugin  = %s
+                        label   = %s
+                        control = { "Threshold (dB)" = %d "Attack (ms)" = %d "Release (ms)" = %d }
+                    }
+                ]
+                links = [
+                    { output = "rnnoise:Output" input = "gate:Input" }`,
+		strconv.Quote(plugin), gateLabel, c.GateThreshold, c.GateAttack, c.GateRelease)
+}
+
+func pipeWireFilterChainConfig(plugin string, e engine, control int, suppression string, inp *device, id string, gate string, latency int, keepAwake string) string {
+	return fmt.Sprintf(`context.properties = {
+    log.level = 0
+}
+
+context.spa-libs = {
+    audio.convert.* = audioconvert/libspa-audioconvert
+    support.*       = support/libspa-support
+}
+
+context.modules = [
+    { name = libpipewire-module-rt
+        args = { nice.level = -11 }
+        flags = [ ifexists nofail ]
+    }
+    { name = libpipewire-module-protocol-native }
+    { name = libpipewire-module-client-node }
+    { name = libpipewire-module-adapter }
+    { name = libpipewire-module-filter-chain
+        args = {
+            node.description = %[1]s
+            media.name       = %[2]s
+            filter.graph = {
+                nodes = [
+                    {
+                        type    = ladspa
+                        name    = rnnoise
+                        plugin  = %[3]s
+                        label   = %[9]s
+                        control = { %[10]q = %[4]d%[16]s }
+                    }%[11]s
+                ]
+            }
+            audio.rate     = 48000
+            audio.channels = 1
+            capture.props = {
+                node.name          = "noisetorch_capture"
+                node.passive       = true
+                node.latency       = %[12]d/48000%[13]s
+                target.object      = %[5]s
+                stream.dont-remix  = true
+                noisetorch.id      = %[7]s
+                noisetorch.version = %[8]s%[14]s
+            }
+            playback.props = {
+                node.name          = %[6]q
+                media.class        = Audio/Source%[13]s
+                noisetorch.id      = %[7]s
+                noisetorch.version = %[8]s%[15]s
+            }
+        }
+    }
+]
+`,
+		strconv.Quote("Filtered Microphone for "+inp.Name),
+		strconv.Quote("Filtered Microphone for "+inp.Name),
+		strconv.Quote(plugin),
+		control,
+		strconv.Quote(inp.ID),
+		pipeWireNodeName,
+		strconv.Quote(id),
+		strconv.Quote(buildinfo.Version),
+		e.label,
+		e.control.port,
+		gate,
+		latency*48,
+		keepAwake,
+		pipeWirePresence(false),
+		pipeWirePresence(true),
+		suppression)
+}
+
+func loadPipeWireNativeInput(ctx *ntcontext, inp *device) error {
+	infof("Loading supressor for pipewire (native filter-chain)\n")
+	dir := pipeWireRuntimeDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	plugin, err := filterChainPlugin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := installModel(plugin, activeModel(ctx)); err != nil {
+		return err
+	}
+
+	chainConf, err := pipeWireNativeConfig(ctx, plugin, inp)
+	if err != nil {
+		return err
+	}
+	conf := filepath.Join(dir, "filter-chain.conf")
+	if err := os.WriteFile(conf, []byte(chainConf), 0600); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("pipewire", "-c", conf)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("couldn't start pipewire filter-chain: %w", err)
+	}
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+
+	if err := os.WriteFile(pipeWirePidFile(), []byte(strconv.Itoa(pid)), 0600); err != nil {
+		return err
+	}
+	debugf("Started filter-chain as pid: %d\n", pid)
+	return nil
+}
+
+// pipeWireNativeConfig returns the filter-chain config filtering inp with the plugin at the given path.
+// filterChainPlugin writes the copy of the plugin the filter-chain loads. It outlives us and loads
+// the plugin asynchronously, so it needs a copy pruneLibs doesn't remove when another version
+// starts, see dumpFile.
+func filterChainPlugin(ctx *ntcontext) (string, error) {
+	lib, err := os.ReadFile(ctx.librnnoise)
+	if err != nil {
+		return "", err
+	}
+	plugin, err := dumpFile(hashedName("libntfilterchain", lib), lib)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write the plugin where the filter-chain can load it: %w", err)
+	}
+	return plugin, nil
+}
+
+func pipeWireNativeConfig(ctx *ntcontext, plugin string, inp *device) (string, error) {
+	gate := ""
+	if ctx.config.Gate {
+		gatePlugin, err := gatePlugin(ctx.config)
+		if err != nil {
+			return "", err
+		}
+		gate = pipeWireGateNode(ctx, gatePlugin)
+	}
+
+	suppression := ""
+	if currentEngine(ctx).rnnoise {
+		suppression = fmt.Sprintf(" %q = %d", suppressionPort, ctx.config.Suppression)
+	}
+	return pipeWireFilterChainConfig(plugin, currentEngine(ctx), *currentEngine(ctx).control.value(ctx.config), suppression, inp, newChainID(), gate, ctx.config.BufferLatency, pipeWireKeepAwakeProps(ctx)), nil
+}
+
+func unloadPipeWireNativeInput() error {
+	return stopDetached(pipeWirePidFile(), "filter-chain.conf")
+}
+
+// stopDetached sends SIGTERM to a process we started detached, whose pid is in pidFile, and removes
+// the pid file. marker is part of its command line, so an unrelated process that reused the pid
+// is left alone.
+func stopDetached(pidFile, marker string) error {
+	if pid, running := detachedPid(pidFile, marker); running {
+		debugf("Found %s at pid [%d], sending SIGTERM\n", marker, pid)
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// detachedPid returns the pid in pidFile, 0 if there's none, and whether it's still the process
+// we started.
+func detachedPid(pidFile, marker string) (int, bool) {
+	pidbuf, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidbuf)))
+	if err != nil {
+		return 0, false
+	}
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	return pid, err == nil && strings.Contains(string(cmdline), marker)
+}
+
+func pipeWireNativeInputLoaded(ctx *ntcontext) (loaded bool, inUse bool) {
+	sources, err := ctx.paClient.Sources()
+	if err != nil {
+		errorf("Couldn't fetch sources to check for filter-chain: %v\n", err)
+		return false, false
+	}
+	for _, s := range sources {
+		if s.Name == pipeWireNodeName {
+			// PA_SOURCE_RUNNING = 0
+			return true, s.SinkState == 0
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,149 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// The audio server loads the plugin of the engine from a file, so we write it out. The file is
+// named after a hash of its content in a directory of the user, XDG_RUNTIME_DIR or the cache, and
+// stays there: starting again reuses it, instances running at the same time share it, and one that
+// crashed leaves nothing behind that the next start doesn't use. The running instance removes the
+// copies of other versions, and those older versions left in the temp dir.
+//
+// The audio server maps the plugin executable, which fails on noexec mounts with an error that
+// doesn't say why. Such directories are skipped.
+
+// pluginDirs returns the directories to write the plugin to, in order of preference.
+func pluginDirs() []string {
+	if dir := pluginDir(); dir != "" {
+		// only shared with the audio server in the sandbox
+		return []string{dir}
+	}
+	var dirs []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "noisetorch"))
+	}
+	return append(dirs, filepath.Join(cacheDir(), "plugins"))
+}
+
+func noexec(dir string) bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return false
+	}
+	// statfs reports the mount flags with the values mount(2) uses
+	return st.Flags&syscall.MS_NOEXEC != 0
+}
+
+// libName is the file name of the plugin of e with the content data.
+func libName(e engine, data []byte) string {
+	return hashedName("lib"+e.id, data)
+}
+
+// hashedName is the file name of a plugin with the content data, prefix tells the copies apart
+// that pruneLibs removes separately, or not at all.
+func hashedName(prefix string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return prefix + "-" + hex.EncodeToString(sum[:8]) + ".so"
+}
+
+// dumpLib writes the engine's plugin to a file the audio server can load it from, unless it's
+// already there.
+func dumpLib(e engine, c *config) (string, error) {
+	data, err := e.lib(c)
+	if err != nil {
+		return "", err
+	}
+	path, err := dumpFile(libName(e, data), data)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write the %s plugin where the audio server can load it: %w", e.name, err)
+	}
+	return path, nil
+}
+
+// dumpFile writes data under name to the first of pluginDirs that works.
+func dumpFile(name string, data []byte) (string, error) {
+	var problems []string
+	for _, dir := range pluginDirs() {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if noexec(dir) {
+			problems = append(problems, fmt.Sprintf("%s is on a filesystem mounted noexec", dir))
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := writeLib(path, data); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		return path, nil
+	}
+	return "", errors.New(strings.Join(problems, "; "))
+}
+
+// writeLib writes data to path if it doesn't hold it yet, atomically, as the audio server may be
+// loading the same plugin for another instance.
+func writeLib(path string, data []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		debugf("Reusing plugin %s\n", path)
+		return nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		// the JACK client host is run, not loaded
+		err = f.Chmod(0700)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	debugf("Wrote plugin to: %s\n", path)
+	return nil
+}
+
+// pruneLibs removes the copies of the plugin other than lib, with the models installed next to
+// them, or of the JACK client host. Only the running instance may, others may still load theirs.
+func pruneLibs(lib string) {
+	base := filepath.Base(lib)
+	prefix := base[:strings.LastIndex(base, "-")+1]
+	var stale []string
+	for _, dir := range append(pluginDirs(), os.TempDir()) {
+		matches, _ := filepath.Glob(filepath.Join(dir, prefix+"*"+filepath.Ext(base)))
+		stale = append(stale, matches...)
+	}
+	for _, f := range stale {
+		if f == lib || strings.HasSuffix(f, ".tmp") {
+			continue
+		}
+		if err := os.Remove(f); err != nil {
+			// e.g. another user's in the temp dir
+			debugf("Couldn't remove old plugin: %v\n", err)
+			continue
+		}
+		os.Remove(f + modelSuffix)
+		debugf("Removed old plugin %s\n", f)
+	}
+}
@@ -0,0 +1,19 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+//go:build !system_plugin
+// +build !system_plugin
+
+package main
+
+import (
+	"embed"
+)
+
+//go:embed c/ladspa/rnnoise_ladspa.so
+var libRNNoiseNative []byte
+
+//go:embed c/ladspa/arch
+var libRNNoiseArchs embed.FS
+
+var libRNNoise = bundledFor(libRNNoiseArchs, "c/ladspa/arch", "rnnoise_ladspa.so", libRNNoiseNative)
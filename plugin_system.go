@@ -0,0 +1,11 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+//go:build system_plugin
+// +build system_plugin
+
+package main
+
+// With -tags system_plugin the RNNoise plugin isn't bundled, distributions ship c/ladspa as a
+// package of its own and point -X noisetorch/buildinfo.SystemPlugin at it.
+var libRNNoise []byte
@@ -0,0 +1,47 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import "fmt"
+
+// Desktops show who uses the microphone: the indicators of GNOME and KDE, the screen share portals
+// and the sound settings go by the application properties of streams and the icon of devices. Our
+// modules belong to the audio server, so without these they show up as an anonymous null sink or
+// loopback. Everything we load carries the identity of the desktop entry instead.
+
+const (
+	appID   = "org.noisetorch.NoiseTorch"
+	appIcon = "noisetorch" // of assets/noisetorch.desktop
+	// the role of our streams. Role policies of the server duck or cork others while "phone" streams
+	// play, and ours always do. "production" is left alone and is what audio processing uses.
+	streamRole = "production"
+)
+
+// streamPresence returns the proplist entries of the streams we create, in module argument syntax.
+func streamPresence() string {
+	return fmt.Sprintf("application.id=%s application.name=NoiseTorch application.icon_name=%s media.role=%s",
+		appID, appIcon, streamRole)
+}
+
+// devicePresence returns the proplist entries of the virtual devices we create. formFactor is how
+// sound settings draw it, e.g. microphone or headphone.
+func devicePresence(formFactor string) string {
+	return fmt.Sprintf("device.icon_name=%s device.form_factor=%s device.class=filter application.id=%s application.icon_name=%s",
+		appIcon, formFactor, appID, appIcon)
+}
+
+// pipeWirePresence is streamPresence for the native filter-chain config, indented to its props.
+func pipeWirePresence(device bool) string {
+	props := fmt.Sprintf(`
+                application.id        = %q
+                application.name      = "NoiseTorch"
+                application.icon_name = %q
+                media.role            = %q`, appID, appIcon, streamRole)
+	if device {
+		props += fmt.Sprintf(`
+                device.icon_name      = %q
+                device.form_factor    = "microphone"`, appIcon)
+	}
+	return props
+}
@@ -0,0 +1,209 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Presets bundle the devices and the settings users change between situations, e.g. "Office" with
+// the headset and a low threshold, "Gaming" with a low latency. Unlike the two quick switch states
+// there can be any number of them, picked by name from the main view or `noisetorch preset apply`.
+
+type preset struct {
+	Name          string
+	Microphone    string // device ID, empty if the microphone isn't filtered
+	Headphones    string // device ID, empty if the headphones aren't filtered
+	Threshold     int
+	OutputGain    int // dB
+	BufferLatency int // ms
+}
+
+type presetui struct {
+	editor nucular.TextEditor
+}
+
+func findPreset(c *config, name string) (int, bool) {
+	for i, p := range c.Presets {
+		if strings.EqualFold(p.Name, name) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// savePreset stores the current devices and settings under name, replacing a preset of the same name.
+func savePreset(ctx *ntcontext, name string) {
+	p := preset{
+		Name:          name,
+		Threshold:     ctx.config.Threshold,
+		OutputGain:    ctx.config.OutputGain,
+		BufferLatency: ctx.config.BufferLatency,
+	}
+	if inp, ok := inputSelection(ctx); ok {
+		p.Microphone = inp.ID
+	}
+	if out, ok := outputSelection(ctx); ok {
+		p.Headphones = out.ID
+	}
+	if i, ok := findPreset(ctx.config, name); ok {
+		ctx.config.Presets[i] = p
+	} else {
+		ctx.config.Presets = append(ctx.config.Presets, p)
+	}
+	ctx.config.ActivePreset = name
+	debugf("Saved preset %s: %+v\n", name, p)
+}
+
+func deletePreset(c *config, i int) {
+	if strings.EqualFold(c.ActivePreset, c.Presets[i].Name) {
+		c.ActivePreset = ""
+	}
+	c.Presets = append(c.Presets[:i], c.Presets[i+1:]...)
+}
+
+// usePreset copies the settings of the named preset into the config and returns it, the caller has to
+// load its devices.
+func usePreset(c *config, name string) (preset, error) {
+	i, ok := findPreset(c, name)
+	if !ok {
+		return preset{}, fmt.Errorf("there is no preset named '%s'", name)
+	}
+	p := c.Presets[i]
+	if p.Microphone == "" && p.Headphones == "" {
+		return p, fmt.Errorf("the preset %s doesn't filter any device", p.Name)
+	}
+	c.ActivePreset = p.Name
+	c.Threshold = p.Threshold
+	c.OutputGain = p.OutputGain
+	c.BufferLatency = p.BufferLatency
+	c.FilterInput = p.Microphone != ""
+	c.FilterOutput = p.Headphones != ""
+	infof("Switching to preset %s\n", p.Name)
+	return p, nil
+}
+
+func uiApplyPreset(ctx *ntcontext, name string) {
+	p, err := usePreset(ctx.config, name)
+	if err != nil {
+		errorf("Couldn't apply preset: %v\n", err)
+		ctx.views.Push(makeErrorView(ctx, trf("Couldn't apply preset: %v", err)))
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	inp, inpOk := selectDevice(ctx.inputList, p.Microphone)
+	out, outOk := selectDevice(ctx.outputList, p.Headphones)
+	if (p.Microphone != "" && !inpOk) || (p.Headphones != "" && !outOk) {
+		warnf("Devices of preset %s are not available\n", p.Name)
+		go writeConfig(ctx.config)
+		ctx.views.Push(makeErrorView(ctx, trf("The devices of %s are not connected.", p.Name)))
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	uiReloadFilters(ctx, inp, out)
+}
+
+// doPresetCommand implements `noisetorch preset ...`.
+func doPresetCommand(ctx *ntcontext, opt CLIOpts) {
+	args := opt.presetArgs
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		for _, p := range ctx.config.Presets {
+			active := " "
+			if strings.EqualFold(p.Name, ctx.config.ActivePreset) {
+				active = "*"
+			}
+			fmt.Printf("%s %s\n", active, p.Name)
+		}
+	case len(args) == 2 && args[0] == "apply":
+		before := ctx.config.BufferLatency
+		p, err := usePreset(ctx.config, args[1])
+		if err != nil {
+			opt.fail("Couldn't apply preset: %v\n", err)
+		}
+		// the running chain doesn't tell the latency, reconcile would keep the old one
+		if state, _ := supressorState(ctx); state != unloaded && p.BufferLatency != before {
+			if err := unloadSupressor(ctx); err != nil {
+				opt.fail("Couldn't unload the filter: %v\n", err)
+			}
+		}
+		if err := reconcile(ctx, simpleSetup(ctx.config, p.Threshold, p.Microphone, p.Headphones)); err != nil {
+			opt.fail("Couldn't load preset %s: %v\n", p.Name, err)
+		}
+		if p.Microphone != "" {
+			if err := applyGain(ctx); err != nil {
+				errorf("Couldn't apply gain: %v\n", err)
+			}
+		}
+		writeConfig(ctx.config)
+		fmt.Printf("Switched to %s\n", p.Name)
+	default:
+		opt.fail("Usage: %s preset apply NAME | list\n", os.Args[0])
+	}
+	cleanupExit(0)
+}
+
+// presetView is the combo box of the main view.
+func presetView(ctx *ntcontext, w *nucular.Window) {
+	presets := ctx.config.Presets
+	if len(presets) == 0 {
+		return
+	}
+	names := make([]string, 0, len(presets)+1)
+	selected := len(presets)
+	for i, p := range presets {
+		names = append(names, p.Name)
+		if strings.EqualFold(p.Name, ctx.config.ActivePreset) {
+			selected = i
+		}
+	}
+	names = append(names, tr("No preset"))
+
+	w.Row(25).Ratio(0.4, 0.6)
+	w.Label(tr("Preset"), "LC")
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected && sel < len(presets) {
+		go uiApplyPreset(ctx, presets[sel].Name)
+	}
+}
+
+// presetsSettingsView lists the presets in the settings, to save and delete them.
+func presetsSettingsView(ctx *ntcontext, w *nucular.Window) {
+	ui := &ctx.presets
+	ui.editor.Flags = nucular.EditField | nucular.EditSigEnter
+
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Presets"), "LC")
+	for i := 0; i < len(ctx.config.Presets); i++ {
+		p := ctx.config.Presets[i]
+		w.Row(25).Ratio(0.7, 0.3)
+		if strings.EqualFold(p.Name, ctx.config.ActivePreset) {
+			w.LabelColored(p.Name, "LC", green)
+		} else {
+			w.Label(p.Name, "LC")
+		}
+		if focusable(ctx, w, w.ButtonText(tr("Delete"))) {
+			deletePreset(ctx.config, i)
+			go writeConfig(ctx.config)
+			i--
+		}
+	}
+
+	w.Row(25).Ratio(0.7, 0.3)
+	ev := focusableEdit(ctx, w, &ui.editor, ui.editor.Edit(w))
+	save := focusable(ctx, w, w.ButtonText(tr("Save")))
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Saves the selected devices, threshold, gain and latency under this name."))
+	}
+	if name := strings.TrimSpace(string(ui.editor.Buffer)); (save || ev&nucular.EditCommitted != 0) && name != "" {
+		savePreset(ctx, name)
+		go writeConfig(ctx.config)
+		ui.editor.Buffer = nil
+	}
+	w.Row(15).Dynamic(1)
+	w.Label(tr("e.g. Office, Gaming or Streaming"), "LC")
+}
@@ -0,0 +1,83 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+// deviceProfile stores the settings last used with a particular device, so switching
+// between e.g. a USB microphone and a headset restores the right settings for each.
+type deviceProfile struct {
+	Threshold     int
+	FilterInput   bool
+	FilterOutput  bool
+	EnableOnStart bool
+	LatencyOffset int // msec
+	Learning      learningStats
+	ModuleArgs    map[string]string // appended to the arguments of the input chain, by module name
+}
+
+func profileFor(ctx *ntcontext, dev *device) (deviceProfile, bool) {
+	p, ok := ctx.config.Profiles[dev.ID]
+	return p, ok
+}
+
+func updateProfile(ctx *ntcontext, dev *device, f func(p *deviceProfile)) {
+	if ctx.config.Profiles == nil {
+		ctx.config.Profiles = make(map[string]deviceProfile)
+	}
+	p, ok := ctx.config.Profiles[dev.ID]
+	if !ok {
+		p = deviceProfile{Threshold: ctx.config.Threshold}
+	}
+	f(&p)
+	ctx.config.Profiles[dev.ID] = p
+}
+
+// applyProfile restores the settings remembered for a newly selected device.
+func applyProfile(ctx *ntcontext, dev *device) {
+	p, ok := profileFor(ctx, dev)
+	if !ok {
+		return
+	}
+	debugf("Applying profile for %s: %+v\n", dev.ID, p)
+	if ctx.config.Threshold != p.Threshold {
+		ctx.config.Threshold = p.Threshold
+		ctx.reloadRequired = ctx.noiseSupressorState == loaded
+	}
+	ctx.config.FilterInput = p.FilterInput
+	ctx.config.FilterOutput = p.FilterOutput
+}
+
+// saveProfile remembers the current settings for a device that was just loaded.
+func saveProfile(ctx *ntcontext, dev *device) {
+	if dev.ID == "" {
+		return
+	}
+	updateProfile(ctx, dev, func(p *deviceProfile) {
+		if ctx.config.LearnThreshold {
+			recordLoad(p, ctx.config.Threshold)
+		}
+		p.Threshold = ctx.config.Threshold
+		p.FilterInput = ctx.config.FilterInput
+		p.FilterOutput = ctx.config.FilterOutput
+	})
+}
+
+// loadOnStart loads the filter if the preselected device asks for it.
+func loadOnStart(ctx *ntcontext) {
+	if state, _ := supressorState(ctx); state != unloaded {
+		return
+	}
+	inp, inpOk := inputSelection(ctx)
+	out, outOk := outputSelection(ctx)
+	if !validConfiguration(ctx, inpOk, outOk) {
+		return
+	}
+	primary := &out
+	if inpOk {
+		primary = &inp
+	}
+	if p, ok := profileFor(ctx, primary); ok && p.EnableOnStart {
+		infof("Loading filter on start for %s\n", primary.ID)
+		uiReloadFilters(ctx, inp, out)
+	}
+}
@@ -0,0 +1,126 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aarzilli/nucular"
+)
+
+// quickState is a complete configuration the user can flip to with a single action.
+type quickState struct {
+	Name       string
+	Saved      bool
+	Threshold  int
+	Microphone string // device ID, empty if the microphone isn't filtered
+	Headphones string // device ID, empty if the headphones aren't filtered
+}
+
+func defaultQuickStates() []quickState {
+	return []quickState{{Name: "Normal"}, {Name: "Stream"}}
+}
+
+func quickStates(ctx *ntcontext) []quickState {
+	if len(ctx.config.QuickStates) != 2 {
+		ctx.config.QuickStates = defaultQuickStates()
+		ctx.config.ActiveQuickState = 0
+	}
+	return ctx.config.QuickStates
+}
+
+func otherQuickState(ctx *ntcontext) int {
+	quickStates(ctx)
+	return 1 - ctx.config.ActiveQuickState
+}
+
+func saveQuickState(ctx *ntcontext, i int) {
+	st := &quickStates(ctx)[i]
+	st.Saved = true
+	st.Threshold = ctx.config.Threshold
+	st.Microphone = ""
+	st.Headphones = ""
+	if inp, ok := inputSelection(ctx); ok {
+		st.Microphone = inp.ID
+	}
+	if out, ok := outputSelection(ctx); ok {
+		st.Headphones = out.ID
+	}
+	ctx.config.ActiveQuickState = i
+	debugf("Saved quick switch state %s: %+v\n", st.Name, *st)
+}
+
+// switchQuickState marks the other state as active and returns it, the caller has to load it.
+func switchQuickState(ctx *ntcontext) (quickState, error) {
+	i := otherQuickState(ctx)
+	st := ctx.config.QuickStates[i]
+	if !st.Saved {
+		return st, fmt.Errorf("the %s state hasn't been saved yet", st.Name)
+	}
+	if st.Microphone == "" && st.Headphones == "" {
+		return st, fmt.Errorf("the %s state doesn't filter any device", st.Name)
+	}
+	ctx.config.ActiveQuickState = i
+	ctx.config.Threshold = st.Threshold
+	ctx.config.FilterInput = st.Microphone != ""
+	ctx.config.FilterOutput = st.Headphones != ""
+	infof("Switching to quick switch state %s\n", st.Name)
+	return st, nil
+}
+
+func selectDevice(devices []device, id string) (device, bool) {
+	var found device
+	var ok bool
+	for i := range devices {
+		devices[i].checked = id != "" && devices[i].ID == id
+		if devices[i].checked {
+			found, ok = devices[i], true
+		}
+	}
+	return found, ok
+}
+
+func uiSwitchQuickState(ctx *ntcontext) {
+	st, err := switchQuickState(ctx)
+	if err != nil {
+		errorf("Couldn't switch state: %v\n", err)
+		return
+	}
+	inp, inpOk := selectDevice(ctx.inputList, st.Microphone)
+	out, outOk := selectDevice(ctx.outputList, st.Headphones)
+	if (st.Microphone != "" && !inpOk) || (st.Headphones != "" && !outOk) {
+		warnf("Devices of state %s are not available\n", st.Name)
+		go writeConfig(ctx.config)
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	uiReloadFilters(ctx, inp, out)
+}
+
+func quickSwitchView(ctx *ntcontext, w *nucular.Window) {
+	states := quickStates(ctx)
+	w.Row(25).Ratio(0.4, 0.3, 0.3)
+	w.Label(tr("Quick switch states"), "LC")
+	for i := range states {
+		if focusable(ctx, w, w.ButtonText(trf("Save as %s", tr(states[i].Name)))) {
+			saveQuickState(ctx, i)
+			go writeConfig(ctx.config)
+		}
+	}
+}
+
+func quickSwitchButton(ctx *ntcontext, w *nucular.Window) {
+	other := quickStates(ctx)[otherQuickState(ctx)]
+	if !other.Saved {
+		return
+	}
+	txt := trf("Switch to %s", tr(other.Name))
+	if key := ctx.config.Hotkeys["quickswitch"]; key != "" {
+		txt += " (" + key + ")"
+	}
+	w.Row(25).Dynamic(1)
+	if focusable(ctx, w, w.ButtonText(txt)) {
+		go uiSwitchQuickState(ctx)
+	}
+}
@@ -0,0 +1,42 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// RNNoise only works at 48kHz, everything in front of it gets resampled to this rate.
+const filterRate = 48000
+
+// sources below this rate lack the upper frequency bands RNNoise looks at
+const minGoodRate = 44100
+
+func formatRate(rate uint32) string {
+	return strconv.FormatFloat(float64(rate)/1000, 'f', -1, 64) + " kHz"
+}
+
+// resampleNote describes the conversion the input chain does for inp, and whether it hurts quality.
+func resampleNote(inp *device) (string, bool) {
+	if inp.rate == 0 || inp.rate == filterRate {
+		return "", false
+	}
+	note := fmt.Sprintf("%s -> %s", formatRate(inp.rate), formatRate(filterRate))
+	return note, inp.rate < minGoodRate
+}
+
+// the loopback requests filterRate from the source, so pulseaudio resamples right at the
+// microphone instead of somewhere inside the chain, unless the user forced another spec
+func loopbackRateArgs(ctx *ntcontext) string {
+	rate := filterRate
+	if ctx.config.CaptureRate != 0 {
+		rate = ctx.config.CaptureRate
+	}
+	args := fmt.Sprintf("rate=%d", rate)
+	if ctx.config.CaptureFormat != "" {
+		args += " format=" + ctx.config.CaptureFormat
+	}
+	return args
+}
@@ -0,0 +1,169 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/aarzilli/nucular"
+)
+
+// PulseAudio's threads run with real-time scheduling, which limits how much CPU time they may take
+// without blocking (RLIMIT_RTTIME). Loading our filter takes longer than that, and the kernel kills
+// the server. Raising another process's rlimit needs CAP_SYS_RESOURCE, which is why the binary used
+// to need setcap.
+//
+// Instead we take real-time scheduling from the server's threads, which a process of the same user
+// may, load the filter, and ask RealtimeKit, which hands it out on most desktops, to give it back
+// to them at their old priority. The limit doesn't apply to threads without real-time scheduling.
+// Only the server's threads are touched, rtkit's ResetKnown would demote every thread on the
+// system. The realtime portal can only promote threads, so this goes to rtkit on the system bus.
+// Only without rtkit we fall back to lifting the rlimit, which needs the capability.
+
+const (
+	realtimeRTKit  = "RealtimeKit"
+	realtimeRlimit = "CAP_SYS_RESOURCE"
+)
+
+var (
+	rtkitOnce sync.Once
+	rtkitOK   bool
+)
+
+func rtkitCall(method string, args ...string) (string, error) {
+	cmd := exec.Command("gdbus", append([]string{"call", "--system",
+		"--dest", "org.freedesktop.RealtimeKit1",
+		"--object-path", "/org/freedesktop/RealtimeKit1",
+		"--method", method}, args...)...)
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", method, err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// rtkitAvailable tells whether RealtimeKit is running and answers us.
+func rtkitAvailable() bool {
+	rtkitOnce.Do(func() {
+		_, err := rtkitCall("org.freedesktop.DBus.Properties.Get", "org.freedesktop.RealtimeKit1", "MaxRealtimePriority")
+		if err != nil {
+			debugf("RealtimeKit not available: %v\n", err)
+		}
+		rtkitOK = err == nil
+	})
+	return rtkitOK
+}
+
+// realtimeMethod returns how we keep PulseAudio alive while loading the filter, "" if we can't.
+func realtimeMethod() string {
+	if rtkitAvailable() {
+		return realtimeRTKit
+	}
+	if hasCapSysResource(getCurrentCaps()) {
+		return realtimeRlimit
+	}
+	return ""
+}
+
+type rtThread struct {
+	pid, tid, priority int
+}
+
+// realtimeThreads lists the threads of the process with real-time scheduling.
+func realtimeThreads(pid int) []rtThread {
+	stats, _ := filepath.Glob(fmt.Sprintf("/proc/%d/task/[0-9]*/stat", pid))
+	var threads []rtThread
+	for _, stat := range stats {
+		var st syscall.Stat_t
+		if err := syscall.Stat(stat, &st); err != nil || int(st.Uid) != os.Getuid() {
+			continue
+		}
+		buf, err := os.ReadFile(stat)
+		if err != nil {
+			continue
+		}
+		// the name in parentheses may contain spaces, the fields after it start with the state (3)
+		fields := strings.Fields(string(buf[strings.LastIndexByte(string(buf), ')')+1:]))
+		if len(fields) < 39 {
+			continue
+		}
+		priority, _ := strconv.Atoi(fields[40-3])
+		policy, _ := strconv.Atoi(fields[41-3])
+		if policy != 1 && policy != 2 { // SCHED_FIFO, SCHED_RR
+			continue
+		}
+		tid, _ := strconv.Atoi(strings.Split(stat, "/")[4])
+		threads = append(threads, rtThread{pid, tid, priority})
+	}
+	return threads
+}
+
+// schedOther gives the thread normal scheduling.
+func schedOther(tid int) error {
+	var param struct{ priority int32 }
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER, uintptr(tid), 0, // SCHED_OTHER
+		uintptr(unsafe.Pointer(&param)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// demoteRealtime takes real-time scheduling from the threads of the pulseaudio server. The returned
+// function asks rtkit for it again.
+func demoteRealtime() (func(), error) {
+	pid, err := getPulsePid()
+	if err != nil {
+		return nil, err
+	}
+	var threads []rtThread
+	restore := func() {
+		for _, t := range threads {
+			_, err := rtkitCall("org.freedesktop.RealtimeKit1.MakeThreadRealtimeWithPID",
+				strconv.Itoa(t.pid), strconv.Itoa(t.tid), strconv.Itoa(t.priority))
+			if err != nil {
+				// the thread may have exited meanwhile
+				debugf("Couldn't restore real-time scheduling of %d/%d: %v\n", t.pid, t.tid, err)
+			}
+		}
+	}
+
+	rt := realtimeThreads(pid)
+	debugf("Demoting %d real-time threads of pulseaudio\n", len(rt))
+	for _, t := range rt {
+		if err := schedOther(t.tid); err != nil {
+			if err == syscall.ESRCH {
+				continue // exited meanwhile
+			}
+			restore()
+			return nil, fmt.Errorf("couldn't demote thread %d: %w", t.tid, err)
+		}
+		threads = append(threads, t)
+	}
+	return restore, nil
+}
+
+func realtimeView(ctx *ntcontext, w *nucular.Window) {
+	if ctx.serverInfo.servertype != servertype_pulse || sandboxed != "" {
+		return
+	}
+	w.Row(15).Dynamic(1)
+	switch realtimeMethod() {
+	case realtimeRTKit:
+		w.Label(tr("PulseAudio is kept running while loading through RealtimeKit."), "LC")
+	case realtimeRlimit:
+		w.Label(tr("PulseAudio is kept running while loading through CAP_SYS_RESOURCE."), "LC")
+	default:
+		w.LabelColored(tr("Neither RealtimeKit nor CAP_SYS_RESOURCE is available, loading may fail."), "LC", orange)
+	}
+}
@@ -0,0 +1,159 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+const defaultSampleSeconds = 10
+
+type recordui struct {
+	running bool
+	dir     string
+	err     error
+}
+
+func defaultSampleDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	return filepath.Join(home, "noisetorch-samples")
+}
+
+// recordSamples records the raw and the filtered microphone at the same time into
+// raw-<time>.wav and filtered-<time>.wav in dir, and returns their paths.
+func recordSamples(ctx *ntcontext, inp *device, dir string, seconds int) ([]string, error) {
+	virt, ok := findVirtualSource(ctx, inp)
+	if !ok {
+		return nil, fmt.Errorf("filtered microphone is not loaded")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	stamp := time.Now().Format("20060102-150405")
+	sources := []string{inp.ID, virt.Name}
+	paths := []string{
+		filepath.Join(dir, "raw-"+stamp+".wav"),
+		filepath.Join(dir, "filtered-"+stamp+".wav"),
+	}
+
+	errs := make(chan error, len(sources))
+	for i := range sources {
+		go func(source, path string) {
+			errs <- recordWAV(source, path, seconds)
+		}(sources[i], paths[i])
+	}
+	var firstErr error
+	for range sources {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	infof("Recorded samples: %v\n", paths)
+	return paths, nil
+}
+
+// the pulseaudio library we use can't record, so use parec for the stream and write the WAV ourselves
+func recordWAV(source, path string, seconds int) error {
+	cmd := audioCommand("parec", "--raw", "--format=s16le", "--channels=1",
+		fmt.Sprintf("--rate=%d", filterRate), "--client-name=NoiseTorch sample recorder", "-d", source)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("couldn't start parec: %w", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	data := make([]byte, filterRate*2*seconds)
+	if _, err := io.ReadFull(bufio.NewReader(stdout), data); err != nil {
+		return fmt.Errorf("couldn't record from %s: %w", source, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeWAV(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeWAV writes mono 16 bit PCM at filterRate, the rate the filter runs at
+func writeWAV(w io.Writer, data []byte) error {
+	const channels, bits = 1, 16
+	hdr := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'}, uint32(36 + len(data)), [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), uint16(channels), uint32(filterRate),
+		uint32(filterRate * channels * bits / 8), uint16(channels * bits / 8), uint16(bits),
+		[4]byte{'d', 'a', 't', 'a'}, uint32(len(data)),
+	}
+	for _, v := range hdr {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func uiRecordSamples(ctx *ntcontext, inp device) {
+	ctx.record = recordui{running: true}
+	(*ctx.masterWindow).Changed()
+	dir := defaultSampleDir()
+	_, err := recordSamples(ctx, &inp, dir, defaultSampleSeconds)
+	ctx.record = recordui{dir: dir, err: err}
+	(*ctx.masterWindow).Changed()
+}
+
+func recordView(ctx *ntcontext, w *nucular.Window) {
+	inp, ok := inputSelection(ctx)
+	if !ok {
+		return
+	}
+	r := &ctx.record
+	w.Row(25).Ratio(0.7, 0.3)
+	switch {
+	case r.running:
+		w.Label(trf("Recording %d seconds, please talk...", defaultSampleSeconds), "LC")
+	case r.err != nil:
+		w.LabelColored(trf("Recording failed: %v", r.err), "LC", red)
+	case r.dir != "":
+		w.Label(trf("Saved to %s", r.dir), "LC")
+	default:
+		w.Label(tr("Record the raw and the filtered microphone to compare them"), "LC")
+	}
+	if r.running {
+		w.Spacing(1)
+	} else if focusable(ctx, w, w.ButtonText(tr("Record test sample"))) {
+		go uiRecordSamples(ctx, inp)
+	}
+	if r.dir != "" && !r.running && r.err == nil {
+		w.Row(25).Ratio(0.7, 0.3)
+		w.Spacing(1)
+		if focusable(ctx, w, w.ButtonText(tr("Open folder"))) {
+			exec.Command("xdg-open", r.dir).Start()
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// When the audio server restarts, e.g. after a suspend, our modules are gone with it. Sometimes only
+// some of them are: the loopback goes down with a device that vanished while the rest of the chain
+// stays, and applications keep recording silence from the filtered microphone. The recovery manager
+// notices both, and builds the chain again from the selection it was loaded with, checking that all
+// of its modules are back.
+//
+// The daemon does the same in its own loop, this is for the GUI.
+
+const (
+	recoveryAttempts = 3
+	// longer than loading takes, so a chain that's still being built isn't mistaken for a broken one
+	recoveryBrokenAfter = 15 * time.Second
+)
+
+type recovery struct {
+	sync.Mutex
+	wanted      bool // the filter was loaded the last time we looked
+	pending     bool // the connection dropped while it was, rebuild it once the server is back
+	running     bool
+	brokenSince time.Time
+}
+
+// trackRecovery is called on every audio server update and starts a recovery when the chain broke.
+func trackRecovery(ctx *ntcontext) {
+	r := &ctx.recovery
+	r.Lock()
+	defer r.Unlock()
+	switch ctx.noiseSupressorState {
+	case loaded:
+		r.wanted = true
+		r.brokenSince = time.Time{}
+	case unloaded:
+		if !r.pending && !r.running {
+			// unloaded on purpose, or a microphone that hotplug takes care of
+			r.wanted = false
+		}
+		r.brokenSince = time.Time{}
+	case inconsistent:
+		if !r.wanted || r.running {
+			return
+		}
+		if r.brokenSince.IsZero() {
+			r.brokenSince = time.Now()
+			// there may be no further update from the server to look again on
+			time.AfterFunc(recoveryBrokenAfter, func() {
+				ctx.noiseSupressorState, _ = supressorState(ctx)
+				trackRecovery(ctx)
+			})
+			return
+		}
+		if time.Since(r.brokenSince) >= recoveryBrokenAfter {
+			warnf("The filter chain is incomplete since %s, rebuilding it\n", r.brokenSince.Format(time.Stamp))
+			r.running = true
+			r.brokenSince = time.Time{}
+			go recoverChain(ctx, 0)
+		}
+	}
+}
+
+// recoveryConnectionLost remembers to rebuild the chain if it was loaded.
+func recoveryConnectionLost(ctx *ntcontext) {
+	r := &ctx.recovery
+	r.Lock()
+	defer r.Unlock()
+	r.pending = r.pending || r.wanted
+}
+
+// recoveryConnected rebuilds the chain after a reconnect if it was loaded before.
+func recoveryConnected(ctx *ntcontext) {
+	r := &ctx.recovery
+	r.Lock()
+	defer r.Unlock()
+	if !r.pending || r.running {
+		return
+	}
+	r.running = true
+	// after a blip the modules are often still there, or reappear shortly after the server is back
+	go recoverChain(ctx, time.Duration(ctx.config.ReconnectGracePeriod)*time.Second)
+}
+
+func recoverChain(ctx *ntcontext, grace time.Duration) {
+	r := &ctx.recovery
+	defer func() {
+		r.Lock()
+		r.running = false
+		r.pending = false
+		r.Unlock()
+	}()
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if state, _ := supressorState(ctx); state == loaded {
+			infof("Re-adopted still loaded supressor after reconnect\n")
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	var err error
+	for attempt := 1; attempt <= recoveryAttempts; attempt++ {
+		if !connected(ctx) {
+			// the next connection tries again
+			r.Lock()
+			r.pending = true
+			r.Unlock()
+			return
+		}
+		infof("Rebuilding the filter chain, attempt %d\n", attempt)
+		if err = rebuildChain(ctx); err == nil {
+			infof("Filter chain restored\n")
+			notify(ctx, notifyConnection, tr("Noise suppression restored"), tr("The filtered microphone was loaded again after the audio server restarted."))
+			return
+		}
+		errorf("Couldn't rebuild the filter chain: %v\n", err)
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+	r.Lock()
+	r.wanted = false
+	r.Unlock()
+	notify(ctx, notifyConnection, tr("Noise suppression lost"), tr("The filtered microphone couldn't be loaded again, load it in NoiseTorch-ng."))
+	ctx.views.Push(makeErrorView(ctx, trf("The filter couldn't be loaded again after the audio server restarted: %v", err)))
+	(*ctx.masterWindow).Changed()
+}
+
+// rebuildChain removes what's left of the chain and loads it again for the selected devices.
+func rebuildChain(ctx *ntcontext) error {
+	if state, _ := supressorState(ctx); state == inconsistent {
+		if err := unloadSupressor(ctx); err != nil {
+			return err
+		}
+	}
+	ctx.noiseSupressorState, _ = supressorState(ctx)
+	inp, inpOk := inputSelection(ctx)
+	out, outOk := outputSelection(ctx)
+	if !validConfiguration(ctx, inpOk, outOk) {
+		return fmt.Errorf("the devices it was loaded for aren't there")
+	}
+	uiReloadFilters(ctx, inp, out)
+	if state, _ := supressorState(ctx); state != loaded {
+		return fmt.Errorf("not all of its modules came back")
+	}
+	return nil
+}
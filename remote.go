@@ -0,0 +1,628 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"image"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The control API is a tiny REST interface that lets another NoiseTorch instance (or curl)
+// manage the filters of this machine. It's served over TLS with a self-signed certificate, see
+// apiCertificate, which clients pin by its fingerprint instead of trusting a CA. Every request needs
+// the token of this install, see apiToken, as "Authorization: Bearer <token>". Browsers can't send that header across sites without asking
+// first, and requests they send from other pages carry an Origin we reject. Requests changing
+// something have to be JSON, which a plain cross-site form can't be.
+
+const (
+	apiTokenFile = "api-token"
+	apiCertFile  = "api-cert.pem"
+	apiKeyFile   = "api-key.pem"
+	maxAPIBody   = 64 << 10
+)
+
+// remoteLoad is the body of POST /load, both fields are optional.
+type remoteLoad struct {
+	Source    string `json:"source,omitempty"`
+	Threshold *int   `json:"threshold,omitempty"`
+}
+
+type remoteStatus struct {
+	State              string   `json:"state"`
+	VirtualDeviceInUse bool     `json:"virtualDeviceInUse"`
+	Threshold          int      `json:"threshold"`
+	Server             string   `json:"server"`
+	Input              string   `json:"input"`
+	Inputs             []string `json:"inputs,omitempty"`
+	Output             string   `json:"output,omitempty"`
+	ServerVersion      string   `json:"serverVersion,omitempty"`
+	CPUPercent         *float64 `json:"cpuPercent,omitempty"` // of the process hosting the filter
+}
+
+type remoteDevice struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type remoteDevices struct {
+	Sources []remoteDevice `json:"sources"`
+	Sinks   []remoteDevice `json:"sinks"`
+}
+
+func stateName(state int) string {
+	switch state {
+	case loaded:
+		return "loaded"
+	case unloaded:
+		return "unloaded"
+	default:
+		return "inconsistent"
+	}
+}
+
+// apiToken returns the token of the control API, it's created on first use and kept in the config
+// directory, readable only by the user.
+func apiToken() (string, error) {
+	path := filepath.Join(configDir(), apiTokenFile)
+	if b, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b[:])
+	return token, writePrivateFile(path, []byte(token+"\n"))
+}
+
+// writePrivateFile replaces the file in the config directory with one only the user can read.
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// apiCertificate returns the certificate the control API is served with, it's created on first use
+// and kept in the config directory like the token. There's no name in it to check, clients pin its
+// fingerprint.
+func apiCertificate() (tls.Certificate, error) {
+	certPath, keyPath := filepath.Join(configDir(), apiCertFile), filepath.Join(configDir(), apiKeyFile)
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err == nil || !os.IsNotExist(err) {
+		return cert, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: appName + " control API"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(20, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writePrivateFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writePrivateFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.LoadX509KeyPair(certPath, keyPath)
+}
+
+// certFingerprint is the SHA-256 of the certificate in hex, what openssl x509 -fingerprint -sha256
+// prints without the colons.
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeFingerprint accepts fingerprints with colons and in upper case, as openssl prints them.
+func normalizeFingerprint(s string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+}
+
+// authorizeAPI rejects requests without the token, from web pages of other sites, and changes
+// that aren't JSON.
+func authorizeAPI(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && origin != "https://"+r.Host {
+			http.Error(w, "foreign origin", http.StatusForbidden)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+				http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxAPIBody)
+		}
+		next(w, r)
+	}
+}
+
+func serveControlAPI(ctx *ntcontext, addr string) {
+	token, err := apiToken()
+	if err != nil {
+		errorf("Couldn't create the control API token: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Couldn't create the control API token: %v\n", err)
+		return
+	}
+	cert, err := apiCertificate()
+	if err != nil {
+		errorf("Couldn't create the control API certificate: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Couldn't create the control API certificate: %v\n", err)
+		return
+	}
+	// the handlers change what the window shows, so they hold its lock like the window does
+	wnd := *ctx.masterWindow
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", authorizeAPI(token, func(w http.ResponseWriter, r *http.Request) {
+		wnd.Lock()
+		inp, _ := inputSelection(ctx)
+		status := remoteStatus{
+			State:              stateName(ctx.noiseSupressorState),
+			VirtualDeviceInUse: ctx.virtualDeviceInUse,
+			Threshold:          ctx.config.Threshold,
+			Server:             ctx.serverInfo.name,
+			Input:              inp.ID,
+		}
+		wnd.Unlock()
+		writeJSON(w, status)
+	}))
+	mux.HandleFunc("/devices", authorizeAPI(token, func(w http.ResponseWriter, r *http.Request) {
+		var devs remoteDevices
+		wnd.Lock()
+		for _, d := range ctx.inputList {
+			devs.Sources = append(devs.Sources, remoteDevice{ID: d.ID, Name: d.Name})
+		}
+		for _, d := range ctx.outputList {
+			devs.Sinks = append(devs.Sinks, remoteDevice{ID: d.ID, Name: d.Name})
+		}
+		wnd.Unlock()
+		writeJSON(w, devs)
+	}))
+	mux.HandleFunc("/load", authorizeAPI(token, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !connected(ctx) {
+			http.Error(w, "not connected to audio server", http.StatusServiceUnavailable)
+			return
+		}
+		var req remoteLoad
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Threshold != nil && (*req.Threshold < 0 || *req.Threshold > 95) {
+			http.Error(w, "invalid threshold", http.StatusBadRequest)
+			return
+		}
+
+		wnd.Lock()
+		found := req.Source == ""
+		for i := range ctx.inputList {
+			found = found || ctx.inputList[i].ID == req.Source
+		}
+		if !found {
+			wnd.Unlock()
+			http.Error(w, "source not found", http.StatusNotFound)
+			return
+		}
+		if req.Threshold != nil {
+			ctx.config.Threshold = *req.Threshold
+		}
+		if req.Source != "" {
+			for i := range ctx.inputList {
+				ctx.inputList[i].checked = ctx.inputList[i].ID == req.Source
+			}
+		}
+		inp, inpOk := inputSelection(ctx)
+		out, outOk := outputSelection(ctx)
+		valid := validConfiguration(ctx, inpOk, outOk)
+		wnd.Unlock()
+		if !valid {
+			http.Error(w, "no valid device selection", http.StatusBadRequest)
+			return
+		}
+		// like the Load button, outside of the lock, the window shows the progress
+		uiReloadFilters(ctx, inp, out)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("/unload", authorizeAPI(token, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !connected(ctx) {
+			http.Error(w, "not connected to audio server", http.StatusServiceUnavailable)
+			return
+		}
+		uiUnloadFilters(ctx)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	srv := &http.Server{
+		Addr:      addr,
+		Handler:   mux,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	infof("Serving control API on https://%s, the token is in %s, the certificate fingerprint is %s\n",
+		addr, filepath.Join(configDir(), apiTokenFile), certFingerprint(cert.Certificate[0]))
+	if err := srv.ListenAndServeTLS("", ""); err != nil {
+		errorf("Control API stopped: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Couldn't serve control API on %s: %v\n", addr, err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		errorf("Couldn't encode control API response: %v\n", err)
+	}
+}
+
+type remoteClient struct {
+	addr   string
+	token  string
+	client http.Client
+}
+
+// newRemoteClient talks to the instance at addr, which has to present the certificate with the
+// given fingerprint.
+func newRemoteClient(addr, token, fingerprint string) *remoteClient {
+	fingerprint = normalizeFingerprint(fingerprint)
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the certificate is self-signed, it's checked against the pinned fingerprint instead
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("%s presented no certificate", addr)
+			}
+			if got := certFingerprint(rawCerts[0]); subtle.ConstantTimeCompare([]byte(got), []byte(fingerprint)) != 1 {
+				return fmt.Errorf("the certificate of %s has the fingerprint %s, not the expected one. Pass the one its log shows with -fingerprint", addr, got)
+			}
+			return nil
+		},
+	}
+	return &remoteClient{addr: addr, token: token, client: http.Client{
+		Timeout:   15 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}}
+}
+
+func (rc *remoteClient) do(method, path string, body interface{}) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, "https://"+rc.addr+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+rc.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := rc.client.Do(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, fmt.Errorf("wrong token, pass the one in ~/.config/noisetorch/%s on %s with -token", apiTokenFile, rc.addr)
+	}
+	return resp, err
+}
+
+func (rc *remoteClient) get(path string, v interface{}) error {
+	resp, err := rc.do(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (rc *remoteClient) post(path string, body interface{}) error {
+	resp, err := rc.do(http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, resp.Status)
+	}
+	return nil
+}
+
+func (rc *remoteClient) Status() (remoteStatus, error) {
+	var s remoteStatus
+	err := rc.get("/status", &s)
+	return s, err
+}
+
+func (rc *remoteClient) Devices() (remoteDevices, error) {
+	var d remoteDevices
+	err := rc.get("/devices", &d)
+	return d, err
+}
+
+func (rc *remoteClient) Load(source string, threshold int) error {
+	req := remoteLoad{Source: source}
+	if threshold >= 0 {
+		req.Threshold = &threshold
+	}
+	return rc.post("/load", req)
+}
+
+func (rc *remoteClient) Unload() error {
+	return rc.post("/unload", struct{}{})
+}
+
+// remoteToken is the token to manage another instance with, -token or else ours, for instances
+// of the same user or with the token file copied over.
+func remoteToken(opt CLIOpts) string {
+	if opt.token != "" {
+		return opt.token
+	}
+	token, err := apiToken()
+	if err != nil {
+		warnf("Couldn't read the control API token: %v\n", err)
+	}
+	return token
+}
+
+// remoteFingerprint is the certificate fingerprint to expect from another instance, -fingerprint
+// or else that of ours, like the token.
+func remoteFingerprint(opt CLIOpts) string {
+	if opt.fingerprint != "" {
+		return opt.fingerprint
+	}
+	cert, err := apiCertificate()
+	if err != nil {
+		warnf("Couldn't read the control API certificate: %v\n", err)
+		return ""
+	}
+	return certFingerprint(cert.Certificate[0])
+}
+
+// doRemoteCLI performs the one-shot CLI actions against a remote instance instead of the local audio server.
+func doRemoteCLI(opt CLIOpts) {
+	rc := newRemoteClient(opt.connect, remoteToken(opt), remoteFingerprint(opt))
+	threshold := opt.threshold
+	if threshold > 0 {
+		threshold = clampThreshold(threshold)
+	}
+
+	if opt.list {
+		devs, err := rc.Devices()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't fetch devices from %s: %v\n", opt.connect, err)
+			os.Exit(1)
+		}
+		fmt.Println("Sources:")
+		for _, d := range devs.Sources {
+			fmt.Printf("\tDevice Name: %s\n\tDevice ID: %s\n\n", d.Name, d.ID)
+		}
+		fmt.Println("Sinks:")
+		for _, d := range devs.Sinks {
+			fmt.Printf("\tDevice Name: %s\n\tDevice ID: %s\n\n", d.Name, d.ID)
+		}
+		os.Exit(0)
+	}
+
+	if opt.unload {
+		if err := rc.Unload(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error unloading on %s: %v\n", opt.connect, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if opt.loadInput {
+		if err := rc.Load(opt.sinkName, threshold); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading on %s: %v\n", opt.connect, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+}
+
+type remoteui struct {
+	client       *remoteClient
+	status       remoteStatus
+	devices      remoteDevices
+	selected     int
+	threshold    int
+	err          error
+	busy         bool
+	masterWindow *nucular.MasterWindow
+}
+
+// runRemoteGUI shows a small management window for a NoiseTorch instance on another host.
+func runRemoteGUI(addr, token, fingerprint string) {
+	r := &remoteui{client: newRemoteClient(addr, token, fingerprint), threshold: -1}
+
+	setWindowHints()
+	wnd := nucular.NewMasterWindowSize(0, appName+" - "+addr, image.Point{600, 400}, func(w *nucular.Window) {
+		remoteView(r, w)
+	})
+	r.masterWindow = &wnd
+
+	go func() {
+		for {
+			r.refresh()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	setUIStyle(wnd, nil)
+	wnd.Main()
+}
+
+// refresh fetches the state of the remote instance, the requests go out without the window lock
+// and the window draws what they returned under it.
+func (r *remoteui) refresh() {
+	status, err := r.client.Status()
+	var devs remoteDevices
+	if err == nil {
+		devs, err = r.client.Devices()
+	}
+	wnd := *r.masterWindow
+	wnd.Lock()
+	if err == nil {
+		r.devices = devs
+	}
+	r.status = status
+	r.err = err
+	if r.threshold < 0 {
+		r.threshold = status.Threshold
+	}
+	wnd.Unlock()
+	wnd.Changed()
+}
+
+func (r *remoteui) do(f func() error) {
+	wnd := *r.masterWindow
+	wnd.Lock()
+	r.busy = true
+	wnd.Unlock()
+	wnd.Changed()
+	err := f()
+	if err != nil {
+		errorf("Remote request failed: %v\n", err)
+	}
+	wnd.Lock()
+	r.busy = false
+	wnd.Unlock()
+	r.refresh()
+	if err != nil {
+		// after the refresh, which would clear it
+		wnd.Lock()
+		r.err = err
+		wnd.Unlock()
+		wnd.Changed()
+	}
+}
+
+func remoteView(r *remoteui, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	if r.err != nil {
+		w.LabelColored(trf("Can't reach %s: %v", r.client.addr, r.err), "LC", red)
+	} else {
+		switch r.status.State {
+		case "loaded":
+			w.LabelColored(trf("Filtering active on %s (%s)", r.client.addr, r.status.Server), "RC", green)
+		case "unloaded":
+			w.LabelColored(trf("Filtering inactive on %s (%s)", r.client.addr, r.status.Server), "RC", red)
+		default:
+			w.LabelColored(tr("Inconsistent state, please unload first."), "RC", orange)
+		}
+	}
+
+	w.Row(25).Ratio(0.5, 0.45, 0.05)
+	w.Label(tr("Voice Activation Threshold"), "LC")
+	if r.threshold >= 0 {
+		w.SliderInt(0, &r.threshold, 95, 1)
+		w.Label(fmt.Sprintf("%d%%", r.threshold), "RC")
+	}
+
+	if w.TreePush(nucular.TreeTab, tr("Select Microphone"), true) {
+		for i, d := range r.devices.Sources {
+			w.Row(15).Dynamic(1)
+			if d.ID == r.status.Input && r.selected == 0 {
+				r.selected = i + 1
+			}
+			checked := r.selected == i+1
+			if w.CheckboxText(d.Name, &checked) && checked {
+				r.selected = i + 1
+			}
+		}
+		w.TreePop()
+	}
+
+	w.Row(25).Dynamic(2)
+	if r.busy {
+		w.Label(tr("Working..."), "CC")
+		return
+	}
+	if w.ButtonText(tr("Unload Filter(s)")) {
+		go r.do(r.client.Unload)
+	}
+	if w.ButtonText(tr("Load Filter(s)")) {
+		source := ""
+		if r.selected > 0 && r.selected <= len(r.devices.Sources) {
+			source = r.devices.Sources[r.selected-1].ID
+		}
+		threshold := r.threshold
+		go r.do(func() error { return r.client.Load(source, threshold) })
+	}
+}
@@ -4,7 +4,6 @@
 package main
 
 import (
-	"log"
 	"os"
 	"strconv"
 	"strings"
@@ -49,7 +48,7 @@ func removeRlimit(pid int) {
 	new := syscall.Rlimit{Cur: MaxUint, Max: MaxUint}
 	err := setRlimit(pid, &new)
 	if err != nil {
-		log.Printf("Couldn't set rlimit with caps\n")
+		errorf("Couldn't set rlimit with caps\n")
 	}
 }
 
@@ -0,0 +1,144 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"noisetorch/buildinfo"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The updater keeps the binary it replaces, so a regression can be undone with "Revert last update"
+// or `noisetorch rollback`. Only the binary is restored, the desktop entry and icon of the tarball
+// rarely change.
+
+func previousDir() string {
+	return filepath.Join(xdgOrFallback("XDG_DATA_HOME", filepath.Join(os.Getenv("HOME"), ".local", "share")), "noisetorch", "previous")
+}
+
+func previousBinary() string {
+	return filepath.Join(previousDir(), "noisetorch")
+}
+
+func previousVersionFile() string {
+	return filepath.Join(previousDir(), "version")
+}
+
+// previousVersion returns the version the last update replaced, "" if there is nothing to roll back to.
+func previousVersion() string {
+	if ok, _ := exists(previousBinary()); !ok {
+		return ""
+	}
+	v, err := os.ReadFile(previousVersionFile())
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(v))
+}
+
+// keepPreviousBinary saves the installed binary before an update replaces it.
+func keepPreviousBinary() error {
+	if err := os.MkdirAll(previousDir(), 0700); err != nil {
+		return err
+	}
+	if err := copyFile(installedBinary(), previousBinary()+".tmp", 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(previousBinary()+".tmp", previousBinary()); err != nil {
+		return err
+	}
+	return os.WriteFile(previousVersionFile(), []byte(buildinfo.Version+"\n"), 0644)
+}
+
+// rollback puts the binary the last update replaced back in place. It needs its capability granted
+// again afterwards, which the caller does.
+func rollback() (string, error) {
+	version := previousVersion()
+	if version == "" {
+		return "", fmt.Errorf("there is no previous version to go back to")
+	}
+	tmp := installedBinary() + ".new"
+	if err := copyFile(previousBinary(), tmp, 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, installedBinary()); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	infof("Rolled back to version %s\n", version)
+	// rolling back twice would reinstall the update
+	os.RemoveAll(previousDir())
+	return version, nil
+}
+
+func copyFile(from, to string, mode os.FileMode) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func uiRollback(ctx *ntcontext) {
+	if _, err := rollback(); err != nil {
+		errorf("Couldn't roll back: %v\n", err)
+		ctx.views.Push(makeErrorView(ctx, err.Error()))
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	pkexecSetcapSelf()
+	ctx.update.capsLost = !hasCapSysResource(getSelfFileCaps())
+	ctx.update.available = false
+	ctx.update.triggered = true
+	ctx.update.updatingText = "Previous version restored! (Restart the program to apply)"
+	(*ctx.masterWindow).Changed()
+}
+
+func rollbackView(ctx *ntcontext, w *nucular.Window) {
+	version := previousVersion()
+	if version == "" || ctx.update.triggered {
+		return
+	}
+	w.Row(25).Ratio(0.7, 0.3)
+	w.Label(trf("Go back to version %s", version), "LC")
+	if focusable(ctx, w, w.ButtonText(tr("Revert last update"))) {
+		ctx.views.Push(makeConfirmView(ctx,
+			"Revert last update",
+			trf("NoiseTorch-ng %s will be installed again.", version),
+			"Revert",
+			"Go back",
+			func() { uiRollback(ctx) },
+			func() {}))
+	}
+}
+
+// rollbackCLI implements `noisetorch rollback`.
+func rollbackCLI(opt CLIOpts) {
+	if !updateable() {
+		opt.fail("Updates are provided by your distribution, use it to install another version.\n")
+	}
+	version, err := rollback()
+	if err != nil {
+		opt.fail("Couldn't roll back: %v\n", err)
+	}
+	fmt.Printf("Version %s restored.\n", version)
+	if err := pkexecSetcapSelf(); err != nil || !hasCapSysResource(getSelfFileCaps()) {
+		fmt.Println("Grant it the capability it needs with: noisetorch fix-permissions")
+	}
+	cleanupExit(0)
+}
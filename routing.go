@@ -0,0 +1,517 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+	"github.com/noisetorch/pulseaudio"
+)
+
+// Applications on the AutoRoute list are moved to the filtered microphone as soon as they start
+// recording, so users don't have to pick it in every application. Each stream is moved only once,
+// if the user moves it back we leave it there.
+//
+// Streams recording from a filtered microphone are moved back to the microphone they came from when
+// the filter is unloaded, instead of wherever the server puts them once the filtered one is gone.
+//
+// Our pulseaudio library can't list or move streams, so this goes through pactl.
+
+// stream is a record or playback stream, as listed by pactl.
+type stream struct {
+	index  uint32
+	device uint32 // index of the source it records from, or the sink it plays to
+	module uint32 // index of the module that created it, if any
+	spec   string // sample spec, e.g. "s16le 2ch 48000Hz"
+	props  map[string]string
+}
+
+// appName is what users know the application by.
+func (o stream) appName() string {
+	if name := o.props["application.name"]; name != "" {
+		return name
+	}
+	return o.props["application.process.binary"]
+}
+
+// matches tells whether the stream belongs to one of the applications, by name or binary.
+func (o stream) matches(apps []string) bool {
+	for _, app := range apps {
+		if strings.EqualFold(app, o.props["application.name"]) || strings.EqualFold(app, o.props["application.process.binary"]) {
+			return true
+		}
+	}
+	return false
+}
+
+func listSourceOutputs() ([]stream, error) {
+	return listStreams("source-outputs", "Source Output #", "Source")
+}
+
+// listStreams lists the streams of kind, source-outputs or sink-inputs. heading starts each
+// stream and field is the device it's connected to.
+func listStreams(kind, heading, field string) ([]stream, error) {
+	cmd := audioCommand("pactl", "list", kind)
+	// the headings are translated
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pactl list %s failed: %w", kind, err)
+	}
+	return parseStreams(out, heading, field), nil
+}
+
+// parseStreams reads the output of `pactl list source-outputs` or `pactl list sink-inputs`:
+//
+//	Source Output #42
+//		Source: 3
+//		Properties:
+//			application.name = "Firefox"
+func parseStreams(out []byte, heading, field string) []stream {
+	var res []stream
+	var cur *stream
+	field = "\t" + field + ": "
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, heading):
+			idx, err := strconv.ParseUint(strings.TrimPrefix(line, heading), 10, 32)
+			if err != nil {
+				cur = nil
+				continue
+			}
+			res = append(res, stream{index: uint32(idx), props: make(map[string]string)})
+			cur = &res[len(res)-1]
+		case cur == nil:
+		case strings.HasPrefix(line, field):
+			idx, err := strconv.ParseUint(strings.TrimPrefix(line, field), 10, 32)
+			if err == nil {
+				cur.device = uint32(idx)
+			}
+		case strings.HasPrefix(line, "\tSample Specification: "):
+			cur.spec = strings.TrimPrefix(line, "\tSample Specification: ")
+		case strings.HasPrefix(line, "\tOwner Module: "):
+			idx, err := strconv.ParseUint(strings.TrimPrefix(line, "\tOwner Module: "), 10, 32)
+			if err == nil {
+				cur.module = uint32(idx)
+			}
+		case strings.HasPrefix(line, "\t\t") && strings.Contains(trimmed, " = "):
+			i := strings.Index(trimmed, " = ")
+			value, err := strconv.Unquote(trimmed[i+3:])
+			if err != nil {
+				value = strings.Trim(trimmed[i+3:], `"`)
+			}
+			cur.props[trimmed[:i]] = value
+		}
+	}
+	return res
+}
+
+func moveSourceOutput(index uint32, source string) error {
+	return moveStream("source-output", index, source)
+}
+
+// moveStream moves the stream of kind, source-output or sink-input, to device.
+func moveStream(kind string, index uint32, device string) error {
+	cmd := audioCommand("pactl", "move-"+kind, strconv.FormatUint(uint64(index), 10), device)
+	debugf("Calling: %s\n", cmd.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pactl move-%s failed: %v: %s", kind, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// routing keeps track of the streams recording from the filtered microphones, so we can move them
+// back to where they came from when the filter is unloaded.
+type routing struct {
+	moved   map[uint32]bool         // streams we moved, by source output index
+	last    map[uint32]string       // source each stream recorded from at the last update
+	origins map[uint32]streamOrigin // streams on a filtered microphone, saved in routesFile
+}
+
+// streamOrigin is where a stream recording from a filtered microphone came from.
+type streamOrigin struct {
+	App    string `json:"app"`
+	Source string `json:"source"`
+}
+
+// the origins outlive us, in case we crash or are closed while the filter stays loaded
+func routesFile() string {
+	return filepath.Join(pipeWireRuntimeDir(), "routes.json")
+}
+
+func readRoutes() map[uint32]streamOrigin {
+	origins := make(map[uint32]streamOrigin)
+	buf, err := os.ReadFile(routesFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			errorf("Couldn't read saved application routing: %v\n", err)
+		}
+		return origins
+	}
+	if err := json.Unmarshal(buf, &origins); err != nil {
+		errorf("Couldn't read saved application routing: %v\n", err)
+	}
+	return origins
+}
+
+func writeRoutes(origins map[uint32]streamOrigin) {
+	if len(origins) == 0 {
+		if err := os.Remove(routesFile()); err != nil && !os.IsNotExist(err) {
+			errorf("Couldn't remove saved application routing: %v\n", err)
+		}
+		return
+	}
+	buf, err := json.Marshal(origins)
+	if err == nil {
+		err = os.MkdirAll(pipeWireRuntimeDir(), 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(routesFile(), buf, 0600)
+	}
+	if err != nil {
+		errorf("Couldn't save application routing: %v\n", err)
+	}
+}
+
+// physicalSource returns the microphone the filtered microphone virt is fed from.
+func physicalSource(ctx *ntcontext, devices []device, virt string) string {
+	for i := range devices {
+		d := &devices[i]
+		if virt == inputChainFor(d).remap || virt == pipeWireInputSource(d) {
+			return d.ID
+		}
+	}
+	// the native filter-chain and chains of older versions don't tell
+	return ctx.config.LastUsedInput
+}
+
+// updateRouting is called on every audio server update.
+func updateRouting(ctx *ntcontext) {
+	r := &ctx.routing
+	if r.origins == nil {
+		r.origins = readRoutes()
+		r.last = make(map[uint32]string)
+		r.moved = make(map[uint32]bool)
+		if ctx.noiseSupressorState == unloaded && len(r.origins) > 0 {
+			// the filter went away while we weren't watching, the server moved the streams elsewhere
+			restoreRouting(ctx, nil)
+		}
+	}
+	if ctx.noiseSupressorState != loaded || !ctx.config.FilterInput || jackMode(ctx) {
+		return
+	}
+
+	outputs, err := listSourceOutputs()
+	if err != nil {
+		errorf("Couldn't list recording applications: %v\n", err)
+		return
+	}
+	trackOrigins(ctx, outputs)
+	routeApplications(ctx, outputs)
+}
+
+// trackOrigins remembers where the streams now recording from a filtered microphone came from.
+func trackOrigins(ctx *ntcontext, outputs []stream) {
+	sources, err := ctx.paClient.Sources()
+	if err != nil {
+		errorf("Couldn't fetch sources from pulseaudio: %v\n", err)
+		return
+	}
+	names := make(map[uint32]string, len(sources))
+	virtual := make(map[uint32]bool)
+	for _, s := range sources {
+		names[s.Index] = s.Name
+	}
+	for _, s := range findVirtualSources(ctx) {
+		virtual[s.Index] = true
+	}
+
+	r := &ctx.routing
+	changed := false
+	present := make(map[uint32]bool, len(outputs))
+	for _, o := range outputs {
+		present[o.index] = true
+		if _, ours := o.props[tagID]; ours {
+			continue
+		}
+		if !virtual[o.device] {
+			r.last[o.index] = names[o.device]
+			if _, ok := r.origins[o.index]; ok {
+				// moved away by the user
+				delete(r.origins, o.index)
+				changed = true
+			}
+			continue
+		}
+		if _, ok := r.origins[o.index]; ok {
+			continue
+		}
+		origin := r.last[o.index]
+		if origin == "" {
+			// started on the filtered microphone
+			origin = physicalSource(ctx, ctx.inputList, names[o.device])
+		}
+		r.origins[o.index] = streamOrigin{App: o.appName(), Source: origin}
+		changed = true
+	}
+	for idx := range r.last {
+		if !present[idx] {
+			delete(r.last, idx)
+		}
+	}
+	for idx := range r.origins {
+		if !present[idx] {
+			delete(r.origins, idx)
+			changed = true
+		}
+	}
+	if changed {
+		writeRoutes(r.origins)
+	}
+}
+
+// routeApplications moves the streams of applications on the AutoRoute list to the filtered microphone.
+func routeApplications(ctx *ntcontext, outputs []stream) {
+	if len(ctx.config.AutoRoute) == 0 {
+		return
+	}
+	virt := findVirtualSources(ctx)
+	if len(virt) == 0 {
+		return
+	}
+	target := virt[0]
+	if inp, ok := inputSelection(ctx); ok {
+		if s, ok := findVirtualSource(ctx, &inp); ok {
+			target = s
+		}
+	}
+
+	r := &ctx.routing
+	present := make(map[uint32]bool, len(outputs))
+	for _, o := range outputs {
+		present[o.index] = true
+		if r.moved[o.index] || o.device == target.Index || !o.matches(ctx.config.AutoRoute) {
+			continue
+		}
+		if _, ours := o.props[tagID]; ours {
+			continue
+		}
+		r.moved[o.index] = true
+		infof("Moving %s to the filtered microphone\n", o.appName())
+		if err := moveSourceOutput(o.index, target.Name); err != nil {
+			errorf("Couldn't move %s to the filtered microphone: %v\n", o.appName(), err)
+		}
+	}
+	for idx := range r.moved {
+		if !present[idx] {
+			delete(r.moved, idx)
+		}
+	}
+}
+
+// restoreRouting moves the streams recording from the filtered microphone of inp, or from all of them
+// if inp is nil, back to where they came from. It's called before unloading, and on start for streams
+// the server moved elsewhere because the filter was unloaded without us.
+func restoreRouting(ctx *ntcontext, inp *device) {
+	r := &ctx.routing
+	if r.origins == nil {
+		r.origins = readRoutes()
+	}
+	outputs, err := listSourceOutputs()
+	if err != nil {
+		errorf("Couldn't list recording applications: %v\n", err)
+		return
+	}
+	sources, err := ctx.paClient.Sources()
+	if err != nil {
+		errorf("Couldn't fetch sources from pulseaudio: %v\n", err)
+		return
+	}
+	byIndex := make(map[uint32]pulseaudio.Source, len(sources))
+	for _, s := range sources {
+		byIndex[s.Index] = s
+	}
+	virtual := make(map[uint32]bool)
+	if inp != nil {
+		if s, ok := findVirtualSource(ctx, inp); ok {
+			virtual[s.Index] = true
+		}
+	} else {
+		for _, s := range findVirtualSources(ctx) {
+			virtual[s.Index] = true
+		}
+	}
+	devices := getSources(ctx, ctx.paClient)
+
+	for _, o := range outputs {
+		origin, known := r.origins[o.index]
+		if known && origin.App != o.appName() {
+			// the index was reused by another stream
+			known = false
+		}
+		from := byIndex[o.device]
+		switch {
+		case virtual[o.device]:
+			if !known || origin.Source == "" {
+				origin.Source = physicalSource(ctx, devices, from.Name)
+			}
+		case inp == nil && known && ctx.noiseSupressorState == unloaded && from.Name != origin.Source:
+			// the filter is gone already
+		default:
+			continue
+		}
+		delete(r.origins, o.index)
+		if _, ok := findDevice(devices, origin.Source); !ok {
+			// unplugged, the server picks the default
+			continue
+		}
+		infof("Moving %s back to %s\n", o.appName(), origin.Source)
+		if err := moveSourceOutput(o.index, origin.Source); err != nil {
+			errorf("Couldn't move %s back: %v\n", o.appName(), err)
+		}
+	}
+	if inp == nil {
+		// whatever is left doesn't exist anymore
+		r.origins = make(map[uint32]streamOrigin)
+	}
+	writeRoutes(r.origins)
+}
+
+// routingui is the state of the application routing settings, of the recording or the playback side.
+type routingui struct {
+	playback bool
+	apps     []string // currently recording or playing, to pick from
+	editor   nucular.TextEditor
+	err      string
+}
+
+// list returns the applications moved to the filtered device of the side shown.
+func (r *routingui) list(ctx *ntcontext) *[]string {
+	if r.playback {
+		return &ctx.config.OutputApps
+	}
+	return &ctx.config.AutoRoute
+}
+
+func openRoutingView(ctx *ntcontext, playback bool) {
+	ctx.routingUI = routingui{playback: playback}
+	ctx.routingUI.editor.Flags = nucular.EditField | nucular.EditSigEnter
+	ctx.views.Push(routingView)
+	go refreshRoutingApps(ctx)
+}
+
+func refreshRoutingApps(ctx *ntcontext) {
+	list := listSourceOutputs
+	if ctx.routingUI.playback {
+		list = listSinkInputs
+	}
+	streams, err := list()
+	if err != nil {
+		ctx.routingUI.err = err.Error()
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	seen := make(map[string]bool)
+	var apps []string
+	for _, o := range streams {
+		name := o.appName()
+		if _, ours := o.props[tagID]; ours || name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		apps = append(apps, name)
+	}
+	sort.Strings(apps)
+	ctx.routingUI.apps = apps
+	(*ctx.masterWindow).Changed()
+}
+
+func routedIndex(list []string, app string) int {
+	for i, a := range list {
+		if strings.EqualFold(a, app) {
+			return i
+		}
+	}
+	return -1
+}
+
+func setRouted(ctx *ntcontext, list *[]string, app string, enabled bool) {
+	i := routedIndex(*list, app)
+	switch {
+	case enabled && i < 0:
+		*list = append(*list, app)
+	case !enabled && i >= 0:
+		*list = append((*list)[:i:i], (*list)[i+1:]...)
+	}
+	go writeConfig(ctx.config)
+}
+
+func routingView(ctx *ntcontext, w *nucular.Window) {
+	r := &ctx.routingUI
+	list := r.list(ctx)
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Application Routing"), "CB")
+	w.Row(30).Dynamic(1)
+	if r.playback {
+		w.LabelWrap(tr("Checked applications are moved to the filtered headphones as soon as they start playing, the others are moved away from them."))
+	} else {
+		w.LabelWrap(tr("Checked applications are moved to the filtered microphone as soon as they start recording."))
+	}
+
+	// everything on the list, and what's recording or playing right now
+	apps := append([]string(nil), *list...)
+	for _, app := range r.apps {
+		if routedIndex(*list, app) < 0 {
+			apps = append(apps, app)
+		}
+	}
+	for _, app := range apps {
+		w.Row(15).Dynamic(1)
+		enabled := routedIndex(*list, app) >= 0
+		if focusable(ctx, w, w.CheckboxText(app, &enabled)) {
+			setRouted(ctx, list, app, enabled)
+		}
+	}
+
+	w.Row(25).Ratio(0.7, 0.3)
+	ev := focusableEdit(ctx, w, &r.editor, r.editor.Edit(w))
+	add := focusable(ctx, w, w.ButtonText(tr("Add")))
+	if name := strings.TrimSpace(string(r.editor.Buffer)); (add || ev&nucular.EditCommitted != 0) && name != "" {
+		setRouted(ctx, list, name, true)
+		r.editor.Buffer = nil
+	}
+	w.Row(15).Dynamic(1)
+	if r.playback {
+		w.Label(tr("Add by application name or binary, e.g. Firefox or zoom."), "LC")
+	} else {
+		w.Label(tr("Add by application name or binary, e.g. Discord or obs."), "LC")
+	}
+
+	if r.err != "" {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(r.err, "LC", red)
+	}
+
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Refresh"))) {
+		r.err = ""
+		go refreshRoutingApps(ctx)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) {
+		ctx.views.Pop()
+	}
+}
@@ -0,0 +1,246 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Every stage of the chain negotiates its sample spec with the audio server, and the filter only
+// works at filterRate. When a stage ends up at another rate, e.g. behind an echo-cancel source
+// running float32le at 32 kHz, the filtered microphone silently records nothing useful. The chain view
+// shows what each stage runs at, and CaptureRate and CaptureFormat force what the loopback requests
+// from the microphone for sources that misbehave when asked for their default.
+
+var captureRates = []int{0, 16000, 32000, 44100, 48000} // 0 is automatic, i.e. filterRate
+
+var captureFormats = []string{"", "s16le", "s24le", "s32le", "float32le"} // "" is automatic
+
+// chainStage is one device or stream of the chain with the spec it runs at.
+type chainStage struct {
+	name    string
+	spec    string
+	warning string
+}
+
+func formatSampleSpec(format, channels byte, rate uint32) string {
+	return fmt.Sprintf("%s %dch %s", sampleFormatName(format), channels, formatRate(rate))
+}
+
+// chainStages returns the stages of the chain filtering inp, from the microphone to the filtered one.
+func chainStages(ctx *ntcontext, inp *device) ([]chainStage, error) {
+	c := ctx.paClient
+	type spec struct {
+		format, channels byte
+		rate             uint32
+	}
+	sources, sinks := make(map[string]spec), make(map[string]spec)
+	srcs, err := c.Sources()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range srcs {
+		sources[s.Name] = spec{s.SampleSpec.Format, s.SampleSpec.Channels, s.SampleSpec.Rate}
+	}
+	snks, err := c.Sinks()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snks {
+		sinks[s.Name] = spec{s.SampleSpec.Format, s.SampleSpec.Channels, s.SampleSpec.Rate}
+	}
+
+	var stages []chainStage
+	add := func(name string, devices map[string]spec, id string, filter bool) {
+		s, ok := devices[id]
+		if !ok {
+			stages = append(stages, chainStage{name, tr("not loaded"), tr("This part of the chain is missing, reload the filter.")})
+			return
+		}
+		st := chainStage{name: name, spec: formatSampleSpec(s.format, s.channels, s.rate)}
+		if filter && s.rate != filterRate {
+			st.warning = trf("The filter only works at %s, it can't remove noise at this rate.", formatRate(filterRate))
+		}
+		stages = append(stages, st)
+	}
+
+	add(tr("Microphone"), sources, inp.ID, false)
+	if note, degrading := resampleNote(inp); degrading {
+		stages[0].warning = note
+	}
+	switch {
+	case ctx.serverInfo.servertype == servertype_pipewire && ctx.config.NativePipeWire:
+		add(tr("Filter"), sources, pipeWireNodeName, true)
+	case ctx.serverInfo.servertype == servertype_pipewire:
+		add(tr("Filter"), sources, pipeWireInputSource(inp), true)
+	default:
+		names := inputChainFor(inp)
+		stages = append(stages, loopbackStage(ctx, names))
+		add(tr("Filter"), sinks, names.raw, true)
+		if ctx.config.Gate {
+			add(tr("Noise gate"), sinks, names.gate, true)
+		}
+		add(tr("Null sink"), sinks, names.denoised, true)
+		add(tr("Filtered Microphone"), sources, names.remap, false)
+	}
+	return stages, nil
+}
+
+// loopbackStage describes the stream recording from the microphone, our pulseaudio library can't
+// list streams so it goes through pactl.
+func loopbackStage(ctx *ntcontext, names inputChain) chainStage {
+	st := chainStage{name: tr("Loopback")}
+	m, found, err := findChainModule(ctx, "module-loopback", "sink="+names.raw+" ")
+	if err != nil || !found {
+		st.spec, st.warning = tr("not loaded"), tr("This part of the chain is missing, reload the filter.")
+		return st
+	}
+	outputs, err := listSourceOutputs()
+	if err != nil {
+		st.spec, st.warning = tr("unknown"), err.Error()
+		return st
+	}
+	for _, o := range outputs {
+		if o.module == m.Index {
+			st.spec = o.spec
+			return st
+		}
+	}
+	st.spec, st.warning = tr("not running"), tr("The loopback doesn't record from the microphone.")
+	return st
+}
+
+type samplespecui struct {
+	stages map[string][]chainStage // by microphone name
+	err    string
+}
+
+func openChainView(ctx *ntcontext) {
+	ctx.samplespec = samplespecui{}
+	ctx.views.Push(chainView)
+	go refreshChainStages(ctx)
+}
+
+func refreshChainStages(ctx *ntcontext) {
+	res := make(map[string][]chainStage)
+	for _, inp := range inputSelections(ctx) {
+		stages, err := chainStages(ctx, &inp)
+		if err != nil {
+			ctx.samplespec.err = err.Error()
+			(*ctx.masterWindow).Changed()
+			return
+		}
+		res[inp.Name] = stages
+	}
+	ctx.samplespec.stages = res
+	(*ctx.masterWindow).Changed()
+}
+
+func chainView(ctx *ntcontext, w *nucular.Window) {
+	s := &ctx.samplespec
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Filter chain"), "CB")
+
+	if s.err != "" {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(s.err, "LC", red)
+	}
+	for _, inp := range inputSelections(ctx) {
+		stages, ok := s.stages[inp.Name]
+		if !ok {
+			continue
+		}
+		w.Row(15).Dynamic(1)
+		w.Label(inp.Name, "LC")
+		for _, st := range stages {
+			w.Row(20).Ratio(0.4, 0.6)
+			w.Label(st.name, "LC")
+			if st.warning == "" {
+				w.Label(st.spec, "LC")
+				continue
+			}
+			w.LabelColored(st.spec, "LC", orange)
+			if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+				w.Tooltip(st.warning)
+			}
+		}
+	}
+
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Refresh"))) {
+		go refreshChainStages(ctx)
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) || ctx.noiseSupressorState != loaded {
+		ctx.views.Pop()
+	}
+}
+
+// sampleSpecView is the settings entry to force the capture spec and show the chain.
+func sampleSpecView(ctx *ntcontext, w *nucular.Window) {
+	if ctx.serverInfo.servertype == servertype_pulse {
+		rates := make([]string, len(captureRates))
+		selRate := 0
+		for i, r := range captureRates {
+			if r == 0 {
+				rates[i] = tr("Automatic")
+			} else {
+				rates[i] = formatRate(uint32(r))
+			}
+			if r == ctx.config.CaptureRate {
+				selRate = i
+			}
+		}
+		formats := make([]string, len(captureFormats))
+		selFormat := 0
+		for i, f := range captureFormats {
+			formats[i] = f
+			if f == "" {
+				formats[i] = tr("Automatic")
+			}
+			if f == ctx.config.CaptureFormat {
+				selFormat = i
+			}
+		}
+
+		w.Row(25).Ratio(0.4, 0.3, 0.3)
+		w.Label(tr("Capture format"), "LC")
+		if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+			w.Tooltip(tr("What the filter requests from the microphone. Force it if the filtered microphone stays silent with a source like echo-cancel."))
+		}
+		if sel := focusableCombo(ctx, w, w.ComboSimple(rates, selRate, 25), len(rates)); sel != selRate {
+			ctx.config.CaptureRate = captureRates[sel]
+			go writeConfig(ctx.config)
+			ctx.reloadRequired = true
+		}
+		if sel := focusableCombo(ctx, w, w.ComboSimple(formats, selFormat, 25), len(formats)); sel != selFormat {
+			ctx.config.CaptureFormat = captureFormats[sel]
+			go writeConfig(ctx.config)
+			ctx.reloadRequired = true
+		}
+	}
+
+	if ctx.noiseSupressorState == loaded && ctx.config.FilterInput {
+		w.Row(25).Ratio(0.7, 0.3)
+		w.Label(tr("Sample rate and format of each part of the chain"), "LC")
+		if focusable(ctx, w, w.ButtonText(tr("Show"))) {
+			openChainView(ctx)
+		}
+	}
+}
+
+func knownCaptureFormat(f string) bool {
+	for _, known := range captureFormats {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+func captureFormatNames() string {
+	return strings.Join(captureFormats[1:], ", ")
+}
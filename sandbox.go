@@ -0,0 +1,101 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Inside Flatpak or Snap we can only talk to the audio server through the socket the sandbox shares,
+// can't see the server's process to lift its rlimit, can't setcap ourselves, and our files aren't
+// where the host looks for them. The package manager updates us, so the self-updater is off.
+//
+// The plugin we load is read by the audio server on the host, so it's written to a directory the
+// host sees at the same path. Start on login goes through the background portal in Flatpak, and
+// through the snap's own autostart directory in Snap.
+
+const (
+	sandboxFlatpak = "Flatpak"
+	sandboxSnap    = "Snap"
+)
+
+// sandboxed is the sandbox we run in, "" if none.
+var sandboxed = detectSandbox()
+
+func detectSandbox() string {
+	if ok, _ := exists("/.flatpak-info"); ok || os.Getenv("FLATPAK_ID") != "" {
+		return sandboxFlatpak
+	}
+	if os.Getenv("SNAP") != "" && os.Getenv("SNAP_NAME") != "" {
+		return sandboxSnap
+	}
+	return ""
+}
+
+// pluginDir is where to write the plugin so the audio server can load it, "" for the temp dir.
+func pluginDir() string {
+	switch sandboxed {
+	case sandboxFlatpak:
+		// the app's directory in XDG_RUNTIME_DIR is shared with the host
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			return filepath.Join(dir, "app", os.Getenv("FLATPAK_ID"))
+		}
+	case sandboxSnap:
+		return os.Getenv("SNAP_USER_DATA")
+	}
+	return ""
+}
+
+// audioCommand runs one of the PulseAudio tools (pactl, parec). A Flatpak whose runtime doesn't
+// ship them runs the host's, which needs --talk-name=org.freedesktop.Flatpak.
+func audioCommand(name string, args ...string) *exec.Cmd {
+	if _, err := exec.LookPath(name); err != nil && sandboxed == sandboxFlatpak {
+		return exec.Command("flatpak-spawn", append([]string{"--host", name}, args...)...)
+	}
+	return exec.Command(name, args...)
+}
+
+// sandboxExecutable is the command that starts us from outside the sandbox.
+func sandboxExecutable() (string, error) {
+	if sandboxed == sandboxSnap {
+		// the path of the binary changes with every revision
+		return filepath.Join("/snap/bin", os.Getenv("SNAP_INSTANCE_NAME")), nil
+	}
+	return os.Executable()
+}
+
+// gvariantString quotes s for the GVariant text format gdbus takes.
+func gvariantString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// requestPortalAutostart asks the background portal to start us on login, or not to anymore. The
+// portal may ask the user first, and writes the autostart entry itself.
+func requestPortalAutostart(ctx *ntcontext, enable bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// the command inside the sandbox, the portal wraps it with flatpak run
+	cmdline := []string{gvariantString(filepath.Base(exe))}
+	for _, a := range autostartLoadArgs(ctx) {
+		cmdline = append(cmdline, gvariantString(a))
+	}
+	options := fmt.Sprintf("{'autostart': <%t>, 'commandline': <[%s]>, 'reason': <%s>}",
+		enable, strings.Join(cmdline, ", "), gvariantString("Load the filtered microphone on login"))
+	cmd := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.portal.Desktop",
+		"--object-path", "/org/freedesktop/portal/desktop",
+		"--method", "org.freedesktop.portal.Background.RequestBackground",
+		"", options)
+	debugf("Calling: %s\n", cmd.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("the background portal refused: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
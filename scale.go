@@ -0,0 +1,138 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/font"
+	"golang.org/x/mobile/event/mouse"
+)
+
+// nucular doesn't know about the scale of the display, everything is drawn in pixels. UIScale
+// picks the scale, 0 follows the desktop: GDK_SCALE if it's set, otherwise the Xft.dpi resource
+// desktops set for their scale factor. At 100% the UI looks like it always did.
+
+const (
+	uiStyleScaling = 2.0
+	uiFontSize     = 16
+	uiBaseDPI      = 96
+	minUIScale     = 50  // percent
+	maxUIScale     = 300 // percent
+)
+
+// uiScale returns the scale factor to draw the UI with.
+func uiScale(c *config) float64 {
+	if c != nil && c.UIScale != 0 {
+		return float64(c.UIScale) / 100
+	}
+	return detectUIScale()
+}
+
+var (
+	desktopScale     float64
+	desktopScaleOnce sync.Once
+)
+
+// detectUIScale returns the scale factor of the desktop, 1 if there is none. It's looked up once,
+// desktops need a restart of their applications after changing it anyway.
+func detectUIScale() float64 {
+	desktopScaleOnce.Do(func() {
+		desktopScale = lookupUIScale()
+	})
+	return desktopScale
+}
+
+func lookupUIScale() float64 {
+	scale := 0.0
+	if n, err := strconv.Atoi(os.Getenv("GDK_SCALE")); err == nil && n > 0 {
+		scale = float64(n)
+		if f, err := strconv.ParseFloat(os.Getenv("GDK_DPI_SCALE"), 64); err == nil && f > 0 {
+			scale *= f
+		}
+	} else if dpi := xftDPI(); dpi > 0 {
+		scale = dpi / uiBaseDPI
+	}
+	if scale <= 0 {
+		return 1
+	}
+	return math.Max(minUIScale, math.Min(maxUIScale, scale*100)) / 100
+}
+
+// xftDPI returns the Xft.dpi X resource, 0 if there is none or no X server to ask. Wayland
+// sessions have it too as long as XWayland runs.
+func xftDPI() float64 {
+	X, err := xgb.NewConn()
+	if err != nil {
+		return 0
+	}
+	defer X.Close()
+	root := xproto.Setup(X).DefaultScreen(X).Root
+	res, err := xproto.GetProperty(X, false, root, xproto.AtomResourceManager, xproto.AtomString, 0, 1<<16).Reply()
+	if err != nil {
+		return 0
+	}
+	return parseXftDPI(string(res.Value))
+}
+
+// parseXftDPI finds Xft.dpi in the X resources, one "name:\tvalue" per line.
+func parseXftDPI(resources string) float64 {
+	for _, line := range strings.Split(resources, "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 || strings.TrimSpace(line[:i]) != "Xft.dpi" {
+			continue
+		}
+		dpi, err := strconv.ParseFloat(strings.TrimSpace(line[i+1:]), 64)
+		if err != nil || dpi <= 0 {
+			return 0
+		}
+		return dpi
+	}
+	return 0
+}
+
+// setUIStyle applies the scale and theme of c to a window, c is nil without a config. The size
+// of a window at creation is scaled by the style too.
+func setUIStyle(wnd nucular.MasterWindow, c *config) {
+	scale := uiScale(c)
+	s := themedStyle(c, uiStyleScaling*scale)
+	s.Font = font.DefaultFont(uiFontSize, scale)
+	wnd.SetStyle(s)
+}
+
+func uiScaleView(ctx *ntcontext, w *nucular.Window) {
+	auto := ctx.config.UIScale == 0
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(trf("Scale with the desktop (%.0f%%)", detectUIScale()*100), &auto)) {
+		if auto {
+			ctx.config.UIScale = 0
+		} else {
+			ctx.config.UIScale = int(math.Round(detectUIScale() * 100))
+		}
+		go writeConfig(ctx.config)
+		setUIStyle(*ctx.masterWindow, ctx.config)
+	}
+	if auto {
+		return
+	}
+	w.Row(25).Ratio(0.3, 0.55, 0.15)
+	w.Label(tr("Scale"), "LC")
+	if focusableSlider(ctx, w, w.SliderInt(minUIScale, &ctx.config.UIScale, maxUIScale, 25), &ctx.config.UIScale, minUIScale, maxUIScale, 25) {
+		go writeConfig(ctx.config)
+	}
+	w.Label(fmt.Sprintf("%d%%", ctx.config.UIScale), "RC")
+	// rescaling moves the slider away from the mouse, so only apply it once it's let go
+	if !w.Input().Mouse.Down(mouse.ButtonLeft) && (*ctx.masterWindow).Style().Scaling != uiStyleScaling*uiScale(ctx.config) {
+		setUIStyle(*ctx.masterWindow, ctx.config)
+		(*ctx.masterWindow).Changed()
+	}
+}
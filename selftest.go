@@ -0,0 +1,298 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// `noisetorch selftest` checks the whole way through the audio server: it loads a null sink as a
+// test microphone, loads the filter for it like for any microphone, plays a noisy sample into the
+// null sink and records the filtered microphone, and compares the signal-to-noise ratio before and
+// after. Nothing touches the real microphone, so it works the same on a developer's machine and
+// in a quiet room.
+//
+// The sample is built in, generated like the audio of bench instead of shipped as a recording:
+// steady noise, then the same noise under a voice-like sound, vowels sung over a gliding pitch.
+// RNNoise is less sure it's a voice than with a real one, so the chain runs with the voice
+// activation threshold at zero, everything else is as configured.
+
+const (
+	selftestPeriod     = 3 * filterRate // samples, half of it noise alone, half noise and voice
+	selftestWarmup     = 2 * time.Second
+	selftestRecord     = 6 * time.Second // two periods
+	minSelftestGain    = 6.0             // dB the signal-to-noise ratio has to improve by
+	maxSelftestLoss    = 20.0            // dB the voice may get quieter by
+	minSelftestLevel   = -60.0           // dBFS the sample has to arrive with
+	selftestSilence    = -120.0          // dBFS of digital silence, e.g. behind the noise gate
+	selftestFrame      = filterRate / 100
+	selftestQuantile   = 4 // the loudest and the quietest quarter of the frames are voice and noise
+	selftestMicrophone = "NoiseTorch test microphone"
+)
+
+type selftestResult struct {
+	Checks        []checkResult `json:"checks"`
+	InputSNR      float64       `json:"inputSNR"`      // dB, of what reached the filter
+	OutputSNR     float64       `json:"outputSNR"`     // dB, of the filtered microphone
+	VoiceLevelIn  float64       `json:"voiceLevelIn"`  // dBFS
+	VoiceLevelOut float64       `json:"voiceLevelOut"` // dBFS
+}
+
+// selftestSample returns one period of the sample.
+func selftestSample() []int16 {
+	// formants of a few vowels, and their bandwidths
+	vowels := [][3]float64{{730, 1090, 2440}, {270, 2290, 3010}, {530, 1840, 2480}, {570, 840, 2410}, {300, 870, 2240}, {660, 1720, 2410}}
+	bandwidths := [3]float64{90, 110, 170}
+	const syllable = filterRate / 4
+
+	pcm := make([]int16, selftestPeriod)
+	seed := uint32(1)
+	var noise, phase float64
+	for i := range pcm {
+		// low passed white noise, like a fan
+		seed = seed*1664525 + 1013904223
+		noise += 0.5 * (float64(int32(seed))/(1<<31) - noise)
+		s := 1500 * noise
+
+		if j := i - selftestPeriod/2; j >= 0 {
+			pos := float64(j%syllable) / syllable
+			env := math.Sqrt(math.Sin(math.Pi * pos))
+			f0 := 120 + 25*math.Sin(2*math.Pi*float64(j)/(selftestPeriod/2)) + 8*math.Sin(2*math.Pi*5*float64(j)/filterRate)
+			phase += 2 * math.Pi * f0 / filterRate
+			formants := vowels[(j/syllable)%len(vowels)]
+			voice := 0.0
+			for h := 1; float64(h)*f0 < 4000; h++ {
+				gain := 0.0
+				for k, f := range formants {
+					d := (float64(h)*f0 - f) / bandwidths[k]
+					gain += 1 / (1 + d*d) / float64(k+1)
+				}
+				voice += gain / float64(h) * math.Sin(float64(h)*phase)
+			}
+			s += 6000 * env * voice
+		}
+		pcm[i] = int16(math.Max(-32768, math.Min(32767, s)))
+	}
+	return pcm
+}
+
+// selftestLevels returns the level of the voice and of the noise in dBFS, of the loudest and the
+// quietest frames. It doesn't need to know where the sample starts in the recording.
+func selftestLevels(pcm []int16) (voice, noise float64) {
+	var energy []float64
+	for i := 0; i+selftestFrame <= len(pcm); i += selftestFrame {
+		sum := 0.0
+		for _, s := range pcm[i : i+selftestFrame] {
+			sum += float64(s) * float64(s)
+		}
+		energy = append(energy, sum/selftestFrame)
+	}
+	sort.Float64s(energy)
+	n := len(energy) / selftestQuantile
+	if n == 0 {
+		return selftestSilence, selftestSilence
+	}
+	level := func(frames []float64) float64 {
+		sum := 0.0
+		for _, e := range frames {
+			sum += e
+		}
+		return math.Max(selftestSilence, 10*math.Log10(sum/float64(len(frames))/(32768*32768)))
+	}
+	return level(energy[len(energy)-n:]), level(energy[:n])
+}
+
+// playSelftestSample plays the sample into sink over and over, until the returned function is called.
+func playSelftestSample(sink string) (func(), error) {
+	cmd := audioCommand("pacat", "--playback", "--raw", "--format=s16le", "--channels=1",
+		fmt.Sprintf("--rate=%d", filterRate), "--client-name=NoiseTorch self-test", "-d", sink)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("couldn't start pacat: %w", err)
+	}
+	pcm := selftestSample()
+	data := make([]byte, 2*len(pcm))
+	for i, s := range pcm {
+		data[2*i], data[2*i+1] = byte(s), byte(uint16(s)>>8)
+	}
+	go func(w io.Writer) {
+		// pacat reads as fast as it plays, this ends when it's killed
+		for {
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+		}
+	}(stdin)
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}, nil
+}
+
+// runSelftest runs the test and unloads everything it loaded. The config of ctx should be a copy,
+// the threshold is changed for the test.
+func runSelftest(ctx *ntcontext) (res selftestResult) {
+	d := &doctor{ctx: ctx}
+	defer func() { res.Checks = d.results }()
+	skip := func(reason string, names ...string) {
+		for _, name := range names {
+			d.report(name, checkSkip, reason, "")
+		}
+	}
+
+	if jackMode(ctx) {
+		d.report("Test microphone loaded", checkFail, "the JACK backend filters JACK ports, not microphones",
+			"Switch the backend to the audio server in the settings to run the test.")
+		skip("no test microphone", "Filter loaded", "Sample reaches the filter", "Voice kept", "Noise removed")
+		return res
+	}
+	if currentEngine(ctx).rnnoise {
+		ctx.config.Threshold = 0
+	}
+	// the native filter-chain runs only one microphone, which may be the user's
+	ctx.config.NativePipeWire = false
+
+	c := ctx.paClient
+	sink := fmt.Sprintf("nui_selftest_%d", os.Getpid())
+	idx, err := c.LoadModule("module-null-sink",
+		fmt.Sprintf(`sink_name=%s rate=%d channels=1 sink_properties="device.description='%s' %s"`,
+			sink, filterRate, selftestMicrophone, transientTags(ctx)))
+	if err != nil {
+		d.report("Test microphone loaded", checkFail, err.Error(),
+			"The audio server refuses to load modules. With PipeWire, make sure pipewire-pulse is installed and running.")
+		skip("no test microphone", "Filter loaded", "Sample reaches the filter", "Voice kept", "Noise removed")
+		return res
+	}
+	defer func() {
+		if err := c.UnloadModule(idx); err != nil {
+			errorf("Couldn't unload the test microphone: %v\n", err)
+		}
+	}()
+	inp := device{ID: sink + ".monitor", Name: selftestMicrophone, isMonitor: true, checked: true, rate: filterRate, channels: 1}
+	if sources, err := c.Sources(); err == nil {
+		for _, s := range sources {
+			if s.Name == inp.ID {
+				inp.dynamicLatency = s.Flags&uint32(0x0040) != 0
+				inp.channelMap = channelMapString(s.ChannelMap)
+			}
+		}
+	}
+	d.report("Test microphone loaded", checkPass, inp.ID, "")
+
+	if err := loadInputSupressor(ctx, &inp); err != nil {
+		d.report("Filter loaded", checkFail, err.Error(),
+			"The audio server's log usually tells why, see 'journalctl --user -u pipewire-pulse' or 'journalctl --user -u pulseaudio'.")
+		skip("filter didn't load", "Sample reaches the filter", "Voice kept", "Noise removed")
+		return res
+	}
+	defer func() {
+		if err := unloadInputSupressor(ctx, &inp); err != nil {
+			errorf("Couldn't unload the filter of the test microphone: %v\n", err)
+		}
+	}()
+	virt, ok := waitVirtualSource(ctx, &inp)
+	if !ok {
+		d.report("Filter loaded", checkFail, "the filter loaded, but no filtered microphone showed up",
+			"Check the log under About > Logs and the audio server's log for errors.")
+		skip("no filtered microphone", "Sample reaches the filter", "Voice kept", "Noise removed")
+		return res
+	}
+	d.report("Filter loaded", checkPass, virt.Name, "")
+
+	stop, err := playSelftestSample(sink)
+	if err != nil {
+		d.report("Sample reaches the filter", checkFail, err.Error(), "Make sure pacat is installed, it comes with pulseaudio-utils.")
+		skip("no sample", "Voice kept", "Noise removed")
+		return res
+	}
+	time.Sleep(selftestWarmup)
+	type recording struct {
+		pcm []int16
+		err error
+	}
+	raw, filtered := make(chan recording, 1), make(chan recording, 1)
+	go func() {
+		pcm, err := recordSource(inp.ID, "NoiseTorch self-test", selftestRecord)
+		raw <- recording{pcm, err}
+	}()
+	go func() {
+		pcm, err := recordSource(virt.Name, "NoiseTorch self-test", selftestRecord)
+		filtered <- recording{pcm, err}
+	}()
+	r, f := <-raw, <-filtered
+	stop()
+
+	if r.err != nil || f.err != nil {
+		err := r.err
+		if err == nil {
+			err = f.err
+		}
+		d.report("Sample reaches the filter", checkFail, err.Error(), "Make sure parec is installed, it comes with pulseaudio-utils.")
+		skip("no recording", "Voice kept", "Noise removed")
+		return res
+	}
+	voiceIn, noiseIn := selftestLevels(r.pcm)
+	voiceOut, noiseOut := selftestLevels(f.pcm)
+	res.InputSNR, res.OutputSNR = voiceIn-noiseIn, voiceOut-noiseOut
+	res.VoiceLevelIn, res.VoiceLevelOut = voiceIn, voiceOut
+	debugf("Self-test levels: voice %.1f/%.1f dBFS, noise %.1f/%.1f dBFS\n", voiceIn, voiceOut, noiseIn, noiseOut)
+
+	if voiceIn < minSelftestLevel {
+		d.report("Sample reaches the filter", checkFail, fmt.Sprintf("the test microphone records only %.0f dBFS", voiceIn),
+			"The sample plays, but doesn't arrive. Check the audio server's log for errors.")
+		skip("no sample", "Voice kept", "Noise removed")
+		return res
+	}
+	d.report("Sample reaches the filter", checkPass, fmt.Sprintf("voice at %.0f dBFS", voiceIn), "")
+
+	if voiceOut < voiceIn-maxSelftestLoss {
+		d.report("Voice kept", checkFail, fmt.Sprintf("from %.0f dBFS down to %.0f dBFS", voiceIn, voiceOut),
+			"The filter removes the voice along with the noise. Check the log under About > Logs, and try the built-in model if you use another one.")
+	} else {
+		d.report("Voice kept", checkPass, fmt.Sprintf("%.0f dBFS", voiceOut), "")
+	}
+
+	detail := fmt.Sprintf("signal-to-noise ratio from %.1f dB to %.1f dB", res.InputSNR, res.OutputSNR)
+	if res.OutputSNR-res.InputSNR < minSelftestGain {
+		d.report("Noise removed", checkFail, detail,
+			fmt.Sprintf("The filter should improve it by at least %.0f dB. Check the suppression setting, and the log under About > Logs.", minSelftestGain))
+	} else {
+		d.report("Noise removed", checkPass, detail, "")
+	}
+	return res
+}
+
+// selftestCLI implements `noisetorch selftest`.
+func selftestCLI(ctx *ntcontext, opt CLIOpts) {
+	c := *ctx.config
+	ctx.config = &c
+	if opt.model != "" {
+		if err := validateModel(opt.model); err != nil {
+			opt.fail("Invalid model: %v\n", err)
+		}
+		ctx.config.Model = opt.model
+	}
+	if !opt.json {
+		fmt.Println("Filtering a noisy sample through a test microphone, this takes about ten seconds...")
+	}
+	res := runSelftest(ctx)
+	if opt.json {
+		printJSON(res)
+	} else {
+		printDoctor(res.Checks)
+	}
+	if doctorFailed(res.Checks) {
+		cleanupExit(1)
+	}
+	cleanupExit(0)
+}
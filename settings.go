@@ -0,0 +1,359 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"noisetorch/buildinfo"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The settings are grouped into collapsible categories. Typing into the search box shows the
+// matching settings of all categories at once instead.
+
+type settingsui struct {
+	search nucular.TextEditor
+}
+
+type settingsEntry struct {
+	keywords string // searched in addition to the category name, lower case
+	view     func(ctx *ntcontext, w *nucular.Window)
+}
+
+type settingsCategory struct {
+	name    string
+	open    bool // initially
+	entries []settingsEntry
+}
+
+var settingsCategories = []settingsCategory{
+	{"Audio", true, []settingsEntry{
+		{"filter microphone headphones input output", filterTargetsView},
+		{"engine rnnoise deepfilternet attenuation", engineView},
+		{"gain volume loudness boost", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				gainView(ctx, w)
+			}
+		}},
+		{"noise type preset keyboard fan hum street speakers", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				noisePresetView(ctx, w)
+			}
+		}},
+		{"threshold voice activation calibrate learn", thresholdView},
+		{"model rnnoise voice", func(ctx *ntcontext, w *nucular.Window) {
+			if currentEngine(ctx).rnnoise {
+				modelView(ctx, w)
+			}
+		}},
+		{"noise gate attack release keyboard clicks", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				gateView(ctx, w)
+			}
+		}},
+	}},
+	{"Devices", false, []settingsEntry{
+		{"monitor sources list", monitorSourcesView},
+		{"hidden devices favorites pinned list", func(ctx *ntcontext, w *nucular.Window) {
+			w.Row(15).Dynamic(1)
+			if focusable(ctx, w, w.CheckboxText(tr("Show hidden devices"), &ctx.config.ShowHiddenDevices)) {
+				ctx.sourceListColdWidthIndex++
+				go writeConfig(ctx.config)
+			}
+		}},
+		{"load start enable device profile", enableOnStartView},
+		{"quit exit close unload window", exitSettingsView},
+		{"quick switch states profile", quickSwitchView},
+		{"presets office gaming streaming profile", presetsSettingsView},
+		{"hotplug plugged reconnect reload", func(ctx *ntcontext, w *nucular.Window) {
+			w.Row(15).Dynamic(1)
+			if focusable(ctx, w, w.CheckboxText(tr("Reload the filter when the microphone is plugged back in"), &ctx.config.ReloadOnHotplug)) {
+				go writeConfig(ctx.config)
+			}
+		}},
+		{"keep awake suspend idle", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				keepAwakeView(ctx, w)
+			}
+		}},
+		{"idle unload cpu power save recording minutes", func(ctx *ntcontext, w *nucular.Window) {
+			if idleUnloadPossible(ctx) {
+				idleUnloadView(ctx, w)
+			}
+		}},
+		{"applications routing move streams apps", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				w.Row(25).Ratio(0.7, 0.3)
+				w.Label(tr("Move applications to the filtered microphone"), "LC")
+				if focusable(ctx, w, w.ButtonText(tr("Applications"))) {
+					openRoutingView(ctx, false)
+				}
+			}
+		}},
+		{"applications routing move streams apps playback speakers headphones", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterOutput {
+				w.Row(25).Ratio(0.7, 0.3)
+				w.Label(tr("Move applications to the filtered headphones"), "LC")
+				if focusable(ctx, w, w.ButtonText(tr("Applications"))) {
+					openRoutingView(ctx, true)
+				}
+			}
+		}},
+	}},
+	{"Appearance", false, []settingsEntry{
+		{"language translation locale", languageView},
+		{"theme dark light colors", themeView},
+		{"scale size dpi hidpi zoom font", uiScaleView},
+	}},
+	{"Updates", false, []settingsEntry{
+		{"updates check version release channel beta stable rollback revert downgrade", updatesView},
+	}},
+	{"Notifications", false, []settingsEntry{
+		{"notifications notify desktop popup connection update", notificationsView},
+	}},
+	{"Integrations", false, []settingsEntry{
+		{"obs studio websocket streaming recording", integrationsView},
+	}},
+	{"Advanced", false, []settingsEntry{
+		{"native pipewire filter-chain experimental", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.serverInfo.servertype == servertype_pipewire {
+				w.Row(15).Dynamic(1)
+				if focusable(ctx, w, w.CheckboxText(tr("Use native PipeWire filter-chain (experimental)"), &ctx.config.NativePipeWire)) {
+					go writeConfig(ctx.config)
+					ctx.reloadRequired = true
+				}
+			}
+		}},
+		{"jack client pro-audio ports connections patchbay pw-jack", jackSettingsView},
+		{"in-process engine ladspa broken module-ladspa-sink cgo", inProcessSettingsView},
+		{"buffer latency delay crackling", bufferLatencyView},
+		{"sample rate format spec chain echo-cancel resample", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				sampleSpecView(ctx, w)
+			}
+		}},
+		{"watchdog stuck silent silence reload", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				watchdogView(ctx, w)
+			}
+		}},
+		{"module arguments custom raw null-sink ladspa loopback", func(ctx *ntcontext, w *nucular.Window) {
+			if ctx.config.FilterInput {
+				moduleArgsSettingsView(ctx, w)
+			}
+		}},
+		{"realtime rtkit capability setcap pulseaudio", realtimeView},
+		{"system plugin ladspa rnnoise distribution package bundled", systemPluginView},
+		{"latency offset obs sync", latencyOffsetView},
+		{"start login autostart boot", autostartView},
+	}},
+	{"Shortcuts", false, []settingsEntry{
+		{"keyboard shortcuts hotkeys keys", func(ctx *ntcontext, w *nucular.Window) {
+			if buildinfo.Enabled().Hotkeys {
+				w.Row(25).Ratio(0.7, 0.3)
+				w.Label(tr("Global keyboard shortcuts"), "LC")
+				if focusable(ctx, w, w.ButtonText(tr("Shortcuts"))) {
+					openShortcuts(ctx)
+				}
+			} else {
+				w.Row(15).Dynamic(1)
+				w.Label(tr("Keyboard shortcuts aren't available in this build."), "LC")
+			}
+		}},
+	}},
+}
+
+func (e settingsEntry) matches(category, query string) bool {
+	return query == "" || strings.Contains(strings.ToLower(category), query) ||
+		strings.Contains(strings.ToLower(tr(category)), query) || strings.Contains(e.keywords, query)
+}
+
+func settingsView(ctx *ntcontext, w *nucular.Window) {
+	s := &ctx.settings
+	s.search.Flags = nucular.EditField | nucular.EditSelectable | nucular.EditClipboard
+	w.Row(25).Ratio(0.2, 0.8)
+	w.Label(tr("Search"), "LC")
+	focusableEdit(ctx, w, &s.search, s.search.Edit(w))
+	query := strings.ToLower(strings.TrimSpace(string(s.search.Buffer)))
+
+	if query == "" {
+		for _, c := range settingsCategories {
+			if w.TreePush(nucular.TreeNode, tr(c.name), c.open) {
+				for _, e := range c.entries {
+					e.view(ctx, w)
+				}
+				w.TreePop()
+			}
+		}
+		return
+	}
+
+	found := false
+	for _, c := range settingsCategories {
+		header := false
+		for _, e := range c.entries {
+			if !e.matches(c.name, query) {
+				continue
+			}
+			if !header {
+				w.Row(15).Dynamic(1)
+				w.Label(tr(c.name), "LB")
+				header = true
+			}
+			e.view(ctx, w)
+		}
+		found = found || header
+	}
+	if !found {
+		w.Row(15).Dynamic(1)
+		w.Label(tr("No settings match your search."), "LC")
+	}
+}
+
+func filterTargetsView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(2)
+	if focusable(ctx, w, w.CheckboxText(tr("Filter Microphone"), &ctx.config.FilterInput)) {
+		ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
+		go writeConfig(ctx.config)
+		go (func() { ctx.noiseSupressorState, _ = supressorState(ctx) })()
+	}
+
+	if focusable(ctx, w, w.CheckboxText(tr("Filter Headphones"), &ctx.config.FilterOutput)) {
+		ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
+		go writeConfig(ctx.config)
+		go (func() { ctx.noiseSupressorState, _ = supressorState(ctx) })()
+	}
+}
+
+func thresholdView(ctx *ntcontext, w *nucular.Window) {
+	if !currentEngine(ctx).rnnoise {
+		return
+	}
+	if inp, ok := inputSelection(ctx); ok && ctx.noiseSupressorState == loaded {
+		w.Row(25).Ratio(0.7, 0.3)
+		w.Label(tr("Let NoiseTorch listen to your surroundings to pick a threshold"), "LC")
+		if focusable(ctx, w, w.ButtonText(tr("Calibrate"))) {
+			go uiCalibrate(ctx, inp)
+		}
+	}
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Learn from usage and suggest a better threshold"), &ctx.config.LearnThreshold)) {
+		go writeConfig(ctx.config)
+	}
+	learningHintView(ctx, w)
+}
+
+// monitorSourcesView toggles listing monitor sources as microphones, the sound played to a sink.
+// Filtering one cleans up desktop audio for capturing it, e.g. a call being recorded.
+func monitorSourcesView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Display Monitor Sources"), &ctx.config.DisplayMonitorSources)) {
+		ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
+		go writeConfig(ctx.config)
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Lists what your speakers and headphones play as microphones, to filter desktop audio you capture."))
+	}
+}
+
+func enableOnStartView(ctx *ntcontext, w *nucular.Window) {
+	dev, ok := primarySelection(ctx)
+	if !ok {
+		return
+	}
+	p, _ := profileFor(ctx, &dev)
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Load automatically on start when this device is selected"), &p.EnableOnStart)) {
+		enable := p.EnableOnStart
+		updateProfile(ctx, &dev, func(p *deviceProfile) { p.EnableOnStart = enable })
+		go writeConfig(ctx.config)
+	}
+}
+
+func updatesView(ctx *ntcontext, w *nucular.Window) {
+	if sandboxed != "" {
+		w.Row(30).Dynamic(1)
+		w.LabelWrap(trf("NoiseTorch-ng runs in %s, which installs its updates. The built-in updater is turned off.", sandboxed))
+		return
+	}
+	if !buildinfo.Enabled().Updates {
+		w.Row(15).Dynamic(1)
+		w.Label(tr("Updates are provided by your distribution."), "LC")
+		return
+	}
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Check for updates on start"), &ctx.config.EnableUpdates)) {
+		go writeConfig(ctx.config)
+	}
+	names := make([]string, len(updateChannels))
+	selected := 0
+	for i, c := range updateChannels {
+		names[i] = tr(c.name)
+		if c.id == ctx.config.UpdateChannel {
+			selected = i
+		}
+	}
+	w.Row(25).Ratio(0.5, 0.5)
+	w.Label(tr("Update channel"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Beta gets new versions before they're released as stable, and their bugs too."))
+	}
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected {
+		ctx.config.UpdateChannel = updateChannels[sel].id
+		go writeConfig(ctx.config)
+		ctx.update.available = false
+		ctx.update.serverVersion = ""
+		go updateCheck(ctx)
+	}
+	w.Row(15).Dynamic(1)
+	if ctx.update.available {
+		w.LabelColored(trf("Version %s is available.", ctx.update.serverVersion), "LC", green)
+	} else if ctx.update.serverVersion != "" {
+		w.Label(trf("You're running the latest version, %s.", buildinfo.Version), "LC")
+	} else {
+		w.Label(trf("Running version %s.", buildinfo.Version), "LC")
+	}
+	rollbackView(ctx, w)
+}
+
+func latencyOffsetView(ctx *ntcontext, w *nucular.Window) {
+	inp, ok := inputSelection(ctx)
+	if !ok || ctx.serverInfo.servertype != servertype_pipewire {
+		return
+	}
+	offset := latencyOffset(ctx, &inp)
+	w.Row(25).Ratio(0.5, 0.4, 0.1)
+	w.Label(tr("Reported Latency Offset"), "LC")
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Extra latency the filtered microphone reports for this device, so apps like OBS can keep audio in sync."))
+	}
+	if focusableSlider(ctx, w, w.SliderInt(0, &offset, maxLatencyOffset, 5), &offset, 0, maxLatencyOffset, 5) {
+		setLatencyOffset(ctx, &inp, offset)
+		go writeConfig(ctx.config)
+		if ctx.noiseSupressorState == loaded {
+			go func() {
+				if err := applyLatencyOffset(ctx, &inp); err != nil {
+					errorf("Couldn't apply latency offset: %v\n", err)
+				}
+			}()
+		}
+	}
+	w.Label(fmt.Sprintf("%dms", offset), "RC")
+}
+
+func autostartView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Start on login (loads the filter for the last used microphone)"), &ctx.config.Autostart)) {
+		go func() {
+			if err := syncAutostart(ctx); err != nil {
+				errorf("Couldn't set up start on login: %v\n", err)
+				ctx.config.Autostart = !ctx.config.Autostart
+				ctx.views.Push(makeErrorView(ctx, err.Error()))
+			}
+			writeConfig(ctx.config)
+		}()
+	}
+}
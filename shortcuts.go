@@ -0,0 +1,85 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"github.com/aarzilli/nucular"
+)
+
+// shortcutsui holds the editors of the shortcuts page while it is open.
+type shortcutsui struct {
+	editors map[string]*nucular.TextEditor
+	err     string
+}
+
+func openShortcuts(ctx *ntcontext) {
+	ctx.shortcuts = shortcutsui{editors: make(map[string]*nucular.TextEditor)}
+	for _, a := range hotkeyActions {
+		ed := &nucular.TextEditor{}
+		ed.Flags = nucular.EditField | nucular.EditSigEnter
+		ed.Buffer = []rune(ctx.config.Hotkeys[a.id])
+		ctx.shortcuts.editors[a.id] = ed
+	}
+	ctx.views.Push(shortcutsView)
+}
+
+func shortcutsView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Shortcuts"), "CB")
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Global keyboard shortcuts, e.g. ctrl+alt+m or super+f9. Leave empty to disable."), "LC")
+	if ctx.hotkeys == nil {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(tr("Global shortcuts aren't available, they require an X11 session."), "LC", orange)
+	}
+
+	for _, a := range hotkeyActions {
+		w.Row(25).Ratio(0.5, 0.5)
+		w.Label(tr(a.name), "LC")
+		focusableEdit(ctx, w, ctx.shortcuts.editors[a.id], ctx.shortcuts.editors[a.id].Edit(w))
+		if err := ctx.hotkeys.err(a.id); err != "" {
+			w.Row(15).Dynamic(1)
+			w.LabelColored(err, "LC", orange)
+		}
+	}
+
+	if ctx.shortcuts.err != "" {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(ctx.shortcuts.err, "LC", red)
+	}
+
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Cancel"))) {
+		ctx.views.Pop()
+		return
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Save"))) {
+		keys := make(map[string]string)
+		for _, a := range hotkeyActions {
+			spec := string(ctx.shortcuts.editors[a.id].Buffer)
+			if spec == "" {
+				keys[a.id] = ""
+				continue
+			}
+			if _, _, err := parseHotkey(spec); err != nil {
+				ctx.shortcuts.err = err.Error()
+				return
+			}
+			keys[a.id] = spec
+		}
+		ctx.config.Hotkeys = keys
+		go writeConfig(ctx.config)
+		ctx.shortcuts.err = ""
+		if ctx.hotkeys != nil {
+			ctx.hotkeys.bind(ctx)
+			for _, a := range hotkeyActions {
+				if ctx.hotkeys.err(a.id) != "" {
+					// stay open, so the user sees which one failed
+					return
+				}
+			}
+		}
+		ctx.views.Pop()
+	}
+}
@@ -0,0 +1,137 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// Killed with SIGINT or SIGTERM, we still wait for the hooks and don't leave half a chain behind:
+// the daemon unloads its filters, a load from the command line that gets interrupted unloads what it
+// loaded so far, and the GUI closes its window as if the user did. SIGHUP makes the daemon read the
+// config again and load the filters with it.
+
+var exitSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// signalExitCode is what shells report for a process killed by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// cliLoad tracks a load from the command line, so a signal in the middle of it is handled once the
+// modules it loads are all there to unload.
+var cliLoad struct {
+	sync.Mutex
+	loading     bool
+	interrupted os.Signal
+}
+
+// catchCLISignals makes one-shot commands clean up when they're killed, until stop is called.
+func catchCLISignals() (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, exitSignals...)
+	done := make(chan struct{})
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-sigs:
+		case <-done:
+			return
+		}
+		cliLoad.Lock()
+		if cliLoad.loading {
+			cliLoad.interrupted = sig
+			cliLoad.Unlock()
+			warnf("Received %s while loading, unloading again once done\n", sig)
+			return
+		}
+		cliLoad.Unlock()
+		infof("Received %s, exiting\n", sig)
+		cleanupExit(signalExitCode(sig))
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// loadSupressorCLI is loadSupressor for one-shot commands, it unloads and exits if the command was
+// killed meanwhile.
+func loadSupressorCLI(ctx *ntcontext, inp, out *device) error {
+	cliLoad.Lock()
+	cliLoad.loading = true
+	cliLoad.Unlock()
+
+	err := loadSupressor(ctx, inp, out)
+
+	cliLoad.Lock()
+	cliLoad.loading = false
+	sig := cliLoad.interrupted
+	cliLoad.Unlock()
+	if sig != nil {
+		fmt.Fprintf(os.Stderr, "Interrupted, unloading again\n")
+		restoreRouting(ctx, nil)
+		if err := turnOffSupressor(ctx); err != nil {
+			errorf("Couldn't unload the interrupted filter: %v\n", err)
+		}
+		cleanupExit(signalExitCode(sig))
+	}
+	return err
+}
+
+// closeOnSignal closes the window on SIGINT and SIGTERM, so the GUI exits like it was closed.
+func closeOnSignal(ctx *ntcontext) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, exitSignals...)
+	sig := <-sigs
+	infof("Received %s, closing the window\n", sig)
+	(*ctx.masterWindow).Close()
+}
+
+// rereadConfig reads the config file again. Unlike readConfig it doesn't exit on errors, a daemon
+// keeps running with the config it has.
+func rereadConfig() (*config, error) {
+	f := filepath.Join(configDir(), configFile)
+	buf, err := os.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	conf, problems, err := parseConfig(buf)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		warnf("Ignoring invalid setting in %s: %v\n", f, p)
+	}
+	return &conf, nil
+}
+
+// reloadDaemonConfig handles SIGHUP: the new config takes effect by unloading the filters, the daemon
+// loads them again on its next round.
+func reloadDaemonConfig(ctx *ntcontext, opt CLIOpts) {
+	conf, err := rereadConfig()
+	if err != nil {
+		errorf("Couldn't read the config again, keeping the current one: %v\n", err)
+		return
+	}
+	*ctx.config = *conf
+	applyDaemonOpts(ctx, opt)
+	infof("Config reloaded\n")
+	if !connected(ctx) {
+		return
+	}
+	if state, _ := supressorState(ctx); state != unloaded {
+		if err := unloadSupressor(ctx); err != nil {
+			errorf("Couldn't unload the filter to apply the config: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"math/cmplx"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The spectrogram shows the raw and the filtered microphone above each other, so users can see
+// what the filter removes, e.g. the hum of a fan. Like the level meters it records through parec
+// and computes the spectrum itself.
+
+const (
+	spectrumRate    = 16000
+	spectrumSize    = 512 // samples per FFT, 32ms
+	spectrumHop     = 256 // samples per column, 16ms
+	spectrumHistory = 2048
+	spectrumFloor   = -100.0 // dB shown as black
+	spectrumCeiling = -20.0  // dB shown as the brightest color
+	spectrumMinFreq = 50.0   // Hz at the bottom, the frequency axis is logarithmic
+	spectrumFPS     = 15
+)
+
+// spectrumColors maps the level, from spectrumFloor to spectrumCeiling, to a color.
+var spectrumColors = []color.RGBA{
+	{0x00, 0x00, 0x00, 0xff},
+	{0x32, 0x0a, 0x5e, 0xff},
+	{0x93, 0x26, 0x67, 0xff},
+	{0xdd, 0x51, 0x3a, 0xff},
+	{0xfc, 0xa5, 0x0a, 0xff},
+	{0xfc, 0xff, 0xa4, 0xff},
+}
+
+type spectrogram struct {
+	cmd     *exec.Cmd
+	mu      sync.Mutex
+	columns [][]float64 // dB per frequency bin, newest last
+}
+
+func startSpectrogram(source string) (*spectrogram, error) {
+	cmd := audioCommand("parec", "--raw", "--format=float32le", "--channels=1",
+		fmt.Sprintf("--rate=%d", spectrumRate), "--latency-msec=20", "--client-name=NoiseTorch spectrogram", "-d", source)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("couldn't start parec: %w", err)
+	}
+
+	s := &spectrogram{cmd: cmd}
+	go s.run(bufio.NewReader(stdout))
+	return s, nil
+}
+
+func (s *spectrogram) run(r io.Reader) {
+	buf := make([]byte, spectrumHop*4)
+	samples := make([]float64, spectrumSize)
+	window := make([]float64, spectrumSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(spectrumSize-1)) // Hann
+	}
+	frame := make([]complex128, spectrumSize)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			s.cmd.Wait()
+			return
+		}
+		copy(samples, samples[spectrumHop:])
+		for i := 0; i < spectrumHop; i++ {
+			samples[spectrumSize-spectrumHop+i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:])))
+		}
+		for i := range frame {
+			frame[i] = complex(samples[i]*window[i], 0)
+		}
+		fft(frame)
+		column := make([]float64, spectrumSize/2)
+		for i := range column {
+			// the Hann window halves the amplitude
+			mag := cmplx.Abs(frame[i]) * 4 / spectrumSize
+			column[i] = 20 * math.Log10(mag+1e-12)
+		}
+
+		s.mu.Lock()
+		s.columns = append(s.columns, column)
+		if len(s.columns) > spectrumHistory {
+			s.columns = s.columns[len(s.columns)-spectrumHistory:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+// fft is an in-place radix-2 FFT, len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+}
+
+func spectrumColor(db float64) color.RGBA {
+	v := (db - spectrumFloor) / (spectrumCeiling - spectrumFloor)
+	if v <= 0 {
+		return spectrumColors[0]
+	}
+	if v >= 1 {
+		return spectrumColors[len(spectrumColors)-1]
+	}
+	pos := v * float64(len(spectrumColors)-1)
+	i := int(pos)
+	f := pos - float64(i)
+	a, b := spectrumColors[i], spectrumColors[i+1]
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*f) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
+
+// render draws the newest columns into an image of the given size, one column per pixel and the
+// newest on the right.
+func (s *spectrogram) render(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if s == nil || width <= 0 || height <= 0 {
+		return img
+	}
+	// the bin each row shows, high frequencies on top
+	bins := make([]int, height)
+	binWidth := float64(spectrumRate) / spectrumSize
+	maxFreq := float64(spectrumRate) / 2
+	for y := range bins {
+		pos := 1.0
+		if height > 1 {
+			pos = 1 - float64(y)/float64(height-1)
+		}
+		freq := spectrumMinFreq * math.Pow(maxFreq/spectrumMinFreq, pos)
+		bins[y] = int(math.Min(freq/binWidth, spectrumSize/2-1))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset := width - len(s.columns)
+	for x := 0; x < width; x++ {
+		i := x - offset
+		if i < 0 {
+			for y := 0; y < height; y++ {
+				img.SetRGBA(x, y, spectrumColors[0])
+			}
+			continue
+		}
+		for y, bin := range bins {
+			img.SetRGBA(x, y, spectrumColor(s.columns[i][bin]))
+		}
+	}
+	return img
+}
+
+func (s *spectrogram) Stop() {
+	if s == nil || s.cmd.Process == nil {
+		return
+	}
+	s.cmd.Process.Kill()
+}
+
+type spectrogramui struct {
+	raw      *spectrogram
+	filtered *spectrogram
+	stop     chan struct{}
+	open     bool
+	err      string
+}
+
+func openSpectrogram(ctx *ntcontext) {
+	ctx.spectrogram = spectrogramui{open: true}
+	ctx.views.Push(spectrogramView)
+	go startSpectrograms(ctx)
+}
+
+func startSpectrograms(ctx *ntcontext) {
+	sp := &ctx.spectrogram
+	inp, ok := inputSelection(ctx)
+	if !ok {
+		return
+	}
+	virt, ok := findVirtualSource(ctx, &inp)
+	if !ok {
+		sp.err = tr("The filtered microphone is not loaded.")
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	raw, err := startSpectrogram(inp.ID)
+	if err != nil {
+		sp.err = err.Error()
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	filtered, err := startSpectrogram(virt.Name)
+	if err != nil {
+		raw.Stop()
+		sp.err = err.Error()
+		(*ctx.masterWindow).Changed()
+		return
+	}
+
+	if !sp.open { // closed while we were starting
+		raw.Stop()
+		filtered.Stop()
+		return
+	}
+	stop := make(chan struct{})
+	sp.raw, sp.filtered, sp.stop = raw, filtered, stop
+	go func() {
+		t := time.NewTicker(time.Second / spectrumFPS)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				(*ctx.masterWindow).Changed()
+			}
+		}
+	}()
+}
+
+func stopSpectrograms(ctx *ntcontext) {
+	sp := &ctx.spectrogram
+	if sp.stop != nil {
+		close(sp.stop)
+	}
+	sp.raw.Stop()
+	sp.filtered.Stop()
+	sp.raw, sp.filtered, sp.stop = nil, nil, nil
+	sp.open = false
+}
+
+func spectrogramView(ctx *ntcontext, w *nucular.Window) {
+	sp := &ctx.spectrogram
+	w.Row(15).Dynamic(1)
+	w.Label(tr("Spectrogram"), "CB")
+
+	if sp.err != "" {
+		w.Row(15).Dynamic(1)
+		w.LabelColored(sp.err, "LC", red)
+	}
+
+	for _, s := range []struct {
+		name string
+		s    *spectrogram
+	}{
+		{"Raw", sp.raw},
+		{"Filtered", sp.filtered},
+	} {
+		w.Row(15).Dynamic(1)
+		w.Label(tr(s.name), "LC")
+		w.Row(100).Dynamic(1)
+		bounds := w.WidgetBounds()
+		w.Image(s.s.render(bounds.W, bounds.H))
+	}
+
+	w.Row(15).Dynamic(1)
+	w.Label(trf("Newest on the right, %d Hz to %d Hz from bottom to top.", int(spectrumMinFreq), spectrumRate/2), "LC")
+
+	w.Row(25).Dynamic(1)
+	if focusable(ctx, w, w.ButtonText(tr("Back"))) || ctx.noiseSupressorState != loaded {
+		stopSpectrograms(ctx)
+		ctx.views.Pop()
+	}
+}
@@ -0,0 +1,225 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/aarzilli/nucular"
+	"github.com/noisetorch/pulseaudio"
+)
+
+// The filter is meant to outlive us, so loaded chains aren't stale just because no NoiseTorch is
+// running. What is stale after a crash: modules that only make sense while we run (the hear myself
+// loopback, the self-test sink), which carry our pid, and chains we crashed in the middle of
+// loading or unloading, which are missing some of their modules.
+
+const tagPid = "noisetorch.pid"
+
+// transientTags returns the tags of modules that go away with this process.
+func transientTags(ctx *ntcontext) string {
+	return fmt.Sprintf("%s %s=%d", chainTags(ctx), tagPid, os.Getpid())
+}
+
+var (
+	argPid = regexp.MustCompile(tagPid + `=(\d+)`)
+	// the slug of the device, see deviceSlug, or the name and part of the hash of older versions
+	argMicChain = regexp.MustCompile(`nui_mic_(?:denoised|raw|gate|remap|awake)_([0-9a-f]{8}|[a-z0-9_]+?_[0-9a-f]{4})\b`)
+	argChainID  = regexp.MustCompile(tagID + `=([0-9a-f-]+)`)
+)
+
+type staleModule struct {
+	module pulseaudio.Module
+	reason string
+}
+
+func processAlive(pid int) bool {
+	// pids get reused, but a false positive only means we leave the module alone
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// findStaleModules returns the modules left behind by instances that didn't exit cleanly.
+func findStaleModules(ctx *ntcontext) ([]staleModule, error) {
+	mods, err := ctx.paClient.ModuleList()
+	if err != nil {
+		return nil, err
+	}
+	var stale []staleModule
+	claimed := make(map[uint32]bool)
+	for _, m := range mods {
+		if match := argPid.FindStringSubmatch(m.Argument); match != nil {
+			if pid, _ := strconv.Atoi(match[1]); pid != os.Getpid() && !processAlive(pid) {
+				stale = append(stale, staleModule{m, fmt.Sprintf("belonged to NoiseTorch-ng process %d, which is gone", pid)})
+				claimed[m.Index] = true
+			}
+		}
+	}
+	if ctx.serverInfo.servertype != servertype_pulse {
+		// on PipeWire every chain is a single module, there's nothing to be half loaded
+		return stale, nil
+	}
+
+	// which parts of each pulseaudio input chain are there
+	type chain struct {
+		mods                           []pulseaudio.Module
+		denoised, raw, loopback, remap bool
+	}
+	chains := make(map[string]*chain)
+	var slugs []string
+	// the headphones chains by their id, "" for those of older versions
+	outputs := make(map[string][]pulseaudio.Module)
+	var ids []string
+	for _, m := range mods {
+		if claimed[m.Index] || argPid.MatchString(m.Argument) {
+			// modules of a running instance, e.g. the in-process filter, are complete or loading
+			continue
+		}
+		if strings.Contains(m.Argument, "nui_out_") {
+			var id string
+			if match := argChainID.FindStringSubmatch(m.Argument); match != nil {
+				id = match[1]
+			}
+			if _, ok := outputs[id]; !ok {
+				ids = append(ids, id)
+			}
+			outputs[id] = append(outputs[id], m)
+			continue
+		}
+		match := argMicChain.FindStringSubmatch(m.Argument)
+		if match == nil {
+			continue
+		}
+		c, ok := chains[match[1]]
+		if !ok {
+			c = &chain{}
+			chains[match[1]] = c
+			slugs = append(slugs, match[1])
+		}
+		c.mods = append(c.mods, m)
+		switch {
+		case m.Name == "module-null-sink" && strings.Contains(m.Argument, "sink_name=nui_mic_denoised_"):
+			c.denoised = true
+		case m.Name == "module-ladspa-sink" && strings.Contains(m.Argument, "sink_name=nui_mic_raw_"):
+			c.raw = true
+		case m.Name == "module-loopback" && strings.Contains(m.Argument, "sink=nui_mic_raw_"):
+			c.loopback = true
+		case m.Name == "module-remap-source":
+			c.remap = true
+		}
+	}
+	for _, slug := range slugs {
+		c := chains[slug]
+		if c.denoised && c.raw && c.loopback && c.remap {
+			continue
+		}
+		for _, m := range c.mods {
+			stale = append(stale, staleModule{m, "part of an incomplete microphone filter"})
+		}
+	}
+	for _, id := range ids {
+		// another NoiseTorch-ng's chain may be loading right now
+		if id != "" && tagID+"="+id != chainTag(ctx) {
+			continue
+		}
+		// two null sinks, the filter and two loopbacks
+		if output := outputs[id]; len(output) < 5 {
+			for _, m := range output {
+				stale = append(stale, staleModule{m, "part of an incomplete headphones filter"})
+			}
+		}
+	}
+	return stale, nil
+}
+
+func unloadStaleModules(ctx *ntcontext, stale []staleModule) error {
+	if ctx.serverInfo.servertype == servertype_pulse {
+		restore, err := liftPulseRlimit()
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+	for _, s := range stale {
+		infof("Unloading stale %s at id [%d]: %s\n", s.module.Name, s.module.Index, s.reason)
+		if err := ctx.paClient.UnloadModule(s.module.Index); err != nil {
+			return fmt.Errorf("couldn't unload %s (%d): %w", s.module.Name, s.module.Index, err)
+		}
+	}
+	return nil
+}
+
+// offerStaleCleanup asks whether to remove stale modules, it runs once after the first connect.
+func offerStaleCleanup(ctx *ntcontext) {
+	stale, err := findStaleModules(ctx)
+	if err != nil {
+		errorf("Couldn't look for stale modules: %v\n", err)
+		return
+	}
+	if len(stale) == 0 {
+		return
+	}
+	warnf("Found %d modules left behind by a previous instance\n", len(stale))
+	ctx.stale = stale
+	ctx.views.Push(staleView)
+	(*ctx.masterWindow).Changed()
+}
+
+func staleView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(30).Dynamic(1)
+	w.LabelWrap(tr("A previous NoiseTorch-ng didn't exit cleanly and left these modules behind in the audio server:"))
+	for _, s := range ctx.stale {
+		w.Row(15).Dynamic(1)
+		w.Label(fmt.Sprintf("%s (%d): %s", s.module.Name, s.module.Index, s.reason), "LC")
+	}
+	w.Row(25).Dynamic(2)
+	if focusable(ctx, w, w.ButtonText(tr("Remove them"))) {
+		stale := ctx.stale
+		ctx.stale = nil
+		ctx.views.Pop()
+		go func() {
+			if err := unloadStaleModules(ctx, stale); err != nil {
+				errorf("%v\n", err)
+				ctx.views.Push(makeErrorView(ctx, err.Error()))
+			}
+			(*ctx.masterWindow).Changed()
+		}()
+	}
+	if focusable(ctx, w, w.ButtonText(tr("Keep them"))) {
+		ctx.stale = nil
+		ctx.views.Pop()
+	}
+}
+
+// cleanupCLI implements `noisetorch cleanup`.
+func cleanupCLI(ctx *ntcontext, opt CLIOpts) {
+	stale, err := findStaleModules(ctx)
+	if err != nil {
+		opt.fail("Couldn't look for stale modules: %v\n", err)
+	}
+	if len(stale) == 0 {
+		fmt.Println("No stale modules found.")
+		cleanupExit(0)
+	}
+	for _, s := range stale {
+		fmt.Printf("%s (%d): %s\n", s.module.Name, s.module.Index, s.reason)
+	}
+	if !opt.yes {
+		fmt.Print("Remove them? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			cleanupExit(0)
+		}
+	}
+	if err := unloadStaleModules(ctx, stale); err != nil {
+		opt.fail("%v\n", err)
+	}
+	cleanupExit(0)
+}
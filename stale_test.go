@@ -0,0 +1,77 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestFindStaleModules(t *testing.T) {
+	tests := []struct {
+		name string
+		// change does something to the loaded chains
+		change func(t *testing.T, ctx *ntcontext, s *fakeServer)
+		stale  int
+	}{
+		{
+			name:   "complete chains",
+			change: func(t *testing.T, ctx *ntcontext, s *fakeServer) {},
+			stale:  0,
+		},
+		{
+			name: "headphones filter of another instance",
+			change: func(t *testing.T, ctx *ntcontext, s *fakeServer) {
+				if err := unloadSupressor(ctx); err != nil {
+					t.Fatalf("unloadSupressor: %v", err)
+				}
+				if _, err := s.LoadModule("module-null-sink", `sink_name=nui_out_out_sink_other sink_properties="noisetorch.id=00000000-0000-4000-8000-000000000000"`); err != nil {
+					t.Fatalf("LoadModule: %v", err)
+				}
+			},
+			stale: 0,
+		},
+		{
+			name: "headphones filter without its ladspa sink",
+			change: func(t *testing.T, ctx *ntcontext, s *fakeServer) {
+				unloadTestModule(t, ctx, "module-ladspa-sink", "sink_name=nui_out_ladspa ")
+			},
+			// the loopback into it goes away with it
+			stale: 3,
+		},
+		{
+			name: "microphone filter without its remap source",
+			change: func(t *testing.T, ctx *ntcontext, s *fakeServer) {
+				unloadTestModule(t, ctx, "module-remap-source", "source_name=nui_mic_remap_")
+			},
+			stale: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeServer(false)
+			ctx := newTestContext(t, s)
+			testLoad(t, ctx)
+			tt.change(t, ctx, s)
+			stale, err := findStaleModules(ctx)
+			if err != nil {
+				t.Fatalf("findStaleModules: %v", err)
+			}
+			if len(stale) != tt.stale {
+				t.Errorf("%d stale modules, want %d: %+v", len(stale), tt.stale, stale)
+			}
+		})
+	}
+}
+
+func unloadTestModule(t *testing.T, ctx *ntcontext, name, match string) {
+	t.Helper()
+	m, found, err := findChainModule(ctx, name, match)
+	if err != nil || !found {
+		t.Fatalf("%s %s not found: %v", name, match, err)
+	}
+	if err := ctx.paClient.UnloadModule(m.Index); err != nil {
+		t.Fatalf("UnloadModule: %v", err)
+	}
+}
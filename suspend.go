@@ -0,0 +1,63 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Audio servers suspend devices nobody has used for a few seconds. Some setups route the filtered
+// microphone through further modules that don't cope with it going away, so KeepAwake keeps it running.
+// PipeWire takes that as a node property. Pulseaudio has no per-device setting, there we keep a
+// stream open on the denoised sink, which is what its suspend-on-idle module looks at.
+
+// keepAwakeProps returns the extra source properties of the filtered microphone on PipeWire.
+func keepAwakeProps(ctx *ntcontext) string {
+	if !ctx.config.KeepAwake {
+		return ""
+	}
+	return " node.pause-on-idle=false session.suspend-timeout-seconds=0"
+}
+
+// pipeWireKeepAwakeProps is keepAwakeProps for the native filter-chain config.
+func pipeWireKeepAwakeProps(ctx *ntcontext) string {
+	if !ctx.config.KeepAwake {
+		return ""
+	}
+	return `
+                node.pause-on-idle = false
+                session.suspend-timeout-seconds = 0`
+}
+
+// loadPulseKeepAwake records from the denoised sink into a null sink of its own. The stream is
+// mono at the filter rate, so the server doesn't need to convert anything for it.
+func loadPulseKeepAwake(ctx *ntcontext, inp *device) error {
+	return loadChainModules(ctx, pulseKeepAwakeModules(ctx, inp))
+}
+
+func pulseKeepAwakeModules(ctx *ntcontext, inp *device) []chainModule {
+	names := inputChainFor(inp)
+	return []chainModule{
+		{name: "module-null-sink", what: "keep awake null sink",
+			args: fmt.Sprintf(`sink_name=%s rate=%d channels=1 sink_properties="device.description='NoiseTorch keep awake' %s"`,
+				names.awake, filterRate, chainTags(ctx))},
+		{name: "module-loopback", what: "keep awake loopback",
+			args: fmt.Sprintf(`source=%s.monitor sink=%s channels=1 rate=%d latency_msec=%d source_dont_move=true sink_dont_move=true `+
+				`sink_input_properties="%s" source_output_properties="%s"`,
+				names.denoised, names.awake, filterRate, maxBufferLatency, chainTags(ctx), chainTags(ctx))},
+	}
+}
+
+func keepAwakeView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(15).Dynamic(1)
+	if focusable(ctx, w, w.CheckboxText(tr("Keep the filtered microphone awake when unused"), &ctx.config.KeepAwake)) {
+		go writeConfig(ctx.config)
+		ctx.reloadRequired = true
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("Stops the audio server from suspending it, for setups that break when it does."))
+	}
+}
@@ -0,0 +1,180 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"noisetorch/buildinfo"
+
+	"github.com/aarzilli/nucular"
+)
+
+// Distributions may package our RNNoise plugin (c/ladspa) on its own and ship NoiseTorch-ng without
+// the bundled copy, see plugin_system.go. With SystemPlugin we use the installed one if it exports
+// the ABI version we expect, and fall back to the bundled one otherwise. It's found at
+// SystemPluginPath, the path the build was configured with, or by name in the LADSPA directories.
+
+// pluginABI is the nt_ladspa_abi of the plugin we were built against, see module.c.
+const pluginABI = 1
+
+// systemPluginNames are the names the plugin may be installed under.
+var systemPluginNames = []string{"noisetorch_ladspa.so", "rnnoise_ladspa.so"}
+
+// pluginABIOf reads the ABI version a plugin exports, without loading it.
+func pluginABIOf(path string) (uint32, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a valid library: %w", path, err)
+	}
+	defer f.Close()
+	if m, ok := elfMachines[runtime.GOARCH]; ok && f.Machine != m {
+		return 0, fmt.Errorf("%s is built for %s, but this system is %s", path, f.Machine, runtime.GOARCH)
+	}
+	syms, err := f.DynamicSymbols()
+	if err != nil {
+		return 0, fmt.Errorf("%s has no symbols: %w", path, err)
+	}
+	for _, s := range syms {
+		if s.Name != "nt_ladspa_abi" {
+			continue
+		}
+		if s.Section == elf.SHN_UNDEF || int(s.Section) >= len(f.Sections) {
+			break
+		}
+		sec := f.Sections[s.Section]
+		data, err := sec.Data()
+		if err != nil {
+			return 0, err
+		}
+		off := s.Value - sec.Addr
+		if s.Value < sec.Addr || off+4 > uint64(len(data)) {
+			break
+		}
+		return f.ByteOrder.Uint32(data[off:]), nil
+	}
+	return 0, fmt.Errorf("%s isn't NoiseTorch-ng's RNNoise plugin", path)
+}
+
+// systemPluginCandidates returns the paths to look for the plugin at, in order.
+func systemPluginCandidates(c *config) []string {
+	if c.SystemPluginPath != "" {
+		return []string{c.SystemPluginPath}
+	}
+	var paths []string
+	if buildinfo.SystemPlugin != "" {
+		paths = append(paths, buildinfo.SystemPlugin)
+	}
+	for _, dir := range ladspaDirs() {
+		for _, name := range systemPluginNames {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// findSystemPlugin returns the first installed plugin with our ABI.
+func findSystemPlugin(c *config) (string, error) {
+	var problems []string
+	for _, path := range systemPluginCandidates(c) {
+		if _, err := os.Stat(path); err != nil {
+			if c.SystemPluginPath != "" {
+				problems = append(problems, err.Error())
+			}
+			continue
+		}
+		abi, err := pluginABIOf(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if abi != pluginABI {
+			problems = append(problems, fmt.Sprintf("%s has ABI version %d, this version of NoiseTorch-ng needs %d", path, abi, pluginABI))
+			continue
+		}
+		return path, nil
+	}
+	if len(problems) == 0 {
+		return "", fmt.Errorf("no RNNoise plugin installed, looked for %s in %s", strings.Join(systemPluginNames, " and "), strings.Join(ladspaDirs(), ", "))
+	}
+	return "", fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// rnnoiseLib returns the RNNoise plugin, the system's if it's preferred and compatible.
+func rnnoiseLib(c *config) ([]byte, error) {
+	if !c.SystemPlugin && len(libRNNoise) > 0 {
+		return libRNNoise, nil
+	}
+	path, err := findSystemPlugin(c)
+	if err == nil {
+		debugf("Using the system's RNNoise plugin %s\n", path)
+		return os.ReadFile(path)
+	}
+	if len(libRNNoise) == 0 {
+		return nil, fmt.Errorf("this build doesn't bundle the RNNoise plugin for %s and %v", runtime.GOARCH, err)
+	}
+	warnf("Using the bundled RNNoise plugin: %v\n", err)
+	return libRNNoise, nil
+}
+
+// systempluginui caches what the settings show, looking for the plugin reads it.
+type systempluginui struct {
+	known bool
+	path  string
+	err   string
+}
+
+func refreshSystemPlugin(ctx *ntcontext) {
+	s := &ctx.systemPlugin
+	s.known = true
+	s.path, s.err = "", ""
+	path, err := findSystemPlugin(ctx.config)
+	if err != nil {
+		s.err = err.Error()
+		return
+	}
+	s.path = path
+}
+
+func systemPluginView(ctx *ntcontext, w *nucular.Window) {
+	s := &ctx.systemPlugin
+	if !s.known {
+		refreshSystemPlugin(ctx)
+	}
+	if len(libRNNoise) == 0 {
+		w.Row(15).Dynamic(1)
+		w.Label(tr("This build uses the RNNoise plugin installed on the system."), "LC")
+	} else {
+		w.Row(15).Dynamic(1)
+		if focusable(ctx, w, w.CheckboxText(tr("Use the RNNoise plugin installed on the system"), &ctx.config.SystemPlugin)) {
+			if err := switchEngine(ctx, ctx.config.Engine); err != nil {
+				errorf("Couldn't switch the plugin: %v\n", err)
+				ctx.views.Push(makeErrorView(ctx, err.Error()))
+			}
+			s.known = false
+			ctx.reloadRequired = true
+			go writeConfig(ctx.config)
+		}
+		if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+			w.Tooltip(tr("Instead of the bundled copy, if your distribution packages it in a compatible version."))
+		}
+	}
+	if !ctx.config.SystemPlugin && len(libRNNoise) > 0 {
+		return
+	}
+	w.Row(30).Dynamic(1)
+	switch {
+	case s.path != "":
+		w.LabelWrap(trf("Using %s", s.path))
+	case len(libRNNoise) == 0:
+		w.LabelWrapColored(s.err, red)
+	default:
+		w.LabelWrapColored(trf("Using the bundled plugin: %s", s.err), orange)
+	}
+}
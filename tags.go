@@ -0,0 +1,148 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"noisetorch/buildinfo"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/noisetorch/pulseaudio"
+)
+
+// Everything we load is tagged with these properties, so we can tell our devices and modules apart
+// from the user's without guessing from their names. The id is kept in the config directory, so
+// the chains we loaded are found again after a restart, or by the daemon.
+const (
+	tagID      = "noisetorch.id"
+	tagVersion = "noisetorch.version"
+
+	chainIDFile = "chain-id"
+)
+
+var validChainID = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// names of the devices created by versions that didn't tag them yet
+var legacyDeviceNames = map[string]bool{
+	"nui_mic_denoised_out": true,
+	"nui_mic_raw_in":       true,
+	"nui_mic_remap":        true,
+	"nui_out_out_sink":     true,
+	"nui_out_in_sink":      true,
+	"nui_out_ladspa":       true,
+}
+
+func newChainID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		log.Fatalf("Couldn't generate chain id: %v\n", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// stableChainID returns the id of our chains, it's created the first time we load one.
+func stableChainID() string {
+	path := filepath.Join(configDir(), chainIDFile)
+	if b, err := ioutil.ReadFile(path); err == nil && validChainID.MatchString(strings.TrimSpace(string(b))) {
+		return strings.TrimSpace(string(b))
+	}
+	id := newChainID()
+	if err := os.MkdirAll(configDir(), 0700); err != nil {
+		warnf("Couldn't keep the chain id, chains loaded now won't be found after a restart: %v\n", err)
+		return id
+	}
+	if err := ioutil.WriteFile(path, []byte(id+"\n"), 0644); err != nil {
+		warnf("Couldn't keep the chain id, chains loaded now won't be found after a restart: %v\n", err)
+	}
+	return id
+}
+
+// chainTag returns the tag our modules carry in their arguments.
+func chainTag(ctx *ntcontext) string {
+	if ctx.chainID == "" {
+		ctx.chainID = stableChainID()
+	}
+	return tagID + "=" + ctx.chainID
+}
+
+// chainTags returns the proplist entries identifying objects created by us, in pulseaudio module argument syntax.
+func chainTags(ctx *ntcontext) string {
+	return fmt.Sprintf("%s %s=%s", chainTag(ctx), tagVersion, buildinfo.Version)
+}
+
+func isNoiseTorchDevice(name string, proplist map[string]string) bool {
+	if _, ok := proplist[tagID]; ok {
+		return true
+	}
+	return legacyDeviceNames[name] ||
+		strings.HasPrefix(name, "Filtered Microphone for ") || name == "Filtered Headphones"
+}
+
+// ourSinkMonitor reports whether a monitor source belongs to one of our sinks, given the sink's name.
+func ourSinkMonitor(sink string) bool {
+	return strings.HasPrefix(sink, "nui_") || isNoiseTorchDevice(sink, nil)
+}
+
+// findTaggedModules returns all modules that were loaded with our tag.
+func findTaggedModules(ctx *ntcontext) ([]pulseaudio.Module, error) {
+	lst, err := ctx.paClient.ModuleList()
+	if err != nil {
+		return nil, err
+	}
+	var res []pulseaudio.Module
+	for _, m := range lst {
+		if strings.Contains(m.Argument, chainTag(ctx)) {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+func unloadTaggedModules(ctx *ntcontext) error {
+	debugf("Searching for tagged modules\n")
+	mods, err := findTaggedModules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range mods {
+		debugf("Found tagged %s at id [%d], sending unload command\n", m.Name, m.Index)
+		ctx.paClient.UnloadModule(m.Index)
+	}
+	return nil
+}
+
+// findChainModule finds the module of our chains by exactly matching the module name, and checking
+// if argMatch is a substring of its arguments. Modules tagged with another id, e.g. of another user's NoiseTorch, don't match. Only
+// if none of ours does, chains of older versions, which weren't tagged yet, are looked at.
+func findChainModule(ctx *ntcontext, name, argMatch string) (pulseaudio.Module, bool, error) {
+	lst, err := ctx.paClient.ModuleList()
+	if err != nil {
+		return pulseaudio.Module{}, false, err
+	}
+	tag := chainTag(ctx)
+	var untagged []pulseaudio.Module
+	for _, m := range lst {
+		if m.Name != name || !strings.Contains(m.Argument, argMatch) {
+			continue
+		}
+		if strings.Contains(m.Argument, tag) {
+			return m, true, nil
+		}
+		if !strings.Contains(m.Argument, tagID+"=") {
+			untagged = append(untagged, m)
+		}
+	}
+	if len(untagged) > 0 {
+		return untagged[0], true, nil
+	}
+	return pulseaudio.Module{}, false, nil
+}
@@ -0,0 +1,179 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"image/color"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aarzilli/nucular"
+	"github.com/aarzilli/nucular/style"
+)
+
+// The UI comes in nucular's dark and white themes. "system" follows the color-scheme of the
+// FreeDesktop settings portal, which GNOME, KDE and most other desktops implement. There's no D-Bus
+// library in our dependencies, gdbus from glib is installed wherever the portal is.
+
+const (
+	themeDark   = "dark"
+	themeLight  = "light"
+	themeSystem = "system"
+)
+
+var themes = []struct {
+	id, name string
+}{
+	{themeDark, "Dark"},
+	{themeLight, "Light"},
+	{themeSystem, "Follow the desktop"},
+}
+
+// palette holds the colors of status texts and warnings, which need more contrast on light backgrounds.
+type palette struct {
+	green, red, orange, lightBlue color.RGBA
+}
+
+var darkPalette = palette{
+	green:     color.RGBA{34, 187, 69, 255},
+	red:       color.RGBA{255, 70, 70, 255},
+	orange:    color.RGBA{255, 140, 0, 255},
+	lightBlue: color.RGBA{173, 216, 230, 255},
+}
+
+var lightPalette = palette{
+	green:     color.RGBA{0, 110, 30, 255},
+	red:       color.RGBA{180, 0, 0, 255},
+	orange:    color.RGBA{160, 70, 0, 255},
+	lightBlue: color.RGBA{0, 70, 130, 255},
+}
+
+// color-scheme of the portal: 0 no preference, 1 prefer dark, 2 prefer light
+const portalPreferLight = 2
+
+var systemColorScheme = -1 // not known yet
+
+// the value in GVariant text format, e.g. (<<uint32 1>>,) or (<uint32 1>,) depending on the portal
+var argColorScheme = regexp.MustCompile(`uint32 (\d+)`)
+
+func parseColorScheme(s string) (int, bool) {
+	match := argColorScheme.FindStringSubmatch(s)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	return n, err == nil
+}
+
+// readColorScheme asks the settings portal for the color-scheme of the desktop.
+func readColorScheme() int {
+	cmd := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.portal.Desktop",
+		"--object-path", "/org/freedesktop/portal/desktop",
+		"--method", "org.freedesktop.portal.Settings.Read",
+		"org.freedesktop.appearance", "color-scheme")
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		debugf("Couldn't read the color-scheme of the desktop: %v\n", err)
+		return 0
+	}
+	if n, ok := parseColorScheme(string(out)); ok {
+		return n
+	}
+	return 0
+}
+
+// lightTheme tells whether to draw the light theme with c, which may be nil before there is a config.
+func lightTheme(c *config) bool {
+	theme := themeSystem
+	if c != nil {
+		theme = c.Theme
+	}
+	switch theme {
+	case themeLight:
+		return true
+	case themeSystem:
+		if systemColorScheme < 0 {
+			systemColorScheme = readColorScheme()
+		}
+		return systemColorScheme == portalPreferLight
+	}
+	return false
+}
+
+// themedStyle returns the nucular style for c, and switches the status colors to match it.
+func themedStyle(c *config, scaling float64) *style.Style {
+	p, theme := darkPalette, style.DarkTheme
+	if lightTheme(c) {
+		p, theme = lightPalette, style.WhiteTheme
+	}
+	green, red, orange, lightBlue = p.green, p.red, p.orange, p.lightBlue
+	s := style.FromTheme(theme, scaling)
+	// the meters are progress bars, keep them readable against the window
+	s.Progress.CursorNormal = style.MakeItemColor(p.green)
+	s.Progress.CursorHover = style.MakeItemColor(p.green)
+	s.Progress.CursorActive = style.MakeItemColor(p.green)
+	return s
+}
+
+// watchColorScheme restyles the window when the desktop switches between dark and light.
+func watchColorScheme(ctx *ntcontext) {
+	cmd := exec.Command("gdbus", "monitor", "--session",
+		"--dest", "org.freedesktop.portal.Desktop",
+		"--object-path", "/org/freedesktop/portal/desktop")
+	debugf("Calling: %s\n", cmd.String())
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		debugf("Couldn't watch the color-scheme of the desktop: %v\n", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		debugf("Couldn't watch the color-scheme of the desktop: %v\n", err)
+		return
+	}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		// e.g. ...SettingChanged ('org.freedesktop.appearance', 'color-scheme', <uint32 1>)
+		line := scanner.Text()
+		if !strings.Contains(line, "SettingChanged") || !strings.Contains(line, "'color-scheme'") {
+			continue
+		}
+		n, ok := parseColorScheme(line)
+		if !ok || n == systemColorScheme {
+			continue
+		}
+		debugf("Desktop color-scheme changed to %d\n", n)
+		wnd := *ctx.masterWindow
+		wnd.Lock()
+		systemColorScheme = n
+		if ctx.config.Theme == themeSystem {
+			setUIStyle(wnd, ctx.config)
+		}
+		wnd.Unlock()
+		wnd.Changed()
+	}
+	cmd.Wait()
+}
+
+func themeView(ctx *ntcontext, w *nucular.Window) {
+	names := make([]string, len(themes))
+	selected := 0
+	for i, t := range themes {
+		names[i] = tr(t.name)
+		if t.id == ctx.config.Theme {
+			selected = i
+		}
+	}
+	w.Row(25).Ratio(0.5, 0.5)
+	w.Label(tr("Theme"), "LC")
+	if sel := focusableCombo(ctx, w, w.ComboSimple(names, selected, 25), len(names)); sel != selected {
+		ctx.config.Theme = themes[sel].id
+		go writeConfig(ctx.config)
+		setUIStyle(*ctx.masterWindow, ctx.config)
+	}
+}
@@ -0,0 +1,11 @@
+module golang.org/x/exp
+
+go 1.16
+
+require (
+	github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20211213063430-748e38ca8aec
+	golang.org/x/image v0.5.0
+	golang.org/x/mobile v0.0.0-20220104184238-4a8be17bd2e3
+	golang.org/x/sys v0.13.0
+)
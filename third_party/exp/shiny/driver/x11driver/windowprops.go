@@ -0,0 +1,80 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package x11driver
+
+// NoiseTorch: shiny can't be told the class or icon of a window, and setting them from another
+// connection after the window was mapped is too late for most window managers and taskbars.
+// _NET_WM_PID is set as well, so other processes can find the windows of a process, and the
+// position, which shiny always leaves to the window manager.
+
+import (
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// WindowInstance and WindowClass, if set, are stored as WM_CLASS of new windows before they are
+// mapped.
+var WindowInstance, WindowClass string
+
+// WindowIcons, if set, are stored as _NET_WM_ICON of new windows before they are mapped. Keep them
+// small, the property has to fit into a single request.
+var WindowIcons []image.Image
+
+// WindowPosition, if set, is where new windows are placed, the top left corner of their frame.
+var WindowPosition *image.Point
+
+func (s *screenImpl) setWindowProps(xw xproto.Window) {
+	if atomNETWMPid, err := s.internAtom("_NET_WM_PID"); err == nil {
+		pid := make([]byte, 4)
+		xgb.Put32(pid, uint32(os.Getpid()))
+		xproto.ChangeProperty(s.xc, xproto.PropModeReplace, xw, atomNETWMPid, xproto.AtomCardinal, 32, 1, pid)
+	}
+
+	if WindowClass != "" {
+		class := []byte(WindowInstance + "\x00" + WindowClass + "\x00")
+		xproto.ChangeProperty(s.xc, xproto.PropModeReplace, xw, xproto.AtomWmClass, xproto.AtomString, 8, uint32(len(class)), class)
+	}
+
+	if WindowPosition != nil {
+		x, y := WindowPosition.X, WindowPosition.Y
+		xproto.ConfigureWindow(s.xc, xw, xproto.ConfigWindowX|xproto.ConfigWindowY, []uint32{uint32(x), uint32(y)})
+		// WM_NORMAL_HINTS with USPosition, otherwise window managers place it where they like
+		hints := make([]byte, 18*4)
+		xgb.Put32(hints[0:], 1)
+		xgb.Put32(hints[4:], uint32(x))
+		xgb.Put32(hints[8:], uint32(y))
+		xproto.ChangeProperty(s.xc, xproto.PropModeReplace, xw, xproto.AtomWmNormalHints, xproto.AtomWmSizeHints, 32, 18, hints)
+	}
+
+	if len(WindowIcons) == 0 {
+		return
+	}
+	atomNETWMIcon, err := s.internAtom("_NET_WM_ICON")
+	if err != nil {
+		return
+	}
+	// width, height, then ARGB pixels, for each icon
+	var data []byte
+	for _, icon := range WindowIcons {
+		b := icon.Bounds()
+		buf := make([]byte, 8+4*b.Dx()*b.Dy())
+		xgb.Put32(buf[0:], uint32(b.Dx()))
+		xgb.Put32(buf[4:], uint32(b.Dy()))
+		i := 8
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				// _NET_WM_ICON wants non-premultiplied alpha
+				c := color.NRGBAModel.Convert(icon.At(x, y)).(color.NRGBA)
+				xgb.Put32(buf[i:], uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+				i += 4
+			}
+		}
+		data = append(data, buf...)
+	}
+	xproto.ChangeProperty(s.xc, xproto.PropModeReplace, xw, atomNETWMIcon, xproto.AtomCardinal, 32, uint32(len(data)/4), data)
+}
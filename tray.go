@@ -0,0 +1,230 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	_ "embed"
+	"strings"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// The tray icon offers what's needed during a call without the window: turning the filter on and
+// off, muting, and switching between the quick switch states and the presets. It's a StatusNotifierItem on the
+// session bus, which KDE, most panels and GNOME with the AppIndicator extension show. Without a
+// host for it nothing appears, and the window works as before.
+
+//go:embed assets/icon/noisetorch.png
+var iconPNG []byte
+
+// how often the menu follows changes made elsewhere, e.g. in the window or with a hotkey
+const trayRefreshInterval = 500 * time.Millisecond
+
+type trayui struct {
+	show, filter, mute, quit *systray.MenuItem
+	states                   []*systray.MenuItem
+	presets                  *systray.MenuItem
+	presetItems              []*systray.MenuItem // grows with the presets, the ones past the end are hidden
+	clicked                  chan func()
+}
+
+// trayState is what the menu shows, read under the window lock.
+type trayState struct {
+	loaded, input, muted bool
+	active               int
+	saved                []bool
+	presets              []string
+	activePreset         string
+}
+
+// startTray puts the icon into the tray. It goes away with our connection to the session bus when
+// we exit.
+func startTray(ctx *ntcontext) {
+	start, _ := systray.RunWithExternalLoop(func() { runTray(ctx) }, nil)
+	start()
+}
+
+func runTray(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	systray.SetIcon(iconPNG)
+	systray.SetTitle(appName)
+	systray.SetTooltip(appName)
+
+	t := &trayui{clicked: make(chan func(), 1)}
+	t.show = systray.AddMenuItem(trf("Show %s", appName), "")
+	systray.AddSeparator()
+	t.filter = systray.AddMenuItemCheckbox(tr("Filter"), "", false)
+	t.mute = systray.AddMenuItemCheckbox(tr("Mute microphone"), "", false)
+	systray.AddSeparator()
+	wnd := *ctx.masterWindow
+	wnd.Lock()
+	states := quickStates(ctx)
+	wnd.Unlock()
+	for _, st := range states {
+		t.states = append(t.states, systray.AddMenuItemCheckbox(tr(st.Name), tr("Quick switch states"), false))
+	}
+	t.presets = systray.AddMenuItem(tr("Presets"), "")
+	systray.AddSeparator()
+	t.quit = systray.AddMenuItem(tr("Quit"), "")
+
+	forward := t.forward
+	forward(t.show, func() {
+		if err := activateWindow(); err != nil {
+			warnf("Couldn't bring the window to the front: %v\n", err)
+		}
+	})
+	forward(t.filter, func() { uiToggleFilters(ctx) })
+	forward(t.mute, func() { uiToggleMute(ctx) })
+	for i := range t.states {
+		i := i
+		forward(t.states[i], func() {
+			// there are two, picking the other one switches to it
+			if i == otherQuickState(ctx) {
+				uiSwitchQuickState(ctx)
+			}
+		})
+	}
+	forward(t.quit, func() {
+		wnd.Lock()
+		quit(ctx)
+		wnd.Unlock()
+		wnd.Changed()
+		// the quit dialog may need an answer
+		if err := activateWindow(); err != nil {
+			debugf("Couldn't bring the window to the front: %v\n", err)
+		}
+	})
+
+	var last *trayState
+	for {
+		select {
+		case action := <-t.clicked:
+			action()
+		case <-time.After(trayRefreshInterval):
+		}
+		cur := readTrayState(ctx)
+		t.update(ctx, last, &cur)
+		last = &cur
+	}
+}
+
+// forward runs action in the loop of runTray whenever item is clicked.
+func (t *trayui) forward(item *systray.MenuItem, action func()) {
+	go func() {
+		for range item.ClickedCh {
+			t.clicked <- action
+		}
+	}()
+}
+
+func readTrayState(ctx *ntcontext) trayState {
+	wnd := *ctx.masterWindow
+	wnd.Lock()
+	defer wnd.Unlock()
+	s := trayState{
+		loaded: ctx.noiseSupressorState == loaded,
+		input:  ctx.noiseSupressorState == loaded && ctx.config.FilterInput && !jackMode(ctx),
+		muted:  ctx.muted && !coughing(ctx),
+		active: ctx.config.ActiveQuickState,
+	}
+	for _, st := range quickStates(ctx) {
+		s.saved = append(s.saved, st.Saved)
+	}
+	for _, p := range ctx.config.Presets {
+		s.presets = append(s.presets, p.Name)
+	}
+	s.activePreset = ctx.config.ActivePreset
+	return s
+}
+
+// update changes the menu items that differ from what was shown last, all of them the first time.
+func (t *trayui) update(ctx *ntcontext, last, cur *trayState) {
+	first := last == nil
+	if first || last.loaded != cur.loaded {
+		setChecked(t.filter, cur.loaded)
+	}
+	if first || last.input != cur.input {
+		setEnabled(t.mute, cur.input)
+	}
+	if first || last.muted != cur.muted {
+		setChecked(t.mute, cur.muted)
+	}
+	for i, item := range t.states {
+		if i >= len(cur.saved) {
+			break
+		}
+		if first || last.active != cur.active || last.saved[i] != cur.saved[i] {
+			setChecked(item, cur.saved[i] && i == cur.active)
+			setEnabled(item, cur.saved[i])
+		}
+	}
+	if first || !equalStrings(last.presets, cur.presets) || last.activePreset != cur.activePreset {
+		t.updatePresets(ctx, cur)
+	}
+}
+
+// updatePresets shows the presets as saved in the settings, checking the one last applied.
+func (t *trayui) updatePresets(ctx *ntcontext, cur *trayState) {
+	for len(t.presetItems) < len(cur.presets) {
+		i := len(t.presetItems)
+		item := t.presets.AddSubMenuItemCheckbox("", "", false)
+		t.presetItems = append(t.presetItems, item)
+		t.forward(item, func() {
+			// the item stands for whatever preset is at its place when it's clicked
+			wnd := *ctx.masterWindow
+			wnd.Lock()
+			var name string
+			if i < len(ctx.config.Presets) {
+				name = ctx.config.Presets[i].Name
+			}
+			wnd.Unlock()
+			if name != "" {
+				uiApplyPreset(ctx, name)
+			}
+		})
+	}
+	for i, item := range t.presetItems {
+		if i >= len(cur.presets) {
+			item.Hide()
+			continue
+		}
+		item.SetTitle(cur.presets[i])
+		setChecked(item, strings.EqualFold(cur.presets[i], cur.activePreset))
+		item.Show()
+	}
+	if len(cur.presets) == 0 {
+		t.presets.Hide()
+	} else {
+		t.presets.Show()
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func setChecked(item *systray.MenuItem, checked bool) {
+	if checked {
+		item.Check()
+	} else {
+		item.Uncheck()
+	}
+}
+
+func setEnabled(item *systray.MenuItem, enabled bool) {
+	if enabled {
+		item.Enable()
+	} else {
+		item.Disable()
+	}
+}
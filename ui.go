@@ -5,8 +5,7 @@ package main
 
 import (
 	"fmt"
-	"image/color"
-	"log"
+	"noisetorch/buildinfo"
 	"os"
 	"os/exec"
 	"syscall"
@@ -14,14 +13,14 @@ import (
 
 	"github.com/aarzilli/nucular"
 	"github.com/aarzilli/nucular/label"
-	"github.com/noisetorch/pulseaudio"
+	"golang.org/x/mobile/event/mouse"
 )
 
 type ntcontext struct {
 	inputList                []device
 	outputList               []device
 	noiseSupressorState      int
-	paClient                 *pulseaudio.Client
+	paClient                 AudioServer
 	librnnoise               string
 	sourceListColdWidthIndex int
 	config                   *config
@@ -34,9 +33,56 @@ type ntcontext struct {
 	views                    *ViewStack
 	serverInfo               audioserverinfo
 	virtualDeviceInUse       bool
+	cough                    coughState
+	muted                    bool
+	mini                     bool // the mini view is shown, see mini.go
+	liveControls             livecontrols
+	chainID                  string
+	startupDone              bool
+	meters                   meters
+	vad                      vadStatus
+	vadKnown                 bool
+	calibration              calibrationui
+	hear                     hearMyself
+	record                   recordui
+	chain                    runningChain
+	hotkeys                  *hotkeys
+	hotplug                  hotplug
+	defaultSource            string
+	details                  detailsui
+	shortcuts                shortcutsui
+	logs                     logui
+	crash                    crashui
+	doctor                   doctorui
+	cpu                      cpuMonitor
+	routing                  routing
+	routingUI                routingui
+	outputRouting            outputRouting
+	spectrogram              spectrogramui
+	bypass                   bypass
+	presets                  presetui
+	noisePresets             noisepresetui
+	watchdog                 streamWatchdog
+	idle                     idleUnload
+	conflicts                conflictui
+	samplespec               samplespecui
+	moduleArgs               moduleargsui
+	stale                    []staleModule
+	settings                 settingsui
+	onboarding               onboardingui
+	notifier                 notifier
+	recovery                 recovery
+	metrics                  metrics
+	obs                      obsIntegration
+	exit                     exitui
+	systemPlugin             systempluginui
+	jack                     jackui
+	keyboard                 keyboardui
+	window                   windowstate
+	connect                  connectui
 }
 
-//TODO pull some of these strucs out of UI, they don't belong here
+// TODO pull some of these strucs out of UI, they don't belong here
 type audioserverinfo struct {
 	servertype       uint
 	name             string
@@ -44,6 +90,7 @@ type audioserverinfo struct {
 	minor            int
 	patch            int
 	outdatedPipeWire bool
+	jack             string // how to reach a JACK server, see jackServer, empty if there's none
 }
 
 const (
@@ -51,14 +98,18 @@ const (
 	servertype_pipewire
 )
 
-var green = color.RGBA{34, 187, 69, 255}
-var red = color.RGBA{255, 70, 70, 255}
-var orange = color.RGBA{255, 140, 0, 255}
-var lightBlue = color.RGBA{173, 216, 230, 255}
+// switched by themedStyle
+var green = darkPalette.green
+var red = darkPalette.red
+var orange = darkPalette.orange
+var lightBlue = darkPalette.lightBlue
 
 const notice = "NoiseTorch Next Gen (stylized NoiseTorch-ng) is a continuation of the NoiseTorch\nproject after it was abandoned by its original author. Please do not confuse\nboth programs. You may convey modified versions of this program under its name."
 
 func updatefn(ctx *ntcontext, w *nucular.Window) {
+	defer recoverCrash(ctx)
+	keyboardBegin(ctx, w)
+	trackWindow(ctx, w)
 	currView := ctx.views.Peek()
 	currView(ctx, w)
 }
@@ -68,18 +119,33 @@ func mainView(ctx *ntcontext, w *nucular.Window) {
 	w.MenubarBegin()
 
 	w.Row(10).Dynamic(1)
-	if w := w.Menu(label.TA("About", "LC"), 120, nil); w != nil {
+	if w := w.Menu(label.TA(tr("About"), "LC"), 150, nil); w != nil {
 		w.Row(10).Dynamic(1)
-		if w.MenuItem(label.T("Licenses")) {
+		if w.MenuItem(label.T(tr("Licenses"))) {
 			ctx.views.Push(licenseView)
 		}
 		w.Row(10).Dynamic(1)
-		if w.MenuItem(label.T("Website")) {
-			exec.Command("xdg-open", websiteURL).Run()
+		if w.MenuItem(label.T(tr("Website"))) {
+			exec.Command("xdg-open", buildinfo.WebsiteURL).Run()
 		}
-		if w.MenuItem(label.T("Version")) {
+		if w.MenuItem(label.T(tr("Version"))) {
 			ctx.views.Push(versionView)
 		}
+		if w.MenuItem(label.T(tr("Logs"))) {
+			openLogView(ctx)
+		}
+		if w.MenuItem(label.T(tr("Troubleshoot"))) {
+			openDoctorView(ctx)
+		}
+		if w.MenuItem(label.T(tr("Setup assistant"))) {
+			openOnboarding(ctx)
+		}
+		if w.MenuItem(label.T(tr("Mini view"))) {
+			openMiniView(ctx)
+		}
+		if w.MenuItem(label.T(tr("Quit"))) {
+			quit(ctx)
+		}
 	}
 
 	w.MenubarEnd()
@@ -88,31 +154,82 @@ func mainView(ctx *ntcontext, w *nucular.Window) {
 
 	if ctx.noiseSupressorState == loaded {
 		if ctx.virtualDeviceInUse {
-			w.LabelColored("Filtering active", "RC", green)
+			w.LabelColored(tr("Filtering active"), "RC", green)
+		} else if len(idleInputs(ctx)) > 0 {
+			w.LabelColored(tr("Filtering idle, starts when an application records"), "RC", lightBlue)
 		} else {
-			w.LabelColored("Filtering unconfigured", "RC", lightBlue)
+			w.LabelColored(tr("Filtering unconfigured"), "RC", lightBlue)
 		}
 	} else if ctx.noiseSupressorState == unloaded {
 		_, inpOk := inputSelection(ctx)
 		_, outOk := outputSelection(ctx)
 		if validConfiguration(ctx, inpOk, outOk) {
-			w.LabelColored("Filtering inactive", "RC", red)
+			w.LabelColored(tr("Filtering inactive"), "RC", red)
 		} else {
-			w.LabelColored("Filtering unconfigured", "RC", lightBlue)
+			w.LabelColored(tr("Filtering unconfigured"), "RC", lightBlue)
 		}
 	} else if ctx.noiseSupressorState == inconsistent {
-		w.LabelColored("Inconsistent state, please unload first.", "RC", orange)
+		w.LabelColored(tr("Inconsistent state, please unload first."), "RC", orange)
+	}
+	conflictsView(ctx, w)
+
+	if ctx.noiseSupressorState == loaded && ctx.config.FilterInput && !jackMode(ctx) {
+		w.Row(25).Ratio(0.7, 0.3)
+		if ctx.muted || coughing(ctx) {
+			w.LabelColored(tr("Microphone muted"), "LC", orange)
+		} else if currentEngine(ctx).rnnoise {
+			vadIndicator(ctx, w)
+		} else {
+			w.Spacing(1)
+		}
+		clicked := focusable(ctx, w, w.ButtonText(tr("Cough (mute 2s)")))
+		// held down with the mouse, it mutes until 2s after it's let go
+		down := w.Input().Mouse.Down(mouse.ButtonLeft) && w.Input().Mouse.HoveringRect(w.LastWidgetBounds)
+		switch {
+		case down && !ctx.cough.button:
+			ctx.cough.button = true
+			coughPress(ctx)
+		case !down && ctx.cough.button:
+			ctx.cough.button = false
+			coughRelease(ctx, coughDuration)
+		case clicked:
+			// from the keyboard
+			coughMute(ctx)
+		}
+		if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+			w.Tooltip(tr("Mutes the filtered microphone while held and for 2s after, e.g. while you cough."))
+		}
+		muteButton(ctx, w)
+		bypassView(ctx, w)
+	}
+
+	if ctx.noiseSupressorState == loaded && ctx.config.FilterInput && !jackMode(ctx) {
+		metersView(ctx, w)
+		hearMyselfView(ctx, w)
+		recordView(ctx, w)
+	} else {
+		if ctx.meters.enabled {
+			ctx.meters.enabled = false
+			stopMeters(ctx)
+		}
+		if ctx.hear.enabled {
+			// the loopback goes away together with the filtered microphone
+			ctx.hear.enabled = false
+			ctx.hear.module = 0
+		}
 	}
 
+	cpuView(ctx, w)
+
 	if ctx.serverInfo.servertype == servertype_pipewire {
 		w.Row(20).Dynamic(1)
-		w.Label("Running in PipeWire mode. PipeWire support is currently alpha quality. Please report bugs.", "LC")
+		w.Label(tr("Running in PipeWire mode. PipeWire support is currently alpha quality. Please report bugs."), "LC")
 	}
 
 	if ctx.update.available && !ctx.update.triggered {
 		w.Row(20).Ratio(0.9, 0.1)
-		w.LabelColored("Update available! Click to install version: "+ctx.update.serverVersion, "LC", green)
-		if w.ButtonText("Update") {
+		w.LabelColored(trf("Update available! Click to install version: %s", ctx.update.serverVersion), "LC", green)
+		if focusable(ctx, w, w.ButtonText(tr("Update"))) {
 			ctx.update.triggered = true
 			go update(ctx)
 			(*ctx.masterWindow).Changed()
@@ -121,109 +238,114 @@ func mainView(ctx *ntcontext, w *nucular.Window) {
 
 	if ctx.update.triggered {
 		w.Row(20).Dynamic(1)
-		w.Label(ctx.update.updatingText, "CC")
+		w.Label(tr(ctx.update.updatingText), "CC")
 	}
 
-	if w.TreePush(nucular.TreeTab, "Settings", true) {
-		w.Row(15).Dynamic(2)
-		if w.CheckboxText("Display Monitor Sources", &ctx.config.DisplayMonitorSources) {
-			ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
-			go writeConfig(ctx.config)
-		}
-
-		w.Spacing(1)
-
-		w.Row(25).Ratio(0.5, 0.45, 0.05)
-		w.Label("Voice Activation Threshold", "LC")
-		if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
-			w.Tooltip("If you have a decent microphone, you can usually turn this all the way up.")
-		}
-		if w.SliderInt(0, &ctx.config.Threshold, 95, 1) {
-			go writeConfig(ctx.config)
-			ctx.reloadRequired = true
-		}
-		w.Label(fmt.Sprintf("%d%%", ctx.config.Threshold), "RC")
-
-		if ctx.reloadRequired {
-			w.Row(20).Dynamic(1)
-			w.LabelColored("Reloading the filter(s) is required to apply these changes.", "LC", orange)
-		}
-
-		w.Row(15).Dynamic(2)
-		if w.CheckboxText("Filter Microphone", &ctx.config.FilterInput) {
-			ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
-			go writeConfig(ctx.config)
-			go (func() { ctx.noiseSupressorState, _ = supressorState(ctx) })()
-		}
-
-		if w.CheckboxText("Filter Headphones", &ctx.config.FilterOutput) {
-			ctx.sourceListColdWidthIndex++ //recompute the with because of new elements
-			go writeConfig(ctx.config)
-			go (func() { ctx.noiseSupressorState, _ = supressorState(ctx) })()
+	if ctx.update.capsLost {
+		w.Row(20).Dynamic(1)
+		w.LabelColored(tr("The update removed the CAP_SYS_RESOURCE capability NoiseTorch needs to work."), "LC", orange)
+		w.Row(25).Ratio(0.7, 0.3)
+		w.LabelColored(tr("Grant it again now, or you'll be asked on the next start."), "LC", orange)
+		if focusable(ctx, w, w.ButtonText(tr("Fix permissions"))) {
+			go fixCapsAfterUpdate(ctx)
 		}
+	}
 
+	if w.TreePush(nucular.TreeTab, tr("Settings"), true) {
+		settingsView(ctx, w)
 		w.TreePop()
 	}
-	if ctx.config.FilterInput && w.TreePush(nucular.TreeTab, "Select Microphone", true) {
+	if jackMode(ctx) {
+		jackMainView(ctx, w)
+	}
+	if ctx.config.FilterInput && !jackMode(ctx) && w.TreePush(nucular.TreeTab, tr("Select Microphone"), true) {
 		w.Row(15).Dynamic(1)
-		w.Label("Select an input device below:", "LC")
+		w.Label(tr("Select an input device below:"), "LC")
 
-		for i := range ctx.inputList {
+		for _, i := range listedDevices(ctx, ctx.inputList) {
 			el := &ctx.inputList[i]
 
-			if el.isMonitor && !ctx.config.DisplayMonitorSources {
-				continue
+			w.Row(20).Static()
+			if !ctx.config.NativePipeWire || ctx.serverInfo.servertype != servertype_pipewire {
+				inputLoadButton(ctx, w, el)
 			}
-			w.Row(15).Static()
+			detailsButton(ctx, w, el, false)
 			w.LayoutFitWidth(0, 0)
-			if w.CheckboxText("", &el.checked) {
-				ensureOnlyOneInputSelected(&ctx.inputList, el)
+			if focusableIn(ctx, w, "inputs", w.CheckboxText("", &el.checked)) {
+				if el.checked && primaryInput(ctx, el) {
+					applyProfile(ctx, el)
+				}
 			}
 
 			w.LayoutFitWidth(ctx.sourceListColdWidthIndex, 0)
-			if el.dynamicLatency {
-				w.Label(el.Name, "LC")
+			name := listedName(ctx, el, el.Name)
+			note, degrading := resampleNote(el)
+			if note != "" {
+				name += " (" + note + ")"
+			}
+			if !el.dynamicLatency {
+				w.LabelColored(trf("(incompatible?) %s", name), "LC", orange)
+			} else if degrading {
+				w.LabelColored(name, "LC", orange)
 			} else {
-				w.LabelColored("(incompatible?) "+el.Name, "LC", orange)
+				w.Label(name, "LC")
+			}
+			if degrading && w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+				w.Tooltip(tr("This microphone runs at a low sample rate. It gets resampled to 48 kHz, but the missing high frequencies make the noise suppression less effective."))
 			}
+			detailsView(ctx, w, el)
 		}
+		showHiddenView(ctx, w, ctx.inputList)
+		monitorSourcesView(ctx, w)
 
 		w.TreePop()
 	}
 
-	if ctx.config.FilterOutput && w.TreePush(nucular.TreeTab, "Select Headphones", true) {
+	if ctx.config.FilterOutput && !jackMode(ctx) && w.TreePush(nucular.TreeTab, tr("Select Headphones"), true) {
 		w.Row(15).Dynamic(1)
-		w.Label("Select an output device below:", "LC")
+		w.Label(tr("Select an output device below:"), "LC")
 
-		for i := range ctx.outputList {
+		for _, i := range listedDevices(ctx, ctx.outputList) {
 			el := &ctx.outputList[i]
 
-			if el.isMonitor && !ctx.config.DisplayMonitorSources {
-				continue
-			}
-			w.Row(15).Static()
+			w.Row(20).Static()
+			detailsButton(ctx, w, el, true)
 			w.LayoutFitWidth(0, 0)
-			if w.CheckboxText("", &el.checked) {
+			if focusableIn(ctx, w, "outputs", w.CheckboxText("", &el.checked)) {
 				ensureOnlyOneInputSelected(&ctx.outputList, el)
+				if el.checked && !ctx.config.FilterInput {
+					applyProfile(ctx, el)
+				}
 			}
 
 			w.LayoutFitWidth(ctx.sourceListColdWidthIndex, 0)
+			name := listedName(ctx, el, el.Name)
 			if el.dynamicLatency {
-				w.Label(el.Name, "LC")
+				w.Label(name, "LC")
 			} else {
-				w.LabelColored("(incompatible?) "+el.Name, "LC", orange)
+				w.LabelColored(trf("(incompatible?) %s", name), "LC", orange)
 			}
+			detailsView(ctx, w, el)
 		}
+		showHiddenView(ctx, w, ctx.outputList)
 
 		w.TreePop()
 	}
 
-	w.Row(15).Dynamic(1)
-	w.Spacing(1)
+	presetView(ctx, w)
+	quickSwitchButton(ctx, w)
+
+	changes := pendingChanges(ctx)
+	if len(changes) > 0 {
+		pendingChangesView(ctx, w, changes)
+	} else {
+		w.Row(15).Dynamic(1)
+		w.Spacing(1)
+	}
 
 	w.Row(25).Dynamic(2)
 	if ctx.noiseSupressorState != unloaded {
-		if w.ButtonText("Unload Filter(s)") {
+		if focusable(ctx, w, w.ButtonText(tr("Unload Filter(s)"))) {
 			ctx.reloadRequired = false
 			if ctx.virtualDeviceInUse {
 				confirm := makeConfirmView(ctx,
@@ -244,15 +366,18 @@ func mainView(ctx *ntcontext, w *nucular.Window) {
 	txt := "Load Filter(s)"
 	if ctx.noiseSupressorState == loaded {
 		txt = "Reload Filter(s)"
+		if len(changes) > 0 {
+			txt = "Apply Changes"
+		}
 	}
 
 	inp, inpOk := inputSelection(ctx)
 	out, outOk := outputSelection(ctx)
 	if validConfiguration(ctx, inpOk, outOk) {
-		if w.ButtonText(txt) {
+		if focusable(ctx, w, w.ButtonText(tr(txt))) {
 			ctx.reloadRequired = false
 
-			if ctx.virtualDeviceInUse {
+			if ctx.virtualDeviceInUse && !canSwapFilters(ctx, &out) {
 				confirm := makeConfirmView(ctx,
 					"Virtual Device in Use",
 					"Some applications may behave weirdly when you reload a device they're currently using",
@@ -273,8 +398,10 @@ func mainView(ctx *ntcontext, w *nucular.Window) {
 
 func uiUnloadFilters(ctx *ntcontext) {
 	ctx.views.Push(loadingView)
-	if err := unloadSupressor(ctx); err != nil {
-		log.Println(err)
+	endBypass(ctx)
+	restoreRouting(ctx, nil)
+	if err := turnOffSupressor(ctx); err != nil {
+		errorf("%v\n", err)
 	}
 	//wait until PA reports it has actually loaded it, timeout at 10s
 	for i := 0; i < 20; i++ {
@@ -286,15 +413,45 @@ func uiUnloadFilters(ctx *ntcontext) {
 	(*ctx.masterWindow).Changed()
 }
 
+// uiToggleFilters unloads the filters if they're loaded, and loads the selected ones otherwise.
+func uiToggleFilters(ctx *ntcontext) {
+	if ctx.noiseSupressorState != unloaded {
+		uiUnloadFilters(ctx)
+		return
+	}
+	inp, inpOk := inputSelection(ctx)
+	out, outOk := outputSelection(ctx)
+	if !validConfiguration(ctx, inpOk, outOk) {
+		warnf("Nothing selected to load\n")
+		return
+	}
+	uiReloadFilters(ctx, inp, out)
+}
+
 func uiReloadFilters(ctx *ntcontext, inp, out device) {
 	ctx.views.Push(loadingView)
-	if ctx.noiseSupressorState == loaded {
-		if err := unloadSupressor(ctx); err != nil {
-			log.Println(err)
+	endBypass(ctx)
+	if canSwapFilters(ctx, &out) {
+		if err := swapFilters(ctx, &out); err != nil {
+			errorf("%v\n", err)
+		}
+	} else {
+		if ctx.noiseSupressorState == loaded {
+			if err := unloadSupressor(ctx); err != nil {
+				errorf("%v\n", err)
+			}
+		}
+		if err := loadSupressor(ctx, &inp, &out); err != nil {
+			errorf("%v\n", err)
+		}
+		for _, extra := range inputSelections(ctx) {
+			if extra.ID == inp.ID {
+				continue
+			}
+			if err := loadInputSupressor(ctx, &extra); err != nil {
+				errorf("%v\n", err)
+			}
 		}
-	}
-	if err := loadSupressor(ctx, &inp, &out); err != nil {
-		log.Println(err)
 	}
 
 	//wait until PA reports it has actually loaded it, timeout at 10s
@@ -305,7 +462,21 @@ func uiReloadFilters(ctx *ntcontext, inp, out device) {
 	}
 	ctx.config.LastUsedInput = inp.ID
 	ctx.config.LastUsedOutput = out.ID
+	ctx.config.AdditionalInputs = nil
+	for _, extra := range inputSelections(ctx) {
+		if extra.ID != inp.ID {
+			ctx.config.AdditionalInputs = append(ctx.config.AdditionalInputs, extra.ID)
+			saveProfile(ctx, &extra)
+		}
+	}
+	saveProfile(ctx, &inp)
+	saveProfile(ctx, &out)
 	go writeConfig(ctx.config)
+	if ctx.config.Autostart {
+		if err := syncAutostart(ctx); err != nil {
+			errorf("Couldn't update start on login: %v\n", err)
+		}
+	}
 	ctx.views.Pop()
 	(*ctx.masterWindow).Changed()
 }
@@ -321,17 +492,89 @@ func ensureOnlyOneInputSelected(inps *[]device, current *device) {
 	current.checked = true
 }
 
-func inputSelection(ctx *ntcontext) (device, bool) {
+// inputSelections returns all microphones that should be filtered.
+func inputSelections(ctx *ntcontext) []device {
 	if !ctx.config.FilterInput {
-		return device{}, false
+		return nil
 	}
 
+	var res []device
 	for _, in := range ctx.inputList {
 		if in.checked && (!in.isMonitor || ctx.config.DisplayMonitorSources) {
-			return in, true
+			res = append(res, in)
 		}
 	}
-	return device{}, false
+	return res
+}
+
+// inputSelection returns the primary microphone, the first one selected. Per-device settings
+// like the latency offset and the level meters apply to it.
+func inputSelection(ctx *ntcontext) (device, bool) {
+	inps := inputSelections(ctx)
+	if len(inps) == 0 {
+		return device{}, false
+	}
+	return inps[0], true
+}
+
+func primaryInput(ctx *ntcontext, d *device) bool {
+	inp, ok := inputSelection(ctx)
+	return ok && inp.ID == d.ID
+}
+
+func inputChainLoaded(ctx *ntcontext, d *device) bool {
+	for _, id := range ctx.chain.inputs {
+		if id == d.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// inputLoadButton loads or unloads the chain of a single microphone, independently of the others.
+func inputLoadButton(ctx *ntcontext, w *nucular.Window, el *device) {
+	w.LayoutSetWidth(70)
+	if inputChainLoaded(ctx, el) {
+		if focusable(ctx, w, w.ButtonText(tr("Unload"))) {
+			el.checked = false
+			d := *el
+			if ctx.virtualDeviceInUse {
+				ctx.views.Push(makeConfirmView(ctx,
+					"Virtual Device in Use",
+					"Some applications may behave weirdly when you remove a device they're currently using",
+					"Unload",
+					"Go back",
+					func() { uiUnloadInput(ctx, d) },
+					func() {}))
+			} else {
+				go uiUnloadInput(ctx, d)
+			}
+		}
+	} else if focusable(ctx, w, w.ButtonText(tr("Load"))) {
+		el.checked = true
+		go uiLoadInput(ctx, *el)
+	}
+}
+
+func uiLoadInput(ctx *ntcontext, inp device) {
+	ctx.views.Push(loadingView)
+	if err := loadInputSupressor(ctx, &inp); err != nil {
+		errorf("%v\n", err)
+	}
+	saveProfile(ctx, &inp)
+	go writeConfig(ctx.config)
+	ctx.views.Pop()
+	(*ctx.masterWindow).Changed()
+}
+
+func uiUnloadInput(ctx *ntcontext, inp device) {
+	ctx.views.Push(loadingView)
+	restoreRouting(ctx, &inp)
+	if err := turnOffInputSupressor(ctx, &inp); err != nil {
+		errorf("%v\n", err)
+	}
+	ctx.views.Pop()
+	(*ctx.masterWindow).Changed()
 }
 
 func outputSelection(ctx *ntcontext) (device, bool) {
@@ -347,7 +590,19 @@ func outputSelection(ctx *ntcontext) (device, bool) {
 	return device{}, false
 }
 
+// primarySelection returns the device whose profile the settings belong to.
+func primarySelection(ctx *ntcontext) (device, bool) {
+	if inp, ok := inputSelection(ctx); ok {
+		return inp, true
+	}
+	return outputSelection(ctx)
+}
+
 func validConfiguration(ctx *ntcontext, inpOk bool, outOk bool) bool {
+	if jackMode(ctx) {
+		// the ports are connected by rules or in the patchbay
+		return ctx.noiseSupressorState != inconsistent
+	}
 	return (!ctx.config.FilterInput || (ctx.config.FilterInput && inpOk)) &&
 		(!ctx.config.FilterOutput || (ctx.config.FilterOutput && outOk)) &&
 		(ctx.config.FilterInput || ctx.config.FilterOutput) &&
@@ -356,9 +611,9 @@ func validConfiguration(ctx *ntcontext, inpOk bool, outOk bool) bool {
 
 func loadingView(ctx *ntcontext, w *nucular.Window) {
 	w.Row(50).Dynamic(1)
-	w.Label("Working...", "CB")
+	w.Label(tr("Working..."), "CB")
 	w.Row(50).Dynamic(1)
-	w.Label("(this may take a few seconds)", "CB")
+	w.Label(tr("(this may take a few seconds)"), "CB")
 }
 
 func licenseView(ctx *ntcontext, w *nucular.Window) {
@@ -371,77 +626,70 @@ func licenseView(ctx *ntcontext, w *nucular.Window) {
 	if len(field.Buffer) < 1 {
 		field.Buffer = []rune(licenseString) // nolint
 	}
-	field.Edit(w)
+	focusableEdit(ctx, w, field, field.Edit(w))
 
 	w.Row(20).Dynamic(2)
 	w.Spacing(1)
-	if w.ButtonText("OK") {
+	if focusable(ctx, w, w.ButtonText(tr("OK"))) {
 		ctx.views.Pop()
 	}
 }
 
 func versionView(ctx *ntcontext, w *nucular.Window) {
 	w.Row(50).Dynamic(1)
-	w.Label("Version", "CB")
+	w.Label(tr("Version"), "CB")
 	w.Row(50).Dynamic(1)
 	w.Label(notice, "CB")
 	w.Row(50).Dynamic(1)
-	w.Label(fmt.Sprintf("%s (%s)", version, distribution), "CB")
+	w.Label(fmt.Sprintf("%s (%s)", buildinfo.Version, buildinfo.Distribution), "CB")
 	w.Row(50).Dynamic(1)
 	w.Spacing(1)
 	w.Row(20).Dynamic(2)
 	w.Spacing(1)
-	if w.ButtonText("OK") {
+	if focusable(ctx, w, w.ButtonText(tr("OK"))) {
 		ctx.views.Pop()
 	}
 }
 
-func connectView(ctx *ntcontext, w *nucular.Window) {
-	w.Row(50).Dynamic(1)
-	w.Label("Connecting to pulseaudio...", "CB")
-}
-
 func capabilitiesView(ctx *ntcontext, w *nucular.Window) {
 	w.Row(15).Dynamic(1)
-	w.Label("This program does not have the capabilities to function properly.", "CB")
+	w.Label(tr("This program does not have the capabilities to function properly."), "CB")
 	w.Row(15).Dynamic(1)
-	w.Label("We require CAP_SYS_RESOURCE. If that doesn't mean anything to you, don't worry. I'll fix it for you.", "CB")
+	w.Label(tr("We require CAP_SYS_RESOURCE. If that doesn't mean anything to you, don't worry. I'll fix it for you."), "CB")
 	if ctx.capsMismatch {
 		w.Row(15).Dynamic(1)
-		w.LabelColored("Warning: File has CAP_SYS_RESOURCE but our process doesn't.", "CB", orange)
-		w.Row(15).Dynamic(1)
-		w.LabelColored("Check if your filesystem has nosuid set or check the troubleshooting page.", "CB", orange)
+		w.Label(tr("The binary is on a filesystem that ignores it, it will be moved to ~/.local/bin."), "CB")
 	}
 	w.Row(40).Dynamic(1)
 	w.Row(25).Dynamic(1)
-	if w.ButtonText("Grant capability (requires root)") {
-		err := pkexecSetcapSelf()
-		if err != nil {
-			ctx.views.Push(makeErrorView(ctx, err.Error()))
-			return
-		}
-		self, err := os.Executable()
-		if err != nil {
-			ctx.views.Push(makeErrorView(ctx, err.Error()))
-			return
-		}
-		err = syscall.Exec(self, []string{""}, os.Environ())
-		if err != nil {
-			ctx.views.Push(makeErrorView(ctx, err.Error()))
-			return
-		}
+	if focusable(ctx, w, w.ButtonText(tr("Fix permissions (requires root)"))) {
+		go uiFixPermissions(ctx)
+	}
+}
+
+// uiFixPermissions grants the capability and restarts, the process only gets it when it starts.
+func uiFixPermissions(ctx *ntcontext) {
+	self, err := fixPermissions()
+	if err != nil {
+		ctx.views.Push(makeErrorView(ctx, err.Error()))
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	if err := syscall.Exec(self, os.Args, os.Environ()); err != nil {
+		ctx.views.Push(makeErrorView(ctx, err.Error()))
+		(*ctx.masterWindow).Changed()
 	}
 }
 
 func makeErrorView(ctx *ntcontext, errorMsg string) ViewFunc {
 	return func(ctx *ntcontext, w *nucular.Window) {
 		w.Row(15).Dynamic(1)
-		w.Label("Error", "CB")
+		w.Label(tr("Error"), "CB")
 		w.Row(15).Dynamic(1)
-		w.Label(errorMsg, "CB")
+		w.Label(tr(errorMsg), "CB")
 		w.Row(40).Dynamic(1)
 		w.Row(25).Dynamic(1)
-		if w.ButtonText("OK") {
+		if focusable(ctx, w, w.ButtonText(tr("OK"))) {
 			ctx.views.Pop()
 			return
 		}
@@ -451,12 +699,12 @@ func makeErrorView(ctx *ntcontext, errorMsg string) ViewFunc {
 func makeFatalErrorView(ctx *ntcontext, errorMsg string) ViewFunc {
 	return func(ctx *ntcontext, w *nucular.Window) {
 		w.Row(15).Dynamic(1)
-		w.Label("Fatal Error", "CB")
+		w.Label(tr("Fatal Error"), "CB")
 		w.Row(15).Dynamic(1)
-		w.Label(errorMsg, "CB")
+		w.Label(tr(errorMsg), "CB")
 		w.Row(40).Dynamic(1)
 		w.Row(25).Dynamic(1)
-		if w.ButtonText("Quit") {
+		if focusable(ctx, w, w.ButtonText(tr("Quit"))) {
 			os.Exit(1)
 			return
 		}
@@ -466,17 +714,17 @@ func makeFatalErrorView(ctx *ntcontext, errorMsg string) ViewFunc {
 func makeConfirmView(ctx *ntcontext, title, text, confirmText, denyText string, confirmfunc, denyfunc func()) ViewFunc {
 	return func(ctx *ntcontext, w *nucular.Window) {
 		w.Row(15).Dynamic(1)
-		w.Label(title, "CB")
+		w.Label(tr(title), "CB")
 		w.Row(15).Dynamic(1)
-		w.Label(text, "CB")
+		w.Label(tr(text), "CB")
 		w.Row(40).Dynamic(1)
 		w.Row(25).Dynamic(2)
-		if w.ButtonText(denyText) {
+		if focusable(ctx, w, w.ButtonText(tr(denyText))) {
 			ctx.views.Pop()
 			go denyfunc()
 			return
 		}
-		if w.ButtonText(confirmText) {
+		if focusable(ctx, w, w.ButtonText(tr(confirmText))) {
 			ctx.views.Pop()
 			go confirmfunc()
 			return
@@ -487,8 +735,13 @@ func makeConfirmView(ctx *ntcontext, title, text, confirmText, denyText string,
 func resetUI(ctx *ntcontext) {
 	ctx.views = NewViewStack()
 	ctx.views.Push(mainView)
+	if ctx.mini {
+		ctx.views.Push(miniView)
+	}
 
-	if !ctx.haveCapabilities {
+	// setcap is impossible in a sandbox, and couldn't reach the audio server's process anyway. With
+	// RealtimeKit we don't need it.
+	if !ctx.haveCapabilities && sandboxed == "" && !rtkitAvailable() {
 		ctx.views.Push(capabilitiesView)
 	}
 
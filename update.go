@@ -12,6 +12,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"noisetorch/buildinfo"
 	"os"
 	"strings"
 	"time"
@@ -76,53 +77,81 @@ type updateui struct {
 	available     bool
 	triggered     bool
 	updatingText  string
+	capsLost      bool
 }
 
-var latestRelease string
-var releaseError error
-
-func init() {
-	if !updateable() {
-		return
-	}
+// Update channels: stable gets the latest release, beta also pre-releases.
+const (
+	channelStable = "stable"
+	channelBeta   = "beta"
+)
 
-	latestRelease, releaseError = getLatestRelease()
+var updateChannels = []struct {
+	id, name string
+}{
+	{channelStable, "Stable"},
+	{channelBeta, "Beta"},
 }
 
 func updateable() bool {
-	return updateURL != "" && publicKeyString != "" && releaseError == nil
+	// the package manager updates sandboxed installs
+	return buildinfo.Enabled().Updates && sandboxed == ""
 }
 
 func updateCheck(ctx *ntcontext) {
 	if !updateable() {
 		return
 	}
-	log.Println("Checking for updates")
-
-	var latestVersion, _ = semver.Make(strings.TrimLeft(latestRelease, "v"))
-	var currentVersion, _ = semver.Make(strings.TrimLeft(version, "v"))
+	infof("Checking for updates on the %s channel\n", ctx.config.UpdateChannel)
 
+	latestRelease, err := getLatestRelease(ctx.config.UpdateChannel)
+	if err != nil {
+		return
+	}
 	ctx.update.serverVersion = latestRelease
-	if currentVersion.Compare(latestVersion) == -1 {
-		ctx.update.available = true
+	ctx.update.available = newerVersion(latestRelease)
+	(*ctx.masterWindow).Changed()
+	if ctx.update.available {
+		notify(ctx, notifyUpdate, tr("Update available"), trf("NoiseTorch-ng %s can be installed from the main window.", latestRelease))
 	}
 }
 
+// newerVersion tells whether the release tag is newer than the running version.
+func newerVersion(tag string) bool {
+	var latestVersion, _ = semver.Make(strings.TrimLeft(tag, "v"))
+	var currentVersion, _ = semver.Make(strings.TrimLeft(buildinfo.Version, "v"))
+	return currentVersion.Compare(latestVersion) == -1
+}
+
 func update(ctx *ntcontext) {
 	if !updateable() {
 		return
 	}
-	sig, err := fetchFile("NoiseTorch_x64_" + latestRelease + ".tgz.sig")
+	latestRelease := ctx.update.serverVersion
+
+	if err := keepPreviousBinary(); err != nil {
+		warnf("Couldn't keep the installed version to roll back to: %v\n", err)
+	}
+
+	err := deltaUpdate(buildinfo.Version, latestRelease)
+	if err == nil {
+		updateInstalled(ctx)
+		return
+	}
+	infof("Delta update not possible, downloading the full release: %v\n", err)
+
+	tarball := fmt.Sprintf("NoiseTorch_%s_%s.tgz", releaseArch(), latestRelease)
+	sig, err := fetchFile(latestRelease, tarball+".sig")
 	if err != nil {
-		log.Println("Couldn't fetch signature", err)
+		errorf("Couldn't fetch signature: %v\n", err)
 		ctx.update.updatingText = "Update failed!"
 		(*ctx.masterWindow).Changed()
 		return
 	}
 
-	tgz, err := fetchFile("NoiseTorch_x64_" + latestRelease + ".tgz")
+	tgz, err := fetchFile(latestRelease, tarball)
 	if err != nil {
-		log.Println("Couldn't fetch tgz", err)
+		errorf("Couldn't fetch tgz: %v\n", err)
 		ctx.update.updatingText = "Update failed!"
 		(*ctx.masterWindow).Changed()
 		return
@@ -130,25 +159,42 @@ func update(ctx *ntcontext) {
 
 	verified := ed25519.Verify(publickey(), tgz, sig)
 
-	log.Printf("VERIFIED UPDATE: %t\n", verified)
+	infof("VERIFIED UPDATE: %t\n", verified)
 
 	if !verified {
-		log.Printf("SIGNATURE VERIFICATION FAILED, ABORTING UPDATE!\n")
+		errorf("SIGNATURE VERIFICATION FAILED, ABORTING UPDATE!\n")
 		ctx.update.updatingText = "Update failed!"
 		(*ctx.masterWindow).Changed()
 		return
 	}
 
 	untar(bytes.NewReader(tgz), os.Getenv("HOME"))
+	updateInstalled(ctx)
+}
+
+func updateInstalled(ctx *ntcontext) {
 	pkexecSetcapSelf()
 
-	log.Printf("Update installed!\n")
+	// replacing the binary drops its file capabilities, if the user dismissed the
+	// pkexec prompt we'd otherwise only notice on the next start
+	ctx.update.capsLost = !hasCapSysResource(getSelfFileCaps())
+	infof("Update installed! File caps lost: %t\n", ctx.update.capsLost)
 	ctx.update.updatingText = "Update installed! (Restart the program to apply)"
 	(*ctx.masterWindow).Changed()
 }
 
-func fetchFile(file string) ([]byte, error) {
-	resp, err := http.Get(updateURL + "/" + latestRelease + "/" + file)
+func fixCapsAfterUpdate(ctx *ntcontext) {
+	if err := pkexecSetcapSelf(); err != nil {
+		ctx.views.Push(makeErrorView(ctx, err.Error()))
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	ctx.update.capsLost = !hasCapSysResource(getSelfFileCaps())
+	(*ctx.masterWindow).Changed()
+}
+
+func fetchFile(version, file string) ([]byte, error) {
+	resp, err := http.Get(buildinfo.UpdateURL + "/" + version + "/" + file)
 	if err != nil {
 		return nil, err
 	}
@@ -163,16 +209,21 @@ func fetchFile(file string) ([]byte, error) {
 }
 
 func publickey() []byte {
-	pub, err := base64.StdEncoding.DecodeString(publicKeyString)
+	pub, err := base64.StdEncoding.DecodeString(buildinfo.PublicKey)
 	if err != nil { // Should only happen when distributor ships an invalid public key
-		log.Fatalf("Error while reading public key: %s\nContact the distribution '%s' about this error.\n", err, distribution)
+		log.Fatalf("Error while reading public key: %s\nContact the distribution '%s' about this error.\n", err, buildinfo.Distribution)
 		os.Exit(1)
 	}
 	return pub
 }
 
-func getLatestRelease() (string, error) {
+// getLatestRelease returns the tag of the newest release on the channel.
+func getLatestRelease(channel string) (string, error) {
 	url := "https://api.github.com/repos/noisetorch/NoiseTorch/releases/latest"
+	if channel == channelBeta {
+		// the newest ones, pre-releases included
+		url = "https://api.github.com/repos/noisetorch/NoiseTorch/releases?per_page=20"
+	}
 
 	httpclient := http.Client{
 		Timeout: time.Second * 2, // Timeout after 2 seconds
@@ -180,15 +231,15 @@ func getLatestRelease() (string, error) {
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		log.Println("Could not create http requester", err)
+		errorf("Could not create http requester: %v\n", err)
 		return "", err
 	}
 
-	req.Header.Set("User-Agent", "NoiseTorch/"+version)
+	req.Header.Set("User-Agent", "NoiseTorch/"+buildinfo.Version)
 
 	res, err := httpclient.Do(req)
 	if err != nil {
-		log.Println("Couldn't fetch latest release", err)
+		errorf("Couldn't fetch latest release: %v\n", err)
 		// Return an empty string when the latest release is unknown
 		return "", err
 	}
@@ -202,14 +253,42 @@ func getLatestRelease() (string, error) {
 		log.Fatal(readErr)
 	}
 
+	if channel == channelBeta {
+		var releases []github_release
+		if err := json.Unmarshal(body, &releases); err != nil {
+			errorf("Reading JSON for releases failed: %v\n", err)
+			return "", err
+		}
+		return newestRelease(releases)
+	}
+
 	var latest_release github_release
 
 	err = json.Unmarshal(body, &latest_release)
 	if err != nil {
-		log.Println("Reading JSON for latest_release failed", err)
+		errorf("Reading JSON for latest_release failed: %v\n", err)
 		// Return an empty string when the JSON is something unexpected, for example: when rate limited
 		return "", err
 	}
 
 	return latest_release.TagName, nil
 }
+
+// newestRelease returns the tag with the highest version, github lists releases by date.
+func newestRelease(releases []github_release) (string, error) {
+	var newest string
+	var newestVersion semver.Version
+	for _, r := range releases {
+		v, err := semver.Make(strings.TrimLeft(r.TagName, "v"))
+		if r.Draft || err != nil {
+			continue
+		}
+		if newest == "" || v.GT(newestVersion) {
+			newest, newestVersion = r.TagName, v
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no releases found")
+	}
+	return newest, nil
+}
@@ -0,0 +1,88 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The LADSPA plugin publishes its voice activity in $XDG_RUNTIME_DIR/noisetorch-vad-<pid>-<instance>,
+// see vadStatus in c/ladspa/module.c for the layout. Without a runtime dir it publishes nothing.
+const (
+	vadStatusSize = 16
+	vadStaleAfter = time.Second
+)
+
+type vadStatus struct {
+	prob     float32
+	gateOpen bool
+}
+
+// readVADStatus returns the voice activity of all running filter instances combined, including
+// the in-process ones. ok is false if no instance has published anything recently.
+func readVADStatus() (status vadStatus, ok bool) {
+	status, ok = inProcessVADStatus()
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return status, ok // the plugin publishes nothing without a runtime dir
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "noisetorch-vad-*"))
+	for _, f := range files {
+		buf, err := os.ReadFile(f)
+		if err != nil || len(buf) < vadStatusSize {
+			continue
+		}
+		updated := time.Unix(0, int64(binary.LittleEndian.Uint64(buf[0:8]))*int64(time.Millisecond))
+		if time.Since(updated) > vadStaleAfter {
+			continue // filter is idle or the server crashed without cleaning up
+		}
+		ok = true
+		prob := math.Float32frombits(binary.LittleEndian.Uint32(buf[8:12]))
+		if prob > status.prob {
+			status.prob = prob
+		}
+		status.gateOpen = status.gateOpen || binary.LittleEndian.Uint32(buf[12:16]) != 0
+	}
+	return status, ok
+}
+
+func (s vadStatus) String() string {
+	if s.gateOpen {
+		return fmt.Sprintf("speaking (%.2f)", s.prob)
+	}
+	return fmt.Sprintf("silent (%.2f)", s.prob)
+}
+
+func vadWatcher(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	for {
+		time.Sleep(100 * time.Millisecond)
+		if ctx.noiseSupressorState != loaded {
+			continue
+		}
+		status, ok := readVADStatus()
+		if status != ctx.vad || ok != ctx.vadKnown {
+			ctx.vad, ctx.vadKnown = status, ok
+			(*ctx.masterWindow).Changed()
+		}
+	}
+}
+
+func vadIndicator(ctx *ntcontext, w *nucular.Window) {
+	switch {
+	case !ctx.vadKnown:
+		w.Spacing(1)
+	case ctx.vad.gateOpen:
+		w.LabelColored(tr("Speaking"), "LC", green)
+	default:
+		w.Label(tr("Silent"), "LC")
+	}
+}
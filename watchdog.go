@@ -0,0 +1,298 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The chain can break without any of its modules going away: the LADSPA sink gets stuck and the
+// filtered microphone stops delivering audio, or delivers nothing but digital silence. Applications
+// keep recording and the other side of the call hears nothing. While an application records from the
+// filtered microphone, the stream watchdog records the raw and the filtered microphone alongside it,
+// and reloads the chain when the raw one is active but the filtered one stays silent or stalled for
+// WatchdogTimeout seconds.
+//
+// Silence is expected while the voice activation gate of RNNoise or the noise gate is closed, so only
+// silence while the filter reports the gate open, or doesn't report at all, counts.
+
+const (
+	watchdogTick       = time.Second
+	watchdogUsersEvery = 5     // ticks between checking who records from the filtered microphone
+	watchdogActive     = -50.0 // dBFS, above this the raw microphone counts as active
+	watchdogCooldown   = time.Minute
+
+	minWatchdogTimeout     = 3   // s
+	maxWatchdogTimeout     = 120 // s
+	defaultWatchdogTimeout = 10  // s
+)
+
+// streamProbe records from a source and remembers when it last delivered audio.
+type streamProbe struct {
+	cmd     *exec.Cmd
+	source  string
+	started time.Time
+	// unix nanoseconds, accessed atomically
+	lastSample int64
+	lastSound  int64 // anything but digital silence
+	lastActive int64 // above watchdogActive
+}
+
+func startStreamProbe(ctx *ntcontext, source string) (*streamProbe, error) {
+	// tagged, so we don't count ourselves as an application recording
+	cmd := audioCommand("parec", "--raw", "--format=float32le", "--channels=1",
+		fmt.Sprintf("--rate=%d", meterRate), "--latency-msec=100", "--client-name=NoiseTorch watchdog",
+		fmt.Sprintf("--property=%s=%s", tagID, ctx.chainID), "-d", source)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	debugf("Calling: %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("couldn't start parec: %w", err)
+	}
+
+	p := &streamProbe{cmd: cmd, source: source, started: time.Now()}
+	go p.run(bufio.NewReader(stdout))
+	return p, nil
+}
+
+func (p *streamProbe) run(r io.Reader) {
+	buf := make([]byte, meterWindow*4)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			p.cmd.Wait()
+			return
+		}
+		now := time.Now().UnixNano()
+		atomic.StoreInt64(&p.lastSample, now)
+		var peak float64
+		for i := 0; i < len(buf); i += 4 {
+			peak = math.Max(peak, math.Abs(float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i:])))))
+		}
+		if peak > 0 {
+			atomic.StoreInt64(&p.lastSound, now)
+		}
+		if 20*math.Log10(peak) > watchdogActive {
+			atomic.StoreInt64(&p.lastActive, now)
+		}
+	}
+}
+
+// since returns how long ago the atomically stored time was, or since the probe started.
+func (p *streamProbe) since(t *int64) time.Duration {
+	v := atomic.LoadInt64(t)
+	if v == 0 {
+		return time.Since(p.started)
+	}
+	return time.Since(time.Unix(0, v))
+}
+
+func (p *streamProbe) Stop() {
+	if p == nil || p.cmd.Process == nil {
+		return
+	}
+	p.cmd.Process.Kill()
+}
+
+type streamWatchdog struct {
+	raw, filtered *streamProbe
+	ticks         int
+	users         bool // an application records from the filtered microphone
+	silentSince   time.Time
+	stalledSince  time.Time
+	lastReload    time.Time
+}
+
+func (wd *streamWatchdog) stop() {
+	wd.raw.Stop()
+	wd.filtered.Stop()
+	wd.raw, wd.filtered = nil, nil
+	wd.silentSince, wd.stalledSince = time.Time{}, time.Time{}
+}
+
+// streamWatchdogLoop checks the filtered microphone once per watchdogTick.
+func streamWatchdogLoop(ctx *ntcontext) {
+	defer recoverCrash(ctx)
+	for {
+		time.Sleep(watchdogTick)
+		checkStreams(ctx)
+	}
+}
+
+func checkStreams(ctx *ntcontext) {
+	wd := &ctx.watchdog
+	inp, ok := inputSelection(ctx)
+	if !ok || !ctx.config.Watchdog || ctx.noiseSupressorState != loaded || ctx.muted || coughing(ctx) ||
+		bypassActive(ctx) || recoveryRunning(ctx) || jackMode(ctx) {
+		wd.stop()
+		wd.ticks = 0
+		return
+	}
+	virt, ok := findVirtualSource(ctx, &inp)
+	if !ok || virt.Muted {
+		wd.stop()
+		return
+	}
+	if wd.ticks%watchdogUsersEvery == 0 {
+		wd.users = recordedByOthers(virt.Index)
+	}
+	wd.ticks++
+	if !wd.users {
+		wd.stop()
+		return
+	}
+
+	if wd.raw == nil || wd.raw.source != inp.ID || wd.filtered.source != virt.Name {
+		wd.stop()
+		raw, err := startStreamProbe(ctx, inp.ID)
+		if err != nil {
+			errorf("Couldn't start the stream watchdog: %v\n", err)
+			return
+		}
+		filtered, err := startStreamProbe(ctx, virt.Name)
+		if err != nil {
+			raw.Stop()
+			errorf("Couldn't start the stream watchdog: %v\n", err)
+			return
+		}
+		wd.raw, wd.filtered = raw, filtered
+		return
+	}
+
+	problem := wd.diagnose(ctx)
+	if problem == "" || time.Since(wd.lastReload) < watchdogCooldown {
+		return
+	}
+	warnf("Stream watchdog: %s for %ds, reloading the filter\n", problem, ctx.config.WatchdogTimeout)
+	wd.stop()
+	wd.lastReload = time.Now()
+	metricsWatchdogReloaded(ctx)
+	notify(ctx, notifyConnection, tr("Noise suppression reloaded"), trf("The filtered microphone %s, it was loaded again.", tr(problem)))
+	reloadStuckChain(ctx)
+}
+
+// diagnose returns what's wrong with the filtered microphone once it has been for WatchdogTimeout.
+func (wd *streamWatchdog) diagnose(ctx *ntcontext) string {
+	now := time.Now()
+	timeout := time.Duration(ctx.config.WatchdogTimeout) * time.Second
+	rawDelivers := wd.raw.since(&wd.raw.lastSample) < watchdogTick
+	rawActive := wd.raw.since(&wd.raw.lastActive) < watchdogTick
+
+	// a microphone that stopped delivering isn't our problem, hotplug and recovery take care of it
+	if rawDelivers && wd.filtered.since(&wd.filtered.lastSample) >= watchdogTick {
+		if wd.stalledSince.IsZero() {
+			wd.stalledSince = now
+		}
+	} else {
+		wd.stalledSince = time.Time{}
+	}
+	if rawActive && wd.filtered.since(&wd.filtered.lastSound) >= watchdogTick && !silenceExpected(ctx) {
+		if wd.silentSince.IsZero() {
+			wd.silentSince = now
+		}
+	} else {
+		wd.silentSince = time.Time{}
+	}
+
+	switch {
+	case !wd.stalledSince.IsZero() && now.Sub(wd.stalledSince) >= timeout:
+		return "stopped delivering audio"
+	case !wd.silentSince.IsZero() && now.Sub(wd.silentSince) >= timeout:
+		return "only delivered silence"
+	}
+	return ""
+}
+
+// silenceExpected is whether a gate may currently output silence on purpose.
+func silenceExpected(ctx *ntcontext) bool {
+	if ctx.config.Gate {
+		return true
+	}
+	if !currentEngine(ctx).rnnoise {
+		return false
+	}
+	// a stuck filter stops publishing, that doesn't explain anything
+	status, ok := readVADStatus()
+	return ok && !status.gateOpen
+}
+
+// recordedByOthers is whether an application other than us records from the source.
+func recordedByOthers(source uint32) bool {
+	outputs, err := listSourceOutputs()
+	if err != nil {
+		errorf("Couldn't list recording applications: %v\n", err)
+		return false
+	}
+	return recordingOthers(outputs, source)
+}
+
+// recordingOthers is whether one of the streams, other than ours, records from the source.
+func recordingOthers(outputs []stream, source uint32) bool {
+	for _, o := range outputs {
+		if _, ours := o.props[tagID]; !ours && o.device == source {
+			return true
+		}
+	}
+	return false
+}
+
+func bypassActive(ctx *ntcontext) bool {
+	ctx.bypass.Lock()
+	defer ctx.bypass.Unlock()
+	return ctx.bypass.active || ctx.bypass.busy
+}
+
+func recoveryRunning(ctx *ntcontext) bool {
+	ctx.recovery.Lock()
+	defer ctx.recovery.Unlock()
+	return ctx.recovery.running
+}
+
+// reloadStuckChain builds the chain again, holding off the recovery manager meanwhile.
+func reloadStuckChain(ctx *ntcontext) {
+	r := &ctx.recovery
+	r.Lock()
+	if r.running {
+		r.Unlock()
+		return
+	}
+	r.running = true
+	r.Unlock()
+	defer func() {
+		r.Lock()
+		r.running = false
+		r.Unlock()
+	}()
+
+	if err := rebuildChain(ctx); err != nil {
+		errorf("Couldn't reload the stuck filter chain: %v\n", err)
+		ctx.views.Push(makeErrorView(ctx, trf("The filter stopped working and couldn't be loaded again: %v", err)))
+		(*ctx.masterWindow).Changed()
+		return
+	}
+	infof("Stream watchdog: filter chain reloaded\n")
+}
+
+func watchdogView(ctx *ntcontext, w *nucular.Window) {
+	w.Row(25).Ratio(0.6, 0.4)
+	if focusable(ctx, w, w.CheckboxText(tr("Reload the filter when it gets stuck"), &ctx.config.Watchdog)) {
+		go writeConfig(ctx.config)
+	}
+	if w.Input().Mouse.HoveringRect(w.LastWidgetBounds) {
+		w.Tooltip(tr("While an application records, reloads the filter if the filtered microphone stays silent although you're speaking."))
+	}
+	if focusableSlider(ctx, w, w.PropertyInt("s:", minWatchdogTimeout, &ctx.config.WatchdogTimeout, maxWatchdogTimeout, 1, 1), &ctx.config.WatchdogTimeout, minWatchdogTimeout, maxWatchdogTimeout, 1) {
+		go writeConfig(ctx.config)
+	}
+}
@@ -0,0 +1,218 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A minimal WebSocket client (RFC 6455), enough to talk to obs-websocket: text messages, ping and
+// close. No extensions, no TLS.
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	wsMaxMessage = 16 << 20
+	wsGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+type wsConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// wsCloseError is the reason the server gave for closing the connection.
+type wsCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *wsCloseError) Error() string {
+	if e.reason == "" {
+		return fmt.Sprintf("connection closed with code %d", e.code)
+	}
+	return fmt.Sprintf("connection closed with code %d: %s", e.code, e.reason)
+}
+
+func dialWebSocket(addr, path string, timeout time.Duration) (*wsConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	_, err = fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path, addr, key)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("server refused the WebSocket upgrade: %s", resp.Status)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("server sent an invalid WebSocket accept key")
+	}
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, r: r}, nil
+}
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | op, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	// clients must mask everything they send
+	header[1] |= 0x80
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	header = append(header, mask...)
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	_, err := c.conn.Write(append(header, masked...))
+	return err
+}
+
+// WriteText sends msg as a single text message.
+func (c *wsConn) WriteText(msg []byte) error {
+	return c.writeFrame(wsOpText, msg)
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.r, head[:]); err != nil {
+		return
+	}
+	fin, op = head[0]&0x80 != 0, head[0]&0x0f
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > wsMaxMessage {
+		err = fmt.Errorf("WebSocket frame of %d bytes is too large", n)
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.r, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// ReadText returns the next text message, answering pings on the way.
+func (c *wsConn) ReadText() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			closeErr := &wsCloseError{code: 1005} // no status
+			echo := []byte{}
+			if len(payload) >= 2 {
+				closeErr.code = int(binary.BigEndian.Uint16(payload))
+				closeErr.reason = string(payload[2:])
+				echo = payload[:2]
+			}
+			c.writeFrame(wsOpClose, echo)
+			c.conn.Close()
+			return nil, closeErr
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			msg = append(msg, payload...)
+			if len(msg) > wsMaxMessage {
+				return nil, fmt.Errorf("WebSocket message is too large")
+			}
+		default:
+			return nil, fmt.Errorf("unknown WebSocket opcode %#x", op)
+		}
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (c *wsConn) Close() error {
+	c.writeFrame(wsOpClose, []byte{0x03, 0xe8}) // 1000, normal closure
+	return c.conn.Close()
+}
@@ -0,0 +1,34 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+//go:build nucular_gio
+// +build nucular_gio
+
+package main
+
+// With -tags nucular_gio the window is drawn by gio, which talks to the Wayland compositor directly
+// when $WAYLAND_DISPLAY is set and falls back to X11 otherwise. It needs cgo and the development
+// files of wayland, egl, xkbcommon and libX11, which is why it isn't the default: release builds
+// are static.
+
+import (
+	"fmt"
+	"image"
+)
+
+// setWindowHints is only needed for the shiny backend, gio names its windows itself.
+func setWindowHints() {}
+
+// activateWindow isn't possible with gio, and Wayland doesn't let clients raise themselves anyway.
+func activateWindow() error {
+	return fmt.Errorf("not supported with the gio backend")
+}
+
+// placeWindow isn't possible with gio, Wayland compositors place windows themselves.
+func placeWindow(p image.Point) {}
+
+// watchWindowPosition has nothing to watch, the position is never known with gio.
+func watchWindowPosition(ctx *ntcontext) {}
+
+// resizeWindow isn't possible with gio, the window keeps its size.
+func resizeWindow(ctx *ntcontext, size image.Point) {}
@@ -0,0 +1,186 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+//go:build !nucular_gio
+// +build !nucular_gio
+
+package main
+
+// The default backend of nucular on Linux is shiny, which talks X11 only: on Wayland sessions the
+// window goes through XWayland. Build with -tags nucular_gio for a native Wayland window.
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"golang.org/x/exp/shiny/driver/x11driver"
+	"golang.org/x/image/draw"
+)
+
+// taskbars want a few small sizes, the full icon doesn't fit into a single X request
+var iconSizes = []int{32, 48, 64}
+
+// setWindowHints makes the windows we create afterwards carry our WM_CLASS and icon from the
+// start, so the taskbar groups them under our desktop entry and shows the right icon.
+func setWindowHints() {
+	x11driver.WindowInstance = "noisetorch"
+	x11driver.WindowClass = appName
+
+	icon, err := png.Decode(bytes.NewReader(iconPNG))
+	if err != nil {
+		warnf("Couldn't decode window icon: %v\n", err)
+		return
+	}
+	x11driver.WindowIcons = nil
+	for _, size := range iconSizes {
+		scaled := image.NewNRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), icon, icon.Bounds(), draw.Src, nil)
+		x11driver.WindowIcons = append(x11driver.WindowIcons, scaled)
+	}
+}
+
+// placeWindow makes the window open at p, unless that's off the screen now.
+func placeWindow(p image.Point) {
+	X, err := xgb.NewConn()
+	if err != nil {
+		return
+	}
+	defer X.Close()
+	screen := xproto.Setup(X).DefaultScreen(X)
+	// a monitor that's gone can leave it too far out to grab
+	const grip = 50
+	if p.X < 0 || p.Y < 0 || p.X > int(screen.WidthInPixels)-grip || p.Y > int(screen.HeightInPixels)-grip {
+		debugf("Not restoring the window position %v, it's off the screen\n", p)
+		return
+	}
+	x11driver.WindowPosition = &p
+}
+
+func internAtom(X *xgb.Conn, name string) (xproto.Atom, error) {
+	r, err := xproto.InternAtom(X, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return r.Atom, nil
+}
+
+// ourWindow finds our window. Shiny doesn't tell us, we find it by _NET_WM_PID.
+func ourWindow(X *xgb.Conn, root xproto.Window) (xproto.Window, error) {
+	clientList, err := internAtom(X, "_NET_CLIENT_LIST")
+	if err != nil {
+		return 0, err
+	}
+	wmPid, err := internAtom(X, "_NET_WM_PID")
+	if err != nil {
+		return 0, err
+	}
+	clients, err := xproto.GetProperty(X, false, root, clientList, xproto.AtomWindow, 0, 1<<16).Reply()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i+4 <= len(clients.Value); i += 4 {
+		w := xproto.Window(xgb.Get32(clients.Value[i:]))
+		pid, err := xproto.GetProperty(X, false, w, wmPid, xproto.AtomCardinal, 0, 1).Reply()
+		if err == nil && len(pid.Value) >= 4 && int(xgb.Get32(pid.Value)) == os.Getpid() {
+			return w, nil
+		}
+	}
+	return 0, fmt.Errorf("couldn't find our window")
+}
+
+// activateWindow asks the window manager to bring our window to the front, e.g. when NoiseTorch-ng
+// is started a second time.
+func activateWindow() error {
+	X, err := xgb.NewConn()
+	if err != nil {
+		return err
+	}
+	defer X.Close()
+	root := xproto.Setup(X).DefaultScreen(X).Root
+
+	activeWindow, err := internAtom(X, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return err
+	}
+	w, err := ourWindow(X, root)
+	if err != nil {
+		return err
+	}
+	// source indication 2: the user asked for it, like a taskbar would
+	ev := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: w,
+		Type:   activeWindow,
+		Data:   xproto.ClientMessageDataUnionData32New([]uint32{2, xproto.TimeCurrentTime, 0, 0, 0}),
+	}
+	return xproto.SendEventChecked(X, false, root,
+		xproto.EventMaskSubstructureRedirect|xproto.EventMaskSubstructureNotify, string(ev.Bytes())).Check()
+}
+
+// resizeWindow asks the window manager to resize our window to size, unscaled.
+func resizeWindow(ctx *ntcontext, size image.Point) {
+	scaling := (*ctx.masterWindow).Style().Scaling
+	go func() {
+		X, err := xgb.NewConn()
+		if err != nil {
+			warnf("Couldn't resize the window: %v\n", err)
+			return
+		}
+		defer X.Close()
+		w, err := ourWindow(X, xproto.Setup(X).DefaultScreen(X).Root)
+		if err != nil {
+			warnf("Couldn't resize the window: %v\n", err)
+			return
+		}
+		width, height := uint32(float64(size.X)*scaling), uint32(float64(size.Y)*scaling)
+		err = xproto.ConfigureWindowChecked(X, w, xproto.ConfigWindowWidth|xproto.ConfigWindowHeight, []uint32{width, height}).Check()
+		if err != nil {
+			warnf("Couldn't resize the window: %v\n", err)
+		}
+	}()
+}
+
+// watchWindowPosition keeps the position of the window known, it's gone by the time the window
+// is closed. Window managers move the frame, not our window, so there is no event for it and it
+// asks once a second.
+func watchWindowPosition(ctx *ntcontext) {
+	X, err := xgb.NewConn()
+	if err != nil {
+		warnf("Couldn't connect to X to remember the window position: %v\n", err)
+		return
+	}
+	defer X.Close()
+	root := xproto.Setup(X).DefaultScreen(X).Root
+	frameExtents, err := internAtom(X, "_NET_FRAME_EXTENTS")
+	if err != nil {
+		return
+	}
+
+	var w xproto.Window
+	for range time.Tick(time.Second) {
+		if w == 0 {
+			if w, err = ourWindow(X, root); err != nil {
+				continue // not mapped yet
+			}
+		}
+		pos, err := xproto.TranslateCoordinates(X, w, root, 0, 0).Reply()
+		if err != nil {
+			w = 0
+			continue
+		}
+		p := image.Pt(int(pos.DstX), int(pos.DstY))
+		// left, right, top, bottom
+		ext, err := xproto.GetProperty(X, false, w, frameExtents, xproto.AtomCardinal, 0, 4).Reply()
+		if err == nil && len(ext.Value) >= 16 {
+			p.X -= int(xgb.Get32(ext.Value[0:]))
+			p.Y -= int(xgb.Get32(ext.Value[8:]))
+		}
+		setWindowPosition(ctx, p)
+	}
+}
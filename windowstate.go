@@ -0,0 +1,111 @@
+// This file is part of the program "NoiseTorch-ng".
+// Please see the LICENSE file for copyright information.
+
+package main
+
+import (
+	"image"
+	"reflect"
+	"sync"
+
+	"github.com/aarzilli/nucular"
+)
+
+// The window comes back with the size, position and view it had when it was closed. The size is
+// kept unscaled, like the sizes nucular is given, so it doesn't grow with the scale. The position
+// is only known on X11, see watchWindowPosition, and it's the frame's, which is what window
+// managers place.
+
+const (
+	defaultWindowWidth  = 600
+	defaultWindowHeight = 400
+	minWindowSize       = 200
+	maxWindowSize       = 10000
+)
+
+type windowstate struct {
+	mu       sync.Mutex
+	size     image.Point  // unscaled, zero before the first frame
+	position *image.Point // nil if unknown
+	view     string       // name of the last restorable view, empty for the main view
+}
+
+// restorableViews are the views opened again on start. Views that need a device or a loaded
+// filter, and dialogs, aren't.
+var restorableViews = []struct {
+	name string
+	view ViewFunc
+	open func(ctx *ntcontext)
+}{
+	{"logs", logView, openLogView},
+	{"troubleshoot", doctorView, openDoctorView},
+	{"shortcuts", shortcutsView, openShortcuts},
+	{"licenses", licenseView, func(ctx *ntcontext) { ctx.views.Push(licenseView) }},
+	{"version", versionView, func(ctx *ntcontext) { ctx.views.Push(versionView) }},
+	{"mini", miniView, openMiniView},
+}
+
+func knownView(name string) bool {
+	for _, v := range restorableViews {
+		if v.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// trackWindow remembers the size of the window and the view shown, every frame.
+func trackWindow(ctx *ntcontext, w *nucular.Window) {
+	scaling := (*ctx.masterWindow).Style().Scaling
+	top := reflect.ValueOf(ctx.views.Peek()).Pointer()
+
+	ws := &ctx.window
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if !ctx.mini {
+		// the size to go back to from the mini view
+		ws.size = image.Pt(int(float64(w.Bounds.W)/scaling), int(float64(w.Bounds.H)/scaling))
+	}
+	if top == reflect.ValueOf(ViewFunc(mainView)).Pointer() {
+		ws.view = ""
+		return
+	}
+	// anything else on top, like a dialog, keeps the view below
+	for _, v := range restorableViews {
+		if top == reflect.ValueOf(v.view).Pointer() {
+			ws.view = v.name
+		}
+	}
+}
+
+func setWindowPosition(ctx *ntcontext, p image.Point) {
+	ctx.window.mu.Lock()
+	ctx.window.position = &p
+	ctx.window.mu.Unlock()
+}
+
+// saveWindowState stores how the window was left, once it's closed.
+func saveWindowState(ctx *ntcontext) {
+	ws := &ctx.window
+	ws.mu.Lock()
+	if ws.size.X >= minWindowSize && ws.size.Y >= minWindowSize {
+		ctx.config.WindowWidth, ctx.config.WindowHeight = ws.size.X, ws.size.Y
+	}
+	if ws.position != nil {
+		ctx.config.WindowPosition = []int{ws.position.X, ws.position.Y}
+	}
+	ctx.config.LastView = ws.view
+	ws.mu.Unlock()
+	writeConfig(ctx.config)
+}
+
+// restoreView opens the view that was shown when the window was closed.
+func restoreView(ctx *ntcontext) {
+	for _, v := range restorableViews {
+		if v.name == ctx.config.LastView {
+			v.open(ctx)
+			(*ctx.masterWindow).Changed()
+			return
+		}
+	}
+}